@@ -4,20 +4,124 @@ package openapi
 
 import "time"
 
+type AlertRoutingDto struct {
+	Service string `yaml:"service" json:"service"`
+	Owner   string `yaml:"owner" json:"owner"`
+	// The kind of alert target, one of email, slack, teams, pagerduty or legacy
+	Kind string `yaml:"kind" json:"kind"`
+	// The normalized alert target without the kind, e.g. an email address, Slack channel, webhook url or integration key
+	Target string `yaml:"target" json:"target"`
+}
+
+type ChangeEventDto struct {
+	// The git commit hash the changes were committed under, also used as the event id.
+	CommitHash string `yaml:"-" json:"commitHash"`
+	// ISO-8601 UTC date time at which the changes were committed.
+	TimeStamp string `yaml:"-" json:"timeStamp"`
+	// The owners, services and repositories affected by the commit.
+	Entities []ChangedEntityDto `yaml:"-" json:"entities"`
+}
+
+type ChangedEntityDto struct {
+	// The kind of entity, one of owner, service or repository.
+	Type string `yaml:"-" json:"type"`
+	// The owner alias, service name or repository key.
+	Key string `yaml:"-" json:"key"`
+	// The alias of the owner after the change, or before it, if the entity was deleted.
+	Owner string `yaml:"-" json:"owner"`
+	// The alias of the owner before the change, only set if the owner changed.
+	PreviousOwner *string `yaml:"-" json:"previousOwner,omitempty"`
+	// True if the entity was deleted.
+	Deleted bool `yaml:"-" json:"deleted"`
+}
+
 type ConditionReferenceDto struct {
 	// Reference of a branch.
 	RefMatcher string `yaml:"refMatcher" json:"refMatcher"`
 }
 
+type ConsistencyIssueDto struct {
+	// The kind of inconsistency, one of missing-repository, unreferenced-repository, missing-group, owner-without-services or missing-replacement
+	Type string `yaml:"type" json:"type"`
+	// The kind of entity the inconsistency was found in, one of service, repository or owner
+	EntityType string `yaml:"entityType" json:"entityType"`
+	// The name of the service, the key of the repository or the alias of the owner
+	Entity string `yaml:"entity" json:"entity"`
+	// The field of the entity that holds the dangling reference
+	Field *string `yaml:"field,omitempty" json:"field,omitempty"`
+	// The dangling reference, e.g. a repository key or a group reference like @some-owner.some-group
+	Reference *string `yaml:"reference,omitempty" json:"reference,omitempty"`
+	// What to change in the metadata repository to resolve the inconsistency
+	SuggestedFix string `yaml:"suggestedFix" json:"suggestedFix"`
+}
+
+type ConsistencyReportDto struct {
+	Issues []ConsistencyIssueDto `yaml:"issues" json:"issues"`
+	// The number of issues by type, including types without issues
+	Counts map[string]int32 `yaml:"counts" json:"counts"`
+}
+
 type DeletionDto struct {
 	// The jira issue to use for committing the deletion.
 	JiraIssue string `yaml:"-" json:"jiraIssue"`
+	// Only for owners: the alias of another owner to move all services and repositories to before deleting.
+	TransferTo string `yaml:"-" json:"transferTo,omitempty"`
+}
+
+type DryRunResultDto struct {
+	// The owner, service or repository as it would be committed. Only the one that was written is set, none for deletions.
+	Owner      *OwnerDto      `yaml:"owner,omitempty" json:"owner,omitempty"`
+	Service    *ServiceDto    `yaml:"service,omitempty" json:"service,omitempty"`
+	Repository *RepositoryDto `yaml:"repository,omitempty" json:"repository,omitempty"`
+	// Unified diff of the yaml files that would be committed, empty if there would be no actual changes.
+	Diff string `yaml:"diff" json:"diff"`
 }
 
 type ErrorDto struct {
 	Details   *string    `yaml:"details,omitempty" json:"details,omitempty"`
 	Message   *string    `yaml:"message,omitempty" json:"message,omitempty"`
 	Timestamp *time.Time `yaml:"timestamp,omitempty" json:"timestamp,omitempty"`
+	// The individual problems found by validation, each attached to the field it is about.
+	Violations []ViolationDto `yaml:"violations,omitempty" json:"violations,omitempty"`
+}
+
+type FieldChangeDto struct {
+	// The name of the changed field. Nested fields are separated by dots, e.g. spec.dependsOn
+	Field string `yaml:"field" json:"field"`
+	// The value before the change, not set if the field was added
+	OldValue interface{} `yaml:"oldValue,omitempty" json:"oldValue,omitempty"`
+	// The value after the change, not set if the field was removed
+	NewValue interface{} `yaml:"newValue,omitempty" json:"newValue,omitempty"`
+}
+
+type GraphqlErrorDto struct {
+	// What went wrong.
+	Message string `yaml:"-" json:"message"`
+	// Where in the query the problem is.
+	Locations []GraphqlErrorLocationDto `yaml:"-" json:"locations,omitempty"`
+	// The path to the field in the result that failed to resolve.
+	Path []interface{} `yaml:"-" json:"path,omitempty"`
+}
+
+type GraphqlErrorLocationDto struct {
+	Line   int `yaml:"-" json:"line"`
+	Column int `yaml:"-" json:"column"`
+}
+
+type GraphqlRequestDto struct {
+	// The GraphQL query document.
+	Query string `yaml:"-" json:"query"`
+	// Which operation in the document to execute, only needed if it contains more than one.
+	OperationName string `yaml:"-" json:"operationName,omitempty"`
+	// Values for the variables declared by the operation.
+	Variables map[string]interface{} `yaml:"-" json:"variables,omitempty"`
+}
+
+type GraphqlResponseDto struct {
+	// The result of the query, shaped like the query. Absent if the query could not be executed at all.
+	Data interface{} `yaml:"-" json:"data,omitempty"`
+	// Problems found while parsing, validating or executing the query.
+	Errors []GraphqlErrorDto `yaml:"-" json:"errors,omitempty"`
 }
 
 type HealthComponent struct {
@@ -25,6 +129,26 @@ type HealthComponent struct {
 	Status      *string `yaml:"status,omitempty" json:"status,omitempty"`
 }
 
+type HistoryDto struct {
+	// The commits that changed the entity, newest first
+	Entries []HistoryEntryDto `yaml:"entries" json:"entries"`
+}
+
+type HistoryEntryDto struct {
+	// The git commit hash of the change
+	CommitHash string `yaml:"commitHash" json:"commitHash"`
+	// ISO-8601 UTC date time at which the change was committed
+	TimeStamp string `yaml:"timeStamp" json:"timeStamp"`
+	// The name of the commit author
+	Author string `yaml:"author" json:"author"`
+	// The jira issue the change was committed under
+	JiraIssue string `yaml:"jiraIssue" json:"jiraIssue"`
+	// True if the entity was deleted in this commit
+	Deleted *bool `yaml:"deleted,omitempty" json:"deleted,omitempty"`
+	// The fields that changed compared to the previous entry
+	Changes []FieldChangeDto `yaml:"changes" json:"changes"`
+}
+
 type Link struct {
 	Url   *string `yaml:"url,omitempty" json:"url,omitempty"`
 	Title *string `yaml:"title,omitempty" json:"title,omitempty"`
@@ -36,6 +160,8 @@ type Notification struct {
 	Event   string               `yaml:"event" json:"event"`
 	Type    string               `yaml:"type" json:"type"`
 	Payload *NotificationPayload `yaml:"payload,omitempty" json:"payload,omitempty"`
+	// Only set for SUNSET_APPROACHING and SUNSET_PASSED, which are sent once for each recipient
+	Recipient *NotificationRecipient `yaml:"recipient,omitempty" json:"recipient,omitempty"`
 }
 
 type NotificationPayload struct {
@@ -44,6 +170,13 @@ type NotificationPayload struct {
 	Repository *RepositoryDto `yaml:"Repository,omitempty" json:"Repository,omitempty"`
 }
 
+type NotificationRecipient struct {
+	// alias of the owner or name of the service that should act on the notification
+	Name string `yaml:"name" json:"name"`
+	// Owner or Service
+	Type string `yaml:"type" json:"type"`
+}
+
 type OwnerCreateDto struct {
 	// The contact information of the owner
 	Contact string `yaml:"contact" json:"contact"`
@@ -86,12 +219,18 @@ type OwnerDto struct {
 	// A display name of the owner, to be presented in user interfaces instead of the owner's name, when available
 	DisplayName *string `yaml:"displayName,omitempty" json:"displayName,omitempty"`
 	Links       []Link  `yaml:"links,omitempty" json:"links,omitempty"`
+	// Violations of policies with severity warn, only present in the response to a write.
+	PolicyWarnings []ViolationDto `yaml:"-" json:"policyWarnings,omitempty"`
 }
 
 type OwnerListDto struct {
 	Owners map[string]OwnerDto `yaml:"owners" json:"owners"`
 	// ISO-8601 UTC date time at which the list of owners was obtained from service-metadata
 	TimeStamp string `yaml:"-" json:"timeStamp"`
+	// The owner aliases on this page, in the requested sort order. JSON objects are unordered, so use this to iterate the map.
+	Order []string `yaml:"-" json:"order,omitempty"`
+	// Pass as cursor to get the next page, absent on the last page
+	NextCursor string `yaml:"-" json:"nextCursor,omitempty"`
 }
 
 type OwnerPatchDto struct {
@@ -118,6 +257,17 @@ type OwnerPatchDto struct {
 	Links       []Link  `yaml:"links,omitempty" json:"links,omitempty"`
 }
 
+type OwnerRenameDto struct {
+	// The new alias of the owner.
+	NewAlias string `yaml:"-" json:"newAlias"`
+	// ISO-8601 UTC date time of the owner version the rename is based on.
+	TimeStamp string `yaml:"-" json:"timeStamp"`
+	// The git commit hash of the owner version the rename is based on.
+	CommitHash string `yaml:"-" json:"commitHash"`
+	// The jira issue to use for committing the rename.
+	JiraIssue string `yaml:"-" json:"jiraIssue"`
+}
+
 type Quicklink struct {
 	Url         *string `yaml:"url,omitempty" json:"url,omitempty"`
 	Title       *string `yaml:"title,omitempty" json:"title,omitempty"`
@@ -128,6 +278,23 @@ type QuicklinkAllOf struct {
 	Description *string `yaml:"description,omitempty" json:"description,omitempty"`
 }
 
+type QuicklinkStatusDto struct {
+	Url   string  `yaml:"url" json:"url"`
+	Title *string `yaml:"title,omitempty" json:"title,omitempty"`
+	// One of ok, broken, skipped (not an absolute http or https url) or unchecked (not checked yet)
+	Status string `yaml:"status" json:"status"`
+	// The http status of the last response, if one was received
+	HttpStatus *int32 `yaml:"httpStatus,omitempty" json:"httpStatus,omitempty"`
+	// Why the link is broken or was skipped
+	Error *string `yaml:"error,omitempty" json:"error,omitempty"`
+	// ISO-8601 UTC date time of the last check
+	CheckedAt *string `yaml:"checkedAt,omitempty" json:"checkedAt,omitempty"`
+}
+
+type QuicklinkStatusListDto struct {
+	Quicklinks []QuicklinkStatusDto `yaml:"quicklinks" json:"quicklinks"`
+}
+
 type RepositoryConfigurationAccessKeyDto struct {
 	Key        string  `yaml:"key" json:"key"`
 	Permission *string `yaml:"permission,omitempty" json:"permission,omitempty"`
@@ -213,12 +380,24 @@ type RepositoryDto struct {
 	JiraIssue string `yaml:"-" json:"jiraIssue"`
 	// A map of arbitrary string labels attached to this repository.
 	Labels *map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// ISO-8601 date since which the repository is deprecated.
+	DeprecatedSince *string `yaml:"deprecatedSince,omitempty" json:"deprecatedSince,omitempty"`
+	// ISO-8601 date after which the repository will no longer be available.
+	SunsetDate *string `yaml:"sunsetDate,omitempty" json:"sunsetDate,omitempty"`
+	// The key of the repository that replaces this one.
+	ReplacedBy *string `yaml:"replacedBy,omitempty" json:"replacedBy,omitempty"`
+	// Violations of policies with severity warn, only present in the response to a write.
+	PolicyWarnings []ViolationDto `yaml:"-" json:"policyWarnings,omitempty"`
 }
 
 type RepositoryListDto struct {
 	Repositories map[string]RepositoryDto `yaml:"repositories" json:"repositories"`
 	// ISO-8601 UTC date time at which the list of repositories was obtained from service-metadata
 	TimeStamp string `yaml:"-" json:"timeStamp"`
+	// The repository keys on this page, in the requested sort order. JSON objects are unordered, so use this to iterate the map.
+	Order []string `yaml:"-" json:"order,omitempty"`
+	// Pass as cursor to get the next page, absent on the last page
+	NextCursor string `yaml:"-" json:"nextCursor,omitempty"`
 }
 
 type RepositoryPatchDto struct {
@@ -241,6 +420,51 @@ type RepositoryPatchDto struct {
 	JiraIssue string `yaml:"-" json:"jiraIssue"`
 	// A map of arbitrary string labels attached to this repository.
 	Labels *map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	// ISO-8601 date since which the repository is deprecated.
+	DeprecatedSince *string `yaml:"deprecatedSince,omitempty" json:"deprecatedSince,omitempty"`
+	// ISO-8601 date after which the repository will no longer be available.
+	SunsetDate *string `yaml:"sunsetDate,omitempty" json:"sunsetDate,omitempty"`
+	// The key of the repository that replaces this one.
+	ReplacedBy *string `yaml:"replacedBy,omitempty" json:"replacedBy,omitempty"`
+}
+
+type RevertDto struct {
+	// The commit whose state of the entity should be restored. May be abbreviated to at least 7 characters.
+	CommitHash string `yaml:"-" json:"commitHash"`
+	// The jira issue to use for committing the revert.
+	JiraIssue string `yaml:"-" json:"jiraIssue"`
+}
+
+type SearchHitDto struct {
+	// The kind of entity found, one of owner, service or repository.
+	Type string `yaml:"-" json:"type"`
+	// The owner alias, service name or repository key.
+	Key string `yaml:"-" json:"key"`
+	// How well the entity matches, higher is better.
+	Score int `yaml:"-" json:"score"`
+	// The fields the query words were found in.
+	Fields []string `yaml:"-" json:"fields"`
+}
+type SearchResultDto struct {
+	// The matching entities, best match first.
+	Hits []SearchHitDto `yaml:"-" json:"hits"`
+	// True if there were more hits than the limit allowed.
+	Truncated bool `yaml:"-" json:"truncated"`
+}
+type ServiceApiIndexDto struct {
+	// Maps each API name to the services providing and consuming it.
+	Apis map[string]ServiceApiUsageDto `yaml:"apis" json:"apis"`
+	// ISO-8601 UTC date time at which the list of services was obtained from service-metadata
+	TimeStamp string `yaml:"-" json:"timeStamp"`
+	// The service names on this page, in the requested sort order. JSON objects are unordered, so use this to iterate the map.
+	Order []string `yaml:"-" json:"order,omitempty"`
+}
+
+type ServiceApiUsageDto struct {
+	// The names of the services that list this API in spec.providesApis
+	Providers []string `yaml:"providers" json:"providers"`
+	// The names of the services that list this API in spec.consumesApis
+	Consumers []string `yaml:"consumers" json:"consumers"`
 }
 
 type ServiceCreateDto struct {
@@ -267,6 +491,31 @@ type ServiceCreateDto struct {
 	JiraIssue string `yaml:"-" json:"jiraIssue"`
 }
 
+type ServiceDependencyEdgeDto struct {
+	// The name of the dependent service
+	From string `yaml:"from" json:"from"`
+	// The name of the service that is depended upon
+	To string `yaml:"to" json:"to"`
+}
+
+type ServiceDependencyGraphDto struct {
+	Services     []ServiceDependencyNodeDto `yaml:"services" json:"services"`
+	Dependencies []ServiceDependencyEdgeDto `yaml:"dependencies" json:"dependencies"`
+	// ISO-8601 UTC date time at which the list of services was obtained from service-metadata
+	TimeStamp string `yaml:"-" json:"timeStamp"`
+	// The service names on this page, in the requested sort order. JSON objects are unordered, so use this to iterate the map.
+	Order []string `yaml:"-" json:"order,omitempty"`
+}
+
+type ServiceDependencyNodeDto struct {
+	// The name of the service
+	Name string `yaml:"name" json:"name"`
+	// The alias of the service owner. Not set for services that are referenced in spec.dependsOn but do not exist.
+	Owner *string `yaml:"owner,omitempty" json:"owner,omitempty"`
+	// The current phase of the service's development.
+	Lifecycle *string `yaml:"lifecycle,omitempty" json:"lifecycle,omitempty"`
+}
+
 type ServiceDto struct {
 	// The alias of the service owner. Note, an update with changed owner will move the service and any associated repositories to the new owner, but of course this will not move e.g. Jenkins jobs. That's your job.
 	Owner string `yaml:"-" json:"owner"`
@@ -293,14 +542,43 @@ type ServiceDto struct {
 	CommitHash string `yaml:"-" json:"commitHash"`
 	// The jira issue to use for committing a change, or the last jira issue used.
 	JiraIssue string `yaml:"-" json:"jiraIssue"`
-	// The current phase of the service's development. A service usually starts off as 'experimental', then becomes 'operational' (i. e. can be reliably used and/or consumed). Once 'deprecated', the service doesn’t guarantee reliable use/consumption any longer.
+	// The current phase of the service's development. A service usually starts off as 'experimental', then becomes 'operational' (i. e. can be reliably used and/or consumed). Once 'deprecated', the service doesn’t guarantee reliable use/consumption any longer. The available states are configurable. Only the lifecycle endpoint can change it.
 	Lifecycle *string `yaml:"lifecycle,omitempty" json:"lifecycle,omitempty"`
+	// ISO-8601 date since which the service is deprecated.
+	DeprecatedSince *string `yaml:"deprecatedSince,omitempty" json:"deprecatedSince,omitempty"`
+	// ISO-8601 date after which the service will no longer be available. Mandatory in lifecycle states that require it, such as 'deprecated'.
+	SunsetDate *string `yaml:"sunsetDate,omitempty" json:"sunsetDate,omitempty"`
+	// The name of the service that replaces this one. Mandatory in lifecycle states that require it, such as 'deprecated'.
+	ReplacedBy *string `yaml:"replacedBy,omitempty" json:"replacedBy,omitempty"`
+	// Violations of policies with severity warn, only present in the response to a write.
+	PolicyWarnings []ViolationDto `yaml:"-" json:"policyWarnings,omitempty"`
+}
+
+type ServiceLifecycleTransitionDto struct {
+	// The lifecycle state to transition to. Must be reachable from the current state of the service.
+	Lifecycle string `yaml:"-" json:"lifecycle"`
+	// ISO-8601 date since which the service is deprecated. Leave out to keep the current value, send an empty string to remove it.
+	DeprecatedSince *string `yaml:"-" json:"deprecatedSince,omitempty"`
+	// ISO-8601 date after which the service will no longer be available. Leave out to keep the current value, send an empty string to remove it.
+	SunsetDate *string `yaml:"-" json:"sunsetDate,omitempty"`
+	// The name of the service that replaces this one. Leave out to keep the current value, send an empty string to remove it.
+	ReplacedBy *string `yaml:"-" json:"replacedBy,omitempty"`
+	// ISO-8601 UTC date time of the service version the transition is based on.
+	TimeStamp string `yaml:"-" json:"timeStamp"`
+	// The git commit hash of the service version the transition is based on.
+	CommitHash string `yaml:"-" json:"commitHash"`
+	// The jira issue to use for committing the transition.
+	JiraIssue string `yaml:"-" json:"jiraIssue"`
 }
 
 type ServiceListDto struct {
 	Services map[string]ServiceDto `yaml:"services" json:"services"`
 	// ISO-8601 UTC date time at which the list of services was obtained from service-metadata
 	TimeStamp string `yaml:"-" json:"timeStamp"`
+	// The service names on this page, in the requested sort order. JSON objects are unordered, so use this to iterate the map.
+	Order []string `yaml:"-" json:"order,omitempty"`
+	// Pass as cursor to get the next page, absent on the last page
+	NextCursor string `yaml:"-" json:"nextCursor,omitempty"`
 }
 
 type ServicePatchDto struct {
@@ -329,14 +607,33 @@ type ServicePatchDto struct {
 	CommitHash string `yaml:"-" json:"commitHash"`
 	// The jira issue to use for committing a change, or the last jira issue used.
 	JiraIssue string `yaml:"-" json:"jiraIssue"`
-	// The current phase of the service's development. A service usually starts off as 'experimental', then becomes 'operational' (i. e. can be reliably used and/or consumed). Once 'deprecated', the service doesn’t guarantee reliable use/consumption any longer.
+	// The current phase of the service's development. A service usually starts off as 'experimental', then becomes 'operational' (i. e. can be reliably used and/or consumed). Once 'deprecated', the service doesn’t guarantee reliable use/consumption any longer. The available states are configurable. Only the lifecycle endpoint can change it.
 	Lifecycle *string `yaml:"lifecycle,omitempty" json:"lifecycle,omitempty"`
+	// ISO-8601 date since which the service is deprecated.
+	DeprecatedSince *string `yaml:"deprecatedSince,omitempty" json:"deprecatedSince,omitempty"`
+	// ISO-8601 date after which the service will no longer be available. Mandatory in lifecycle states that require it, such as 'deprecated'.
+	SunsetDate *string `yaml:"sunsetDate,omitempty" json:"sunsetDate,omitempty"`
+	// The name of the service that replaces this one. Mandatory in lifecycle states that require it, such as 'deprecated'.
+	ReplacedBy *string `yaml:"replacedBy,omitempty" json:"replacedBy,omitempty"`
 }
 
 type ServicePromotersDto struct {
 	Promoters []string `yaml:"promoters" json:"promoters"`
 }
 
+type ServiceRenameDto struct {
+	// The new name of the service.
+	NewName string `yaml:"-" json:"newName"`
+	// Also rename the helm-deployment and none repositories of the service, whose keys must match the service name.
+	RenameRepositories bool `yaml:"-" json:"renameRepositories"`
+	// ISO-8601 UTC date time of the service version the rename is based on.
+	TimeStamp string `yaml:"-" json:"timeStamp"`
+	// The git commit hash of the service version the rename is based on.
+	CommitHash string `yaml:"-" json:"commitHash"`
+	// The jira issue to use for committing the rename.
+	JiraIssue string `yaml:"-" json:"jiraIssue"`
+}
+
 type ServiceSpecDto struct {
 	// A relation denoting a dependency on another entity
 	DependsOn []string `yaml:"dependsOn,omitempty" json:"dependsOn,omitempty"`
@@ -345,3 +642,45 @@ type ServiceSpecDto struct {
 	// A relation with an API, consumed by this entity
 	ConsumesApis []string `yaml:"consumesApis,omitempty" json:"consumesApis,omitempty"`
 }
+
+type TransactionDto struct {
+	// The jira issue to use for the single commit of the transaction. Also used for any operation whose body does not give a jiraIssue.
+	JiraIssue string `yaml:"-" json:"jiraIssue"`
+	// The operations to apply, in order. Later operations see the results of earlier ones.
+	Operations []TransactionOperationDto `yaml:"operations" json:"operations"`
+}
+
+type TransactionOperationDto struct {
+	// One of create, update, patch or delete.
+	Operation string `yaml:"operation" json:"operation"`
+	// One of owner, service or repository.
+	Kind string `yaml:"kind" json:"kind"`
+	// The owner alias, service name or repository key.
+	Key string `yaml:"key" json:"key"`
+	// The request body the endpoint for this kind of entity and operation expects. Leave out for deletes.
+	Body map[string]interface{} `yaml:"body,omitempty" json:"body,omitempty"`
+}
+
+type TransactionResultDto struct {
+	// The commit hash of the single commit, empty if there were no actual changes.
+	CommitHash string `yaml:"-" json:"commitHash"`
+	// ISO-8601 UTC date time of the commit
+	TimeStamp string `yaml:"-" json:"timeStamp"`
+	// The owners, services and repositories that were created or changed, as they were committed.
+	Owners       map[string]OwnerDto      `yaml:"owners" json:"owners"`
+	Services     map[string]ServiceDto    `yaml:"services" json:"services"`
+	Repositories map[string]RepositoryDto `yaml:"repositories" json:"repositories"`
+	// Violations of policies with severity warn, pointing into the operations of the transaction. Only present if there are any.
+	PolicyWarnings []ViolationDto `yaml:"-" json:"policyWarnings,omitempty"`
+}
+
+type ViolationDto struct {
+	// JSON pointer to the field in the request body the problem is about.
+	Pointer string `yaml:"pointer" json:"pointer"`
+	// Machine readable code of the problem, made from entity type, field and problem, e.g. service.alertTarget.invalid
+	Code string `yaml:"code" json:"code"`
+	// Human readable description of the problem.
+	Message string `yaml:"message" json:"message"`
+	// Values the problem depends on, e.g. the maximum length, for building localized messages.
+	Parameters map[string]string `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"github.com/Interhyp/metadata-service/internal/types"
 	"github.com/Roshick/go-autumn-kafka/pkg/aukafka"
+	"net"
 	"regexp"
 
 	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
@@ -50,6 +51,14 @@ type CustomConfiguration interface {
 	ServiceNamePermittedRegex() *regexp.Regexp
 	ServiceNameProhibitedRegex() *regexp.Regexp
 	ServiceNameMaxLength() uint16
+	ServiceConsumedApiValidation() string
+	ServiceLifecycleModel() ServiceLifecycleModel
+	SunsetWarningDays() uint16
+
+	LinkCheckIntervalMinutes() uint16
+	LinkCheckConcurrency() uint16
+	LinkCheckDelayMilliseconds() uint16
+	LinkCheckAllowedNetworks() []*net.IPNet
 
 	RepositoryNamePermittedRegex() *regexp.Regexp
 	RepositoryNameProhibitedRegex() *regexp.Regexp
@@ -61,6 +70,11 @@ type CustomConfiguration interface {
 
 	AllowedFileCategories() []string
 
+	Policies() []Policy
+
+	GraphqlMaxDepth() uint16
+	GraphqlMaxComplexity() uint16
+
 	Kafka() *aukafka.Config
 	KafkaGroupIdOverride() string
 
@@ -73,6 +87,43 @@ type NotificationConsumerConfig struct {
 	ConsumerURL string
 }
 
+// ServiceLifecycleModel lists the lifecycle states a service can be in, and the transitions allowed between them.
+type ServiceLifecycleModel struct {
+	// Initial is the lifecycle state of newly created services
+	Initial string
+	States  map[string]ServiceLifecycleState
+}
+
+type ServiceLifecycleState struct {
+	// Next lists the states a service in this state may transition to
+	Next []string
+	// RequiresSunsetDate means services in this state must have a sunsetDate
+	RequiresSunsetDate bool
+	// RequiresReplacedBy means services in this state must name the service replacing them in replacedBy
+	RequiresReplacedBy bool
+	// ProhibitsDependents means no service may list a service in this state in spec.dependsOn
+	ProhibitsDependents bool
+}
+
+// Policy is a declarative rule that services, repositories or owners are checked against before they are written.
+type Policy struct {
+	Name string
+	// Entity is the kind of entity the policy applies to, one of service, repository or owner
+	Entity string
+	// Severity is deny (the write fails) or warn (the write succeeds, but the response carries a warning)
+	Severity string
+	// Message explains the policy when it is violated
+	Message string
+	// When is a query selecting the entities the policy applies to, empty for all of them
+	When string
+	// Require is a query the entity must match
+	Require string
+	// RequireRepositories is a query each repository of a service must match
+	RequireRepositories string
+	// RequireOwner is a query the owner of a service or repository must match
+	RequireOwner string
+}
+
 // Custom is a type casting helper that gets you from the configuration acorn to your CustomConfiguration
 func Custom(configuration librepo.Configuration) CustomConfiguration {
 	return configuration.Custom().(CustomConfiguration)
@@ -114,6 +165,13 @@ const (
 	KeyServiceNamePermittedRegex      = "SERVICE_NAME_PERMITTED_REGEX"
 	KeyServiceNameProhibitedRegex     = "SERVICE_NAME_PROHIBITED_REGEX"
 	KeyServiceNameMaxLength           = "SERVICE_NAME_MAX_LENGTH"
+	KeyServiceConsumedApiValidation   = "SERVICE_CONSUMED_API_VALIDATION"
+	KeyServiceLifecycleModel          = "SERVICE_LIFECYCLE_MODEL"
+	KeySunsetWarningDays              = "SUNSET_WARNING_DAYS"
+	KeyLinkCheckIntervalMinutes       = "LINK_CHECK_INTERVAL_MINUTES"
+	KeyLinkCheckConcurrency           = "LINK_CHECK_CONCURRENCY"
+	KeyLinkCheckDelayMilliseconds     = "LINK_CHECK_DELAY_MILLISECONDS"
+	KeyLinkCheckAllowedNetworks       = "LINK_CHECK_ALLOWED_NETWORKS"
 	KeyRepositoryNamePermittedRegex   = "REPOSITORY_NAME_PERMITTED_REGEX"
 	KeyRepositoryNameProhibitedRegex  = "REPOSITORY_NAME_PROHIBITED_REGEX"
 	KeyRepositoryNameMaxLength        = "REPOSITORY_NAME_MAX_LENGTH"
@@ -121,6 +179,29 @@ const (
 	KeyRepositoryTypes                = "REPOSITORY_TYPES"
 	KeyNotificationConsumerConfigs    = "NOTIFICATION_CONSUMER_CONFIGS"
 	KeyAllowedFileCategories          = "ALLOWED_FILE_CATEGORIES"
+	KeyPolicies                       = "POLICIES"
+	KeyGraphqlMaxDepth                = "GRAPHQL_MAX_DEPTH"
+	KeyGraphqlMaxComplexity           = "GRAPHQL_MAX_COMPLEXITY"
 	KeyRedisUrl                       = "REDIS_URL"
 	KeyRedisPassword                  = "REDIS_PASSWORD"
 )
+
+// values for Policy.Entity
+const (
+	PolicyEntityService    = "service"
+	PolicyEntityRepository = "repository"
+	PolicyEntityOwner      = "owner"
+)
+
+// values for Policy.Severity
+const (
+	PolicySeverityDeny = "deny"
+	PolicySeverityWarn = "warn"
+)
+
+// values for ServiceConsumedApiValidation
+const (
+	ConsumedApiValidationIgnore = "ignore"
+	ConsumedApiValidationWarn   = "warn"
+	ConsumedApiValidationReject = "reject"
+)
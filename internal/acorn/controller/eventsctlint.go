@@ -0,0 +1,13 @@
+package controller
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+)
+
+// EventsController provides a server-sent events stream of changes to owners, services and repositories
+type EventsController interface {
+	IsEventsController() bool
+
+	WireUp(ctx context.Context, router chi.Router)
+}
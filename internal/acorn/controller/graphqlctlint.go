@@ -0,0 +1,13 @@
+package controller
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+)
+
+// GraphqlController provides a read only GraphQL endpoint over owners, services and repositories
+type GraphqlController interface {
+	IsGraphqlController() bool
+
+	WireUp(ctx context.Context, router chi.Router)
+}
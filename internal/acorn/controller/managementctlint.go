@@ -0,0 +1,13 @@
+package controller
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+)
+
+// ManagementController provides endpoints for maintaining the metadata as a whole.
+type ManagementController interface {
+	IsManagementController() bool
+
+	WireUp(ctx context.Context, router chi.Router)
+}
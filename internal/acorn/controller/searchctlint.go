@@ -0,0 +1,13 @@
+package controller
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+)
+
+// SearchController provides an endpoint for full text search across owners, services and repositories
+type SearchController interface {
+	IsSearchController() bool
+
+	WireUp(ctx context.Context, router chi.Router)
+}
@@ -0,0 +1,13 @@
+package controller
+
+import (
+	"context"
+	"github.com/go-chi/chi/v5"
+)
+
+// TransactionController provides an endpoint for changing several owners, services and repositories in one commit
+type TransactionController interface {
+	IsTransactionController() bool
+
+	WireUp(ctx context.Context, router chi.Router)
+}
@@ -0,0 +1,45 @@
+package nosuchcommiterror
+
+import (
+	"context"
+	"errors"
+)
+
+// NoSuchCommitError is raised when a point in time read refers to a commit that cannot be found on the mainline.
+type NoSuchCommitError interface {
+	Ctx() context.Context
+	IsNoSuchCommit() bool
+}
+
+// this also implements the error interface
+
+type Impl struct {
+	ctx context.Context
+	err error
+}
+
+func New(ctx context.Context, details string) error {
+	return &Impl{
+		ctx: ctx,
+		err: errors.New(details),
+	}
+}
+
+func (e *Impl) Error() string {
+	return e.err.Error()
+}
+
+func (e *Impl) Ctx() context.Context {
+	return e.ctx
+}
+
+// the presence of this method makes the interface unique and thus recognizable by a simple type check
+
+func (e *Impl) IsNoSuchCommit() bool {
+	return true
+}
+
+func Is(err error) bool {
+	_, ok := err.(NoSuchCommitError)
+	return ok
+}
@@ -0,0 +1,29 @@
+package preconditionerror
+
+import (
+	"github.com/StephanHCB/go-backend-service-common/api"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"net/http"
+	"time"
+)
+
+// New gives an api error with status 412, raised when the If-Match precondition of a write does not hold.
+//
+// The apierrors library has no constructor for this status, but handles any AnnotatedError.
+func New(message string, details string, response any, timestamp time.Time) apierrors.AnnotatedError {
+	return &apierrors.AnnotatedErrorImpl{
+		VApiError: api.ErrorDto{
+			Details:   &details,
+			Message:   &message,
+			Timestamp: &timestamp,
+		},
+		VResponseObject: response,
+		VHttpStatus:     http.StatusPreconditionFailed,
+	}
+}
+
+// Is can be passed to apierrors.HandleError like the IsXyz functions of that package.
+func Is(err error) bool {
+	annotated, ok := err.(apierrors.AnnotatedError)
+	return ok && annotated.HttpStatus() == http.StatusPreconditionFailed
+}
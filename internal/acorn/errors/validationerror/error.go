@@ -0,0 +1,42 @@
+package validationerror
+
+import (
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/StephanHCB/go-backend-service-common/api"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"net/http"
+	"time"
+)
+
+// New gives an api error with status 400 whose response lists the individual violations.
+//
+// apierrors.IsBadRequestError recognizes it like any other bad request.
+func New(message string, details string, violations []openapi.ViolationDto, timestamp time.Time) apierrors.AnnotatedError {
+	return &apierrors.AnnotatedErrorImpl{
+		VApiError: api.ErrorDto{
+			Details:   &details,
+			Message:   &message,
+			Timestamp: &timestamp,
+		},
+		VResponseObject: openapi.ErrorDto{
+			Details:    &details,
+			Message:    &message,
+			Timestamp:  &timestamp,
+			Violations: violations,
+		},
+		VHttpStatus: http.StatusBadRequest,
+	}
+}
+
+// Violations gives the violations listed by an error, if any.
+func Violations(err error) []openapi.ViolationDto {
+	annotated, ok := err.(apierrors.AnnotatedError)
+	if !ok {
+		return nil
+	}
+	response, ok := annotated.ResponseObject().(openapi.ErrorDto)
+	if !ok {
+		return nil
+	}
+	return response.Violations
+}
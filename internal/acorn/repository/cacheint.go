@@ -100,4 +100,29 @@ type Cache interface {
 	//
 	// This is an atomic operation.
 	DeleteRepository(ctx context.Context, key string) error
+
+	// --- overlay ---
+
+	// WithOverlay returns a child context that keeps every Put... and Delete... made with it in a private overlay
+	// instead of the shared cache. Reads made with that context see the overlay on top of the shared cache,
+	// all other readers never see it. The overlay is dropped together with the context.
+	//
+	// If ctx already carries an overlay, ctx is returned unchanged.
+	WithOverlay(ctx context.Context) context.Context
+
+	// --- notifications ---
+
+	// WasNotificationSent checks whether MarkNotificationSent has been called for the key.
+	//
+	// Shared between all instances if redis is used.
+	WasNotificationSent(ctx context.Context, key string) (bool, error)
+
+	// MarkNotificationSent remembers that the notification identified by the key has been sent.
+	MarkNotificationSent(ctx context.Context, key string) error
+
+	// ObtainLock waits until it holds the lock with the given key, which is shared between all instances
+	// if redis is used.
+	//
+	// The lock is held until cancel is called. If it is lost, the returned context is cancelled.
+	ObtainLock(ctx context.Context, key string) (context.Context, context.CancelFunc, error)
 }
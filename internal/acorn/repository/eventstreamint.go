@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+)
+
+// EventFilter selects the changes a subscriber is interested in. Empty fields match everything.
+type EventFilter struct {
+	// EntityType is one of the SearchType... constants.
+	EntityType string
+	// Owner matches entities that belong to the owner before or after the change.
+	Owner string
+}
+
+// Subscription is a single subscriber's view of the event stream.
+type Subscription struct {
+	// Replay holds the buffered events after the requested event id, oldest first.
+	Replay []openapi.ChangeEventDto
+	// Resumed is false if an event id was requested that is no longer buffered, so events may have been missed.
+	Resumed bool
+	// Events receives new events as they are published. It is closed when the subscriber cannot keep up,
+	// or the subscription is cancelled.
+	Events <-chan openapi.ChangeEventDto
+}
+
+// EventStream distributes change events to any number of subscribers within this instance.
+//
+// Published events are kept in a limited buffer, so subscribers can resume after a reconnect.
+// The commit hash of an event is its id.
+type EventStream interface {
+	IsEventStream() bool
+
+	Setup() error
+
+	// Publish buffers the event and passes it on to all subscribers whose filter matches.
+	//
+	// Never blocks, a subscriber that cannot keep up is dropped.
+	Publish(ctx context.Context, event openapi.ChangeEventDto)
+
+	// Subscribe registers a new subscriber. If lastEventId is not empty, the buffered events after it are replayed.
+	//
+	// Events are reduced to the entities matching the filter, events with no matching entities are left out.
+	//
+	// Call the returned function to cancel the subscription.
+	Subscribe(ctx context.Context, filter EventFilter, lastEventId string) (Subscription, func())
+}
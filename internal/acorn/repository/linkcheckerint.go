@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+)
+
+const (
+	LinkStatusOk      = "ok"
+	LinkStatusBroken  = "broken"
+	LinkStatusSkipped = "skipped"
+)
+
+// LinkCheckResult is the outcome of checking a single url.
+type LinkCheckResult struct {
+	// Status is one of the LinkStatus... constants.
+	Status string
+	// HttpStatus is the status of the response, 0 if no response was received.
+	HttpStatus int
+	// Error describes why the link is broken or was skipped, empty if it is ok.
+	Error string
+}
+
+// LinkChecker checks whether urls can be reached.
+//
+// Each host gets its own circuit breaker, so an unreachable host fails fast, and requests to the same host
+// are spaced out by the configured delay.
+type LinkChecker interface {
+	IsLinkChecker() bool
+
+	Setup() error
+
+	// Check sends a HEAD request to the url, falling back to GET if the server responds with an error status.
+	//
+	// Any status below 400 counts as ok. Urls that are not absolute http or https urls are skipped.
+	Check(ctx context.Context, url string) LinkCheckResult
+}
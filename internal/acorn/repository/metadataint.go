@@ -14,6 +14,15 @@ type CommitInfo struct {
 	FilesChanged []string
 }
 
+// FileRevision holds the contents of a file as of a commit that changed it.
+type FileRevision struct {
+	CommitInfo
+	Author string
+	Path   string
+	// Contents is nil if the file was deleted in this commit
+	Contents []byte
+}
+
 // Metadata is the central singleton representing the service-metadata git repository.
 //
 // All operations are protected by a mutex, but of course this does not prevent multiple
@@ -67,6 +76,21 @@ type Metadata interface {
 	// ReadFile returns the contents of a file, the commit hash, timestamp and message for the last change to the file
 	ReadFile(filename string) ([]byte, CommitInfo, error)
 
+	// ReadFileHistory returns all revisions of files whose path matches pathMatcher, newest first.
+	//
+	// If a commit touches more than one matching path (e.g. when a file is moved), only one revision is
+	// returned for it, preferring the path that still exists after the commit.
+	ReadFileHistory(ctx context.Context, pathMatcher func(path string) bool) ([]FileRevision, error)
+
+	// SnapshotAt returns a read only view of the metadata repository tree as of a past mainline commit,
+	// together with the info for that commit.
+	//
+	// at is either a commit hash, which may be abbreviated, or an RFC 3339 timestamp, in which case the newest
+	// commit made at or before that time is used. If no commit matches, a nosuchcommiterror is returned.
+	//
+	// The snapshot does not touch the worktree. All write operations on it fail.
+	SnapshotAt(ctx context.Context, at string) (Metadata, CommitInfo, error)
+
 	// WriteFile creates or overwrites a file in the local copy
 	WriteFile(filename string, contents []byte) error
 
@@ -18,4 +18,13 @@ type Notifier interface {
 	PublishModification(ctx context.Context, payloadName string, payload openapi.NotificationPayload) error
 
 	PublishDeletion(ctx context.Context, payloadName string, payloadType types.NotificationPayloadType)
+
+	// PublishLifecycleChange is sent in addition to PublishModification when the lifecycle of a service changes.
+	PublishLifecycleChange(ctx context.Context, payloadName string, payload openapi.NotificationPayload) error
+
+	// PublishSunset is sent once for each recipient, which is either the owner of the entity or a service that
+	// depends on it. Event must be SunsetApproachingEvent or SunsetPassedEvent.
+	//
+	// Unlike the other notifications, it is delivered synchronously, and fails if any consumer could not be reached.
+	PublishSunset(ctx context.Context, payloadName string, event types.NotificationEventType, payload openapi.NotificationPayload, recipient openapi.NotificationRecipient) error
 }
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+)
+
+const (
+	SearchTypeOwner      = "owner"
+	SearchTypeService    = "service"
+	SearchTypeRepository = "repository"
+)
+
+// SearchIndex is an in-memory inverted index over the text fields of the cached metadata.
+//
+// It is kept in sync with the cache by the updater.
+type SearchIndex interface {
+	IsSearchIndex() bool
+
+	Setup() error
+
+	// IndexOwner adds or replaces the index entries of an owner.
+	IndexOwner(ctx context.Context, alias string, owner openapi.OwnerDto)
+
+	// IndexService adds or replaces the index entries of a service.
+	IndexService(ctx context.Context, name string, service openapi.ServiceDto)
+
+	// IndexRepository adds or replaces the index entries of a repository.
+	IndexRepository(ctx context.Context, key string, repository openapi.RepositoryDto)
+
+	// Remove drops all index entries of an entity. Removing an entity that is not indexed is not an error.
+	Remove(ctx context.Context, entityType string, key string)
+
+	// Search gives the entities matching all words of the query, best match first.
+	//
+	// Words match whole words in the indexed fields, or their beginnings at a lower score.
+	// An empty entityType searches all types.
+	Search(ctx context.Context, query string, entityType string) []openapi.SearchHitDto
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+)
+
+// Consistency finds dangling references between the cached owners, services and repositories.
+type Consistency interface {
+	IsConsistency() bool
+
+	Setup() error
+
+	// CheckConsistency scans the cache and reports every inconsistency found, and updates the metrics.
+	// Called after each update of the cache by Trigger.
+	//
+	// Inconsistencies are services listing repositories that do not exist, repositories not listed by any service,
+	// references to groups of owners that do not exist, and owners without services.
+	CheckConsistency(ctx context.Context) (openapi.ConsistencyReportDto, error)
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+)
+
+// Events provides the business logic for watching changes to owners, services and repositories.
+type Events interface {
+	IsEvents() bool
+
+	Setup() error
+
+	// Subscribe validates the filter and subscribes to the event stream.
+	//
+	// entityType and owner restrict the events to matching entities if not empty. If lastEventId is not empty,
+	// the buffered events after that commit hash are replayed.
+	//
+	// Call the returned function to cancel the subscription.
+	Subscribe(ctx context.Context, entityType string, owner string, lastEventId string) (repository.Subscription, func(), error)
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+)
+
+// Graphql provides read only access to owners, services and repositories and their relationships via GraphQL.
+type Graphql interface {
+	IsGraphql() bool
+
+	Setup() error
+
+	// Execute runs a GraphQL query against the cache.
+	//
+	// Queries that fail to parse or validate, or that exceed the configured depth and complexity limits, are not
+	// executed, so the response has errors but no data.
+	Execute(ctx context.Context, request openapi.GraphqlRequestDto) openapi.GraphqlResponseDto
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+)
+
+// Links keeps track of broken service quicklinks and owner links.
+type Links interface {
+	IsLinks() bool
+
+	Setup() error
+
+	// CheckAllLinks checks every quicklink of every service and every link of every owner once, and updates the
+	// results and metrics. Called periodically by Trigger.
+	//
+	// Only a limited number of links are checked at the same time.
+	CheckAllLinks(ctx context.Context) error
+
+	// GetServiceQuicklinkStatus gives the latest results for the quicklinks of a service, in the order they
+	// are listed in the service. Quicklinks that have not been checked yet are reported as unchecked.
+	GetServiceQuicklinkStatus(ctx context.Context, serviceName string) (openapi.QuicklinkStatusListDto, error)
+}
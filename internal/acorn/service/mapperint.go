@@ -22,6 +22,8 @@ type Mapper interface {
 
 	Setup() error
 
+	// RefreshMetadata pulls the metadata, and returns an update event for each commit pulled since it was last
+	// called, including the commits pulled by write operations in between.
 	RefreshMetadata(ctx context.Context) ([]repository.UpdateEvent, error)
 	ContainsNewInformation(ctx context.Context, event repository.UpdateEvent) bool
 
@@ -31,6 +33,12 @@ type Mapper interface {
 	DeleteOwner(ctx context.Context, ownerAlias string, jiraIssue string) (openapi.OwnerPatchDto, error)
 	IsOwnerEmpty(ctx context.Context, ownerAlias string) bool
 
+	// RenameOwner moves the whole owner directory to the new alias, and changes all @ownerAlias.group references
+	// in owner groups and promoters, and in repository approvers and watchers, all in a single commit.
+	//
+	// Returns the owner as committed, and every entity that was moved or changed.
+	RenameOwner(ctx context.Context, ownerAlias string, newOwnerAlias string, jiraIssue string) (openapi.OwnerDto, repository.EventAffects, error)
+
 	GetSortedServiceNames(ctx context.Context) ([]string, error)
 	GetService(ctx context.Context, serviceName string) (openapi.ServiceDto, error)
 	WriteService(ctx context.Context, serviceName string, service openapi.ServiceDto) (openapi.ServiceDto, error)
@@ -41,12 +49,47 @@ type Mapper interface {
 	WriteRepository(ctx context.Context, repoKey string, repository openapi.RepositoryDto) (openapi.RepositoryDto, error)
 	DeleteRepository(ctx context.Context, repoKey string, jiraIssue string) (openapi.RepositoryPatchDto, error)
 
+	// GetOwnerHistory, GetServiceHistory and GetRepositoryHistory walk the git log for the entity's yaml file,
+	// following it across owner changes where applicable.
+	GetOwnerHistory(ctx context.Context, ownerAlias string) (openapi.HistoryDto, error)
+	GetServiceHistory(ctx context.Context, serviceName string) (openapi.HistoryDto, error)
+	GetRepositoryHistory(ctx context.Context, repoKey string) (openapi.HistoryDto, error)
+
+	// SnapshotAt returns a read only Mapper for the metadata as of a past commit, given as a commit hash or
+	// an RFC 3339 timestamp, together with the info for that commit. See repository.Metadata.SnapshotAt.
+	//
+	// Only the Get... methods of the returned Mapper are useful, all writes fail.
+	SnapshotAt(ctx context.Context, at string) (Mapper, repository.CommitInfo, error)
+
+	// WithTransaction calls closure with a child context in which all writes and deletes are only staged in the
+	// local clone. If closure succeeds, the staged changes are committed and pushed as a single commit, whose
+	// message lists all of them. If anything fails, the local clone is reset.
+	//
+	// Gives a nochangeserror if there were no actual changes.
+	WithTransaction(ctx context.Context, jiraIssue string, closure func(context.Context) error) (repository.CommitInfo, error)
+
+	// DryRun calls closure with a child context in which all writes and deletes are only staged, just like
+	// WithTransaction, but nothing is ever committed. Once closure returns, the staged changes are rendered
+	// as a unified diff and then discarded from the local clone.
+	DryRun(ctx context.Context, closure func(context.Context) error) (string, error)
+
 	// WriteServiceWithChangedOwner groups the whole operation into a single commit.
 	//
 	// A service takes all its referenced repositories along, but unreferenced repositories will be missed and stay.
 	// They can be moved as part of a repository update.
 	WriteServiceWithChangedOwner(ctx context.Context, serviceName string, service openapi.ServiceDto) (openapi.ServiceDto, error)
 
+	// RenameService groups the whole operation into a single commit.
+	//
+	// The service file is moved to its new name, with service as its new contents, so service.Repositories must
+	// already list the new repository keys. The repositories in renamedRepositories (old key to new key) are
+	// moved along, and spec.dependsOn and replacedBy of each of the dependents is changed to the new name.
+	// Likewise, replacedBy of the renamed repositories and of each of the repositoryDependents is changed
+	// to the new repository key.
+	//
+	// The owner cannot change at the same time.
+	RenameService(ctx context.Context, serviceName string, newServiceName string, service openapi.ServiceDto, renamedRepositories map[string]string, dependents []string, repositoryDependents []string) (openapi.ServiceDto, error)
+
 	// WriteRepositoryWithChangedOwner groups the whole operation into a single commit.
 	//
 	// Note that you MUST NOT call this for a repo that is referenced by a service (needs to be verified before
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/types"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
 )
 
 // Owners provides the business logic for owner metadata.
@@ -11,19 +13,54 @@ type Owners interface {
 
 	Setup() error
 
-	GetOwners(ctx context.Context) (openapi.OwnerListDto, error)
+	// ValidOwnerAlias checks validity of an owner alias and returns an error describing the problem if invalid
+	ValidOwnerAlias(ctx context.Context, ownerAlias string) apierrors.AnnotatedError
+
+	// GetOwners and GetOwnersAt give the whole list unless page asks for less.
+	// A cursor is only valid for the list timestamp it was issued with, afterwards a conflict error is returned.
+	GetOwners(ctx context.Context, page types.PageRequest) (openapi.OwnerListDto, error)
 	GetOwner(ctx context.Context, ownerAlias string) (openapi.OwnerDto, error)
 
+	// GetOwnersAt and GetOwnerAt read the owners as of a past commit, given as a commit hash or an RFC 3339 timestamp.
+	GetOwnersAt(ctx context.Context, at string, page types.PageRequest) (openapi.OwnerListDto, error)
+	GetOwnerAt(ctx context.Context, at string, ownerAlias string) (openapi.OwnerDto, error)
+
+	// GetOwnerHistory returns all commits that changed the owner info, newest first, with field level diffs.
+	GetOwnerHistory(ctx context.Context, ownerAlias string) (openapi.HistoryDto, error)
+
 	GetAllGroupMembers(ctx context.Context, groupOwner string, groupName string) []string
 
 	// CreateOwner returns the owner as it was created, with commit hash and timestamp filled in.
 	CreateOwner(ctx context.Context, ownerAlias string, ownerDto openapi.OwnerCreateDto) (openapi.OwnerDto, error)
 
 	// UpdateOwner returns the owner as it was committed, with commit hash and timestamp filled in.
+	//
+	// UpdateOwner and PatchOwner also accept an If-Match precondition in ctx (see types.WithIfMatch), in which
+	// case commit hash and timestamp may be left out.
 	UpdateOwner(ctx context.Context, ownerAlias string, ownerDto openapi.OwnerDto) (openapi.OwnerDto, error)
 
 	// PatchOwner returns the owner as it was committed, with commit hash and timestamp filled in.
 	PatchOwner(ctx context.Context, ownerAlias string, ownerPatchDto openapi.OwnerPatchDto) (openapi.OwnerDto, error)
 
+	// ApplyOwnerPatch applies a JSON Merge Patch or JSON Patch to the current owner, then updates it like UpdateOwner.
+	//
+	// The patch is applied to the owner without timeStamp, commitHash and jiraIssue, so it has to set them.
+	ApplyOwnerPatch(ctx context.Context, ownerAlias string, patch types.PatchDocument) (openapi.OwnerDto, error)
+
+	// DeleteOwner deletes an owner that no longer has any services or repositories.
+	//
+	// If deletionInfo.TransferTo is set, all services and repositories are first moved to that owner instead,
+	// in the same commit.
 	DeleteOwner(ctx context.Context, ownerAlias string, deletionInfo openapi.DeletionDto) error
+
+	// RevertOwner writes the owner as it was at a past commit, and returns it as committed.
+	RevertOwner(ctx context.Context, ownerAlias string, revertInfo openapi.RevertDto) (openapi.OwnerDto, error)
+
+	// RenameOwner gives the owner a new alias in a single commit, and returns it as committed.
+	//
+	// All its services and repositories move along, and all @ownerAlias.group references in owner groups and promoters,
+	// and in repository approvers and watchers, are changed to the new alias.
+	//
+	// Accepts an If-Match precondition in ctx like UpdateOwner.
+	RenameOwner(ctx context.Context, ownerAlias string, renameInfo openapi.OwnerRenameDto) (openapi.OwnerDto, error)
 }
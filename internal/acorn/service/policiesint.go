@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+)
+
+// Policies checks services, repositories and owners against the declarative rules configured in POLICIES
+// before they are written.
+type Policies interface {
+	IsPolicies() bool
+
+	Setup() error
+
+	// CheckService checks a service that is about to be written against the service policies.
+	//
+	// Violations of policies with severity deny are returned as a validation error, violations of policies with
+	// severity warn as the list of warnings. Must be called while holding the metadata lock, because the
+	// repositories and the owner of the service are read from the cache.
+	CheckService(ctx context.Context, serviceName string, service openapi.ServiceDto) ([]openapi.ViolationDto, error)
+
+	// CheckRepository checks a repository that is about to be written against the repository policies, and
+	// against the requireRepositories of the policies of all services that refer to it.
+	//
+	// Results and locking as for CheckService.
+	CheckRepository(ctx context.Context, key string, repository openapi.RepositoryDto) ([]openapi.ViolationDto, error)
+
+	// CheckOwner checks an owner that is about to be written against the owner policies, and against the
+	// requireOwner of the policies of all its services and repositories.
+	//
+	// Results and locking as for CheckService.
+	CheckOwner(ctx context.Context, ownerAlias string, owner openapi.OwnerDto) ([]openapi.ViolationDto, error)
+}
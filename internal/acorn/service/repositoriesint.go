@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/types"
 	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
 )
 
@@ -14,11 +15,30 @@ type Repositories interface {
 	// ValidRepositoryKey checks validity of a repository key and returns an error describing the problem if invalid
 	ValidRepositoryKey(ctx context.Context, repoKey string) apierrors.AnnotatedError
 
+	// GetRepositories and GetRepositoriesAt give the whole list unless page asks for less. The page size is the number of entries after filtering.
+	// A cursor is only valid for the list timestamp it was issued with, afterwards a conflict error is returned.
+	//
+	// queryFilter is an optional query expression like "configuration.archived=false AND NOT type=api",
+	// see query.Expression for the syntax. An invalid query is a bad request.
 	GetRepositories(ctx context.Context,
 		ownerAliasFilter string, serviceNameFilter string,
-		nameFilter string, typeFilter string) (openapi.RepositoryListDto, error)
+		nameFilter string, typeFilter string, queryFilter string,
+		page types.PageRequest) (openapi.RepositoryListDto, error)
 	GetRepository(ctx context.Context, repoKey string) (openapi.RepositoryDto, error)
 
+	// GetRepositoriesAt and GetRepositoryAt read the repositories as of a past commit, given as a commit hash or
+	// an RFC 3339 timestamp.
+	//
+	// Group references among approvers and watchers are expanded using the current owner groups.
+	GetRepositoriesAt(ctx context.Context, at string,
+		ownerAliasFilter string, serviceNameFilter string,
+		nameFilter string, typeFilter string, queryFilter string,
+		page types.PageRequest) (openapi.RepositoryListDto, error)
+	GetRepositoryAt(ctx context.Context, at string, repoKey string) (openapi.RepositoryDto, error)
+
+	// GetRepositoryHistory returns all commits that changed the repository, newest first, with field level diffs.
+	GetRepositoryHistory(ctx context.Context, repoKey string) (openapi.HistoryDto, error)
+
 	// CreateRepository returns the repository as it was created, with commit hash and timestamp filled in.
 	CreateRepository(ctx context.Context, key string, repositoryDto openapi.RepositoryCreateDto) (openapi.RepositoryDto, error)
 
@@ -26,6 +46,9 @@ type Repositories interface {
 	//
 	// Changing the owner of a repository is supported, unless it's still referenced by its service. In that case,
 	// move the whole service (including its repositories).
+	//
+	// UpdateRepository and PatchRepository also accept an If-Match precondition in ctx (see types.WithIfMatch), in
+	// which case commit hash and timestamp may be left out.
 	UpdateRepository(ctx context.Context, key string, repositoryDto openapi.RepositoryDto) (openapi.RepositoryDto, error)
 
 	// PatchRepository returns the repository as it was committed, with commit hash and timestamp filled in.
@@ -34,6 +57,15 @@ type Repositories interface {
 	// move the whole service (including its repositories).
 	PatchRepository(ctx context.Context, key string, repositoryPatchDto openapi.RepositoryPatchDto) (openapi.RepositoryDto, error)
 
+	// ApplyRepositoryPatch applies a JSON Merge Patch or JSON Patch to the current repository, then updates it like
+	// UpdateRepository.
+	//
+	// The patch is applied to the repository without timeStamp, commitHash and jiraIssue, so it has to set them.
+	ApplyRepositoryPatch(ctx context.Context, key string, patch types.PatchDocument) (openapi.RepositoryDto, error)
+
 	// DeleteRepository will fail if the repo is still referenced by its service. Delete that one first.
 	DeleteRepository(ctx context.Context, key string, deletionInfo openapi.DeletionDto) error
+
+	// RevertRepository writes the repository as it was at a past commit, and returns it as committed.
+	RevertRepository(ctx context.Context, key string, revertInfo openapi.RevertDto) (openapi.RepositoryDto, error)
 }
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+)
+
+// Search provides the business logic for full text search across owners, services and repositories.
+type Search interface {
+	IsSearch() bool
+
+	Setup() error
+
+	// Search gives the owners, services and repositories matching all words of the query, best match first.
+	//
+	// entityType restricts the search to one kind of entity if not empty. A limit of 0 gives all hits.
+	Search(ctx context.Context, query string, entityType string, limit int) (openapi.SearchResultDto, error)
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/types"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
 )
 
 // Services provides the business logic for service metadata.
@@ -11,15 +13,33 @@ type Services interface {
 
 	Setup() error
 
-	GetServices(ctx context.Context, ownerAliasFilter string) (openapi.ServiceListDto, error)
+	// ValidServiceName checks validity of a service name and returns an error describing the problem if invalid
+	ValidServiceName(ctx context.Context, serviceName string) apierrors.AnnotatedError
+
+	// GetServices and GetServicesAt give the whole list unless page asks for less. The page size is the number of entries after filtering.
+	// A cursor is only valid for the list timestamp it was issued with, afterwards a conflict error is returned.
+	//
+	// queryFilter is an optional query expression like "lifecycle!=deprecated AND labels.team=payments",
+	// see query.Expression for the syntax. An invalid query is a bad request.
+	GetServices(ctx context.Context, ownerAliasFilter string, queryFilter string, page types.PageRequest) (openapi.ServiceListDto, error)
 	GetService(ctx context.Context, serviceName string) (openapi.ServiceDto, error)
 
+	// GetServicesAt and GetServiceAt read the services as of a past commit, given as a commit hash or an RFC 3339 timestamp.
+	GetServicesAt(ctx context.Context, at string, ownerAliasFilter string, queryFilter string, page types.PageRequest) (openapi.ServiceListDto, error)
+	GetServiceAt(ctx context.Context, at string, serviceName string) (openapi.ServiceDto, error)
+
+	// GetServiceHistory returns all commits that changed the service, newest first, with field level diffs.
+	GetServiceHistory(ctx context.Context, serviceName string) (openapi.HistoryDto, error)
+
 	// CreateService returns the service as it was created, with commit hash and timestamp filled in.
 	CreateService(ctx context.Context, serviceName string, serviceDto openapi.ServiceCreateDto) (openapi.ServiceDto, error)
 
 	// UpdateService returns the service as it was committed, with commit hash and timestamp filled in.
 	//
 	// Changing the owner of a service is supported, and will also move any referenced repositories to the new owner.
+	//
+	// UpdateService and PatchService also accept an If-Match precondition in ctx (see types.WithIfMatch), in which
+	// case commit hash and timestamp may be left out.
 	UpdateService(ctx context.Context, serviceName string, serviceDto openapi.ServiceDto) (openapi.ServiceDto, error)
 
 	// PatchService returns the service as it was committed, with commit hash and timestamp filled in.
@@ -27,8 +47,59 @@ type Services interface {
 	// Changing the owner of a service is supported, and will also move any referenced repositories to the new owner.
 	PatchService(ctx context.Context, serviceName string, servicePatchDto openapi.ServicePatchDto) (openapi.ServiceDto, error)
 
+	// ApplyServicePatch applies a JSON Merge Patch or JSON Patch to the current service, then updates it like UpdateService.
+	//
+	// The patch is applied to the service without timeStamp, commitHash and jiraIssue, so it has to set them.
+	ApplyServicePatch(ctx context.Context, serviceName string, patch types.PatchDocument) (openapi.ServiceDto, error)
+
+	// GetServicePromoters returns the sorted list of users who may promote services of the given owner.
+	//
+	// This is the union of the owner's promoters (with @owner.group references expanded) and the product
+	// owners of all owners.
+	GetServicePromoters(ctx context.Context, serviceOwnerAlias string) (openapi.ServicePromotersDto, error)
+
+	// GetServiceDependencyGraph returns all services and the dependencies between them given by spec.dependsOn.
+	//
+	// Services that are referenced but do not exist are included without an owner.
+	GetServiceDependencyGraph(ctx context.Context) (openapi.ServiceDependencyGraphDto, error)
+
+	// GetServiceDependencyClosure returns the subgraph of services that the given service transitively
+	// depends on (direction "upstream"), that transitively depend on it ("downstream"), or both ("both" or empty).
+	GetServiceDependencyClosure(ctx context.Context, serviceName string, direction string) (openapi.ServiceDependencyGraphDto, error)
+
+	// GetServiceApiIndex returns all APIs referenced in spec.providesApis or spec.consumesApis, with the
+	// services providing and consuming them.
+	GetServiceApiIndex(ctx context.Context) (openapi.ServiceApiIndexDto, error)
+
 	// DeleteService deletes a service, but leaves its repositories behind
 	//
 	// Reason: they still need to be configured by bit-brother.
 	DeleteService(ctx context.Context, serviceName string, deletionInfo openapi.DeletionDto) error
+
+	// RevertService writes the service as it was at a past commit, and returns it as committed.
+	//
+	// The old state must still be valid, e.g. all referenced repositories must still exist.
+	RevertService(ctx context.Context, serviceName string, revertInfo openapi.RevertDto) (openapi.ServiceDto, error)
+
+	// RenameService gives the service a new name in a single commit, and returns it as committed.
+	//
+	// All spec.dependsOn references in other services are changed to the new name. The helm-deployment and none
+	// repositories of the service carry its name in their keys, so they must be renamed along with it if present.
+	//
+	// Accepts an If-Match precondition in ctx like UpdateService.
+	RenameService(ctx context.Context, serviceName string, renameInfo openapi.ServiceRenameDto) (openapi.ServiceDto, error)
+
+	// TransitionServiceLifecycle moves the service to another lifecycle state, and returns it as committed.
+	//
+	// The configured lifecycle model must allow the transition, and the service must satisfy the rules of the new
+	// state, e.g. name a sunset date and a successor. UpdateService and PatchService cannot change the lifecycle.
+	//
+	// Accepts an If-Match precondition in ctx like UpdateService.
+	TransitionServiceLifecycle(ctx context.Context, serviceName string, transition openapi.ServiceLifecycleTransitionDto) (openapi.ServiceDto, error)
+
+	// GetServiceAlertRouting resolves the alert target of the service into its kind and normalized value.
+	//
+	// Alert targets written before they had kinds are parsed backwards-compatibly. If the stored alert target
+	// is no longer valid, e.g. because the configured prefix or suffix changed, a conflict error is returned.
+	GetServiceAlertRouting(ctx context.Context, serviceName string) (openapi.AlertRoutingDto, error)
 }
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+)
+
+// Transactions provides the business logic for changing several owners, services and repositories at once.
+type Transactions interface {
+	IsTransactions() bool
+
+	Setup() error
+
+	// ApplyTransaction validates all operations, then applies them in order, resulting in a single commit.
+	//
+	// Either all operations succeed, or nothing is changed at all.
+	ApplyTransaction(ctx context.Context, transaction openapi.TransactionDto) (openapi.TransactionResultDto, error)
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
 )
 
 // Updater is the central orchestrator component that manages information flow.
@@ -27,12 +28,33 @@ type Updater interface {
 	// Any error closure returns is passed through, and the lock is finally released.
 	WithMetadataLock(ctx context.Context, closure func(context.Context) error) error
 
+	// -- Transactions --
+
+	// WithTransaction obtains the lock (unless already held), performs a full update, and then calls closure with
+	// a child context. All Write... and Delete... calls made with that context are only staged. Once closure succeeds,
+	// they are committed and pushed as a single commit, and a single kafka event covering every affected entity is sent.
+	//
+	// While the transaction is open, cache reads made with the child context already reflect the staged changes,
+	// so each step can rely on the previous ones. Everybody else keeps seeing the committed state, and the shared
+	// cache and the search index are only updated after the commit. Should anything fail, the local clone is reset.
+	//
+	// Returns the event that was sent. If there were no actual changes, nothing is sent and the commit hash is empty.
+	WithTransaction(ctx context.Context, jiraIssue string, closure func(context.Context) error) (repository.UpdateEvent, error)
+
+	// DryRun works like WithTransaction, except that the staged changes are never committed, and no kafka
+	// event is sent. Once closure returns, whether it succeeded or not, the staged changes are discarded by restoring
+	// the touched files in the local clone, which is only cloned again if restoring fails. The shared cache and the
+	// search index are never touched.
+	//
+	// Returns a unified diff of the files that would have been committed.
+	DryRun(ctx context.Context, closure func(context.Context) error) (string, error)
+
 	// -- these do lock unless used inside WithMetadataLock(), use that if you need to hold the lock longer --
 
 	// PerformFullUpdate is called by Trigger both for initial cache population and periodic updates.
 	//
 	// It does not send any kafka events - one situation where it might be called is when an event
-	// has been received.
+	// has been received. The new commits it pulls are published on the event stream, though.
 	//
 	// Both the git tree and all caches are updated.
 	PerformFullUpdate(ctx context.Context) error
@@ -56,6 +78,12 @@ type Updater interface {
 
 	CanDeleteOwner(ctx context.Context, ownerAlias string) bool
 
+	// RenameOwner moves an owner with all its services and repositories to a new alias, and changes all group
+	// references to it. Returns the owner as written, with commit hash and timestamp filled in.
+	//
+	// Sends a single kafka event covering every affected entity and updates the cache.
+	RenameOwner(ctx context.Context, ownerAlias string, newOwnerAlias string, jiraIssue string) (openapi.OwnerDto, error)
+
 	// WriteService returns the service as written, with commit hash and timestamp filled in.
 	//
 	// This supports changing the owner.
@@ -65,6 +93,16 @@ type Updater interface {
 	// Sends a kafka event and updates the cache.
 	WriteService(ctx context.Context, serviceName string, validServiceDto openapi.ServiceDto) (openapi.ServiceDto, error)
 
+	// RenameService moves a service to a new name, together with the repositories in renamedRepositories
+	// (old key to new key), and changes spec.dependsOn and replacedBy of all services that refer to it, as well
+	// as replacedBy of all repositories that refer to one of the renamed repositories. Returns the service
+	// as written, with commit hash and timestamp filled in.
+	//
+	// Assumes up-to-date cache.
+	//
+	// Sends a single kafka event covering both names and updates the cache.
+	RenameService(ctx context.Context, serviceName string, newServiceName string, validServiceDto openapi.ServiceDto, renamedRepositories map[string]string) (openapi.ServiceDto, error)
+
 	// DeleteService deletes a service.
 	//
 	// Sends a kafka event and updates the cache.
@@ -84,6 +122,42 @@ type Updater interface {
 	// Sends a kafka event and updates the cache.
 	DeleteRepository(ctx context.Context, key string, deletionInfo openapi.DeletionDto) error
 
+	// -- Deprecation --
+
+	// NotifySunsets is called daily by Trigger. It sends a sunset approaching or sunset passed notification
+	// to the owner and all dependent services of each service and repository with a sunset date that is
+	// less than the configured number of days away, or has already been reached.
+	//
+	// Each notification is only sent once per sunset date and recipient, also with several instances, because
+	// both the sent notifications and a lock held while sending are kept in the cache. Does not need the metadata lock.
+	NotifySunsets(ctx context.Context) error
+
+	// -- History --
+
+	// GetOwnerHistory returns all commits that changed an owner, newest first, with field level diffs.
+	//
+	// Does not need the lock, reading the git log does not interfere with writes.
+	GetOwnerHistory(ctx context.Context, ownerAlias string) (openapi.HistoryDto, error)
+
+	// GetServiceHistory returns all commits that changed a service, newest first, with field level diffs.
+	//
+	// Does not need the lock, reading the git log does not interfere with writes.
+	GetServiceHistory(ctx context.Context, serviceName string) (openapi.HistoryDto, error)
+
+	// GetRepositoryHistory returns all commits that changed a repository, newest first, with field level diffs.
+	//
+	// Does not need the lock, reading the git log does not interfere with writes.
+	GetRepositoryHistory(ctx context.Context, key string) (openapi.HistoryDto, error)
+
+	// -- Point in time reads --
+
+	// SnapshotAt returns a read only stand-in for the cache that serves the metadata as of a past commit,
+	// given as a commit hash or an RFC 3339 timestamp. Entries are read from the git history on demand,
+	// neither the worktree nor the cache are touched.
+	//
+	// Does not need the lock. The result is meant to be used for a single request and then discarded.
+	SnapshotAt(ctx context.Context, at string) (repository.Cache, error)
+
 	// CanMoveOrDeleteRepository checks that no service still references the repository key.
 	//
 	// Expects a current cache and you must be holding the lock.
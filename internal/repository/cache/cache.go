@@ -6,6 +6,7 @@ import (
 	"github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Interhyp/metadata-service/internal/acorn/repository"
 	libcache "github.com/Roshick/go-autumn-synchronisation/pkg/cache"
+	liblocker "github.com/Roshick/go-autumn-synchronisation/pkg/locker"
 	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
 	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
 	"time"
@@ -23,6 +24,9 @@ type Impl struct {
 	ServiceCache    libcache.Cache[openapi.ServiceDto]
 	RepositoryCache libcache.Cache[openapi.RepositoryDto]
 	TimestampCache  libcache.Cache[string]
+
+	NotificationCache libcache.Cache[string]
+	Locker            liblocker.Locker
 }
 
 func New(
@@ -60,6 +64,7 @@ const (
 	serviceKeyPrefix    = "v1-service"
 	repositoryKeyPrefix = "v1-repository"
 	timestampKeyPrefix  = "v1-timestamp"
+	notificationPrefix  = "v1-notification"
 )
 
 func (s *Impl) SetupCache(ctx context.Context) error {
@@ -78,6 +83,12 @@ func (s *Impl) SetupCache(ctx context.Context) error {
 		if s.TimestampCache == nil {
 			s.TimestampCache = libcache.NewMemoryCache[string]()
 		}
+		if s.NotificationCache == nil {
+			s.NotificationCache = libcache.NewMemoryCache[string]()
+		}
+		if s.Locker == nil {
+			s.Locker = liblocker.NewMemoryLocker()
+		}
 	} else {
 		s.Logging.Logger().Ctx(ctx).Info().Printf("using redis at %s", redisUrl)
 		redisPassword := s.CustomConfiguration.RedisUrl()
@@ -93,6 +104,16 @@ func (s *Impl) SetupCache(ctx context.Context) error {
 		if s.TimestampCache == nil {
 			s.TimestampCache = libcache.NewRedisCache[string](redisUrl, redisPassword, timestampKeyPrefix)
 		}
+		if s.NotificationCache == nil {
+			s.NotificationCache = libcache.NewRedisCache[string](redisUrl, redisPassword, notificationPrefix)
+		}
+		if s.Locker == nil {
+			locker, err := liblocker.NewRedisLocker(redisUrl, redisPassword)
+			if err != nil {
+				return err
+			}
+			s.Locker = locker
+		}
 	}
 	return nil
 }
@@ -49,12 +49,15 @@ func getSortedKeys[E any](ctx context.Context, what string, s *Impl, cache libca
 		s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("%s: %s", details, err.Error())
 		return []string{}, apierrors.NewBadGatewayError(messageKey, details, err, s.Timestamp.Now())
 	}
+	if o, ok := currentOverlay(ctx); ok {
+		keys = o.applyToKeys(what, keys)
+	}
 	sort.Strings(keys)
 	return keys, nil
 }
 
 func getEntry[E any](ctx context.Context, what string, s *Impl, cache libcache.Cache[E], key string) (E, error) {
-	copiedEntryPtr, err := cache.Get(ctx, key)
+	copiedEntryPtr, err := getEntryPtr(ctx, what, cache, key)
 	if err != nil {
 		var empty E
 		messageKey := fmt.Sprintf("cache.%s.error", what)
@@ -73,8 +76,26 @@ func getEntry[E any](ctx context.Context, what string, s *Impl, cache libcache.C
 	}
 }
 
+// getEntryPtr reads the entry from the overlay in ctx, if it knows the key, and from the cache otherwise.
+func getEntryPtr[E any](ctx context.Context, what string, cache libcache.Cache[E], key string) (*E, error) {
+	if o, ok := currentOverlay(ctx); ok {
+		if value, found := o.lookup(what, key); found {
+			if value == nil {
+				return nil, nil
+			}
+			return unmarshalOverlayEntry[E](*value)
+		}
+	}
+	return cache.Get(ctx, key)
+}
+
 func putEntry[E any](ctx context.Context, what string, s *Impl, cache libcache.Cache[E], key string, entry E) error {
-	err := cache.Set(ctx, key, entry, cacheRetention)
+	var err error
+	if o, ok := currentOverlay(ctx); ok {
+		err = putOverlayEntry(o, what, key, entry)
+	} else {
+		err = cache.Set(ctx, key, entry, cacheRetention)
+	}
 	if err != nil {
 		messageKey := fmt.Sprintf("cache.%s.error", what)
 		details := fmt.Sprintf("error writing %s %s to cache", what, key)
@@ -85,6 +106,10 @@ func putEntry[E any](ctx context.Context, what string, s *Impl, cache libcache.C
 }
 
 func removeEntry[E any](ctx context.Context, what string, s *Impl, cache libcache.Cache[E], key string) error {
+	if o, ok := currentOverlay(ctx); ok {
+		o.set(what, key, nil)
+		return nil
+	}
 	err := cache.Remove(ctx, key)
 	if err != nil {
 		messageKey := fmt.Sprintf("cache.%s.error", what)
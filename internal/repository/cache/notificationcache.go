@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"time"
+)
+
+const notificationWhat = "notification"
+
+// notificationRetention outlasts the longest possible sunset warning period.
+var notificationRetention = 400 * 24 * time.Hour
+
+func (s *Impl) WasNotificationSent(ctx context.Context, key string) (bool, error) {
+	valPtr, err := s.NotificationCache.Get(ctx, key)
+	if err != nil {
+		messageKey := fmt.Sprintf("cache.%s.error", notificationWhat)
+		details := fmt.Sprintf("error reading %s %s from cache", notificationWhat, key)
+		s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("%s: %s", details, err.Error())
+		return false, apierrors.NewBadGatewayError(messageKey, details, err, s.Timestamp.Now())
+	}
+	return valPtr != nil, nil
+}
+
+func (s *Impl) MarkNotificationSent(ctx context.Context, key string) error {
+	err := s.NotificationCache.Set(ctx, key, s.Timestamp.Now().UTC().Format(time.RFC3339), notificationRetention)
+	if err != nil {
+		messageKey := fmt.Sprintf("cache.%s.error", notificationWhat)
+		details := fmt.Sprintf("error writing %s %s to cache", notificationWhat, key)
+		s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("%s: %s", details, err.Error())
+		return apierrors.NewBadGatewayError(messageKey, details, err, s.Timestamp.Now())
+	}
+	return nil
+}
+
+func (s *Impl) ObtainLock(ctx context.Context, key string) (context.Context, context.CancelFunc, error) {
+	lockCtx, cancel, err := s.Locker.ObtainLock(ctx, key)
+	if err != nil {
+		details := fmt.Sprintf("error obtaining lock %s", key)
+		s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("%s: %s", details, err.Error())
+		return ctx, func() {}, apierrors.NewBadGatewayError("cache.lock.error", details, err, s.Timestamp.Now())
+	}
+	return lockCtx, cancel, nil
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+type overlayType int
+
+const overlayKey overlayType = 0
+
+// overlay holds cache writes that only the context carrying it can see.
+//
+// Entries are stored as json, just like the in-memory cache does, so every read gets its own deep copy.
+type overlay struct {
+	mu sync.Mutex
+	// entries by what and key, nil if the entry was deleted
+	entries map[string]map[string]*string
+}
+
+func (s *Impl) WithOverlay(ctx context.Context) context.Context {
+	if _, ok := currentOverlay(ctx); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, overlayKey, &overlay{
+		entries: make(map[string]map[string]*string),
+	})
+}
+
+func currentOverlay(ctx context.Context) (*overlay, bool) {
+	o, ok := ctx.Value(overlayKey).(*overlay)
+	return o, ok
+}
+
+func (o *overlay) set(what string, key string, value *string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.entries[what]; !ok {
+		o.entries[what] = make(map[string]*string)
+	}
+	o.entries[what][key] = value
+}
+
+// lookup returns the json for the entry, or nil if it was deleted. found is false if the overlay does not know the key.
+func (o *overlay) lookup(what string, key string) (value *string, found bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	value, found = o.entries[what][key]
+	return value, found
+}
+
+// applyToKeys adds the keys written to the overlay and drops the keys deleted from it.
+func (o *overlay) applyToKeys(what string, keys []string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, found := o.entries[what][key]; !found {
+			result = append(result, key)
+		}
+	}
+	for key, value := range o.entries[what] {
+		if value != nil {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+func putOverlayEntry[E any](o *overlay, what string, key string, entry E) error {
+	jsonBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	value := string(jsonBytes)
+	o.set(what, key, &value)
+	return nil
+}
+
+func unmarshalOverlayEntry[E any](value string) (*E, error) {
+	var entry E
+	if err := json.Unmarshal([]byte(value), &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
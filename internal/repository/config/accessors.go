@@ -3,6 +3,7 @@ package config
 import (
 	"github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Roshick/go-autumn-kafka/pkg/aukafka"
+	"net"
 	"os"
 	"regexp"
 	"strings"
@@ -135,6 +136,34 @@ func (c *CustomConfigImpl) ServiceNameMaxLength() uint16 {
 	return c.VServiceNameMaxLength
 }
 
+func (c *CustomConfigImpl) ServiceConsumedApiValidation() string {
+	return c.VServiceConsumedApiValidation
+}
+
+func (c *CustomConfigImpl) ServiceLifecycleModel() config.ServiceLifecycleModel {
+	return c.VServiceLifecycleModel
+}
+
+func (c *CustomConfigImpl) SunsetWarningDays() uint16 {
+	return c.VSunsetWarningDays
+}
+
+func (c *CustomConfigImpl) LinkCheckIntervalMinutes() uint16 {
+	return c.VLinkCheckIntervalMinutes
+}
+
+func (c *CustomConfigImpl) LinkCheckConcurrency() uint16 {
+	return c.VLinkCheckConcurrency
+}
+
+func (c *CustomConfigImpl) LinkCheckDelayMilliseconds() uint16 {
+	return c.VLinkCheckDelayMilliseconds
+}
+
+func (c *CustomConfigImpl) LinkCheckAllowedNetworks() []*net.IPNet {
+	return c.VLinkCheckAllowedNetworks
+}
+
 func (c *CustomConfigImpl) RepositoryNamePermittedRegex() *regexp.Regexp {
 	return c.VRepositoryNamePermittedRegex
 }
@@ -163,6 +192,18 @@ func (c *CustomConfigImpl) AllowedFileCategories() []string {
 	return c.VAllowedFileCategories
 }
 
+func (c *CustomConfigImpl) Policies() []config.Policy {
+	return c.VPolicies
+}
+
+func (c *CustomConfigImpl) GraphqlMaxDepth() uint16 {
+	return c.VGraphqlMaxDepth
+}
+
+func (c *CustomConfigImpl) GraphqlMaxComplexity() uint16 {
+	return c.VGraphqlMaxComplexity
+}
+
 func (c *CustomConfigImpl) Kafka() *aukafka.Config {
 	return c.VKafkaConfig
 }
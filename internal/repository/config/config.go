@@ -7,6 +7,16 @@ import (
 	"math"
 )
 
+const defaultServiceLifecycleModel = `{
+  "initial": "experimental",
+  "states": {
+    "experimental": {"next": ["production", "retired"]},
+    "production": {"next": ["deprecated"]},
+    "deprecated": {"next": ["production", "retired"], "requiresSunsetDate": true, "requiresReplacedBy": true},
+    "retired": {"prohibitsDependents": true}
+  }
+}`
+
 var CustomConfigItems = []auconfigapi.ConfigItem{
 	{
 		Key:         config.KeyBasicAuthUsername,
@@ -223,6 +233,63 @@ var CustomConfigItems = []auconfigapi.ConfigItem{
 		Description: "maximum length of a valid service name.",
 		Validate:    auconfigenv.ObtainIntRangeValidator(1, 100),
 	},
+	{
+		Key:         config.KeyServiceConsumedApiValidation,
+		EnvName:     config.KeyServiceConsumedApiValidation,
+		Default:     config.ConsumedApiValidationWarn,
+		Description: "how to handle services that consume apis no service provides. One of ignore, warn (log a warning) or reject (fail validation).",
+		Validate:    auconfigenv.ObtainPatternValidator("^(ignore|warn|reject)$"),
+	},
+	{
+		Key:         config.KeyServiceLifecycleModel,
+		EnvName:     config.KeyServiceLifecycleModel,
+		Default:     defaultServiceLifecycleModel,
+		Description: "json configuration of the service lifecycle states and the transitions allowed between them.",
+		Validate: func(key string) error {
+			value := auconfigenv.Get(key)
+			_, err := parseServiceLifecycleModel(value)
+			return err
+		},
+	},
+	{
+		Key:         config.KeySunsetWarningDays,
+		EnvName:     config.KeySunsetWarningDays,
+		Default:     "30",
+		Description: "number of days before the sunset date of a service or repository that its owner and dependents are first notified.",
+		Validate:    auconfigenv.ObtainUintRangeValidator(1, 365),
+	},
+	{
+		Key:         config.KeyLinkCheckIntervalMinutes,
+		EnvName:     config.KeyLinkCheckIntervalMinutes,
+		Default:     "60",
+		Description: "time in minutes between two checks of all service quicklinks and owner links. Set to 0 to disable link checking.",
+		Validate:    auconfigenv.ObtainUintRangeValidator(0, 1440),
+	},
+	{
+		Key:         config.KeyLinkCheckConcurrency,
+		EnvName:     config.KeyLinkCheckConcurrency,
+		Default:     "4",
+		Description: "maximum number of links that are checked at the same time.",
+		Validate:    auconfigenv.ObtainUintRangeValidator(1, 64),
+	},
+	{
+		Key:         config.KeyLinkCheckDelayMilliseconds,
+		EnvName:     config.KeyLinkCheckDelayMilliseconds,
+		Default:     "1000",
+		Description: "minimum time in milliseconds between two link check requests to the same host.",
+		Validate:    auconfigenv.ObtainUintRangeValidator(0, 60000),
+	},
+	{
+		Key:         config.KeyLinkCheckAllowedNetworks,
+		EnvName:     config.KeyLinkCheckAllowedNetworks,
+		Default:     "",
+		Description: "space separated list of networks in CIDR notation that links may point to although they are not public, e.g. an internal wiki.",
+		Validate: func(key string) error {
+			value := auconfigenv.Get(key)
+			_, err := parseNetworks(value)
+			return err
+		},
+	},
 	{
 		Key:         config.KeyRepositoryNamePermittedRegex,
 		EnvName:     config.KeyRepositoryNamePermittedRegex,
@@ -280,6 +347,31 @@ var CustomConfigItems = []auconfigapi.ConfigItem{
 			return err
 		},
 	},
+	{
+		Key:         config.KeyPolicies,
+		EnvName:     config.KeyPolicies,
+		Default:     "[]",
+		Description: "json list of policies that services, repositories and owners are checked against before they are written.",
+		Validate: func(key string) error {
+			value := auconfigenv.Get(key)
+			_, err := parsePolicies(value)
+			return err
+		},
+	},
+	{
+		Key:         config.KeyGraphqlMaxDepth,
+		EnvName:     config.KeyGraphqlMaxDepth,
+		Default:     "8",
+		Description: "maximum nesting depth of graphql queries.",
+		Validate:    auconfigenv.ObtainUintRangeValidator(1, 100),
+	},
+	{
+		Key:         config.KeyGraphqlMaxComplexity,
+		EnvName:     config.KeyGraphqlMaxComplexity,
+		Default:     "5000",
+		Description: "maximum estimated complexity of graphql queries. Each field counts 1, fields below a list count 10 times.",
+		Validate:    auconfigenv.ObtainUintRangeValidator(1, 65535),
+	},
 	{
 		Key:         config.KeyRedisUrl,
 		EnvName:     config.KeyRedisUrl,
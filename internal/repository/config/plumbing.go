@@ -11,7 +11,9 @@ import (
 	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
 	libconfig "github.com/StephanHCB/go-backend-service-common/repository/config"
 	"github.com/StephanHCB/go-backend-service-common/repository/vault"
+	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -54,6 +56,13 @@ type CustomConfigImpl struct {
 	VServiceNamePermittedRegex      *regexp.Regexp
 	VServiceNameProhibitedRegex     *regexp.Regexp
 	VServiceNameMaxLength           uint16
+	VServiceConsumedApiValidation   string
+	VServiceLifecycleModel          config.ServiceLifecycleModel
+	VSunsetWarningDays              uint16
+	VLinkCheckIntervalMinutes       uint16
+	VLinkCheckConcurrency           uint16
+	VLinkCheckDelayMilliseconds     uint16
+	VLinkCheckAllowedNetworks       []*net.IPNet
 	VRepositoryNamePermittedRegex   *regexp.Regexp
 	VRepositoryNameProhibitedRegex  *regexp.Regexp
 	VRepositoryNameMaxLength        uint16
@@ -61,6 +70,9 @@ type CustomConfigImpl struct {
 	VRepositoryKeySeparator         string
 	VNotificationConsumerConfigs    map[string]config.NotificationConsumerConfig
 	VAllowedFileCategories          []string
+	VPolicies                       []config.Policy
+	VGraphqlMaxDepth                uint16
+	VGraphqlMaxComplexity           uint16
 	VRedisUrl                       string
 	VRedisPassword                  string
 
@@ -114,6 +126,13 @@ func (c *CustomConfigImpl) Obtain(getter func(key string) string) {
 	c.VServiceNamePermittedRegex, _ = regexp.Compile(getter(config.KeyServiceNamePermittedRegex))
 	c.VServiceNameProhibitedRegex, _ = regexp.Compile(getter(config.KeyServiceNameProhibitedRegex))
 	c.VServiceNameMaxLength = toUint16(getter(config.KeyServiceNameMaxLength))
+	c.VServiceConsumedApiValidation = getter(config.KeyServiceConsumedApiValidation)
+	c.VServiceLifecycleModel, _ = parseServiceLifecycleModel(getter(config.KeyServiceLifecycleModel))
+	c.VSunsetWarningDays = toUint16(getter(config.KeySunsetWarningDays))
+	c.VLinkCheckIntervalMinutes = toUint16(getter(config.KeyLinkCheckIntervalMinutes))
+	c.VLinkCheckConcurrency = toUint16(getter(config.KeyLinkCheckConcurrency))
+	c.VLinkCheckDelayMilliseconds = toUint16(getter(config.KeyLinkCheckDelayMilliseconds))
+	c.VLinkCheckAllowedNetworks, _ = parseNetworks(getter(config.KeyLinkCheckAllowedNetworks))
 	c.VRepositoryNamePermittedRegex, _ = regexp.Compile(getter(config.KeyRepositoryNamePermittedRegex))
 	c.VRepositoryNameProhibitedRegex, _ = regexp.Compile(getter(config.KeyRepositoryNameProhibitedRegex))
 	c.VRepositoryNameMaxLength = toUint16(getter(config.KeyRepositoryNameMaxLength))
@@ -121,6 +140,9 @@ func (c *CustomConfigImpl) Obtain(getter func(key string) string) {
 	c.VRepositoryKeySeparator = getter(config.KeyRepositoryKeySeparator)
 	c.VNotificationConsumerConfigs, _ = parseNotificationConsumerConfigs(getter(config.KeyNotificationConsumerConfigs))
 	c.VAllowedFileCategories, _ = parseAllowedFileCategories(getter(config.KeyAllowedFileCategories))
+	c.VPolicies, _ = parsePolicies(getter(config.KeyPolicies))
+	c.VGraphqlMaxDepth = toUint16(getter(config.KeyGraphqlMaxDepth))
+	c.VGraphqlMaxComplexity = toUint16(getter(config.KeyGraphqlMaxComplexity))
 	c.VRedisUrl = getter(config.KeyRedisUrl)
 	c.VRedisPassword = getter(config.KeyRedisPassword)
 
@@ -201,6 +223,15 @@ func parseNotificationConsumerConfigs(rawJson string) (map[string]config.Notific
 				case openapi.DeletedEvent.String():
 					types[key][openapi.DeletedEvent] = struct{}{}
 					break
+				case openapi.LifecycleChangedEvent.String():
+					types[key][openapi.LifecycleChangedEvent] = struct{}{}
+					break
+				case openapi.SunsetApproachingEvent.String():
+					types[key][openapi.SunsetApproachingEvent] = struct{}{}
+					break
+				case openapi.SunsetPassedEvent.String():
+					types[key][openapi.SunsetPassedEvent] = struct{}{}
+					break
 				default:
 					errors = append(errors, fmt.Sprintf("Notification consumer config '%s' contains invalid event type '%s'.", configIdentifier, eventCandidate))
 					continue
@@ -219,6 +250,63 @@ func parseNotificationConsumerConfigs(rawJson string) (map[string]config.Notific
 	return result, nil
 }
 
+func parseServiceLifecycleModel(rawJson string) (config.ServiceLifecycleModel, error) {
+	type StringBasedState struct {
+		Next                []string `json:"next"`
+		RequiresSunsetDate  bool     `json:"requiresSunsetDate"`
+		RequiresReplacedBy  bool     `json:"requiresReplacedBy"`
+		ProhibitsDependents bool     `json:"prohibitsDependents"`
+	}
+	type StringBasedModel struct {
+		Initial string                      `json:"initial"`
+		States  map[string]StringBasedState `json:"states"`
+	}
+	parsedModel := StringBasedModel{}
+	if err := json.Unmarshal([]byte(rawJson), &parsedModel); err != nil {
+		return config.ServiceLifecycleModel{}, err
+	}
+
+	errors := make([]string, 0)
+	if _, ok := parsedModel.States[parsedModel.Initial]; !ok {
+		errors = append(errors, fmt.Sprintf("Service lifecycle model has invalid initial state '%s'.", parsedModel.Initial))
+	}
+
+	result := config.ServiceLifecycleModel{
+		Initial: parsedModel.Initial,
+		States:  make(map[string]config.ServiceLifecycleState),
+	}
+	for name, state := range parsedModel.States {
+		for _, next := range state.Next {
+			if _, ok := parsedModel.States[next]; !ok {
+				errors = append(errors, fmt.Sprintf("Service lifecycle state '%s' has transition to unknown state '%s'.", name, next))
+			}
+		}
+		result.States[name] = config.ServiceLifecycleState{
+			Next:                state.Next,
+			RequiresSunsetDate:  state.RequiresSunsetDate,
+			RequiresReplacedBy:  state.RequiresReplacedBy,
+			ProhibitsDependents: state.ProhibitsDependents,
+		}
+	}
+	if len(errors) > 0 {
+		sort.Strings(errors)
+		return config.ServiceLifecycleModel{}, fmt.Errorf(strings.Join(errors, " "))
+	}
+	return result, nil
+}
+
+func parseNetworks(spaceSeparated string) ([]*net.IPNet, error) {
+	result := make([]*net.IPNet, 0)
+	for _, cidr := range strings.Fields(spaceSeparated) {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, network)
+	}
+	return result, nil
+}
+
 func parseAllowedFileCategories(rawJson string) ([]string, error) {
 	result := make([]string, 0)
 	if rawJson == "" {
@@ -231,3 +319,67 @@ func parseAllowedFileCategories(rawJson string) ([]string, error) {
 
 	return result, nil
 }
+
+func parsePolicies(rawJson string) ([]config.Policy, error) {
+	type StringBasedPolicy struct {
+		Name                string `json:"name"`
+		Entity              string `json:"entity"`
+		Severity            string `json:"severity"`
+		Message             string `json:"message"`
+		When                string `json:"when"`
+		Require             string `json:"require"`
+		RequireRepositories string `json:"requireRepositories"`
+		RequireOwner        string `json:"requireOwner"`
+	}
+	parsedPolicies := make([]StringBasedPolicy, 0)
+	if rawJson != "" {
+		if err := json.Unmarshal([]byte(rawJson), &parsedPolicies); err != nil {
+			return nil, err
+		}
+	}
+
+	// the queries are kept as they are, the policies business component parses them
+	errors := make([]string, 0)
+	names := make(map[string]struct{})
+	result := make([]config.Policy, 0, len(parsedPolicies))
+	for _, policy := range parsedPolicies {
+		if policy.Name == "" {
+			errors = append(errors, "Policy without a name.")
+		} else if _, ok := names[policy.Name]; ok {
+			errors = append(errors, fmt.Sprintf("Policy '%s' is defined more than once.", policy.Name))
+		}
+		names[policy.Name] = struct{}{}
+
+		if policy.Severity != config.PolicySeverityDeny && policy.Severity != config.PolicySeverityWarn {
+			errors = append(errors, fmt.Sprintf("Policy '%s' has invalid severity '%s', must be deny or warn.", policy.Name, policy.Severity))
+		}
+		if policy.Require == "" && policy.RequireRepositories == "" && policy.RequireOwner == "" {
+			errors = append(errors, fmt.Sprintf("Policy '%s' requires nothing.", policy.Name))
+		}
+
+		if policy.Entity != config.PolicyEntityService && policy.Entity != config.PolicyEntityRepository && policy.Entity != config.PolicyEntityOwner {
+			errors = append(errors, fmt.Sprintf("Policy '%s' has invalid entity '%s', must be service, repository or owner.", policy.Name, policy.Entity))
+		}
+		if policy.RequireRepositories != "" && policy.Entity != config.PolicyEntityService {
+			errors = append(errors, fmt.Sprintf("Policy '%s' can only use requireRepositories for services.", policy.Name))
+		}
+		if policy.RequireOwner != "" && policy.Entity == config.PolicyEntityOwner {
+			errors = append(errors, fmt.Sprintf("Policy '%s' cannot use requireOwner for owners.", policy.Name))
+		}
+
+		result = append(result, config.Policy{
+			Name:                policy.Name,
+			Entity:              policy.Entity,
+			Severity:            policy.Severity,
+			Message:             policy.Message,
+			When:                policy.When,
+			Require:             policy.Require,
+			RequireRepositories: policy.RequireRepositories,
+			RequireOwner:        policy.RequireOwner,
+		})
+	}
+	if len(errors) > 0 {
+		return nil, fmt.Errorf(strings.Join(errors, " "))
+	}
+	return result, nil
+}
@@ -78,7 +78,7 @@ func TestValidate_LotsOfErrors(t *testing.T) {
 	_, err := tstSetupCutAndLogRecorder(t, "invalid-config-values.yaml")
 
 	require.NotNil(t, err)
-	require.Contains(t, err.Error(), "some configuration values failed to validate or parse. There were 27 error(s). See details above")
+	require.Contains(t, err.Error(), "some configuration values failed to validate or parse. There were 32 error(s). See details above")
 
 	actualLog := goauzerolog.RecordedLogForTesting.String()
 
@@ -111,6 +111,17 @@ func TestValidate_LotsOfErrors(t *testing.T) {
 	require.Contains(t, actualLog, "Notification consumer config 'caseInvalidEvents' contains invalid event type 'AGAIN_INVALID'.")
 	require.Contains(t, actualLog, "Notification consumer config 'caseMissingUrl' is missing url.")
 	require.Contains(t, actualLog, "Notification consumer config 'caseInvalidUrl' contains invalid url 'this-is-invalid'.")
+
+	require.Contains(t, actualLog, "failed to validate configuration field SERVICE_LIFECYCLE_MODEL:")
+	require.Contains(t, actualLog, "Service lifecycle model has invalid initial state 'unknown'.")
+	require.Contains(t, actualLog, "Service lifecycle state 'experimental' has transition to unknown state 'production'.")
+
+	require.Contains(t, actualLog, "failed to validate configuration field POLICIES:")
+	require.Contains(t, actualLog, "Policy 'caseInvalid' has invalid severity 'block', must be deny or warn.")
+	require.Contains(t, actualLog, "Policy 'caseInvalid' requires nothing.")
+	require.Contains(t, actualLog, "Policy 'caseInvalid' has invalid entity 'unicorn', must be service, repository or owner.")
+	require.Contains(t, actualLog, "Policy 'caseInvalidQuery' can only use requireRepositories for services.")
+	require.NotContains(t, actualLog, "Policy 'caseInvalidQuery' has invalid require")
 }
 
 func TestAccessors(t *testing.T) {
@@ -148,10 +159,36 @@ func TestAccessors(t *testing.T) {
 	require.Equal(t, "[a-z][0-4]+", config.Custom(cut).ServiceNamePermittedRegex().String())
 	require.Equal(t, "[a-z][0-5]+", config.Custom(cut).ServiceNameProhibitedRegex().String())
 	require.Equal(t, uint16(2), config.Custom(cut).ServiceNameMaxLength())
+	require.Equal(t, "reject", config.Custom(cut).ServiceConsumedApiValidation())
+	require.Equal(t, config.ServiceLifecycleModel{
+		Initial: "new",
+		States: map[string]config.ServiceLifecycleState{
+			"new": {Next: []string{"old"}},
+			"old": {RequiresSunsetDate: true},
+		},
+	}, config.Custom(cut).ServiceLifecycleModel())
+	require.Equal(t, uint16(14), config.Custom(cut).SunsetWarningDays())
+	require.Equal(t, uint16(15), config.Custom(cut).LinkCheckIntervalMinutes())
+	require.Equal(t, uint16(2), config.Custom(cut).LinkCheckConcurrency())
+	require.Equal(t, uint16(250), config.Custom(cut).LinkCheckDelayMilliseconds())
+	require.Equal(t, "10.0.0.0/8", config.Custom(cut).LinkCheckAllowedNetworks()[0].String())
+	require.Equal(t, "fd00::/8", config.Custom(cut).LinkCheckAllowedNetworks()[1].String())
 	require.Equal(t, "[a-z][0-6]+", config.Custom(cut).RepositoryNamePermittedRegex().String())
 	require.Equal(t, "[a-z][0-7]+", config.Custom(cut).RepositoryNameProhibitedRegex().String())
 	require.Equal(t, uint16(3), config.Custom(cut).RepositoryNameMaxLength())
 	require.Equal(t, ";", config.Custom(cut).RepositoryKeySeparator())
 	require.Equal(t, []string{"some-type", "some-other-type"}, config.Custom(cut).RepositoryTypes())
 	require.Equal(t, []string{"some-type", "some-other-type"}, config.Custom(cut).RepositoryTypes())
+	require.Equal(t, []config.Policy{
+		{
+			Name:     "described",
+			Entity:   "service",
+			Severity: "warn",
+			Message:  "services should be described",
+			When:     "operationType=PLATFORM",
+			Require:  `description!=""`,
+		},
+	}, config.Custom(cut).Policies())
+	require.Equal(t, uint16(4), config.Custom(cut).GraphqlMaxDepth())
+	require.Equal(t, uint16(500), config.Custom(cut).GraphqlMaxComplexity())
 }
@@ -0,0 +1,157 @@
+package eventstream
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"sync"
+)
+
+// how many events are kept for subscribers resuming after a reconnect
+const bufferSize = 256
+
+// how many events may be waiting for a single subscriber before it is dropped
+const subscriberBufferSize = 64
+
+type subscriber struct {
+	filter repository.EventFilter
+	events chan openapi.ChangeEventDto
+}
+
+type Impl struct {
+	Logging librepo.Logging
+
+	mu sync.Mutex
+	// oldest first
+	buffer      []openapi.ChangeEventDto
+	subscribers map[*subscriber]struct{}
+}
+
+func New(
+	logging librepo.Logging,
+) repository.EventStream {
+	return &Impl{
+		Logging: logging,
+	}
+}
+
+var (
+	_ repository.EventStream = (*Impl)(nil)
+)
+
+func (r *Impl) IsEventStream() bool {
+	return true
+}
+
+func (r *Impl) Setup() error {
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+
+	r.buffer = make([]openapi.ChangeEventDto, 0, bufferSize)
+	r.subscribers = make(map[*subscriber]struct{})
+
+	r.Logging.Logger().Ctx(ctx).Info().Print("successfully set up event stream")
+	return nil
+}
+
+func (r *Impl) Publish(ctx context.Context, event openapi.ChangeEventDto) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.buffer) == bufferSize {
+		r.buffer = append(r.buffer[:0], r.buffer[1:]...)
+	}
+	r.buffer = append(r.buffer, event)
+
+	for sub := range r.subscribers {
+		filtered, ok := apply(sub.filter, event)
+		if !ok {
+			continue
+		}
+		select {
+		case sub.events <- filtered:
+		default:
+			r.Logging.Logger().Ctx(ctx).Warn().Print("event stream subscriber cannot keep up, dropping it")
+			r.removeLocked(sub)
+		}
+	}
+}
+
+func (r *Impl) Subscribe(_ context.Context, filter repository.EventFilter, lastEventId string) (repository.Subscription, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub := &subscriber{
+		filter: filter,
+		events: make(chan openapi.ChangeEventDto, subscriberBufferSize),
+	}
+	r.subscribers[sub] = struct{}{}
+
+	result := repository.Subscription{
+		Replay:  []openapi.ChangeEventDto{},
+		Resumed: lastEventId == "",
+		Events:  sub.events,
+	}
+	if lastEventId != "" {
+		for i := range r.buffer {
+			if r.buffer[i].CommitHash == lastEventId {
+				result.Resumed = true
+				result.Replay = r.filterAll(filter, r.buffer[i+1:])
+				break
+			}
+		}
+	}
+
+	return result, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.removeLocked(sub)
+	}
+}
+
+// --- internals ---
+
+func (r *Impl) removeLocked(sub *subscriber) {
+	if _, ok := r.subscribers[sub]; ok {
+		delete(r.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (r *Impl) filterAll(filter repository.EventFilter, events []openapi.ChangeEventDto) []openapi.ChangeEventDto {
+	result := make([]openapi.ChangeEventDto, 0, len(events))
+	for _, event := range events {
+		if filtered, ok := apply(filter, event); ok {
+			result = append(result, filtered)
+		}
+	}
+	return result
+}
+
+// apply reduces the event to the matching entities. False if there are none.
+func apply(filter repository.EventFilter, event openapi.ChangeEventDto) (openapi.ChangeEventDto, bool) {
+	entities := make([]openapi.ChangedEntityDto, 0, len(event.Entities))
+	for _, entity := range event.Entities {
+		if matches(filter, entity) {
+			entities = append(entities, entity)
+		}
+	}
+	if len(entities) == 0 {
+		return openapi.ChangeEventDto{}, false
+	}
+	event.Entities = entities
+	return event, true
+}
+
+func matches(filter repository.EventFilter, entity openapi.ChangedEntityDto) bool {
+	if filter.EntityType != "" && entity.Type != filter.EntityType {
+		return false
+	}
+	if filter.Owner != "" && entity.Owner != filter.Owner &&
+		(entity.PreviousOwner == nil || *entity.PreviousOwner != filter.Owner) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,129 @@
+package eventstream
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func p(v string) *string {
+	return &v
+}
+
+func tstStream() *Impl {
+	return &Impl{
+		buffer:      make([]openapi.ChangeEventDto, 0, bufferSize),
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+func tstEvent(commitHash string, entities ...openapi.ChangedEntityDto) openapi.ChangeEventDto {
+	return openapi.ChangeEventDto{
+		CommitHash: commitHash,
+		TimeStamp:  "2022-11-06T18:14:10Z",
+		Entities:   entities,
+	}
+}
+
+func tstCommits(events []openapi.ChangeEventDto) []string {
+	result := make([]string, 0)
+	for _, event := range events {
+		result = append(result, event.CommitHash)
+	}
+	return result
+}
+
+var (
+	tstOwnerChange   = openapi.ChangedEntityDto{Type: repository.SearchTypeOwner, Key: "payments", Owner: "payments"}
+	tstServiceChange = openapi.ChangedEntityDto{Type: repository.SearchTypeService, Key: "payout", Owner: "payments"}
+	tstServiceMove   = openapi.ChangedEntityDto{Type: repository.SearchTypeService, Key: "billing", Owner: "search", PreviousOwner: p("payments")}
+	tstRepoDeletion  = openapi.ChangedEntityDto{Type: repository.SearchTypeRepository, Key: "search.implementation", Owner: "search", Deleted: true}
+)
+
+func TestPublish_Filter(t *testing.T) {
+	stream := tstStream()
+	ctx := context.Background()
+
+	all, cancelAll := stream.Subscribe(ctx, repository.EventFilter{}, "")
+	defer cancelAll()
+	services, cancelServices := stream.Subscribe(ctx, repository.EventFilter{EntityType: repository.SearchTypeService}, "")
+	defer cancelServices()
+	payments, cancelPayments := stream.Subscribe(ctx, repository.EventFilter{Owner: "payments"}, "")
+	defer cancelPayments()
+
+	stream.Publish(ctx, tstEvent("c1", tstOwnerChange, tstServiceChange))
+	stream.Publish(ctx, tstEvent("c2", tstRepoDeletion))
+	stream.Publish(ctx, tstEvent("c3", tstServiceMove))
+
+	require.Equal(t, tstEvent("c1", tstOwnerChange, tstServiceChange), <-all.Events)
+	require.Equal(t, tstEvent("c2", tstRepoDeletion), <-all.Events)
+	require.Equal(t, tstEvent("c3", tstServiceMove), <-all.Events)
+
+	require.Equal(t, tstEvent("c1", tstServiceChange), <-services.Events)
+	require.Equal(t, tstEvent("c3", tstServiceMove), <-services.Events)
+	require.Empty(t, services.Events)
+
+	// a service moved away from an owner is still of interest to it
+	require.Equal(t, tstEvent("c1", tstOwnerChange, tstServiceChange), <-payments.Events)
+	require.Equal(t, tstEvent("c3", tstServiceMove), <-payments.Events)
+	require.Empty(t, payments.Events)
+}
+
+func TestSubscribe_Resume(t *testing.T) {
+	stream := tstStream()
+	ctx := context.Background()
+
+	stream.Publish(ctx, tstEvent("c1", tstOwnerChange))
+	stream.Publish(ctx, tstEvent("c2", tstRepoDeletion))
+	stream.Publish(ctx, tstEvent("c3", tstServiceChange))
+
+	resumed, cancel := stream.Subscribe(ctx, repository.EventFilter{}, "c1")
+	defer cancel()
+	require.True(t, resumed.Resumed)
+	require.Equal(t, []string{"c2", "c3"}, tstCommits(resumed.Replay))
+
+	filtered, cancel := stream.Subscribe(ctx, repository.EventFilter{Owner: "payments"}, "c1")
+	defer cancel()
+	require.True(t, filtered.Resumed)
+	require.Equal(t, []string{"c3"}, tstCommits(filtered.Replay))
+
+	current, cancel := stream.Subscribe(ctx, repository.EventFilter{}, "c3")
+	defer cancel()
+	require.True(t, current.Resumed)
+	require.Empty(t, current.Replay)
+
+	unknown, cancel := stream.Subscribe(ctx, repository.EventFilter{}, "c0")
+	defer cancel()
+	require.False(t, unknown.Resumed)
+	require.Empty(t, unknown.Replay)
+}
+
+func TestSubscribe_BufferLimit(t *testing.T) {
+	stream := tstStream()
+	ctx := context.Background()
+
+	stream.Publish(ctx, tstEvent("first", tstOwnerChange))
+	for i := 0; i < bufferSize; i++ {
+		stream.Publish(ctx, tstEvent("later", tstOwnerChange))
+	}
+
+	sub, cancel := stream.Subscribe(ctx, repository.EventFilter{}, "first")
+	defer cancel()
+	require.False(t, sub.Resumed)
+}
+
+func TestSubscribe_Cancel(t *testing.T) {
+	stream := tstStream()
+	ctx := context.Background()
+
+	sub, cancel := stream.Subscribe(ctx, repository.EventFilter{}, "")
+	cancel()
+	_, open := <-sub.Events
+	require.False(t, open)
+
+	// cancelling twice is harmless
+	cancel()
+	stream.Publish(ctx, tstEvent("c1", tstOwnerChange))
+}
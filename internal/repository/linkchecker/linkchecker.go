@@ -0,0 +1,278 @@
+package linkchecker
+
+import (
+	"context"
+	"fmt"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	aurestbreaker "github.com/StephanHCB/go-autumn-restclient-circuitbreaker/implementation/breaker"
+	aurestclientapi "github.com/StephanHCB/go-autumn-restclient/api"
+	auresthttpclient "github.com/StephanHCB/go-autumn-restclient/implementation/httpclient"
+	aurestlogging "github.com/StephanHCB/go-autumn-restclient/implementation/requestlogging"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+)
+
+type Impl struct {
+	Configuration       librepo.Configuration
+	CustomConfiguration config.CustomConfiguration
+	Logging             librepo.Logging
+
+	Client aurestclientapi.Client
+
+	mu sync.Mutex
+	// at most maxHosts entries, see host
+	hosts map[string]*host
+}
+
+// maxHosts limits how many hosts keep their circuit breaker and request spacing, because the number of hosts
+// grows with the links in the metadata.
+var maxHosts = 1000
+
+// host holds the circuit breaker for a single host, and when the next request to it may be sent.
+type host struct {
+	mu     sync.Mutex
+	client aurestclientapi.Client
+	next   time.Time
+}
+
+func New(
+	configuration librepo.Configuration,
+	customConfig config.CustomConfiguration,
+	logging librepo.Logging,
+) repository.LinkChecker {
+	return &Impl{
+		Configuration:       configuration,
+		CustomConfiguration: customConfig,
+		Logging:             logging,
+	}
+}
+
+func (r *Impl) IsLinkChecker() bool {
+	return true
+}
+
+func (r *Impl) Setup() error {
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+
+	if err := r.SetupClient(ctx); err != nil {
+		r.Logging.Logger().Ctx(ctx).Error().WithErr(err).Print("failed to set up link checker. BAILING OUT")
+		return err
+	}
+
+	r.Logging.Logger().Ctx(ctx).Info().Print("successfully set up link checker")
+	return nil
+}
+
+func (r *Impl) SetupClient(_ context.Context) error {
+	r.hosts = make(map[string]*host)
+
+	// allow tests to pre-populate
+	if r.Client != nil {
+		return nil
+	}
+
+	// not instrumented, because the metrics would be partitioned by every host any link points to
+	client, err := newHttpClient(r.allowedAddress)
+	if err != nil {
+		return err
+	}
+
+	r.Client = aurestlogging.New(client)
+	return nil
+}
+
+func (r *Impl) Check(ctx context.Context, link string) repository.LinkCheckResult {
+	parsed, err := url.Parse(link)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return repository.LinkCheckResult{
+			Status: repository.LinkStatusSkipped,
+			Error:  "not an absolute http or https url",
+		}
+	}
+
+	target := r.host(parsed.Host)
+	status, err := r.perform(ctx, target, http.MethodHead, link)
+	if err == nil && status >= 400 {
+		// some servers do not implement HEAD, or do not implement it correctly
+		status, err = r.perform(ctx, target, http.MethodGet, link)
+	}
+
+	if err != nil {
+		return repository.LinkCheckResult{
+			Status:     repository.LinkStatusBroken,
+			HttpStatus: status,
+			Error:      err.Error(),
+		}
+	}
+	if status >= 400 {
+		return repository.LinkCheckResult{
+			Status:     repository.LinkStatusBroken,
+			HttpStatus: status,
+			Error:      fmt.Sprintf("got http status %d", status),
+		}
+	}
+	return repository.LinkCheckResult{
+		Status:     repository.LinkStatusOk,
+		HttpStatus: status,
+	}
+}
+
+// host gives the circuit breaker and request spacing for a host.
+//
+// Once maxHosts hosts are known, the idle ones, which have no request waiting, are forgotten first.
+func (r *Impl) host(name string) *host {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.hosts[name]; ok {
+		return existing
+	}
+
+	if len(r.hosts) >= maxHosts {
+		r.forgetIdleHostsMustHoldMutex()
+	}
+
+	// not instrumented, because the metrics would be partitioned by every host any link points to
+	circuitBreakerWrapper := aurestbreaker.New(
+		r.Client,
+		fmt.Sprintf("linkchecker-%s-client", name),
+		10,
+		5*time.Minute,
+		60*time.Second,
+		15*time.Second,
+	)
+
+	created := &host{client: circuitBreakerWrapper}
+	r.hosts[name] = created
+	return created
+}
+
+func (r *Impl) forgetIdleHostsMustHoldMutex() {
+	now := time.Now()
+	for name, known := range r.hosts {
+		known.mu.Lock()
+		idle := !known.next.After(now)
+		known.mu.Unlock()
+		if idle {
+			delete(r.hosts, name)
+		}
+	}
+}
+
+// perform waits until the next request to the host may be sent, then sends it.
+func (r *Impl) perform(ctx context.Context, target *host, method string, link string) (int, error) {
+	delay := time.Duration(r.CustomConfiguration.LinkCheckDelayMilliseconds()) * time.Millisecond
+
+	target.mu.Lock()
+	now := time.Now()
+	wait := target.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	target.next = now.Add(wait + delay)
+	target.mu.Unlock()
+
+	if wait > 0 {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	var responseData *[]byte
+	response := &aurestclientapi.ParsedResponse{
+		Body: &responseData,
+	}
+	err := target.client.Perform(ctx, method, link, nil, response)
+	return response.Status, err
+}
+
+// maxDrainedBytes is how much of a response body is read and thrown away on close, so the connection can be reused.
+const maxDrainedBytes = 64 * 1024
+
+// newHttpClient builds a client that only connects to addresses accepted by allowed, and that never hands out
+// response bodies, the link checker only needs the status.
+func newHttpClient(allowed func(ip net.IP) bool) (aurestclientapi.Client, error) {
+	client, err := auresthttpclient.New(0, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		// called after name resolution, also for redirects, so a host name cannot sneak in an internal address
+		Control: func(_ string, address string, _ syscall.RawConn) error {
+			hostname, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(hostname); ip == nil || !allowed(ip) {
+				return fmt.Errorf("refusing to connect to non-public address %s", hostname)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would connect on our behalf, so the address could not be checked
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	client.(*auresthttpclient.HttpClientImpl).HttpClient.Transport = &discardingTransport{wrapped: transport}
+	return client, nil
+}
+
+// allowedAddress accepts public addresses, and addresses in the configured allowed networks.
+func (r *Impl) allowedAddress(ip net.IP) bool {
+	if isPublicAddress(ip) {
+		return true
+	}
+	for _, network := range r.CustomConfiguration.LinkCheckAllowedNetworks() {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublicAddress refuses loopback, private, link-local and other special addresses, so links cannot be used
+// to probe the internal network or cloud metadata endpoints.
+func isPublicAddress(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// discardingTransport replaces response bodies by empty ones, so the GET fallback does not read whole pages into memory.
+type discardingTransport struct {
+	wrapped http.RoundTripper
+}
+
+func (t *discardingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.wrapped.RoundTrip(request)
+	if err == nil {
+		response.Body = &discardedBody{wrapped: response.Body}
+	}
+	return response, err
+}
+
+type discardedBody struct {
+	wrapped io.ReadCloser
+}
+
+func (b *discardedBody) Read(_ []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (b *discardedBody) Close() error {
+	_, _ = io.CopyN(io.Discard, b.wrapped, maxDrainedBytes)
+	return b.wrapped.Close()
+}
@@ -0,0 +1,181 @@
+package linkchecker
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/test/mock/configmock"
+	auresthttpclient "github.com/StephanHCB/go-autumn-restclient/implementation/httpclient"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type tstConfig struct {
+	configmock.MockConfig
+	delayMilliseconds uint16
+	allowedNetworks   []*net.IPNet
+}
+
+func (c *tstConfig) LinkCheckDelayMilliseconds() uint16 {
+	return c.delayMilliseconds
+}
+
+func (c *tstConfig) LinkCheckAllowedNetworks() []*net.IPNet {
+	return c.allowedNetworks
+}
+
+func tstChecker(t *testing.T, delayMilliseconds uint16) *Impl {
+	// the test servers listen on the loopback address
+	client, err := newHttpClient(func(net.IP) bool { return true })
+	require.Nil(t, err)
+	checker := &Impl{
+		CustomConfiguration: &tstConfig{delayMilliseconds: delayMilliseconds},
+		Client:              client,
+	}
+	require.Nil(t, checker.SetupClient(context.Background()))
+	return checker
+}
+
+func tstServer(methods *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*methods = append(*methods, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/large":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(make([]byte, 1024*1024))
+		case "/get-only":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			} else {
+				w.WriteHeader(http.StatusOK)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestCheck_Ok(t *testing.T) {
+	methods := make([]string, 0)
+	server := tstServer(&methods)
+	defer server.Close()
+
+	result := tstChecker(t, 0).Check(context.Background(), server.URL+"/ok")
+	require.Equal(t, repository.LinkCheckResult{Status: repository.LinkStatusOk, HttpStatus: http.StatusOK}, result)
+	require.Equal(t, []string{"HEAD /ok"}, methods)
+}
+
+func TestCheck_HeadNotAllowed(t *testing.T) {
+	methods := make([]string, 0)
+	server := tstServer(&methods)
+	defer server.Close()
+
+	result := tstChecker(t, 0).Check(context.Background(), server.URL+"/get-only")
+	require.Equal(t, repository.LinkCheckResult{Status: repository.LinkStatusOk, HttpStatus: http.StatusOK}, result)
+	require.Equal(t, []string{"HEAD /get-only", "GET /get-only"}, methods)
+}
+
+func TestCheck_Broken(t *testing.T) {
+	methods := make([]string, 0)
+	server := tstServer(&methods)
+	defer server.Close()
+
+	result := tstChecker(t, 0).Check(context.Background(), server.URL+"/gone")
+	require.Equal(t, repository.LinkCheckResult{Status: repository.LinkStatusBroken, HttpStatus: http.StatusNotFound, Error: "got http status 404"}, result)
+	require.Equal(t, []string{"HEAD /gone", "GET /gone"}, methods)
+}
+
+func TestCheck_Skipped(t *testing.T) {
+	result := tstChecker(t, 0).Check(context.Background(), "/swagger-ui/index.html")
+	require.Equal(t, repository.LinkStatusSkipped, result.Status)
+	require.Equal(t, 0, result.HttpStatus)
+}
+
+func TestCheck_HostDelay(t *testing.T) {
+	methods := make([]string, 0)
+	server := tstServer(&methods)
+	defer server.Close()
+
+	checker := tstChecker(t, 200)
+	started := time.Now()
+	checker.Check(context.Background(), server.URL+"/ok")
+	checker.Check(context.Background(), server.URL+"/ok")
+	require.GreaterOrEqual(t, time.Now().Sub(started), 200*time.Millisecond)
+}
+
+func TestHost_ForgetsIdleHosts(t *testing.T) {
+	defer func(previous int) { maxHosts = previous }(maxHosts)
+	maxHosts = 2
+
+	checker := tstChecker(t, 0)
+	first := checker.host("first.example.com")
+	busy := checker.host("busy.example.com")
+	busy.next = time.Now().Add(time.Hour)
+
+	checker.host("third.example.com")
+	require.Equal(t, 2, len(checker.hosts))
+	require.Same(t, busy, checker.host("busy.example.com"))
+	require.NotSame(t, first, checker.host("first.example.com"))
+}
+
+func TestCheck_NonPublicAddressRefused(t *testing.T) {
+	methods := make([]string, 0)
+	server := tstServer(&methods)
+	defer server.Close()
+
+	checker := &Impl{
+		CustomConfiguration: &tstConfig{},
+	}
+	require.Nil(t, checker.SetupClient(context.Background()))
+
+	result := checker.Check(context.Background(), server.URL+"/ok")
+	require.Equal(t, repository.LinkStatusBroken, result.Status)
+	require.Contains(t, result.Error, "refusing to connect to non-public address 127.0.0.1")
+	require.Empty(t, methods)
+}
+
+func TestCheck_AllowedNetwork(t *testing.T) {
+	methods := make([]string, 0)
+	server := tstServer(&methods)
+	defer server.Close()
+
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	checker := &Impl{
+		CustomConfiguration: &tstConfig{allowedNetworks: []*net.IPNet{loopback}},
+	}
+	require.Nil(t, checker.SetupClient(context.Background()))
+
+	result := checker.Check(context.Background(), server.URL+"/ok")
+	require.Equal(t, repository.LinkCheckResult{Status: repository.LinkStatusOk, HttpStatus: http.StatusOK}, result)
+}
+
+func TestIsPublicAddress(t *testing.T) {
+	for _, address := range []string{"127.0.0.1", "::1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "fe80::1", "fd00::1", "0.0.0.0", "::ffff:10.0.0.1"} {
+		require.False(t, isPublicAddress(net.ParseIP(address)), address)
+	}
+	for _, address := range []string{"8.8.8.8", "2001:4860:4860::8888"} {
+		require.True(t, isPublicAddress(net.ParseIP(address)), address)
+	}
+}
+
+func TestNewHttpClient_DiscardsBody(t *testing.T) {
+	methods := make([]string, 0)
+	server := tstServer(&methods)
+	defer server.Close()
+
+	client, err := newHttpClient(func(net.IP) bool { return true })
+	require.Nil(t, err)
+	response, err := client.(*auresthttpclient.HttpClientImpl).HttpClient.Get(server.URL + "/large")
+	require.Nil(t, err)
+	body, err := io.ReadAll(response.Body)
+	require.Nil(t, err)
+	require.Nil(t, response.Body.Close())
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Empty(t, body)
+}
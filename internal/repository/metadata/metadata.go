@@ -24,6 +24,7 @@ import (
 	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/tidwall/tinylru"
 )
 
 type Impl struct {
@@ -48,8 +49,15 @@ type Impl struct {
 	// AlreadySeenCommit is the commit hash of the newest commit that we have already cached
 	AlreadySeenCommit string
 
-	mu       sync.Mutex
-	LastPull time.Time
+	// snapshots keeps the most recently used snapshots of past commits, keyed by commit hash
+	snapshots *tinylru.LRUG[plumbing.Hash, *snapshot]
+
+	mu sync.Mutex
+	// objectsMu keeps writes to the object store of GitRepo apart from history walks, which do not hold mu.
+	//
+	// Written commits never change, so walks only need to exclude writers. Lock after mu.
+	objectsMu sync.RWMutex
+	LastPull  time.Time
 
 	consoleOutput bytes.Buffer
 }
@@ -230,6 +238,7 @@ func (r *Impl) Clone(ctx context.Context) error {
 	r.CommitCacheByFilePath = make(map[string]repository.CommitInfo)
 	r.NewCommits = make([]repository.CommitInfo, 0)
 	r.KnownCommits = make(map[string]bool)
+	r.snapshots = newSnapshotCache()
 
 	childCtxWithTimeout, cancel := context.WithTimeout(ctx, 1*time.Minute)
 	defer cancel()
@@ -283,6 +292,8 @@ func (r *Impl) Pull(ctx context.Context) error {
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.objectsMu.Lock()
+	defer r.objectsMu.Unlock()
 
 	r.LastPull = r.Timestamp.Now()
 
@@ -340,6 +351,8 @@ func (r *Impl) Commit(ctx context.Context, message string) (repository.CommitInf
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.objectsMu.Lock()
+	defer r.objectsMu.Unlock()
 
 	commitInfo := repository.CommitInfo{
 		CommitHash: "",
@@ -400,6 +413,8 @@ func (r *Impl) Push(ctx context.Context) error {
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.objectsMu.Lock()
+	defer r.objectsMu.Unlock()
 
 	childCtxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
@@ -452,6 +467,7 @@ func (r *Impl) Discard(ctx context.Context) {
 	r.CommitCacheByFilePath = make(map[string]repository.CommitInfo)
 	r.NewCommits = make([]repository.CommitInfo, 0)
 	r.KnownCommits = make(map[string]bool)
+	r.snapshots = newSnapshotCache()
 }
 
 func (r *Impl) logContextErrorDetails(ctx context.Context, operation string, contextName string) {
@@ -546,6 +562,104 @@ func (r *Impl) ReadFile(filename string) ([]byte, repository.CommitInfo, error)
 	return data, commitInfo, nil
 }
 
+func (r *Impl) ReadFileHistory(ctx context.Context, pathMatcher func(path string) bool) ([]repository.FileRevision, error) {
+	r.mu.Lock()
+	gitRepo := r.GitRepo
+	headRef, err := gitRepo.Head()
+	r.mu.Unlock()
+	if err != nil {
+		return make([]repository.FileRevision, 0), err
+	}
+
+	return r.readFileHistory(ctx, gitRepo, headRef.Hash(), pathMatcher)
+}
+
+// readFileHistory walks the history without holding mu, so it does not block reads and writes of the worktree.
+func (r *Impl) readFileHistory(ctx context.Context, gitRepo *git.Repository, from plumbing.Hash, pathMatcher func(path string) bool) ([]repository.FileRevision, error) {
+	r.objectsMu.RLock()
+	defer r.objectsMu.RUnlock()
+
+	result := make([]repository.FileRevision, 0)
+
+	commitIterator, err := gitRepo.Log(&git.LogOptions{
+		From:       from,
+		Order:      git.LogOrderCommitterTime,
+		PathFilter: pathMatcher,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	err = commitIterator.ForEach(func(c *object.Commit) error {
+		revision, found, err := r.fileRevisionInCommit(ctx, c, pathMatcher)
+		if err != nil {
+			return err
+		}
+		if found {
+			result = append(result, revision)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *Impl) fileRevisionInCommit(ctx context.Context, commit *object.Commit, pathMatcher func(path string) bool) (repository.FileRevision, bool, error) {
+	revision := repository.FileRevision{
+		CommitInfo: repository.CommitInfo{
+			CommitHash: commit.Hash.String(),
+			TimeStamp:  commit.Author.When,
+			Message:    commit.Message,
+		},
+		Author: commit.Author.Name,
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return revision, false, err
+	}
+
+	parentTree := &object.Tree{}
+	if commit.NumParents() != 0 {
+		firstParent, err := commit.Parents().Next()
+		if err != nil {
+			return revision, false, err
+		}
+
+		parentTree, err = firstParent.Tree()
+		if err != nil {
+			return revision, false, err
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, parentTree, tree, nil)
+	if err != nil {
+		return revision, false, err
+	}
+
+	found := false
+	for _, change := range changes {
+		if change.To.Name != "" && pathMatcher(change.To.Name) {
+			file, err := tree.File(change.To.Name)
+			if err != nil {
+				return revision, false, err
+			}
+			contents, err := file.Contents()
+			if err != nil {
+				return revision, false, err
+			}
+			revision.Path = change.To.Name
+			revision.Contents = []byte(contents)
+			return revision, true, nil
+		}
+		if !found && change.From.Name != "" && pathMatcher(change.From.Name) {
+			// deleted or moved away, keep looking for the new location
+			revision.Path = change.From.Name
+			found = true
+		}
+	}
+	return revision, found, nil
+}
+
 func (r *Impl) WriteFile(filename string, contents []byte) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -0,0 +1,324 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/nosuchcommiterror"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/tidwall/tinylru"
+)
+
+var errReadOnlySnapshot = errors.New("metadata snapshot is read only")
+
+// snapshotCacheSize is how many snapshots are kept, so repeated point in time reads of recent commits do not rebuild the tree
+const snapshotCacheSize = 8
+
+func newSnapshotCache() *tinylru.LRUG[plumbing.Hash, *snapshot] {
+	cache := &tinylru.LRUG[plumbing.Hash, *snapshot]{}
+	cache.Resize(snapshotCacheSize)
+	return cache
+}
+
+// snapshot is a read only view of the metadata tree as of a past commit.
+//
+// The tree is copied into its own in-memory filesystem, so reading it never touches the worktree.
+type snapshot struct {
+	parent  *Impl
+	gitRepo *git.Repository
+	commit  repository.CommitInfo
+	hash    plumbing.Hash
+	fs      billy.Filesystem
+
+	mu sync.Mutex
+	// commitCacheByFilePath holds information about the newest commit up to this snapshot that touches a file,
+	// filled in as files are read
+	commitCacheByFilePath map[string]repository.CommitInfo
+}
+
+func (r *Impl) SnapshotAt(ctx context.Context, at string) (repository.Metadata, repository.CommitInfo, error) {
+	r.mu.Lock()
+	gitRepo := r.GitRepo
+	snapshots := r.snapshots
+	r.mu.Unlock()
+
+	r.objectsMu.RLock()
+	defer r.objectsMu.RUnlock()
+
+	commit, err := resolveCommit(ctx, gitRepo, at)
+	if err != nil {
+		return nil, repository.CommitInfo{}, err
+	}
+
+	commitInfo := repository.CommitInfo{
+		CommitHash: commit.Hash.String(),
+		TimeStamp:  commit.Author.When,
+		Message:    commit.Message,
+	}
+
+	if cached, ok := snapshots.Get(commit.Hash); ok {
+		return cached, commitInfo, nil
+	}
+
+	r.Logging.Logger().Ctx(ctx).Info().Printf("building metadata snapshot at commit %s", commitInfo.CommitHash)
+
+	fs, err := treeToFilesystem(commit)
+	if err != nil {
+		return nil, commitInfo, err
+	}
+
+	result := &snapshot{
+		parent:                r,
+		gitRepo:               gitRepo,
+		commit:                commitInfo,
+		hash:                  commit.Hash,
+		fs:                    fs,
+		commitCacheByFilePath: make(map[string]repository.CommitInfo),
+	}
+	snapshots.Set(commit.Hash, result)
+	return result, commitInfo, nil
+}
+
+// resolveCommit finds the mainline commit for a (possibly abbreviated) commit hash or an RFC 3339 timestamp.
+//
+// Timestamps are compared against the committer time, because that is when a change arrived on the mainline.
+func resolveCommit(ctx context.Context, gitRepo *git.Repository, at string) (*object.Commit, error) {
+	headRef, err := gitRepo.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	commitIterator, err := gitRepo.Log(&git.LogOptions{
+		From:  headRef.Hash(),
+		Order: git.LogOrderCommitterTime,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pointInTime, timeErr := time.Parse(time.RFC3339, at)
+	hashPrefix := strings.ToLower(at)
+
+	var result *object.Commit
+	ambiguous := false
+	err = commitIterator.ForEach(func(c *object.Commit) error {
+		if timeErr == nil {
+			if !c.Committer.When.After(pointInTime) {
+				result = c
+				return storer.ErrStop
+			}
+			return nil
+		}
+
+		if strings.HasPrefix(c.Hash.String(), hashPrefix) {
+			if result != nil {
+				ambiguous = true
+				return storer.ErrStop
+			}
+			result = c
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ambiguous {
+		return nil, nosuchcommiterror.New(ctx, fmt.Sprintf("commit hash %s is ambiguous", at))
+	}
+	if result == nil {
+		return nil, nosuchcommiterror.New(ctx, fmt.Sprintf("no mainline commit found for %s", at))
+	}
+	return result, nil
+}
+
+// newestCommitTouching finds the newest commit up to this snapshot that touches filename.
+//
+// The log stops at the first match, so only the history since the last change of the file is walked.
+func (s *snapshot) newestCommitTouching(ctx context.Context, filename string) (repository.CommitInfo, bool, error) {
+	s.parent.objectsMu.RLock()
+	defer s.parent.objectsMu.RUnlock()
+
+	commitIterator, err := s.gitRepo.Log(&git.LogOptions{
+		From:  s.hash,
+		Order: git.LogOrderCommitterTime,
+		PathFilter: func(path string) bool {
+			return path == filename
+		},
+	})
+	if err != nil {
+		return repository.CommitInfo{}, false, err
+	}
+
+	commit, err := commitIterator.Next()
+	commitIterator.Close()
+	if err == io.EOF {
+		return repository.CommitInfo{}, false, nil
+	}
+	if err != nil {
+		return repository.CommitInfo{}, false, err
+	}
+
+	pathsTouched, err := s.parent.pathsTouchedInCommit(ctx, commit)
+	if err != nil {
+		return repository.CommitInfo{}, false, err
+	}
+
+	return repository.CommitInfo{
+		CommitHash:   commit.Hash.String(),
+		TimeStamp:    commit.Author.When,
+		Message:      commit.Message,
+		FilesChanged: pathsTouched,
+	}, true, nil
+}
+
+func (s *snapshot) commitInfoFor(filename string) (repository.CommitInfo, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if commitInfo, ok := s.commitCacheByFilePath[filename]; ok {
+		return commitInfo, true, nil
+	}
+
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+	commitInfo, found, err := s.newestCommitTouching(ctx, filename)
+	if err != nil || !found {
+		return commitInfo, found, err
+	}
+
+	s.commitCacheByFilePath[filename] = commitInfo
+	return commitInfo, true, nil
+}
+
+func treeToFilesystem(commit *object.Commit) (billy.Filesystem, error) {
+	fs := memfs.New()
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return fs, err
+	}
+
+	err = tree.Files().ForEach(func(f *object.File) error {
+		contents, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		return util.WriteFile(fs, f.Name, []byte(contents), 0644)
+	})
+	return fs, err
+}
+
+// --- implementation of repository.Metadata for the snapshot ---
+
+func (s *snapshot) IsMetadata() bool {
+	return true
+}
+
+func (s *snapshot) Setup() error {
+	return nil
+}
+
+func (s *snapshot) Teardown() {
+}
+
+func (s *snapshot) Clone(_ context.Context) error {
+	return errReadOnlySnapshot
+}
+
+func (s *snapshot) Pull(_ context.Context) error {
+	return errReadOnlySnapshot
+}
+
+func (s *snapshot) Commit(_ context.Context, _ string) (repository.CommitInfo, error) {
+	return repository.CommitInfo{}, errReadOnlySnapshot
+}
+
+func (s *snapshot) Push(_ context.Context) error {
+	return errReadOnlySnapshot
+}
+
+func (s *snapshot) Discard(_ context.Context) {
+}
+
+func (s *snapshot) LastUpdated() time.Time {
+	return s.commit.TimeStamp
+}
+
+func (s *snapshot) NewPulledCommits() []repository.CommitInfo {
+	return make([]repository.CommitInfo, 0)
+}
+
+func (s *snapshot) IsCommitKnown(hash string) bool {
+	return s.parent.IsCommitKnown(hash)
+}
+
+func (s *snapshot) Stat(filename string) (os.FileInfo, error) {
+	return s.fs.Stat(filename)
+}
+
+func (s *snapshot) ReadDir(path string) ([]os.FileInfo, error) {
+	return s.fs.ReadDir(path)
+}
+
+func (s *snapshot) ReadFile(filename string) ([]byte, repository.CommitInfo, error) {
+	errorCommitInfo := repository.CommitInfo{
+		CommitHash: "",
+		TimeStamp:  s.commit.TimeStamp,
+		Message:    "",
+	}
+
+	fileHandle, err := s.fs.Open(filename)
+	if err != nil {
+		return nil, errorCommitInfo, err
+	}
+	defer fileHandle.Close()
+
+	data, err := io.ReadAll(fileHandle)
+	if err != nil {
+		return nil, errorCommitInfo, err
+	}
+
+	commitInfo, ok, err := s.commitInfoFor(filename)
+	if err != nil {
+		return nil, errorCommitInfo, err
+	}
+	if !ok {
+		return nil, errorCommitInfo, fmt.Errorf("failed to find commit info on %s", filename)
+	}
+
+	return data, commitInfo, nil
+}
+
+func (s *snapshot) ReadFileHistory(ctx context.Context, pathMatcher func(path string) bool) ([]repository.FileRevision, error) {
+	return s.parent.readFileHistory(ctx, s.gitRepo, s.hash, pathMatcher)
+}
+
+func (s *snapshot) SnapshotAt(ctx context.Context, at string) (repository.Metadata, repository.CommitInfo, error) {
+	return s.parent.SnapshotAt(ctx, at)
+}
+
+func (s *snapshot) WriteFile(_ string, _ []byte) error {
+	return errReadOnlySnapshot
+}
+
+func (s *snapshot) DeleteFile(_ string) error {
+	return errReadOnlySnapshot
+}
+
+func (s *snapshot) MkdirAll(_ string) error {
+	return errReadOnlySnapshot
+}
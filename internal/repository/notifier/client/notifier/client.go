@@ -20,8 +20,8 @@ import (
 type NotifierClient interface {
 	Setup(clientIdentifier string, url string) error
 
-	// Send will log any errors, but since we use it async, it cannot return the error
-	Send(ctx context.Context, notification openapi.Notification)
+	// Send logs any errors, and also returns them for callers that do not send asynchronously.
+	Send(ctx context.Context, notification openapi.Notification) error
 }
 
 type Impl struct {
@@ -72,7 +72,7 @@ func (i *Impl) Setup(clientIdentifier string, url string) error {
 	return nil
 }
 
-func (i *Impl) Send(ctx context.Context, notification openapi.Notification) {
+func (i *Impl) Send(ctx context.Context, notification openapi.Notification) error {
 	var responseData *[]byte
 	responseDto := &aurestclientapi.ParsedResponse{
 		Body: &responseData,
@@ -80,7 +80,7 @@ func (i *Impl) Send(ctx context.Context, notification openapi.Notification) {
 	err := i.Client.Perform(ctx, http.MethodPost, i.url, notification, responseDto)
 	if err != nil {
 		i.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("failure in downstream notifier %s: %s", i.clientIdentifier, err.Error())
-		return
+		return err
 	}
 	if responseData != nil {
 		i.Logging.Logger().Ctx(ctx).Info().Printf("got response result in downstream notifier %s %s", i.clientIdentifier, string(*responseData))
@@ -88,4 +88,8 @@ func (i *Impl) Send(ctx context.Context, notification openapi.Notification) {
 	if responseDto.Status != http.StatusNoContent {
 		i.Logging.Logger().Ctx(ctx).Warn().Printf("unexpected response status in downstream notifier %s: %d", i.clientIdentifier, responseDto.Status)
 	}
+	if responseDto.Status >= http.StatusMultipleChoices {
+		return fmt.Errorf("downstream notifier %s responded with status %d", i.clientIdentifier, responseDto.Status)
+	}
+	return nil
 }
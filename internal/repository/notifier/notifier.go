@@ -2,6 +2,7 @@ package notifier
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	openapi "github.com/Interhyp/metadata-service/api"
 	"github.com/Interhyp/metadata-service/internal/acorn/config"
@@ -100,7 +101,7 @@ func (r *Impl) PublishCreation(ctx context.Context, name string, payload openapi
 	if notificationType == nil {
 		return fmt.Errorf("unable to determine payload type")
 	}
-	r.publish(ctx, name, types.CreatedEvent, *notificationType, &payload)
+	r.publish(ctx, name, types.CreatedEvent, *notificationType, &payload, nil)
 	return nil
 }
 
@@ -109,12 +110,44 @@ func (r *Impl) PublishModification(ctx context.Context, name string, payload ope
 	if notificationType == nil {
 		return fmt.Errorf("unable to determine payload type")
 	}
-	r.publish(ctx, name, types.ModifiedEvent, *notificationType, &payload)
+	r.publish(ctx, name, types.ModifiedEvent, *notificationType, &payload, nil)
 	return nil
 }
 
 func (r *Impl) PublishDeletion(ctx context.Context, name string, payloadType types.NotificationPayloadType) {
-	r.publish(ctx, name, types.DeletedEvent, payloadType, nil)
+	r.publish(ctx, name, types.DeletedEvent, payloadType, nil, nil)
+}
+
+func (r *Impl) PublishLifecycleChange(ctx context.Context, name string, payload openapi.NotificationPayload) error {
+	notificationType := determineType(payload)
+	if notificationType == nil {
+		return fmt.Errorf("unable to determine payload type")
+	}
+	r.publish(ctx, name, types.LifecycleChangedEvent, *notificationType, &payload, nil)
+	return nil
+}
+
+func (r *Impl) PublishSunset(ctx context.Context, name string, event types.NotificationEventType, payload openapi.NotificationPayload, recipient openapi.NotificationRecipient) error {
+	notificationType := determineType(payload)
+	if notificationType == nil {
+		return fmt.Errorf("unable to determine payload type")
+	}
+	errs := make([]error, 0)
+	for identifier, consumerConfig := range r.CustomConfiguration.NotificationConsumerConfigs() {
+		if _, ok := consumerConfig.Subscribed[*notificationType][event]; ok {
+			notification := openapi.Notification{
+				Name:      name,
+				Event:     event.String(),
+				Type:      notificationType.String(),
+				Payload:   &payload,
+				Recipient: &recipient,
+			}
+			if err := r.Clients[identifier].Send(ctx, notification); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func determineType(payload openapi.NotificationPayload) *types.NotificationPayloadType {
@@ -141,14 +174,16 @@ func (r *Impl) publish(ctx context.Context,
 	name string, event types.NotificationEventType,
 	payloadType types.NotificationPayloadType,
 	payload *openapi.NotificationPayload,
+	recipient *openapi.NotificationRecipient,
 ) {
 	for identifier, consumerConfig := range r.CustomConfiguration.NotificationConsumerConfigs() {
 		if _, ok := consumerConfig.Subscribed[payloadType][event]; ok {
 			notification := openapi.Notification{
-				Name:    name,
-				Event:   event.String(),
-				Type:    payloadType.String(),
-				Payload: payload,
+				Name:      name,
+				Event:     event.String(),
+				Type:      payloadType.String(),
+				Payload:   payload,
+				Recipient: recipient,
 			}
 			client := r.Clients[identifier]
 			if r.SkipAsync {
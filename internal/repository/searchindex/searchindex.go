@@ -0,0 +1,305 @@
+package searchindex
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// field weights, a match on a word beginning counts half
+const (
+	weightName        = 8
+	weightDisplayName = 6
+	weightTag         = 4
+	weightLabel       = 4
+	weightDescription = 2
+	weightLinkTitle   = 2
+	weightUrl         = 2
+)
+
+const (
+	fieldName        = "name"
+	fieldDisplayName = "displayName"
+	fieldTags        = "tags"
+	fieldLabels      = "labels"
+	fieldDescription = "description"
+	fieldLinks       = "links"
+	fieldQuicklinks  = "quicklinks"
+	fieldUrl         = "url"
+)
+
+type entityId struct {
+	entityType string
+	key        string
+}
+
+// posting records how well a word matches one entity.
+type posting struct {
+	weight int
+	fields []string
+}
+
+type Impl struct {
+	Logging librepo.Logging
+
+	mu sync.RWMutex
+	// word -> entity -> posting
+	postings map[string]map[entityId]*posting
+	// all words in postings, sorted, so prefix matches are a binary search away
+	terms []string
+	// entity -> words, needed for removal
+	words map[entityId][]string
+}
+
+func New(
+	logging librepo.Logging,
+) repository.SearchIndex {
+	return &Impl{
+		Logging: logging,
+	}
+}
+
+var (
+	_ repository.SearchIndex = (*Impl)(nil)
+)
+
+func (r *Impl) IsSearchIndex() bool {
+	return true
+}
+
+func (r *Impl) Setup() error {
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+
+	r.postings = make(map[string]map[entityId]*posting)
+	r.terms = make([]string, 0)
+	r.words = make(map[entityId][]string)
+
+	r.Logging.Logger().Ctx(ctx).Info().Print("successfully set up search index")
+	return nil
+}
+
+func (r *Impl) IndexOwner(_ context.Context, alias string, owner openapi.OwnerDto) {
+	doc := newDocument()
+	doc.add(fieldName, weightName, alias)
+	doc.addOptional(fieldDisplayName, weightDisplayName, owner.DisplayName)
+	for _, link := range owner.Links {
+		doc.addOptional(fieldLinks, weightLinkTitle, link.Title)
+	}
+	r.replace(entityId{entityType: repository.SearchTypeOwner, key: alias}, doc)
+}
+
+func (r *Impl) IndexService(_ context.Context, name string, service openapi.ServiceDto) {
+	doc := newDocument()
+	doc.add(fieldName, weightName, name)
+	doc.addOptional(fieldDescription, weightDescription, service.Description)
+	for _, quicklink := range service.Quicklinks {
+		doc.addOptional(fieldQuicklinks, weightLinkTitle, quicklink.Title)
+	}
+	for _, tag := range service.Tags {
+		doc.add(fieldTags, weightTag, tag)
+	}
+	doc.addLabels(service.Labels)
+	r.replace(entityId{entityType: repository.SearchTypeService, key: name}, doc)
+}
+
+func (r *Impl) IndexRepository(_ context.Context, key string, repo openapi.RepositoryDto) {
+	doc := newDocument()
+	doc.add(fieldName, weightName, key)
+	doc.add(fieldUrl, weightUrl, repo.Url)
+	doc.addLabels(repo.Labels)
+	r.replace(entityId{entityType: repository.SearchTypeRepository, key: key}, doc)
+}
+
+func (r *Impl) Remove(_ context.Context, entityType string, key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeLocked(entityId{entityType: entityType, key: key})
+}
+
+func (r *Impl) Search(_ context.Context, query string, entityType string) []openapi.SearchHitDto {
+	queryWords := tokenize(query)
+	if len(queryWords) == 0 {
+		return []openapi.SearchHitDto{}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var candidates map[entityId]*posting
+	for _, word := range queryWords {
+		matches := r.match(word, entityType)
+		if candidates == nil {
+			candidates = matches
+			continue
+		}
+		// all words must match
+		for id, candidate := range candidates {
+			if match, ok := matches[id]; ok {
+				candidate.weight += match.weight
+				candidate.fields = mergeFields(candidate.fields, match.fields)
+			} else {
+				delete(candidates, id)
+			}
+		}
+	}
+
+	result := make([]openapi.SearchHitDto, 0, len(candidates))
+	for id, candidate := range candidates {
+		result = append(result, openapi.SearchHitDto{
+			Type:   id.entityType,
+			Key:    id.key,
+			Score:  candidate.weight,
+			Fields: candidate.fields,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// --- internals ---
+
+// match gives a posting per entity for a single query word, which is a copy safe to modify.
+func (r *Impl) match(queryWord string, entityType string) map[entityId]*posting {
+	result := make(map[entityId]*posting)
+	// the words starting with queryWord form a contiguous range in the sorted terms
+	for i := sort.SearchStrings(r.terms, queryWord); i < len(r.terms) && strings.HasPrefix(r.terms[i], queryWord); i++ {
+		word := r.terms[i]
+		exact := word == queryWord
+		for id, p := range r.postings[word] {
+			if entityType != "" && id.entityType != entityType {
+				continue
+			}
+			weight := p.weight
+			if !exact {
+				weight = weight / 2
+			}
+			// the best matching word counts, but all fields are reported
+			if existing, ok := result[id]; ok {
+				existing.weight = max(existing.weight, weight)
+				existing.fields = mergeFields(existing.fields, p.fields)
+			} else {
+				result[id] = &posting{weight: weight, fields: p.fields}
+			}
+		}
+	}
+	return result
+}
+
+func (r *Impl) replace(id entityId, doc document) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeLocked(id)
+
+	words := make([]string, 0, len(doc))
+	for word, p := range doc {
+		entities, ok := r.postings[word]
+		if !ok {
+			entities = make(map[entityId]*posting)
+			r.postings[word] = entities
+			r.addTerm(word)
+		}
+		entities[id] = p
+		words = append(words, word)
+	}
+	r.words[id] = words
+}
+
+func (r *Impl) removeLocked(id entityId) {
+	for _, word := range r.words[id] {
+		entities := r.postings[word]
+		delete(entities, id)
+		if len(entities) == 0 {
+			delete(r.postings, word)
+			r.removeTerm(word)
+		}
+	}
+	delete(r.words, id)
+}
+
+func (r *Impl) addTerm(word string) {
+	i := sort.SearchStrings(r.terms, word)
+	r.terms = append(r.terms, "")
+	copy(r.terms[i+1:], r.terms[i:])
+	r.terms[i] = word
+}
+
+func (r *Impl) removeTerm(word string) {
+	i := sort.SearchStrings(r.terms, word)
+	if i < len(r.terms) && r.terms[i] == word {
+		r.terms = append(r.terms[:i], r.terms[i+1:]...)
+	}
+}
+
+// document collects the words of one entity before it is put into the index.
+type document map[string]*posting
+
+func newDocument() document {
+	return make(document)
+}
+
+func (d document) add(field string, weight int, text string) {
+	for _, word := range tokenize(text) {
+		p, ok := d[word]
+		if !ok {
+			p = &posting{}
+			d[word] = p
+		}
+		// a word counts with its best field, but all fields it appears in are reported
+		if weight > p.weight {
+			p.weight = weight
+		}
+		p.fields = mergeFields(p.fields, []string{field})
+	}
+}
+
+func (d document) addOptional(field string, weight int, text *string) {
+	if text != nil {
+		d.add(field, weight, *text)
+	}
+}
+
+func (d document) addLabels(labels *map[string]string) {
+	if labels != nil {
+		for key, value := range *labels {
+			d.add(fieldLabels, weightLabel, key)
+			d.add(fieldLabels, weightLabel, value)
+		}
+	}
+}
+
+// tokenize splits text into lower case words, anything but letters and digits separates words.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// mergeFields gives the sorted union of two field lists without modifying either.
+func mergeFields(a []string, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	result := make([]string, 0, len(a)+len(b))
+	for _, field := range append(append([]string{}, a...), b...) {
+		if !seen[field] {
+			seen[field] = true
+			result = append(result, field)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,97 @@
+package searchindex
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/stretchr/testify/require"
+	"sort"
+	"testing"
+)
+
+func p(v string) *string {
+	return &v
+}
+
+func tstIndex() *Impl {
+	index := &Impl{
+		postings: make(map[string]map[entityId]*posting),
+		terms:    make([]string, 0),
+		words:    make(map[entityId][]string),
+	}
+	ctx := context.Background()
+	index.IndexOwner(ctx, "payments", openapi.OwnerDto{DisplayName: p("Payments Team")})
+	index.IndexService(ctx, "payout-service", openapi.ServiceDto{
+		Description: p("Sends payouts to customers"),
+		Tags:        []string{"money"},
+	})
+	index.IndexService(ctx, "billing", openapi.ServiceDto{
+		Quicklinks: []openapi.Quicklink{{Title: p("Payout Dashboard")}},
+		Labels:     &map[string]string{"team": "payments"},
+	})
+	index.IndexRepository(ctx, "billing.implementation", openapi.RepositoryDto{
+		Url: "ssh://git@example.com/billing.git",
+	})
+	return index
+}
+
+func tstKeys(hits []openapi.SearchHitDto) []string {
+	result := make([]string, 0)
+	for _, hit := range hits {
+		result = append(result, hit.Type+":"+hit.Key)
+	}
+	return result
+}
+
+func TestSearch_Ranking(t *testing.T) {
+	index := tstIndex()
+
+	hits := index.Search(context.Background(), "payout", "")
+	// name beats quicklink title beats word beginning in description
+	require.Equal(t, []string{"service:payout-service", "service:billing"}, tstKeys(hits))
+	require.Equal(t, 8, hits[0].Score)
+	require.Equal(t, []string{"description", "name"}, hits[0].Fields)
+
+	hits = index.Search(context.Background(), "pay", "")
+	require.Equal(t, []string{"owner:payments", "service:payout-service", "service:billing"}, tstKeys(hits))
+}
+
+func TestSearch_AllWordsAndType(t *testing.T) {
+	index := tstIndex()
+
+	require.Equal(t, []string{"service:billing"}, tstKeys(index.Search(context.Background(), "Payments billing", "")))
+	require.Equal(t, []string{"repository:billing.implementation"}, tstKeys(index.Search(context.Background(), "billing", repository.SearchTypeRepository)))
+	require.Empty(t, index.Search(context.Background(), "money dashboard", ""))
+	require.Empty(t, index.Search(context.Background(), " - ", ""))
+}
+
+func TestSearch_ReplaceAndRemove(t *testing.T) {
+	index := tstIndex()
+	ctx := context.Background()
+
+	index.IndexService(ctx, "payout-service", openapi.ServiceDto{Description: p("Sends invoices")})
+	require.Empty(t, index.Search(ctx, "payouts", ""))
+	require.Equal(t, []string{"service:payout-service"}, tstKeys(index.Search(ctx, "invoices", "")))
+
+	index.Remove(ctx, repository.SearchTypeService, "billing")
+	index.Remove(ctx, repository.SearchTypeService, "does-not-exist")
+	require.Empty(t, index.Search(ctx, "dashboard", ""))
+	require.Equal(t, []string{"repository:billing.implementation"}, tstKeys(index.Search(ctx, "billing", "")))
+}
+
+func TestSearch_PrefixRangeFollowsTerms(t *testing.T) {
+	index := tstIndex()
+	ctx := context.Background()
+	require.True(t, sort.StringsAreSorted(index.terms))
+
+	index.Remove(ctx, repository.SearchTypeService, "payout-service")
+	require.True(t, sort.StringsAreSorted(index.terms))
+	require.NotContains(t, index.terms, "payouts")
+	require.Equal(t, len(index.postings), len(index.terms))
+	require.Equal(t, []string{"owner:payments", "service:billing"}, tstKeys(index.Search(ctx, "pay", "")))
+
+	index.IndexService(ctx, "paz", openapi.ServiceDto{})
+	require.True(t, sort.StringsAreSorted(index.terms))
+	require.Equal(t, []string{"service:paz"}, tstKeys(index.Search(ctx, "paz", "")))
+	require.Equal(t, []string{"owner:payments", "service:billing"}, tstKeys(index.Search(ctx, "pay", "")))
+}
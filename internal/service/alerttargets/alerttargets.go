@@ -0,0 +1,145 @@
+package alerttargets
+
+import (
+	"errors"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"strings"
+)
+
+// Kind is one kind of alert target, such as an email address or a Teams webhook.
+type Kind struct {
+	// Name is also the prefix of the typed notation, as in "slack:#some-channel".
+	Name string
+
+	// Recognizes tells whether an untyped value, as written before alert targets had kinds, is of this kind.
+	//
+	// May be nil, then the kind can only be given in typed notation.
+	Recognizes func(value string) bool
+
+	// Normalize brings a value into canonical form. It is called before Validate.
+	Normalize func(value string) string
+
+	// Validate returns an error describing the problem if the value is not valid for this kind.
+	Validate func(value string) error
+}
+
+// AlertTarget is an alert target that has been parsed and normalized.
+type AlertTarget struct {
+	Kind  string
+	Value string
+
+	// Typed is false for values that did not use the typed notation, they are kept that way when normalized.
+	Typed bool
+}
+
+// String gives the alert target in the notation it was parsed from.
+func (t AlertTarget) String() string {
+	if t.Typed {
+		return t.Kind + ":" + t.Value
+	}
+	return t.Value
+}
+
+// ErrUnrecognized is returned by Parse for untyped values that no kind recognizes.
+var ErrUnrecognized = errors.New("alert target is not of any known kind")
+
+// Registry holds the known kinds of alert targets. Untyped values are matched against them in order.
+type Registry struct {
+	kinds []Kind
+
+	// legacy takes the untyped values that were valid before alert targets had kinds, but that no kind accepts
+	legacy *Kind
+}
+
+// NewRegistry creates a registry with the built-in kinds, configured from customConfig.
+//
+// The built-in kinds are email, slack, teams and pagerduty, and the legacy kind for untyped values.
+func NewRegistry(customConfig config.CustomConfiguration) *Registry {
+	legacy := Legacy(customConfig.AlertTargetPrefix(), customConfig.AlertTargetSuffix())
+	r := &Registry{legacy: &legacy}
+	r.Register(Email(customConfig.AlertTargetSuffix()))
+	r.Register(Slack())
+	r.Register(Teams(customConfig.AlertTargetPrefix()))
+	r.Register(PagerDuty())
+	return r
+}
+
+// Register adds a kind, or replaces the kind of the same name.
+func (r *Registry) Register(kind Kind) {
+	for i, existing := range r.kinds {
+		if existing.Name == kind.Name {
+			r.kinds[i] = kind
+			return
+		}
+	}
+	r.kinds = append(r.kinds, kind)
+}
+
+// KindNames lists the names of the known kinds in order. The legacy kind cannot be given and is not listed.
+func (r *Registry) KindNames() []string {
+	result := make([]string, 0, len(r.kinds))
+	for _, kind := range r.kinds {
+		result = append(result, kind.Name)
+	}
+	return result
+}
+
+// Parse determines the kind of an alert target, then normalizes and validates it.
+//
+// Values in typed notation ("kind:value") must name a known kind. Any other value is parsed backwards-compatibly, using
+// the first kind that recognizes it. Untyped values that no kind recognizes or that fail its validation are of the
+// legacy kind if they pass the checks from before alert targets had kinds, and fail with ErrUnrecognized or the
+// validation error otherwise. If validation fails, the returned alert target still carries the kind, so the error can
+// be reported for it.
+func (r *Registry) Parse(raw string) (AlertTarget, error) {
+	kind, value, typed := r.split(raw)
+	if kind == nil {
+		if r.isLegacy(raw) {
+			return parseAs(r.legacy, raw, false)
+		}
+		return AlertTarget{Value: raw}, ErrUnrecognized
+	}
+
+	result, err := parseAs(kind, value, typed)
+	if err != nil && !typed && r.isLegacy(raw) {
+		return parseAs(r.legacy, raw, false)
+	}
+	return result, err
+}
+
+func (r *Registry) isLegacy(raw string) bool {
+	return r.legacy != nil && r.legacy.Recognizes(raw)
+}
+
+func parseAs(kind *Kind, value string, typed bool) (AlertTarget, error) {
+	result := AlertTarget{
+		Kind:  kind.Name,
+		Value: value,
+		Typed: typed,
+	}
+	if kind.Normalize != nil {
+		result.Value = kind.Normalize(value)
+	}
+	if kind.Validate != nil {
+		if err := kind.Validate(result.Value); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (r *Registry) split(raw string) (*Kind, string, bool) {
+	if name, value, found := strings.Cut(raw, ":"); found {
+		for i := range r.kinds {
+			if r.kinds[i].Name == name {
+				return &r.kinds[i], value, true
+			}
+		}
+	}
+	for i := range r.kinds {
+		if r.kinds[i].Recognizes != nil && r.kinds[i].Recognizes(raw) {
+			return &r.kinds[i], raw, false
+		}
+	}
+	return nil, raw, false
+}
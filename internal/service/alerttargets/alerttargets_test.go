@@ -0,0 +1,130 @@
+package alerttargets
+
+import (
+	"github.com/Interhyp/metadata-service/test/mock/configmock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func tstRegistry() *Registry {
+	return NewRegistry(&configmock.MockConfig{})
+}
+
+func tstParse(t *testing.T, raw string, expected AlertTarget) {
+	actual, err := tstRegistry().Parse(raw)
+	require.Nil(t, err)
+	require.Equal(t, expected, actual)
+}
+
+func TestParse_Untyped(t *testing.T) {
+	tstParse(t, "Squad@Some-Organisation.com", AlertTarget{Kind: KindEmail, Value: "squad@some-organisation.com"})
+	tstParse(t, "https://some-domain.com/webhook", AlertTarget{Kind: KindTeams, Value: "https://some-domain.com/webhook"})
+	tstParse(t, "https://hooks.slack.com/services/T0/B0/x", AlertTarget{Kind: KindSlack, Value: "https://hooks.slack.com/services/T0/B0/x"})
+}
+
+func TestParse_Typed(t *testing.T) {
+	tstParse(t, "email:squad@some-organisation.com", AlertTarget{Kind: KindEmail, Value: "squad@some-organisation.com", Typed: true})
+	tstParse(t, "teams:https://some-domain.com/webhook", AlertTarget{Kind: KindTeams, Value: "https://some-domain.com/webhook", Typed: true})
+	tstParse(t, "slack:#Some-Channel", AlertTarget{Kind: KindSlack, Value: "#some-channel", Typed: true})
+	tstParse(t, "pagerduty:0123456789abcdef0123456789abcdef", AlertTarget{Kind: KindPagerDuty, Value: "0123456789abcdef0123456789abcdef", Typed: true})
+}
+
+func TestParse_Unrecognized(t *testing.T) {
+	_, err := tstRegistry().Parse("somethingelse")
+	require.Equal(t, ErrUnrecognized, err)
+
+	_, err = tstRegistry().Parse("https://other-domain.com/webhook")
+	require.Equal(t, ErrUnrecognized, err)
+}
+
+type tstUnsetConfig struct {
+	configmock.MockConfig
+	prefix string
+	suffix string
+}
+
+func (c *tstUnsetConfig) AlertTargetPrefix() string {
+	return c.prefix
+}
+
+func (c *tstUnsetConfig) AlertTargetSuffix() string {
+	return c.suffix
+}
+
+func TestParse_LegacyWithUnsetPrefixOrSuffix(t *testing.T) {
+	for _, config := range []*tstUnsetConfig{
+		{},
+		{prefix: "https://some-domain.com/"},
+		{suffix: "@some-organisation.com"},
+	} {
+		registry := NewRegistry(config)
+
+		actual, err := registry.Parse("somethingelse")
+		require.Nil(t, err)
+		require.Equal(t, AlertTarget{Kind: KindLegacy, Value: "somethingelse"}, actual)
+
+		// recognized as an email address, but not a valid one
+		actual, err = registry.Parse("squad..team@some-organisation.com")
+		require.Nil(t, err)
+		require.Equal(t, AlertTarget{Kind: KindLegacy, Value: "squad..team@some-organisation.com"}, actual)
+
+		// recognized kinds still come first
+		actual, err = registry.Parse("https://hooks.slack.com/services/T0/B0/x")
+		require.Nil(t, err)
+		require.Equal(t, KindSlack, actual.Kind)
+
+		// the legacy kind cannot be given in typed notation
+		actual, err = registry.Parse("legacy:somethingelse")
+		require.Nil(t, err)
+		require.Equal(t, AlertTarget{Kind: KindLegacy, Value: "legacy:somethingelse"}, actual)
+		require.Equal(t, []string{KindEmail, KindSlack, KindTeams, KindPagerDuty}, registry.KindNames())
+	}
+}
+
+func TestParse_LegacyWithPrefixAndSuffix(t *testing.T) {
+	actual, err := tstRegistry().Parse("squad..team@some-organisation.com")
+	require.Nil(t, err)
+	require.Equal(t, AlertTarget{Kind: KindLegacy, Value: "squad..team@some-organisation.com"}, actual)
+
+	_, err = tstRegistry().Parse("squad..team@other-organisation.com")
+	require.Equal(t, ErrUnrecognized, err)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	actual, err := tstRegistry().Parse("email:squad@other-organisation.com")
+	require.Equal(t, KindEmail, actual.Kind)
+	require.EqualError(t, err, "must be an email address ending in @some-organisation.com")
+
+	actual, err = tstRegistry().Parse("teams:https://other-domain.com/webhook")
+	require.Equal(t, KindTeams, actual.Kind)
+	require.EqualError(t, err, "must be a webhook url starting with https://some-domain.com/")
+
+	actual, err = tstRegistry().Parse("pagerduty:short")
+	require.Equal(t, KindPagerDuty, actual.Kind)
+	require.EqualError(t, err, "must be an integration key of 32 letters and digits")
+}
+
+func TestRegister(t *testing.T) {
+	registry := tstRegistry()
+	registry.Register(Kind{
+		Name: "opsgenie",
+		Recognizes: func(value string) bool {
+			return value == "ops"
+		},
+	})
+	registry.Register(PagerDuty())
+	require.Equal(t, []string{KindEmail, KindSlack, KindTeams, KindPagerDuty, "opsgenie"}, registry.KindNames())
+
+	tstParseWith := func(raw string, expected AlertTarget) {
+		actual, err := registry.Parse(raw)
+		require.Nil(t, err)
+		require.Equal(t, expected, actual)
+	}
+	tstParseWith("ops", AlertTarget{Kind: "opsgenie", Value: "ops"})
+	tstParseWith("opsgenie:team", AlertTarget{Kind: "opsgenie", Value: "team", Typed: true})
+}
+
+func TestString(t *testing.T) {
+	require.Equal(t, "slack:#some-channel", AlertTarget{Kind: KindSlack, Value: "#some-channel", Typed: true}.String())
+	require.Equal(t, "squad@some-organisation.com", AlertTarget{Kind: KindEmail, Value: "squad@some-organisation.com"}.String())
+}
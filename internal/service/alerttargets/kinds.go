@@ -0,0 +1,133 @@
+package alerttargets
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	KindEmail     = "email"
+	KindSlack     = "slack"
+	KindTeams     = "teams"
+	KindPagerDuty = "pagerduty"
+	KindLegacy    = "legacy"
+)
+
+// Email is an email address. If suffix is set, e.g. "@some-organisation.com", the address must end with it.
+//
+// Untyped values are recognized by the suffix, or if none is configured, by the @.
+func Email(suffix string) Kind {
+	suffix = strings.ToLower(suffix)
+	return Kind{
+		Name: KindEmail,
+		Recognizes: func(value string) bool {
+			if suffix != "" {
+				return strings.HasSuffix(strings.ToLower(value), suffix)
+			}
+			return strings.Contains(value, "@") && !strings.Contains(value, "://")
+		},
+		Normalize: func(value string) string {
+			return strings.ToLower(strings.TrimSpace(value))
+		},
+		Validate: func(value string) error {
+			address, err := mail.ParseAddress(value)
+			if err != nil || address.Address != value {
+				return errors.New("must be a plain email address")
+			}
+			if suffix != "" && !strings.HasSuffix(value, suffix) {
+				return fmt.Errorf("must be an email address ending in %s", suffix)
+			}
+			return nil
+		},
+	}
+}
+
+const slackWebhookPrefix = "https://hooks.slack.com/"
+
+var slackChannelRegex = regexp.MustCompile(`^#[a-z0-9][a-z0-9_-]{0,79}$`)
+
+// Slack is either a channel like "#some-channel", or a Slack incoming webhook url.
+//
+// Untyped values are recognized if they are Slack webhook urls.
+func Slack() Kind {
+	return Kind{
+		Name: KindSlack,
+		Recognizes: func(value string) bool {
+			return strings.HasPrefix(value, slackWebhookPrefix)
+		},
+		Normalize: func(value string) string {
+			value = strings.TrimSpace(value)
+			if strings.HasPrefix(value, "#") {
+				return strings.ToLower(value)
+			}
+			return value
+		},
+		Validate: func(value string) error {
+			if slackChannelRegex.MatchString(value) {
+				return nil
+			}
+			if strings.HasPrefix(value, slackWebhookPrefix) && len(value) > len(slackWebhookPrefix) {
+				return nil
+			}
+			return fmt.Errorf("must be a channel like #some-channel or a webhook url starting with %s", slackWebhookPrefix)
+		},
+	}
+}
+
+// Teams is a Teams webhook url. If prefix is set, the url must start with it.
+//
+// Untyped values are recognized by the prefix, or if none is configured, by being an http or https url.
+func Teams(prefix string) Kind {
+	return Kind{
+		Name: KindTeams,
+		Recognizes: func(value string) bool {
+			if prefix != "" {
+				return strings.HasPrefix(value, prefix)
+			}
+			return strings.HasPrefix(value, "https://") || strings.HasPrefix(value, "http://")
+		},
+		Normalize: strings.TrimSpace,
+		Validate: func(value string) error {
+			parsed, err := url.Parse(value)
+			if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				return errors.New("must be an absolute http or https url")
+			}
+			if prefix != "" && !strings.HasPrefix(value, prefix) {
+				return fmt.Errorf("must be a webhook url starting with %s", prefix)
+			}
+			return nil
+		},
+	}
+}
+
+var pagerDutyKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9]{32}$`)
+
+// PagerDuty is the integration key of a PagerDuty style service. It can only be given in typed notation.
+func PagerDuty() Kind {
+	return Kind{
+		Name:      KindPagerDuty,
+		Normalize: strings.TrimSpace,
+		Validate: func(value string) error {
+			if !pagerDutyKeyRegex.MatchString(value) {
+				return errors.New("must be an integration key of 32 letters and digits")
+			}
+			return nil
+		},
+	}
+}
+
+// Legacy is an untyped value that was accepted before alert targets had kinds: one starting with prefix or ending
+// with suffix. As before, an unset prefix or suffix accepts any value. It cannot be given in typed notation, and
+// values are kept as they are.
+func Legacy(prefix string, suffix string) Kind {
+	return Kind{
+		Name: KindLegacy,
+		Recognizes: func(value string) bool {
+			return strings.HasPrefix(value, prefix) || strings.HasSuffix(value, suffix)
+		},
+	}
+}
@@ -0,0 +1,347 @@
+package consistency
+
+import (
+	"context"
+	"fmt"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	"github.com/Interhyp/metadata-service/internal/service/util"
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"sort"
+)
+
+type Impl struct {
+	Configuration       librepo.Configuration
+	CustomConfiguration config.CustomConfiguration
+	Logging             librepo.Logging
+	Timestamp           librepo.Timestamp
+	Cache               repository.Cache
+
+	issueGauge *prometheus.GaugeVec
+}
+
+func New(
+	configuration librepo.Configuration,
+	customConfig config.CustomConfiguration,
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	cache repository.Cache,
+) service.Consistency {
+	return &Impl{
+		Configuration:       configuration,
+		CustomConfiguration: customConfig,
+		Logging:             logging,
+		Timestamp:           timestamp,
+		Cache:               cache,
+	}
+}
+
+func (s *Impl) IsConsistency() bool {
+	return true
+}
+
+func (s *Impl) Setup() error {
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+
+	s.SetupMetrics(ctx)
+
+	s.Logging.Logger().Ctx(ctx).Info().Print("successfully set up consistency business component")
+	return nil
+}
+
+const (
+	IssueMissingRepository      = "missing-repository"
+	IssueUnreferencedRepository = "unreferenced-repository"
+	IssueMissingGroup           = "missing-group"
+	IssueOwnerWithoutServices   = "owner-without-services"
+	IssueMissingReplacement     = "missing-replacement"
+)
+
+// issueTypes lists all types of issues, so their counts are reported even if there are none.
+var issueTypes = []string{IssueMissingRepository, IssueUnreferencedRepository, IssueMissingGroup, IssueOwnerWithoutServices, IssueMissingReplacement}
+
+const (
+	entityTypeService    = "service"
+	entityTypeRepository = "repository"
+	entityTypeOwner      = "owner"
+)
+
+var IssueGaugeName = "consistency_issues"
+
+// --- metrics ---
+
+func (s *Impl) SetupMetrics(_ context.Context) {
+	s.issueGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: IssueGaugeName,
+			Help: "How many inconsistencies were found in the last consistency check, partitioned by type.",
+		},
+		[]string{"type"},
+	)
+	prometheus.MustRegister(s.issueGauge)
+}
+
+// --- business logic ---
+
+func (s *Impl) CheckConsistency(ctx context.Context) (openapi.ConsistencyReportDto, error) {
+	snapshot, err := s.loadSnapshot(ctx)
+	if err != nil {
+		return openapi.ConsistencyReportDto{}, err
+	}
+
+	result := openapi.ConsistencyReportDto{
+		Issues: make([]openapi.ConsistencyIssueDto, 0),
+		Counts: make(map[string]int32),
+	}
+	result.Issues = append(result.Issues, snapshot.checkRepositoryReferences()...)
+	result.Issues = append(result.Issues, snapshot.checkGroupReferences()...)
+	result.Issues = append(result.Issues, snapshot.checkOwnersWithoutServices()...)
+	result.Issues = append(result.Issues, snapshot.checkReplacedByReferences()...)
+
+	for _, issueType := range issueTypes {
+		result.Counts[issueType] = 0
+	}
+	for _, issue := range result.Issues {
+		result.Counts[issue.Type]++
+	}
+	for issueType, count := range result.Counts {
+		s.issueGauge.WithLabelValues(issueType).Set(float64(count))
+	}
+
+	s.Logging.Logger().Ctx(ctx).Info().Printf("consistency check found %d issues", len(result.Issues))
+	return result, nil
+}
+
+// snapshot holds all cached entities, so each check sees the same state.
+type snapshot struct {
+	ownerAliases   []string
+	owners         map[string]openapi.OwnerDto
+	serviceNames   []string
+	services       map[string]openapi.ServiceDto
+	repositoryKeys []string
+	repositories   map[string]openapi.RepositoryDto
+}
+
+// loadSnapshot reads all cached entities. Entities that vanish between listing and reading them, because an update
+// deleted them in the meantime, are left out.
+func (s *Impl) loadSnapshot(ctx context.Context) (*snapshot, error) {
+	result := &snapshot{
+		ownerAliases:   make([]string, 0),
+		owners:         make(map[string]openapi.OwnerDto),
+		serviceNames:   make([]string, 0),
+		services:       make(map[string]openapi.ServiceDto),
+		repositoryKeys: make([]string, 0),
+		repositories:   make(map[string]openapi.RepositoryDto),
+	}
+
+	ownerAliases, err := s.Cache.GetSortedOwnerAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range ownerAliases {
+		owner, err := s.Cache.GetOwner(ctx, alias)
+		if err != nil {
+			continue
+		}
+		result.ownerAliases = append(result.ownerAliases, alias)
+		result.owners[alias] = owner
+	}
+
+	serviceNames, err := s.Cache.GetSortedServiceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range serviceNames {
+		service, err := s.Cache.GetService(ctx, name)
+		if err != nil {
+			continue
+		}
+		result.serviceNames = append(result.serviceNames, name)
+		result.services[name] = service
+	}
+
+	repositoryKeys, err := s.Cache.GetSortedRepositoryKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range repositoryKeys {
+		repository, err := s.Cache.GetRepository(ctx, key)
+		if err != nil {
+			continue
+		}
+		result.repositoryKeys = append(result.repositoryKeys, key)
+		result.repositories[key] = repository
+	}
+
+	return result, nil
+}
+
+// checkRepositoryReferences finds services listing repositories that do not exist, and repositories that no
+// service lists.
+func (sn *snapshot) checkRepositoryReferences() []openapi.ConsistencyIssueDto {
+	result := make([]openapi.ConsistencyIssueDto, 0)
+
+	referenced := make(map[string]bool)
+	for _, name := range sn.serviceNames {
+		for i, key := range sn.services[name].Repositories {
+			referenced[key] = true
+			if _, ok := sn.repositories[key]; !ok {
+				result = append(result, openapi.ConsistencyIssueDto{
+					Type:         IssueMissingRepository,
+					EntityType:   entityTypeService,
+					Entity:       name,
+					Field:        p(fmt.Sprintf("repositories.%d", i)),
+					Reference:    p(key),
+					SuggestedFix: fmt.Sprintf("remove repository %s from service %s, or create the repository", key, name),
+				})
+			}
+		}
+	}
+
+	for _, key := range sn.repositoryKeys {
+		if !referenced[key] {
+			result = append(result, openapi.ConsistencyIssueDto{
+				Type:         IssueUnreferencedRepository,
+				EntityType:   entityTypeRepository,
+				Entity:       key,
+				SuggestedFix: fmt.Sprintf("add repository %s to the service it belongs to, or delete it", key),
+			})
+		}
+	}
+	return result
+}
+
+// checkGroupReferences finds @owner.group references to groups that do not exist, in the groups and promoters of
+// owners and in the approvers and watchers of repositories.
+func (sn *snapshot) checkGroupReferences() []openapi.ConsistencyIssueDto {
+	result := make([]openapi.ConsistencyIssueDto, 0)
+
+	check := func(entityType string, entity string, field string, usersAndGroups []string) {
+		for _, reference := range usersAndGroups {
+			isGroup, groupOwner, groupName := util.ParseGroupOwnerAndGroupName(reference)
+			if !isGroup || sn.groupExists(groupOwner, groupName) {
+				continue
+			}
+			result = append(result, openapi.ConsistencyIssueDto{
+				Type:         IssueMissingGroup,
+				EntityType:   entityType,
+				Entity:       entity,
+				Field:        p(field),
+				Reference:    p(reference),
+				SuggestedFix: fmt.Sprintf("create group %s in owner %s, or remove the reference %s", groupName, groupOwner, reference),
+			})
+		}
+	}
+
+	for _, alias := range sn.ownerAliases {
+		owner := sn.owners[alias]
+		if owner.Groups != nil {
+			for _, groupName := range sortedKeys(*owner.Groups) {
+				check(entityTypeOwner, alias, "groups."+groupName, (*owner.Groups)[groupName])
+			}
+		}
+		check(entityTypeOwner, alias, "promoters", owner.Promoters)
+	}
+
+	for _, key := range sn.repositoryKeys {
+		configuration := sn.repositories[key].Configuration
+		if configuration == nil {
+			continue
+		}
+		if configuration.Approvers != nil {
+			for _, groupName := range sortedKeys(*configuration.Approvers) {
+				check(entityTypeRepository, key, "configuration.approvers."+groupName, (*configuration.Approvers)[groupName])
+			}
+		}
+		check(entityTypeRepository, key, "configuration.watchers", configuration.Watchers)
+	}
+	return result
+}
+
+func (sn *snapshot) groupExists(ownerAlias string, groupName string) bool {
+	owner, ok := sn.owners[ownerAlias]
+	if !ok || owner.Groups == nil {
+		return false
+	}
+	_, ok = (*owner.Groups)[groupName]
+	return ok
+}
+
+// checkOwnersWithoutServices finds owners that own no service.
+func (sn *snapshot) checkOwnersWithoutServices() []openapi.ConsistencyIssueDto {
+	result := make([]openapi.ConsistencyIssueDto, 0)
+
+	withServices := make(map[string]bool)
+	for _, name := range sn.serviceNames {
+		withServices[sn.services[name].Owner] = true
+	}
+
+	for _, alias := range sn.ownerAliases {
+		if !withServices[alias] {
+			result = append(result, openapi.ConsistencyIssueDto{
+				Type:         IssueOwnerWithoutServices,
+				EntityType:   entityTypeOwner,
+				Entity:       alias,
+				SuggestedFix: fmt.Sprintf("move services to owner %s, or delete the owner", alias),
+			})
+		}
+	}
+	return result
+}
+
+// checkReplacedByReferences finds services and repositories whose replacedBy names an entity that does not exist.
+func (sn *snapshot) checkReplacedByReferences() []openapi.ConsistencyIssueDto {
+	result := make([]openapi.ConsistencyIssueDto, 0)
+
+	for _, name := range sn.serviceNames {
+		replacedBy := sn.services[name].ReplacedBy
+		if replacedBy == nil {
+			continue
+		}
+		if _, ok := sn.services[*replacedBy]; !ok {
+			result = append(result, openapi.ConsistencyIssueDto{
+				Type:         IssueMissingReplacement,
+				EntityType:   entityTypeService,
+				Entity:       name,
+				Field:        p("replacedBy"),
+				Reference:    p(*replacedBy),
+				SuggestedFix: fmt.Sprintf("change replacedBy of service %s to an existing service, or create service %s", name, *replacedBy),
+			})
+		}
+	}
+
+	for _, key := range sn.repositoryKeys {
+		replacedBy := sn.repositories[key].ReplacedBy
+		if replacedBy == nil {
+			continue
+		}
+		if _, ok := sn.repositories[*replacedBy]; !ok {
+			result = append(result, openapi.ConsistencyIssueDto{
+				Type:         IssueMissingReplacement,
+				EntityType:   entityTypeRepository,
+				Entity:       key,
+				Field:        p("replacedBy"),
+				Reference:    p(*replacedBy),
+				SuggestedFix: fmt.Sprintf("change replacedBy of repository %s to an existing repository, or create repository %s", key, *replacedBy),
+			})
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string][]string) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func p(v string) *string {
+	return &v
+}
@@ -0,0 +1,176 @@
+package consistency
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/test/mock/cachemock"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"github.com/StephanHCB/go-backend-service-common/repository/logging"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// tstGauge is not registered, so each test can use it.
+var tstGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: IssueGaugeName}, []string{"type"})
+
+// tstCache adds a service and a repository to the owners of the cache mock.
+type tstCache struct {
+	ownersmock.Mock
+}
+
+func (c *tstCache) GetSortedServiceNames(ctx context.Context) ([]string, error) {
+	return []string{"some-service"}, nil
+}
+
+func (c *tstCache) GetService(ctx context.Context, name string) (openapi.ServiceDto, error) {
+	return openapi.ServiceDto{
+		Owner:        "ownerWithGroup",
+		Repositories: []string{"some-service.implementation", "some-service.helm-deployment"},
+		ReplacedBy:   p("deleted-service"),
+	}, nil
+}
+
+func (c *tstCache) GetSortedRepositoryKeys(ctx context.Context) ([]string, error) {
+	return []string{"some-service.implementation", "unused.implementation"}, nil
+}
+
+func (c *tstCache) GetRepository(ctx context.Context, key string) (openapi.RepositoryDto, error) {
+	var replacedBy *string
+	if key == "unused.implementation" {
+		replacedBy = p("deleted.implementation")
+	}
+	return openapi.RepositoryDto{
+		Owner:      "ownerWithGroup",
+		ReplacedBy: replacedBy,
+		Configuration: &openapi.RepositoryConfigurationDto{
+			Approvers: &map[string][]string{"some": {"@ownerWithGroup.someGroupName"}},
+			Watchers:  []string{"someone", "@ownerWithGroup.otherGroupName", "@unknownOwner.someGroupName"},
+		},
+	}, nil
+}
+
+func TestCheckConsistency(t *testing.T) {
+	cut := &Impl{
+		Logging:    &logging.LoggingImpl{},
+		Cache:      &tstCache{},
+		issueGauge: tstGauge,
+	}
+
+	report, err := cut.CheckConsistency(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, []openapi.ConsistencyIssueDto{
+		{
+			Type:         IssueMissingRepository,
+			EntityType:   "service",
+			Entity:       "some-service",
+			Field:        p("repositories.1"),
+			Reference:    p("some-service.helm-deployment"),
+			SuggestedFix: "remove repository some-service.helm-deployment from service some-service, or create the repository",
+		},
+		{
+			Type:         IssueUnreferencedRepository,
+			EntityType:   "repository",
+			Entity:       "unused.implementation",
+			SuggestedFix: "add repository unused.implementation to the service it belongs to, or delete it",
+		},
+		{
+			Type:         IssueMissingGroup,
+			EntityType:   "repository",
+			Entity:       "some-service.implementation",
+			Field:        p("configuration.watchers"),
+			Reference:    p("@ownerWithGroup.otherGroupName"),
+			SuggestedFix: "create group otherGroupName in owner ownerWithGroup, or remove the reference @ownerWithGroup.otherGroupName",
+		},
+		{
+			Type:         IssueMissingGroup,
+			EntityType:   "repository",
+			Entity:       "some-service.implementation",
+			Field:        p("configuration.watchers"),
+			Reference:    p("@unknownOwner.someGroupName"),
+			SuggestedFix: "create group someGroupName in owner unknownOwner, or remove the reference @unknownOwner.someGroupName",
+		},
+		// the watchers of unused.implementation are the same
+		{
+			Type:         IssueMissingGroup,
+			EntityType:   "repository",
+			Entity:       "unused.implementation",
+			Field:        p("configuration.watchers"),
+			Reference:    p("@ownerWithGroup.otherGroupName"),
+			SuggestedFix: "create group otherGroupName in owner ownerWithGroup, or remove the reference @ownerWithGroup.otherGroupName",
+		},
+		{
+			Type:         IssueMissingGroup,
+			EntityType:   "repository",
+			Entity:       "unused.implementation",
+			Field:        p("configuration.watchers"),
+			Reference:    p("@unknownOwner.someGroupName"),
+			SuggestedFix: "create group someGroupName in owner unknownOwner, or remove the reference @unknownOwner.someGroupName",
+		},
+		{
+			Type:         IssueOwnerWithoutServices,
+			EntityType:   "owner",
+			Entity:       "someOwner",
+			SuggestedFix: "move services to owner someOwner, or delete the owner",
+		},
+		{
+			Type:         IssueMissingReplacement,
+			EntityType:   "service",
+			Entity:       "some-service",
+			Field:        p("replacedBy"),
+			Reference:    p("deleted-service"),
+			SuggestedFix: "change replacedBy of service some-service to an existing service, or create service deleted-service",
+		},
+		{
+			Type:         IssueMissingReplacement,
+			EntityType:   "repository",
+			Entity:       "unused.implementation",
+			Field:        p("replacedBy"),
+			Reference:    p("deleted.implementation"),
+			SuggestedFix: "change replacedBy of repository unused.implementation to an existing repository, or create repository deleted.implementation",
+		},
+	}, report.Issues)
+	require.Equal(t, map[string]int32{
+		IssueMissingRepository:      1,
+		IssueUnreferencedRepository: 1,
+		IssueMissingGroup:           4,
+		IssueOwnerWithoutServices:   1,
+		IssueMissingReplacement:     2,
+	}, report.Counts)
+
+	require.Equal(t, float64(4), testutil.ToFloat64(cut.issueGauge.WithLabelValues(IssueMissingGroup)))
+	require.Equal(t, float64(1), testutil.ToFloat64(cut.issueGauge.WithLabelValues(IssueOwnerWithoutServices)))
+}
+
+// tstVanishingCache lists a repository that has been deleted by the time it is read.
+type tstVanishingCache struct {
+	tstCache
+}
+
+func (c *tstVanishingCache) GetSortedRepositoryKeys(ctx context.Context) ([]string, error) {
+	return []string{"some-service.implementation", "vanished.implementation"}, nil
+}
+
+func (c *tstVanishingCache) GetRepository(ctx context.Context, key string) (openapi.RepositoryDto, error) {
+	if key == "vanished.implementation" {
+		return openapi.RepositoryDto{}, apierrors.NewNotFoundError("repository.notfound", "repository not found", nil, time.Now())
+	}
+	return c.tstCache.GetRepository(ctx, key)
+}
+
+func TestCheckConsistency_VanishedEntity(t *testing.T) {
+	cut := &Impl{
+		Logging:    &logging.LoggingImpl{},
+		Cache:      &tstVanishingCache{},
+		issueGauge: tstGauge,
+	}
+
+	report, err := cut.CheckConsistency(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, int32(0), report.Counts[IssueUnreferencedRepository])
+	for _, issue := range report.Issues {
+		require.NotEqual(t, "vanished.implementation", issue.Entity)
+	}
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	"strings"
+
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+)
+
+type Impl struct {
+	Configuration librepo.Configuration
+	Logging       librepo.Logging
+	Timestamp     librepo.Timestamp
+	EventStream   repository.EventStream
+}
+
+func New(
+	configuration librepo.Configuration,
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	eventStream repository.EventStream,
+) service.Events {
+	return &Impl{
+		Configuration: configuration,
+		Logging:       logging,
+		Timestamp:     timestamp,
+		EventStream:   eventStream,
+	}
+}
+
+func (s *Impl) IsEvents() bool {
+	return true
+}
+
+func (s *Impl) Setup() error {
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+
+	// nothing to do
+
+	s.Logging.Logger().Ctx(ctx).Info().Print("successfully set up events business component")
+	return nil
+}
+
+var entityTypes = []string{repository.SearchTypeOwner, repository.SearchTypeService, repository.SearchTypeRepository}
+
+func (s *Impl) Subscribe(ctx context.Context, entityType string, owner string, lastEventId string) (repository.Subscription, func(), error) {
+	if entityType != "" && !contains(entityTypes, entityType) {
+		details := fmt.Sprintf("type must be one of %s", strings.Join(entityTypes, ", "))
+		s.Logging.Logger().Ctx(ctx).Info().Printf("event filter values invalid: %s", details)
+		return repository.Subscription{}, func() {}, apierrors.NewBadRequestError("events.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	}
+
+	subscription, cancel := s.EventStream.Subscribe(ctx, repository.EventFilter{
+		EntityType: entityType,
+		Owner:      owner,
+	}, lastEventId)
+	if !subscription.Resumed {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("event id %s no longer available, subscriber must reset", lastEventId)
+	}
+	return subscription, cancel, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
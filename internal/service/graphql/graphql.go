@@ -0,0 +1,132 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+)
+
+type Impl struct {
+	Configuration       librepo.Configuration
+	CustomConfiguration config.CustomConfiguration
+	Logging             librepo.Logging
+	Timestamp           librepo.Timestamp
+	Cache               repository.Cache
+
+	schema gql.Schema
+}
+
+func New(
+	configuration librepo.Configuration,
+	customConfig config.CustomConfiguration,
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	cache repository.Cache,
+) service.Graphql {
+	return &Impl{
+		Configuration:       configuration,
+		CustomConfiguration: customConfig,
+		Logging:             logging,
+		Timestamp:           timestamp,
+		Cache:               cache,
+	}
+}
+
+func (s *Impl) IsGraphql() bool {
+	return true
+}
+
+func (s *Impl) Setup() error {
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+
+	schema, err := newSchema()
+	if err != nil {
+		s.Logging.Logger().Ctx(ctx).Error().WithErr(err).Print("failed to build graphql schema")
+		return err
+	}
+	s.schema = schema
+
+	s.Logging.Logger().Ctx(ctx).Info().Print("successfully set up graphql business component")
+	return nil
+}
+
+func (s *Impl) Execute(ctx context.Context, request openapi.GraphqlRequestDto) openapi.GraphqlResponseDto {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{
+			Body: []byte(request.Query),
+			Name: "GraphQL request",
+		}),
+	})
+	if err != nil {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("graphql query does not parse: %s", err.Error())
+		return errorResponse(gqlerrors.FormatErrors(err))
+	}
+
+	validation := gql.ValidateDocument(&s.schema, document, nil)
+	if !validation.IsValid {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("graphql query invalid: %s", validation.Errors[0].Message)
+		return errorResponse(validation.Errors)
+	}
+
+	cost := measure(&s.schema, document)
+	if maxDepth := int(s.CustomConfiguration.GraphqlMaxDepth()); cost.depth > maxDepth {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("graphql query too deep: %d > %d", cost.depth, maxDepth)
+		return errorResponse([]gqlerrors.FormattedError{
+			gqlerrors.NewFormattedError(fmt.Sprintf("query depth %d exceeds the maximum of %d", cost.depth, maxDepth)),
+		})
+	}
+	if maxComplexity := int(s.CustomConfiguration.GraphqlMaxComplexity()); cost.complexity > maxComplexity {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("graphql query too complex: %d > %d", cost.complexity, maxComplexity)
+		return errorResponse([]gqlerrors.FormattedError{
+			gqlerrors.NewFormattedError(fmt.Sprintf("query complexity %d exceeds the maximum of %d", cost.complexity, maxComplexity)),
+		})
+	}
+
+	result := gql.Execute(gql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           document,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context: context.WithValue(ctx, graphKey, &graph{
+			cache:     s.Cache,
+			separator: s.CustomConfiguration.RepositoryKeySeparator(),
+		}),
+	})
+	if result.HasErrors() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("graphql query had %d errors, first: %s", len(result.Errors), result.Errors[0].Message)
+	}
+
+	response := errorResponse(result.Errors)
+	response.Data = result.Data
+	return response
+}
+
+// --- helpers ---
+
+func errorResponse(errs []gqlerrors.FormattedError) openapi.GraphqlResponseDto {
+	result := openapi.GraphqlResponseDto{}
+	for _, e := range errs {
+		dto := openapi.GraphqlErrorDto{
+			Message: e.Message,
+			Path:    e.Path,
+		}
+		for _, l := range e.Locations {
+			dto.Locations = append(dto.Locations, openapi.GraphqlErrorLocationDto{
+				Line:   l.Line,
+				Column: l.Column,
+			})
+		}
+		result.Errors = append(result.Errors, dto)
+	}
+	return result
+}
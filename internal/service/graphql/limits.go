@@ -0,0 +1,124 @@
+package graphql
+
+import (
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"strings"
+)
+
+// listFactor is the number of entries assumed for each list when estimating the complexity of a query.
+const listFactor = 10
+
+type cost struct {
+	depth      int
+	complexity int
+}
+
+// measure estimates the cost of the most expensive operation in a validated document.
+//
+// Top level fields have depth 1. Every field costs 1, and the cost of the fields selected below a list of
+// objects is multiplied by listFactor. Introspection fields are free.
+func measure(schema *gql.Schema, document *ast.Document) cost {
+	m := measurement{
+		schema:    schema,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		visiting:  make(map[string]bool),
+	}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			m.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	result := cost{}
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			c := m.selections(operation.SelectionSet, schema.QueryType(), 1)
+			result.depth = max(result.depth, c.depth)
+			result.complexity = max(result.complexity, c.complexity)
+		}
+	}
+	return result
+}
+
+type measurement struct {
+	schema    *gql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	// guards against fragment cycles, although validation should already have rejected them
+	visiting map[string]bool
+}
+
+func (m *measurement) selections(set *ast.SelectionSet, parent *gql.Object, depth int) cost {
+	result := cost{}
+	if set == nil || parent == nil {
+		return result
+	}
+	for _, selection := range set.Selections {
+		var c cost
+		switch s := selection.(type) {
+		case *ast.Field:
+			c = m.field(s, parent, depth)
+		case *ast.InlineFragment:
+			c = m.selections(s.SelectionSet, m.typeCondition(s.TypeCondition, parent), depth)
+		case *ast.FragmentSpread:
+			fragment, ok := m.fragments[s.Name.Value]
+			if !ok || m.visiting[s.Name.Value] {
+				continue
+			}
+			m.visiting[s.Name.Value] = true
+			c = m.selections(fragment.SelectionSet, m.typeCondition(fragment.TypeCondition, parent), depth)
+			delete(m.visiting, s.Name.Value)
+		}
+		result.depth = max(result.depth, c.depth)
+		result.complexity += c.complexity
+	}
+	return result
+}
+
+func (m *measurement) field(f *ast.Field, parent *gql.Object, depth int) cost {
+	if strings.HasPrefix(f.Name.Value, "__") {
+		return cost{}
+	}
+	result := cost{depth: depth, complexity: 1}
+
+	definition, ok := parent.Fields()[f.Name.Value]
+	if !ok || f.SelectionSet == nil {
+		return result
+	}
+	object, isList := unwrap(definition.Type)
+	children := m.selections(f.SelectionSet, object, depth+1)
+	if isList {
+		children.complexity *= listFactor
+	}
+	result.depth = max(result.depth, children.depth)
+	result.complexity += children.complexity
+	return result
+}
+
+func (m *measurement) typeCondition(condition *ast.Named, parent *gql.Object) *gql.Object {
+	if condition == nil || condition.Name == nil {
+		return parent
+	}
+	if object, ok := m.schema.Type(condition.Name.Value).(*gql.Object); ok {
+		return object
+	}
+	return parent
+}
+
+// unwrap gives the object type of a field and whether it is a list.
+func unwrap(t gql.Type) (*gql.Object, bool) {
+	isList := false
+	for {
+		switch w := t.(type) {
+		case *gql.NonNull:
+			t = w.OfType
+		case *gql.List:
+			isList = true
+			t = w.OfType
+		case *gql.Object:
+			return w, isList
+		default:
+			return nil, isList
+		}
+	}
+}
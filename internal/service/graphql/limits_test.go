@@ -0,0 +1,45 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func tstMeasure(t *testing.T, query string) cost {
+	schema, err := newSchema()
+	require.Nil(t, err)
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	require.Nil(t, err)
+	return measure(&schema, document)
+}
+
+func TestMeasure_Scalars(t *testing.T) {
+	require.Equal(t, cost{depth: 2, complexity: 3}, tstMeasure(t, `{ owner(alias: "x") { alias contact } }`))
+}
+
+func TestMeasure_Lists(t *testing.T) {
+	// owners: 1 + 10 * (alias + services: 1 + 10 * (name))
+	require.Equal(t, cost{depth: 3, complexity: 1 + 10*(1+1+10*1)}, tstMeasure(t, `{ owners { alias services { name } } }`))
+}
+
+func TestMeasure_Fragments(t *testing.T) {
+	query := `
+		query { service(name: "x") { ...details ... on Service { owner { alias } } } }
+		fragment details on Service { name repositories { key } }
+	`
+	// service: 1 + name + repositories: 1 + 10 * key + owner: 1 + alias
+	require.Equal(t, cost{depth: 3, complexity: 1 + 1 + 1 + 10 + 1 + 1}, tstMeasure(t, query))
+}
+
+func TestMeasure_MaxOverOperations(t *testing.T) {
+	query := `
+		query small { owner(alias: "x") { alias } }
+		query large { owners { services { repositories { key } } } }
+	`
+	require.Equal(t, cost{depth: 4, complexity: 1 + 10*(1+10*(1+10))}, tstMeasure(t, query))
+}
+
+func TestMeasure_IntrospectionIsFree(t *testing.T) {
+	require.Equal(t, cost{depth: 1, complexity: 1}, tstMeasure(t, `{ __schema { types { name } } owners { __typename } }`))
+}
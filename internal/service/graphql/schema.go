@@ -0,0 +1,491 @@
+package graphql
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	gql "github.com/graphql-go/graphql"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// --- nodes passed to the field resolvers ---
+
+type ownerNode struct {
+	alias string
+	owner openapi.OwnerDto
+}
+
+type serviceNode struct {
+	name    string
+	service openapi.ServiceDto
+}
+
+type repositoryNode struct {
+	key        string
+	repository openapi.RepositoryDto
+}
+
+type labelNode struct {
+	key   string
+	value string
+}
+
+// --- per query access to the cache ---
+
+type graphType int
+
+const graphKey graphType = 0
+
+// graph gives the resolvers of a single query access to the cache.
+//
+// The relationships that are not stored with the entities are computed on first use, so nested lists
+// do not have to scan all services or repositories over and over.
+type graph struct {
+	cache     repository.Cache
+	separator string
+
+	once                sync.Once
+	err                 error
+	servicesByOwner     map[string][]string
+	repositoriesByOwner map[string][]string
+	serviceByRepository map[string]string
+	dependents          map[string][]string
+}
+
+func graphOf(ctx context.Context) *graph {
+	return ctx.Value(graphKey).(*graph)
+}
+
+func (g *graph) relations(ctx context.Context) error {
+	g.once.Do(func() {
+		g.servicesByOwner = make(map[string][]string)
+		g.repositoriesByOwner = make(map[string][]string)
+		g.serviceByRepository = make(map[string]string)
+		g.dependents = make(map[string][]string)
+
+		names, err := g.cache.GetSortedServiceNames(ctx)
+		if err != nil {
+			g.err = err
+			return
+		}
+		for _, name := range names {
+			service, err := g.cache.GetService(ctx, name)
+			if err != nil {
+				g.err = err
+				return
+			}
+			g.servicesByOwner[service.Owner] = append(g.servicesByOwner[service.Owner], name)
+			for _, key := range service.Repositories {
+				g.serviceByRepository[key] = name
+			}
+			if service.Spec != nil {
+				for _, dependency := range service.Spec.DependsOn {
+					g.dependents[dependency] = append(g.dependents[dependency], name)
+				}
+			}
+		}
+
+		keys, err := g.cache.GetSortedRepositoryKeys(ctx)
+		if err != nil {
+			g.err = err
+			return
+		}
+		for _, key := range keys {
+			repo, err := g.cache.GetRepository(ctx, key)
+			if err != nil {
+				g.err = err
+				return
+			}
+			g.repositoriesByOwner[repo.Owner] = append(g.repositoriesByOwner[repo.Owner], key)
+		}
+	})
+	return g.err
+}
+
+// owner gives nil if the owner does not exist.
+func (g *graph) owner(ctx context.Context, alias string) (interface{}, error) {
+	owner, err := g.cache.GetOwner(ctx, alias)
+	if err != nil {
+		if apierrors.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return ownerNode{alias: alias, owner: owner}, nil
+}
+
+// service gives nil if the service does not exist.
+func (g *graph) service(ctx context.Context, name string) (interface{}, error) {
+	service, err := g.cache.GetService(ctx, name)
+	if err != nil {
+		if apierrors.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return serviceNode{name: name, service: service}, nil
+}
+
+// repository gives nil if the repository does not exist.
+func (g *graph) repository(ctx context.Context, key string) (interface{}, error) {
+	repo, err := g.cache.GetRepository(ctx, key)
+	if err != nil {
+		if apierrors.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return repositoryNode{key: key, repository: repo}, nil
+}
+
+func (g *graph) owners(ctx context.Context) ([]ownerNode, error) {
+	aliases, err := g.cache.GetSortedOwnerAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]ownerNode, 0, len(aliases))
+	for _, alias := range aliases {
+		owner, err := g.cache.GetOwner(ctx, alias)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ownerNode{alias: alias, owner: owner})
+	}
+	return result, nil
+}
+
+// services leaves out names that do not refer to an existing service.
+func (g *graph) services(ctx context.Context, names []string) ([]serviceNode, error) {
+	result := make([]serviceNode, 0, len(names))
+	for _, name := range names {
+		node, err := g.service(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if node != nil {
+			result = append(result, node.(serviceNode))
+		}
+	}
+	return result, nil
+}
+
+// repositories leaves out keys that do not refer to an existing repository.
+func (g *graph) repositories(ctx context.Context, keys []string) ([]repositoryNode, error) {
+	result := make([]repositoryNode, 0, len(keys))
+	for _, key := range keys {
+		node, err := g.repository(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if node != nil {
+			result = append(result, node.(repositoryNode))
+		}
+	}
+	return result, nil
+}
+
+// --- schema ---
+
+func newSchema() (gql.Schema, error) {
+	linkType := gql.NewObject(gql.ObjectConfig{
+		Name: "Link",
+		Fields: gql.Fields{
+			"url":   &gql.Field{Type: gql.String, Resolve: field(func(l openapi.Link) interface{} { return l.Url })},
+			"title": &gql.Field{Type: gql.String, Resolve: field(func(l openapi.Link) interface{} { return l.Title })},
+		},
+	})
+
+	quicklinkType := gql.NewObject(gql.ObjectConfig{
+		Name: "Quicklink",
+		Fields: gql.Fields{
+			"url":         &gql.Field{Type: gql.String, Resolve: field(func(l openapi.Quicklink) interface{} { return l.Url })},
+			"title":       &gql.Field{Type: gql.String, Resolve: field(func(l openapi.Quicklink) interface{} { return l.Title })},
+			"description": &gql.Field{Type: gql.String, Resolve: field(func(l openapi.Quicklink) interface{} { return l.Description })},
+		},
+	})
+
+	labelType := gql.NewObject(gql.ObjectConfig{
+		Name: "Label",
+		Fields: gql.Fields{
+			"key":   &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(l labelNode) interface{} { return l.key })},
+			"value": &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(l labelNode) interface{} { return l.value })},
+		},
+	})
+
+	var ownerType, serviceType, repositoryType *gql.Object
+
+	ownerType = gql.NewObject(gql.ObjectConfig{
+		Name:        "Owner",
+		Description: "An owner of services and repositories, usually a team.",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"alias":              &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(o ownerNode) interface{} { return o.alias })},
+				"contact":            &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(o ownerNode) interface{} { return o.owner.Contact })},
+				"teamsChannelURL":    &gql.Field{Type: gql.String, Resolve: field(func(o ownerNode) interface{} { return o.owner.TeamsChannelURL })},
+				"productOwner":       &gql.Field{Type: gql.String, Resolve: field(func(o ownerNode) interface{} { return o.owner.ProductOwner })},
+				"defaultJiraProject": &gql.Field{Type: gql.String, Resolve: field(func(o ownerNode) interface{} { return o.owner.DefaultJiraProject })},
+				"displayName":        &gql.Field{Type: gql.String, Resolve: field(func(o ownerNode) interface{} { return o.owner.DisplayName })},
+				"promoters":          &gql.Field{Type: stringList, Resolve: field(func(o ownerNode) interface{} { return orEmpty(o.owner.Promoters) })},
+				"links":              &gql.Field{Type: listOf(linkType), Resolve: field(func(o ownerNode) interface{} { return orEmpty(o.owner.Links) })},
+				"commitHash":         &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(o ownerNode) interface{} { return o.owner.CommitHash })},
+				"timeStamp":          &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(o ownerNode) interface{} { return o.owner.TimeStamp })},
+				"services": &gql.Field{
+					Type:        listOf(serviceType),
+					Description: "The services belonging to this owner.",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						g := graphOf(p.Context)
+						if err := g.relations(p.Context); err != nil {
+							return nil, err
+						}
+						return g.services(p.Context, g.servicesByOwner[p.Source.(ownerNode).alias])
+					},
+				},
+				"repositories": &gql.Field{
+					Type:        listOf(repositoryType),
+					Description: "The repositories belonging to this owner.",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						g := graphOf(p.Context)
+						if err := g.relations(p.Context); err != nil {
+							return nil, err
+						}
+						return g.repositories(p.Context, g.repositoriesByOwner[p.Source.(ownerNode).alias])
+					},
+				},
+			}
+		}),
+	})
+
+	serviceType = gql.NewObject(gql.ObjectConfig{
+		Name:        "Service",
+		Description: "A service, consisting of one or more repositories.",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"name":            &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(s serviceNode) interface{} { return s.name })},
+				"description":     &gql.Field{Type: gql.String, Resolve: field(func(s serviceNode) interface{} { return s.service.Description })},
+				"alertTarget":     &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(s serviceNode) interface{} { return s.service.AlertTarget })},
+				"developmentOnly": &gql.Field{Type: gql.Boolean, Resolve: field(func(s serviceNode) interface{} { return s.service.DevelopmentOnly })},
+				"operationType":   &gql.Field{Type: gql.String, Resolve: field(func(s serviceNode) interface{} { return s.service.OperationType })},
+				"internetExposed": &gql.Field{Type: gql.Boolean, Resolve: field(func(s serviceNode) interface{} { return s.service.InternetExposed })},
+				"lifecycle":       &gql.Field{Type: gql.String, Resolve: field(func(s serviceNode) interface{} { return s.service.Lifecycle })},
+				"tags":            &gql.Field{Type: stringList, Resolve: field(func(s serviceNode) interface{} { return orEmpty(s.service.Tags) })},
+				"labels":          &gql.Field{Type: listOf(labelType), Resolve: field(func(s serviceNode) interface{} { return labels(s.service.Labels) })},
+				"quicklinks":      &gql.Field{Type: listOf(quicklinkType), Resolve: field(func(s serviceNode) interface{} { return orEmpty(s.service.Quicklinks) })},
+				"providesApis":    &gql.Field{Type: stringList, Resolve: field(func(s serviceNode) interface{} { return orEmpty(spec(s.service).ProvidesApis) })},
+				"consumesApis":    &gql.Field{Type: stringList, Resolve: field(func(s serviceNode) interface{} { return orEmpty(spec(s.service).ConsumesApis) })},
+				"commitHash":      &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(s serviceNode) interface{} { return s.service.CommitHash })},
+				"timeStamp":       &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(s serviceNode) interface{} { return s.service.TimeStamp })},
+				"owner": &gql.Field{
+					Type: ownerType,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return graphOf(p.Context).owner(p.Context, p.Source.(serviceNode).service.Owner)
+					},
+				},
+				"repositories": &gql.Field{
+					Type: listOf(repositoryType),
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return graphOf(p.Context).repositories(p.Context, p.Source.(serviceNode).service.Repositories)
+					},
+				},
+				"dependsOn": &gql.Field{
+					Type:        listOf(serviceType),
+					Description: "The services given in spec.dependsOn, as far as they exist.",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return graphOf(p.Context).services(p.Context, spec(p.Source.(serviceNode).service).DependsOn)
+					},
+				},
+				"dependents": &gql.Field{
+					Type:        listOf(serviceType),
+					Description: "The services that list this service in spec.dependsOn.",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						g := graphOf(p.Context)
+						if err := g.relations(p.Context); err != nil {
+							return nil, err
+						}
+						return g.services(p.Context, g.dependents[p.Source.(serviceNode).name])
+					},
+				},
+			}
+		}),
+	})
+
+	repositoryType = gql.NewObject(gql.ObjectConfig{
+		Name:        "Repository",
+		Description: "A repository, identified by its name and type.",
+		Fields: gql.FieldsThunk(func() gql.Fields {
+			return gql.Fields{
+				"key": &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(r repositoryNode) interface{} { return r.key })},
+				"name": &gql.Field{
+					Type: gql.NewNonNull(gql.String),
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						name, _, _ := strings.Cut(p.Source.(repositoryNode).key, graphOf(p.Context).separator)
+						return name, nil
+					},
+				},
+				"type": &gql.Field{
+					Type: gql.NewNonNull(gql.String),
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						_, repoType, _ := strings.Cut(p.Source.(repositoryNode).key, graphOf(p.Context).separator)
+						return repoType, nil
+					},
+				},
+				"url":        &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(r repositoryNode) interface{} { return r.repository.Url })},
+				"mainline":   &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(r repositoryNode) interface{} { return r.repository.Mainline })},
+				"generator":  &gql.Field{Type: gql.String, Resolve: field(func(r repositoryNode) interface{} { return r.repository.Generator })},
+				"unittest":   &gql.Field{Type: gql.Boolean, Resolve: field(func(r repositoryNode) interface{} { return r.repository.Unittest })},
+				"labels":     &gql.Field{Type: listOf(labelType), Resolve: field(func(r repositoryNode) interface{} { return labels(r.repository.Labels) })},
+				"commitHash": &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(r repositoryNode) interface{} { return r.repository.CommitHash })},
+				"timeStamp":  &gql.Field{Type: gql.NewNonNull(gql.String), Resolve: field(func(r repositoryNode) interface{} { return r.repository.TimeStamp })},
+				"owner": &gql.Field{
+					Type: ownerType,
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						return graphOf(p.Context).owner(p.Context, p.Source.(repositoryNode).repository.Owner)
+					},
+				},
+				"service": &gql.Field{
+					Type:        serviceType,
+					Description: "The service this repository belongs to, if any.",
+					Resolve: func(p gql.ResolveParams) (interface{}, error) {
+						g := graphOf(p.Context)
+						if err := g.relations(p.Context); err != nil {
+							return nil, err
+						}
+						name, ok := g.serviceByRepository[p.Source.(repositoryNode).key]
+						if !ok {
+							return nil, nil
+						}
+						return g.service(p.Context, name)
+					},
+				},
+			}
+		}),
+	})
+
+	queryType := gql.NewObject(gql.ObjectConfig{
+		Name: "Query",
+		Fields: gql.Fields{
+			"owners": &gql.Field{
+				Type: listOf(ownerType),
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return graphOf(p.Context).owners(p.Context)
+				},
+			},
+			"owner": &gql.Field{
+				Type: ownerType,
+				Args: gql.FieldConfigArgument{
+					"alias": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+				},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return graphOf(p.Context).owner(p.Context, p.Args["alias"].(string))
+				},
+			},
+			"services": &gql.Field{
+				Type: listOf(serviceType),
+				Args: gql.FieldConfigArgument{
+					"owner": &gql.ArgumentConfig{Type: gql.String, Description: "Only give the services of this owner."},
+				},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					g := graphOf(p.Context)
+					if owner, ok := p.Args["owner"].(string); ok {
+						if err := g.relations(p.Context); err != nil {
+							return nil, err
+						}
+						return g.services(p.Context, g.servicesByOwner[owner])
+					}
+					names, err := g.cache.GetSortedServiceNames(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					return g.services(p.Context, names)
+				},
+			},
+			"service": &gql.Field{
+				Type: serviceType,
+				Args: gql.FieldConfigArgument{
+					"name": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+				},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return graphOf(p.Context).service(p.Context, p.Args["name"].(string))
+				},
+			},
+			"repositories": &gql.Field{
+				Type: listOf(repositoryType),
+				Args: gql.FieldConfigArgument{
+					"owner": &gql.ArgumentConfig{Type: gql.String, Description: "Only give the repositories of this owner."},
+				},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					g := graphOf(p.Context)
+					if owner, ok := p.Args["owner"].(string); ok {
+						if err := g.relations(p.Context); err != nil {
+							return nil, err
+						}
+						return g.repositories(p.Context, g.repositoriesByOwner[owner])
+					}
+					keys, err := g.cache.GetSortedRepositoryKeys(p.Context)
+					if err != nil {
+						return nil, err
+					}
+					return g.repositories(p.Context, keys)
+				},
+			},
+			"repository": &gql.Field{
+				Type: repositoryType,
+				Args: gql.FieldConfigArgument{
+					"key": &gql.ArgumentConfig{Type: gql.NewNonNull(gql.String)},
+				},
+				Resolve: func(p gql.ResolveParams) (interface{}, error) {
+					return graphOf(p.Context).repository(p.Context, p.Args["key"].(string))
+				},
+			},
+		},
+	})
+
+	return gql.NewSchema(gql.SchemaConfig{
+		Query: queryType,
+	})
+}
+
+// --- helpers ---
+
+var stringList = listOf(gql.String)
+
+func listOf(t gql.Type) gql.Output {
+	return gql.NewNonNull(gql.NewList(gql.NewNonNull(t)))
+}
+
+// field resolves a field from the source node.
+func field[N any](get func(N) interface{}) gql.FieldResolveFn {
+	return func(p gql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(N)), nil
+	}
+}
+
+// orEmpty avoids null for non-null lists.
+func orEmpty[E any](values []E) []E {
+	if values == nil {
+		return []E{}
+	}
+	return values
+}
+
+func labels(values *map[string]string) []labelNode {
+	result := make([]labelNode, 0)
+	if values != nil {
+		for k, v := range *values {
+			result = append(result, labelNode{key: k, value: v})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].key < result[j].key
+	})
+	return result
+}
+
+func spec(service openapi.ServiceDto) openapi.ServiceSpecDto {
+	if service.Spec == nil {
+		return openapi.ServiceSpecDto{}
+	}
+	return *service.Spec
+}
@@ -0,0 +1,262 @@
+package links
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+	"time"
+)
+
+type Impl struct {
+	Configuration       librepo.Configuration
+	CustomConfiguration config.CustomConfiguration
+	Logging             librepo.Logging
+	Timestamp           librepo.Timestamp
+	Cache               repository.Cache
+	LinkChecker         repository.LinkChecker
+
+	mu sync.RWMutex
+	// latest result by url, replaced as a whole after each round of checks
+	results map[string]linkResult
+
+	linkGauge       *prometheus.GaugeVec
+	brokenLinkGauge *prometheus.GaugeVec
+}
+
+type linkResult struct {
+	repository.LinkCheckResult
+	CheckedAt time.Time
+}
+
+// linkOwner is a service or owner together with the urls of its links.
+type linkOwner struct {
+	EntityType string
+	Name       string
+	Urls       []string
+}
+
+func New(
+	configuration librepo.Configuration,
+	customConfig config.CustomConfiguration,
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	cache repository.Cache,
+	linkChecker repository.LinkChecker,
+) service.Links {
+	return &Impl{
+		Configuration:       configuration,
+		CustomConfiguration: customConfig,
+		Logging:             logging,
+		Timestamp:           timestamp,
+		Cache:               cache,
+		LinkChecker:         linkChecker,
+	}
+}
+
+func (s *Impl) IsLinks() bool {
+	return true
+}
+
+func (s *Impl) Setup() error {
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+
+	s.SetupMetrics(ctx)
+
+	s.Logging.Logger().Ctx(ctx).Info().Print("successfully set up links business component")
+	return nil
+}
+
+const linkStatusUnchecked = "unchecked"
+
+const timeStampFormat = "2006-01-02T15:04:05Z"
+
+const (
+	entityTypeService = "service"
+	entityTypeOwner   = "owner"
+)
+
+var (
+	LinkGaugeName       = "link_check_links"
+	BrokenLinkGaugeName = "link_check_broken_links"
+)
+
+// --- metrics ---
+
+func (s *Impl) SetupMetrics(_ context.Context) {
+	s.results = make(map[string]linkResult)
+
+	s.linkGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: LinkGaugeName,
+			Help: "How many links were checked in the last round, partitioned by entity type.",
+		},
+		[]string{"entity_type"},
+	)
+	prometheus.MustRegister(s.linkGauge)
+
+	s.brokenLinkGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: BrokenLinkGaugeName,
+			Help: "How many links were broken in the last round, partitioned by entity type.",
+		},
+		[]string{"entity_type"},
+	)
+	prometheus.MustRegister(s.brokenLinkGauge)
+}
+
+// --- business logic ---
+
+func (s *Impl) CheckAllLinks(ctx context.Context) error {
+	owners, err := s.collectLinks(ctx)
+	if err != nil {
+		return err
+	}
+
+	urls := make(map[string]struct{})
+	for _, owner := range owners {
+		for _, url := range owner.Urls {
+			urls[url] = struct{}{}
+		}
+	}
+
+	results := make(map[string]linkResult)
+	var resultsMu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, s.CustomConfiguration.LinkCheckConcurrency())
+	for url := range urls {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case slots <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			result := s.LinkChecker.Check(ctx, url)
+			resultsMu.Lock()
+			results[url] = linkResult{LinkCheckResult: result, CheckedAt: s.Timestamp.Now()}
+			resultsMu.Unlock()
+		}(url)
+	}
+	wg.Wait()
+
+	s.mu.Lock()
+	s.results = results
+	s.mu.Unlock()
+
+	// per entity results are only available from the status endpoints, the metrics would grow with the metadata
+	linkCounts := map[string]int{entityTypeService: 0, entityTypeOwner: 0}
+	brokenCounts := map[string]int{entityTypeService: 0, entityTypeOwner: 0}
+	broken := 0
+	for _, owner := range owners {
+		for _, url := range owner.Urls {
+			linkCounts[owner.EntityType]++
+			if results[url].Status == repository.LinkStatusBroken {
+				brokenCounts[owner.EntityType]++
+				broken++
+			}
+		}
+	}
+	for entityType, count := range linkCounts {
+		s.linkGauge.WithLabelValues(entityType).Set(float64(count))
+		s.brokenLinkGauge.WithLabelValues(entityType).Set(float64(brokenCounts[entityType]))
+	}
+
+	s.Logging.Logger().Ctx(ctx).Info().Printf("checked %d links, %d broken", len(results), broken)
+	return nil
+}
+
+func (s *Impl) collectLinks(ctx context.Context) ([]linkOwner, error) {
+	result := make([]linkOwner, 0)
+
+	serviceNames, err := s.Cache.GetSortedServiceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range serviceNames {
+		serviceDto, err := s.Cache.GetService(ctx, name)
+		if err != nil {
+			continue
+		}
+		urls := make([]string, 0)
+		for _, quicklink := range serviceDto.Quicklinks {
+			if quicklink.Url != nil {
+				urls = append(urls, *quicklink.Url)
+			}
+		}
+		if len(urls) > 0 {
+			result = append(result, linkOwner{EntityType: entityTypeService, Name: name, Urls: urls})
+		}
+	}
+
+	ownerAliases, err := s.Cache.GetSortedOwnerAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range ownerAliases {
+		ownerDto, err := s.Cache.GetOwner(ctx, alias)
+		if err != nil {
+			continue
+		}
+		urls := make([]string, 0)
+		for _, link := range ownerDto.Links {
+			if link.Url != nil {
+				urls = append(urls, *link.Url)
+			}
+		}
+		if len(urls) > 0 {
+			result = append(result, linkOwner{EntityType: entityTypeOwner, Name: alias, Urls: urls})
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Impl) GetServiceQuicklinkStatus(ctx context.Context, serviceName string) (openapi.QuicklinkStatusListDto, error) {
+	serviceDto, err := s.Cache.GetService(ctx, serviceName)
+	if err != nil {
+		return openapi.QuicklinkStatusListDto{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := openapi.QuicklinkStatusListDto{
+		Quicklinks: make([]openapi.QuicklinkStatusDto, 0),
+	}
+	for _, quicklink := range serviceDto.Quicklinks {
+		if quicklink.Url == nil {
+			continue
+		}
+		status := openapi.QuicklinkStatusDto{
+			Url:    *quicklink.Url,
+			Title:  quicklink.Title,
+			Status: linkStatusUnchecked,
+		}
+		if checked, ok := s.results[*quicklink.Url]; ok {
+			status.Status = checked.Status
+			if checked.HttpStatus != 0 {
+				httpStatus := int32(checked.HttpStatus)
+				status.HttpStatus = &httpStatus
+			}
+			if checked.Error != "" {
+				checkError := checked.Error
+				status.Error = &checkError
+			}
+			checkedAt := checked.CheckedAt.UTC().Format(timeStampFormat)
+			status.CheckedAt = &checkedAt
+		}
+		result.Quicklinks = append(result.Quicklinks, status)
+	}
+	return result, nil
+}
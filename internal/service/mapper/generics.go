@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/Interhyp/metadata-service/api"
-	"github.com/Interhyp/metadata-service/internal/acorn/errors/nochangeserror"
 	"github.com/StephanHCB/go-backend-service-common/web/middleware/requestid"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
@@ -114,19 +113,14 @@ func WriteT[T Dtos](ctx context.Context, s *Impl, resultPtr *T, path string, fil
 		return err
 	}
 
-	err = s.Metadata.WriteFile(fileName, yamlBytes)
+	err = s.writeFile(ctx, fileName, yamlBytes)
 	if err != nil {
 		s.resetLocalClone(ctx)
 		return err
 	}
 
-	message := fmt.Sprintf("%s: update %s", jiraIssue, description)
-	commitInfo, err := s.Metadata.Commit(ctx, message)
+	commitInfo, err := s.commitAndPush(ctx, jiraIssue, "update "+description)
 	if err != nil {
-		if !nochangeserror.Is(err) {
-			// empty commits need no re-clone
-			s.resetLocalClone(ctx)
-		}
 		SetJiraIssue(resultPtr, "")
 		return err
 	}
@@ -134,66 +128,47 @@ func WriteT[T Dtos](ctx context.Context, s *Impl, resultPtr *T, path string, fil
 	SetCommitHash(resultPtr, commitInfo.CommitHash)
 	SetTimeStamp(resultPtr, commitInfo.TimeStamp)
 	SetJiraIssue(resultPtr, commitInfo.Message)
-
-	err = s.Metadata.Push(ctx)
-	if err != nil {
-		s.resetLocalClone(ctx)
-		return err
-	}
-
 	return nil
 }
 
 func DeleteT[T PatchDtos](ctx context.Context, s *Impl, resultPtr *T, fullPath string, description string, jiraIssue string) error {
-	err := s.Metadata.DeleteFile(fullPath)
+	err := s.deleteFile(ctx, fullPath)
 	if err != nil {
 		s.resetLocalClone(ctx)
 		return err
 	}
 
-	message := fmt.Sprintf("%s: delete %s", jiraIssue, description)
-	commitInfo, err := s.Metadata.Commit(ctx, message)
+	commitInfo, err := s.commitAndPush(ctx, jiraIssue, "delete "+description)
 	if err != nil {
-		if !nochangeserror.Is(err) {
-			// empty commits need no re-clone
-			s.resetLocalClone(ctx)
-		}
 		return err
 	}
 
 	SetCommitHash(resultPtr, commitInfo.CommitHash)
 	SetTimeStamp(resultPtr, commitInfo.TimeStamp)
 	SetJiraIssue(resultPtr, commitInfo.Message)
-
-	err = s.Metadata.Push(ctx)
-	if err != nil {
-		s.resetLocalClone(ctx)
-		return err
-	}
-
 	return nil
 }
 
 func Move(ctx context.Context, s *Impl, v interface{}, oldFullPath string, newPath string, newFileNameNoPath string) error {
-	err := s.Metadata.DeleteFile(oldFullPath)
+	err := s.deleteFile(ctx, oldFullPath)
 	if err != nil {
 		return err
 	}
 
-	err = s.Metadata.MkdirAll(newPath)
-	if err != nil {
-		return err
-	}
+	return Put(ctx, s, v, newPath, newFileNameNoPath)
+}
 
-	yamlBytes, err := yaml.Marshal(v)
+// Put writes v to the local clone without committing, so it can become part of a larger commit.
+func Put(ctx context.Context, s *Impl, v interface{}, path string, fileNameNoPath string) error {
+	err := s.Metadata.MkdirAll(path)
 	if err != nil {
 		return err
 	}
 
-	err = s.Metadata.WriteFile(newPath+"/"+newFileNameNoPath, yamlBytes)
+	yamlBytes, err := yaml.Marshal(v)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.writeFile(ctx, path+"/"+fileNameNoPath, yamlBytes)
 }
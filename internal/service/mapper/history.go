@@ -0,0 +1,173 @@
+package mapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Interhyp/metadata-service/api"
+	"gopkg.in/yaml.v3"
+)
+
+// fields that describe the commit rather than the entity, they are reported separately
+var historyIgnoredFields = map[string]bool{
+	"timeStamp":  true,
+	"commitHash": true,
+	"jiraIssue":  true,
+}
+
+func (s *Impl) GetOwnerHistory(ctx context.Context, ownerAlias string) (openapi.HistoryDto, error) {
+	fullPath := "owners/" + ownerAlias + "/owner.info.yaml"
+	matcher := func(path string) bool {
+		return path == fullPath
+	}
+	return HistoryT[openapi.OwnerDto](ctx, s, matcher, func(_ string, _ *openapi.OwnerDto) {})
+}
+
+func (s *Impl) GetServiceHistory(ctx context.Context, serviceName string) (openapi.HistoryDto, error) {
+	return HistoryT[openapi.ServiceDto](ctx, s, ownedEntityPathMatcher("services", serviceName), func(ownerAlias string, dto *openapi.ServiceDto) {
+		dto.Repositories = transformKeys(dto.Repositories, "/", ".")
+		dto.Owner = ownerAlias
+	})
+}
+
+func (s *Impl) GetRepositoryHistory(ctx context.Context, repoKey string) (openapi.HistoryDto, error) {
+	return HistoryT[openapi.RepositoryDto](ctx, s, ownedEntityPathMatcher("repositories", repoKey), func(ownerAlias string, dto *openapi.RepositoryDto) {
+		dto.Owner = ownerAlias
+	})
+}
+
+// ownedEntityPathMatcher matches owners/<any owner>/<subdir>/<name>.yaml, so history is followed across owner changes.
+func ownedEntityPathMatcher(subdir string, name string) func(path string) bool {
+	suffix := fmt.Sprintf("/%s/%s.yaml", subdir, name)
+	return func(path string) bool {
+		return strings.HasPrefix(path, "owners/") &&
+			strings.HasSuffix(path, suffix) &&
+			strings.Count(path, "/") == 3
+	}
+}
+
+func ownerAliasFromPath(path string) string {
+	components := strings.Split(path, "/")
+	if len(components) > 1 {
+		return components[1]
+	}
+	return ""
+}
+
+// HistoryT reads all revisions of an entity and converts them into history entries, newest first.
+//
+// normalize is called on each parsed revision with the owner alias from its path, so the diff compares
+// entities the same way the API presents them.
+func HistoryT[T Dtos](ctx context.Context, s *Impl, pathMatcher func(path string) bool, normalize func(ownerAlias string, dto *T)) (openapi.HistoryDto, error) {
+	result := openapi.HistoryDto{
+		Entries: make([]openapi.HistoryEntryDto, 0),
+	}
+
+	revisions, err := s.Metadata.ReadFileHistory(ctx, pathMatcher)
+	if err != nil {
+		return result, err
+	}
+
+	// revisions are newest first, but each diff needs the previous (older) version
+	versions := make([]map[string]interface{}, len(revisions))
+	for i, revision := range revisions {
+		if revision.Contents == nil {
+			continue
+		}
+
+		var dto T
+		err = yaml.Unmarshal(revision.Contents, &dto)
+		if err != nil {
+			return result, fmt.Errorf("failed to parse %s as yaml from metadata commit %s: %s", revision.Path, revision.CommitHash, err.Error())
+		}
+		normalize(ownerAliasFromPath(revision.Path), &dto)
+
+		versions[i], err = asFieldMap(dto)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for i, revision := range revisions {
+		var previous map[string]interface{}
+		if i+1 < len(versions) {
+			previous = versions[i+1]
+		}
+
+		entry := openapi.HistoryEntryDto{
+			CommitHash: revision.CommitHash,
+			TimeStamp:  timeStamp(revision.TimeStamp),
+			Author:     revision.Author,
+			JiraIssue:  jiraIssue(revision.Message),
+			Changes:    diffFields("", previous, versions[i]),
+		}
+		if revision.Contents == nil {
+			deleted := true
+			entry.Deleted = &deleted
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	return result, nil
+}
+
+func asFieldMap(dto interface{}) (map[string]interface{}, error) {
+	jsonBytes, err := json.Marshal(dto)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{})
+	err = json.Unmarshal(jsonBytes, &result)
+	if err != nil {
+		return nil, err
+	}
+	for field := range historyIgnoredFields {
+		delete(result, field)
+	}
+	return result, nil
+}
+
+// diffFields compares two field maps, descending into nested objects. Lists are compared as a whole.
+//
+// Nested fields are reported with dot separated names, e.g. spec.dependsOn.
+func diffFields(prefix string, oldValues map[string]interface{}, newValues map[string]interface{}) []openapi.FieldChangeDto {
+	result := make([]openapi.FieldChangeDto, 0)
+
+	keys := make(map[string]bool)
+	for key := range oldValues {
+		keys[key] = true
+	}
+	for key := range newValues {
+		keys[key] = true
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	for _, key := range sortedKeys {
+		oldValue := oldValues[key]
+		newValue := newValues[key]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if (oldIsMap || oldValue == nil) && (newIsMap || newValue == nil) {
+			result = append(result, diffFields(prefix+key+".", oldMap, newMap)...)
+			continue
+		}
+
+		result = append(result, openapi.FieldChangeDto{
+			Field:    prefix + key,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+	return result
+}
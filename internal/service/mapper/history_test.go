@@ -0,0 +1,28 @@
+package mapper
+
+import (
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDiffFields_Unchanged(t *testing.T) {
+	values := map[string]interface{}{"owner": "some-owner"}
+	result := diffFields("", values, values)
+	require.Equal(t, 0, len(result))
+}
+
+func TestDiffFields_NestedAndRemoved(t *testing.T) {
+	oldValues := map[string]interface{}{
+		"owner": "some-owner",
+		"spec":  map[string]interface{}{"dependsOn": []interface{}{"a"}, "providesApis": []interface{}{"x"}},
+	}
+	newValues := map[string]interface{}{
+		"spec": map[string]interface{}{"dependsOn": []interface{}{"a", "b"}, "providesApis": []interface{}{"x"}},
+	}
+	result := diffFields("", oldValues, newValues)
+	require.Equal(t, []openapi.FieldChangeDto{
+		{Field: "owner", OldValue: "some-owner"},
+		{Field: "spec.dependsOn", OldValue: []interface{}{"a"}, NewValue: []interface{}{"a", "b"}},
+	}, result)
+}
@@ -22,6 +22,10 @@ type Impl struct {
 	muOwnerCaches        sync.Mutex
 	serviceOwnerCache    map[string]string
 	repositoryOwnerCache map[string]string
+
+	// events for the commits pulled since the last RefreshMetadata
+	muPulledEvents sync.Mutex
+	pulledEvents   []repository.UpdateEvent
 }
 
 func New(
@@ -67,16 +71,35 @@ func (s *Impl) SetupMapper(_ context.Context) error {
 }
 
 func (s *Impl) RefreshMetadata(ctx context.Context) ([]repository.UpdateEvent, error) {
-	events := make([]repository.UpdateEvent, 0)
+	err := s.pullMetadata(ctx)
+	if err != nil {
+		return make([]repository.UpdateEvent, 0), err
+	}
+
+	s.muPulledEvents.Lock()
+	defer s.muPulledEvents.Unlock()
 
+	events := s.pulledEvents
+	s.pulledEvents = nil
+	if events == nil {
+		events = make([]repository.UpdateEvent, 0)
+	}
+	return events, nil
+}
+
+// pullMetadata pulls the local clone and remembers an update event for each commit it pulled, so
+// RefreshMetadata can report commits pulled by write operations, too.
+func (s *Impl) pullMetadata(ctx context.Context) error {
 	err := s.Metadata.Pull(ctx)
 	if err != nil {
-		return events, err
+		return err
 	}
 
-	newCommits := s.Metadata.NewPulledCommits()
-	for _, commitInfo := range newCommits {
-		event := repository.UpdateEvent{
+	s.muPulledEvents.Lock()
+	defer s.muPulledEvents.Unlock()
+
+	for _, commitInfo := range s.Metadata.NewPulledCommits() {
+		s.pulledEvents = append(s.pulledEvents, repository.UpdateEvent{
 			Affected: repository.EventAffects{
 				OwnerAliases:   ownerAliasesFromCommitInfo(commitInfo),
 				ServiceNames:   serviceNamesFromCommitInfo(commitInfo),
@@ -84,10 +107,9 @@ func (s *Impl) RefreshMetadata(ctx context.Context) ([]repository.UpdateEvent, e
 			},
 			TimeStamp:  timeStamp(commitInfo.TimeStamp),
 			CommitHash: commitInfo.CommitHash,
-		}
-		events = append(events, event)
+		})
 	}
-	return events, nil
+	return nil
 }
 
 func ownerAliasesFromCommitInfo(commitInfo repository.CommitInfo) []string {
@@ -2,8 +2,12 @@ package mapper
 
 import (
 	"context"
+	"fmt"
 	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"gopkg.in/yaml.v3"
 	"sort"
+	"strings"
 
 	"github.com/Interhyp/metadata-service/internal/service/util"
 )
@@ -71,7 +75,7 @@ func (s *Impl) processGroupMap(ctx context.Context, groupsMap *map[string][]stri
 }
 
 func (s *Impl) WriteOwner(ctx context.Context, ownerAlias string, owner openapi.OwnerDto) (openapi.OwnerDto, error) {
-	err := s.Metadata.Pull(ctx)
+	err := s.pull(ctx)
 	if err != nil {
 		return owner, err
 	}
@@ -87,7 +91,7 @@ func (s *Impl) WriteOwner(ctx context.Context, ownerAlias string, owner openapi.
 func (s *Impl) DeleteOwner(ctx context.Context, ownerAlias string, jiraIssue string) (openapi.OwnerPatchDto, error) {
 	result := openapi.OwnerPatchDto{}
 
-	err := s.Metadata.Pull(ctx)
+	err := s.pull(ctx)
 	if err != nil {
 		return result, err
 	}
@@ -117,3 +121,212 @@ func (s *Impl) IsOwnerEmpty(_ context.Context, ownerAlias string) bool {
 
 	return true
 }
+
+func (s *Impl) RenameOwner(ctx context.Context, ownerAlias string, newOwnerAlias string, jiraIssue string) (openapi.OwnerDto, repository.EventAffects, error) {
+	affected := repository.EventAffects{
+		OwnerAliases:   []string{ownerAlias, newOwnerAlias},
+		ServiceNames:   []string{},
+		RepositoryKeys: []string{},
+	}
+
+	err := s.pull(ctx)
+	if err != nil {
+		return openapi.OwnerDto{}, affected, err
+	}
+
+	ownerAliases, err := s.GetSortedOwnerAliases(ctx)
+	if err != nil {
+		return openapi.OwnerDto{}, affected, err
+	}
+
+	// move the owner directory, renaming group references on the way
+
+	err = s.moveOwnerDir(ctx, "owners/"+ownerAlias, "owners/"+newOwnerAlias, ownerAlias, newOwnerAlias, &affected)
+	if err != nil {
+		s.resetLocalClone(ctx)
+		return openapi.OwnerDto{}, affected, err
+	}
+
+	// rename group references of other owners
+
+	for _, otherAlias := range ownerAliases {
+		if otherAlias == ownerAlias {
+			continue
+		}
+
+		fullPath := "owners/" + otherAlias + "/owner.info.yaml"
+		changed, err := s.renameGroupReferencesInFile(ctx, fullPath, fullPath, renameGroupOwnerInOwner, ownerAlias, newOwnerAlias)
+		if err != nil {
+			s.resetLocalClone(ctx)
+			return openapi.OwnerDto{}, affected, err
+		}
+		if changed {
+			affected.OwnerAliases = append(affected.OwnerAliases, otherAlias)
+		}
+
+		path := "owners/" + otherAlias + "/repositories"
+		fileInfos, err := s.Metadata.ReadDir(path)
+		if err != nil {
+			// acceptable to not have a repositories dir
+			continue
+		}
+		for _, fileInfo := range fileInfos {
+			name := fileInfo.Name()
+			if fileInfo.IsDir() || !strings.HasSuffix(name, ".yaml") {
+				continue
+			}
+
+			fullPath := path + "/" + name
+			changed, err := s.renameGroupReferencesInFile(ctx, fullPath, fullPath, renameGroupOwnerInRepository, ownerAlias, newOwnerAlias)
+			if err != nil {
+				s.resetLocalClone(ctx)
+				return openapi.OwnerDto{}, affected, err
+			}
+			if changed {
+				affected.RepositoryKeys = append(affected.RepositoryKeys, strings.TrimSuffix(name, ".yaml"))
+			}
+		}
+	}
+
+	owner := openapi.OwnerDto{}
+	err = GetT[openapi.OwnerDto](ctx, s, &owner, "owners/"+newOwnerAlias+"/owner.info.yaml")
+	if err != nil {
+		s.resetLocalClone(ctx)
+		return openapi.OwnerDto{}, affected, err
+	}
+
+	// commit and push
+
+	description := fmt.Sprintf("rename owner %s to %s", ownerAlias, newOwnerAlias)
+	commitInfo, err := s.commitAndPush(ctx, jiraIssue, description)
+
+	// rebuild the owner caches, which still have the old alias
+	_, _ = s.GetSortedServiceNames(ctx)
+	_, _ = s.GetSortedRepositoryKeys(ctx)
+
+	if err != nil {
+		return openapi.OwnerDto{}, affected, err
+	}
+
+	SetCommitHash(&owner, commitInfo.CommitHash)
+	SetTimeStamp(&owner, commitInfo.TimeStamp)
+	SetJiraIssue(&owner, commitInfo.Message)
+
+	return owner, affected, nil
+}
+
+// moveOwnerDir moves all files below oldPath to newPath, recursively.
+//
+// Group references are renamed in the owner info and in repositories, and all services and repositories
+// found are added to affected.
+func (s *Impl) moveOwnerDir(ctx context.Context, oldPath string, newPath string, ownerAlias string, newOwnerAlias string, affected *repository.EventAffects) error {
+	fileInfos, err := s.Metadata.ReadDir(oldPath)
+	if err != nil {
+		return err
+	}
+
+	err = s.Metadata.MkdirAll(newPath)
+	if err != nil {
+		return err
+	}
+
+	for _, fileInfo := range fileInfos {
+		name := fileInfo.Name()
+		oldFullPath := oldPath + "/" + name
+		newFullPath := newPath + "/" + name
+		if fileInfo.IsDir() {
+			err = s.moveOwnerDir(ctx, oldFullPath, newFullPath, ownerAlias, newOwnerAlias, affected)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		var rename func([]byte, string, string) ([]byte, bool, error)
+		if name == "owner.info.yaml" {
+			rename = renameGroupOwnerInOwner
+		} else if strings.HasSuffix(name, ".yaml") && strings.HasSuffix(oldPath, "/repositories") {
+			rename = renameGroupOwnerInRepository
+			affected.RepositoryKeys = append(affected.RepositoryKeys, strings.TrimSuffix(name, ".yaml"))
+		} else if strings.HasSuffix(name, ".yaml") && strings.HasSuffix(oldPath, "/services") {
+			affected.ServiceNames = append(affected.ServiceNames, strings.TrimSuffix(name, ".yaml"))
+		}
+
+		_, err = s.renameGroupReferencesInFile(ctx, oldFullPath, newFullPath, rename, ownerAlias, newOwnerAlias)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameGroupReferencesInFile writes the file at oldFullPath to newFullPath, with group references renamed by rename
+// (which may be nil). The file is only written if something changed.
+func (s *Impl) renameGroupReferencesInFile(ctx context.Context, oldFullPath string, newFullPath string, rename func([]byte, string, string) ([]byte, bool, error), ownerAlias string, newOwnerAlias string) (bool, error) {
+	contents, _, err := s.Metadata.ReadFile(oldFullPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s from metadata: %s", oldFullPath, err.Error())
+	}
+
+	changed := false
+	if rename != nil {
+		contents, changed, err = rename(contents, ownerAlias, newOwnerAlias)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse %s as yaml from metadata: %s", oldFullPath, err.Error())
+		}
+	}
+
+	if oldFullPath != newFullPath {
+		err = s.deleteFile(ctx, oldFullPath)
+		if err != nil {
+			return changed, err
+		}
+	} else if !changed {
+		return false, nil
+	}
+
+	return changed, s.writeFile(ctx, newFullPath, contents)
+}
+
+func renameGroupOwnerInOwner(contents []byte, ownerAlias string, newOwnerAlias string) ([]byte, bool, error) {
+	owner := openapi.OwnerDto{}
+	if err := yaml.Unmarshal(contents, &owner); err != nil {
+		return nil, false, err
+	}
+
+	changed := false
+	if owner.Groups != nil {
+		for groupName, groupMembers := range *owner.Groups {
+			if renamed, ok := util.RenameGroupOwner(groupMembers, ownerAlias, newOwnerAlias); ok {
+				(*owner.Groups)[groupName] = renamed
+				changed = true
+			}
+		}
+	}
+	if renamed, ok := util.RenameGroupOwner(owner.Promoters, ownerAlias, newOwnerAlias); ok {
+		owner.Promoters = renamed
+		changed = true
+	}
+
+	if !changed {
+		return contents, false, nil
+	}
+	renamedContents, err := yaml.Marshal(owner)
+	return renamedContents, true, err
+}
+
+func renameGroupOwnerInRepository(contents []byte, ownerAlias string, newOwnerAlias string) ([]byte, bool, error) {
+	repository := openapi.RepositoryDto{}
+	if err := yaml.Unmarshal(contents, &repository); err != nil {
+		return nil, false, err
+	}
+
+	configuration, changed := util.RenameGroupOwnerInRepositoryConfiguration(repository.Configuration, ownerAlias, newOwnerAlias)
+	repository.Configuration = configuration
+
+	if !changed {
+		return contents, false, nil
+	}
+	renamedContents, err := yaml.Marshal(repository)
+	return renamedContents, true, err
+}
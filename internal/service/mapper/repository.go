@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Interhyp/metadata-service/api"
-	"github.com/Interhyp/metadata-service/internal/acorn/errors/nochangeserror"
 	"github.com/Interhyp/metadata-service/internal/service/util"
 	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
 	"sort"
@@ -114,7 +113,7 @@ func (s *Impl) WriteRepository(ctx context.Context, repoKey string, repository o
 		return openapi.RepositoryDto{}, errors.New("internal error - cannot write repository with no owner")
 	}
 
-	err := s.Metadata.Pull(ctx)
+	err := s.pull(ctx)
 	if err != nil {
 		return openapi.RepositoryDto{}, err
 	}
@@ -139,7 +138,7 @@ func (s *Impl) WriteRepository(ctx context.Context, repoKey string, repository o
 func (s *Impl) DeleteRepository(ctx context.Context, repoKey string, jiraIssue string) (openapi.RepositoryPatchDto, error) {
 	result := openapi.RepositoryPatchDto{}
 
-	err := s.Metadata.Pull(ctx)
+	err := s.pull(ctx)
 	if err != nil {
 		return result, err
 	}
@@ -164,7 +163,7 @@ func (s *Impl) WriteRepositoryWithChangedOwner(ctx context.Context, repoKey stri
 		return openapi.RepositoryDto{}, errors.New("internal error - cannot write repository with no owner")
 	}
 
-	err := s.Metadata.Pull(ctx)
+	err := s.pull(ctx)
 	if err != nil {
 		return openapi.RepositoryDto{}, err
 	}
@@ -191,13 +190,9 @@ func (s *Impl) WriteRepositoryWithChangedOwner(ctx context.Context, repoKey stri
 		return openapi.RepositoryDto{}, err
 	}
 
-	message := fmt.Sprintf("%s: move repository %s from owner %s to owner %s", repository.JiraIssue, repoKey, oldOwnerAlias, repository.Owner)
-	commitInfo, err := s.Metadata.Commit(ctx, message)
+	description := fmt.Sprintf("move repository %s from owner %s to owner %s", repoKey, oldOwnerAlias, repository.Owner)
+	commitInfo, err := s.commitAndPush(ctx, repository.JiraIssue, description)
 	if err != nil {
-		if !nochangeserror.Is(err) {
-			// empty commits need no re-clone
-			s.resetLocalClone(ctx)
-		}
 		return openapi.RepositoryDto{}, err
 	}
 
@@ -205,11 +200,5 @@ func (s *Impl) WriteRepositoryWithChangedOwner(ctx context.Context, repoKey stri
 	repository.TimeStamp = timeStamp(commitInfo.TimeStamp)
 	repository.JiraIssue = jiraIssue(commitInfo.Message)
 
-	err = s.Metadata.Push(ctx)
-	if err != nil {
-		s.resetLocalClone(ctx)
-		return openapi.RepositoryDto{}, err
-	}
-
 	return repository, nil
 }
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Interhyp/metadata-service/api"
-	"github.com/Interhyp/metadata-service/internal/acorn/errors/nochangeserror"
 	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
 	"sort"
 	"strings"
@@ -103,7 +102,7 @@ func (s *Impl) WriteService(ctx context.Context, serviceName string, service ope
 		return openapi.ServiceDto{}, errors.New("internal error - cannot write service with no owner")
 	}
 
-	err := s.Metadata.Pull(ctx)
+	err := s.pull(ctx)
 	if err != nil {
 		return openapi.ServiceDto{}, err
 	}
@@ -133,7 +132,7 @@ func (s *Impl) WriteService(ctx context.Context, serviceName string, service ope
 func (s *Impl) DeleteService(ctx context.Context, serviceName string, jiraIssue string) (openapi.ServicePatchDto, error) {
 	result := openapi.ServicePatchDto{}
 
-	err := s.Metadata.Pull(ctx)
+	err := s.pull(ctx)
 	if err != nil {
 		return result, err
 	}
@@ -158,7 +157,7 @@ func (s *Impl) WriteServiceWithChangedOwner(ctx context.Context, serviceName str
 		return openapi.ServiceDto{}, errors.New("internal error - cannot write service with no owner")
 	}
 
-	err := s.Metadata.Pull(ctx)
+	err := s.pull(ctx)
 	if err != nil {
 		return openapi.ServiceDto{}, err
 	}
@@ -215,13 +214,9 @@ func (s *Impl) WriteServiceWithChangedOwner(ctx context.Context, serviceName str
 
 	// commit and push
 
-	message := fmt.Sprintf("%s: move service %s from owner %s to owner %s", service.JiraIssue, serviceName, oldOwnerAlias, service.Owner)
-	commitInfo, err := s.Metadata.Commit(ctx, message)
+	description := fmt.Sprintf("move service %s from owner %s to owner %s", serviceName, oldOwnerAlias, service.Owner)
+	commitInfo, err := s.commitAndPush(ctx, service.JiraIssue, description)
 	if err != nil {
-		if !nochangeserror.Is(err) {
-			// empty commits need no re-clone
-			s.resetLocalClone(ctx)
-		}
 		return openapi.ServiceDto{}, err
 	}
 
@@ -229,11 +224,160 @@ func (s *Impl) WriteServiceWithChangedOwner(ctx context.Context, serviceName str
 	service.TimeStamp = timeStamp(commitInfo.TimeStamp)
 	service.JiraIssue = jiraIssue(commitInfo.Message)
 
-	err = s.Metadata.Push(ctx)
+	return service, nil
+}
+
+func (s *Impl) RenameService(ctx context.Context, serviceName string, newServiceName string, service openapi.ServiceDto, renamedRepositories map[string]string, dependents []string, repositoryDependents []string) (openapi.ServiceDto, error) {
+	if service.Owner == "" {
+		return openapi.ServiceDto{}, errors.New("internal error - cannot write service with no owner")
+	}
+
+	err := s.pull(ctx)
+	if err != nil {
+		return openapi.ServiceDto{}, err
+	}
+
+	// rebuild the owner cache after pull
+	_, err = s.GetSortedServiceNames(ctx)
+	if err != nil {
+		return openapi.ServiceDto{}, err
+	}
+
+	ownerAlias, err := s.lookupServiceOwnerWithRefresh(ctx, serviceName)
+	if err != nil {
+		return openapi.ServiceDto{}, err
+	}
+	if ownerAlias != service.Owner {
+		return openapi.ServiceDto{}, errors.New("internal error - cannot change owners while renaming")
+	}
+
+	// rename associated repositories
+
+	oldRepoKeys := make([]string, 0, len(renamedRepositories))
+	for oldRepoKey := range renamedRepositories {
+		oldRepoKeys = append(oldRepoKeys, oldRepoKey)
+	}
+	sort.Strings(oldRepoKeys)
+
+	for _, oldRepoKey := range oldRepoKeys {
+		oldFullPath := fmt.Sprintf("owners/%s/repositories/%s.yaml", ownerAlias, oldRepoKey)
+
+		repository := openapi.RepositoryDto{}
+		err = GetT[openapi.RepositoryDto](ctx, s, &repository, oldFullPath)
+		if err != nil {
+			s.resetLocalClone(ctx)
+			return openapi.ServiceDto{}, err
+		}
+
+		if repository.ReplacedBy != nil {
+			if newRepoKey, ok := renamedRepositories[*repository.ReplacedBy]; ok {
+				repository.ReplacedBy = &newRepoKey
+			}
+		}
+
+		newPath := fmt.Sprintf("owners/%s/repositories", ownerAlias)
+		err = Move(ctx, s, repository, oldFullPath, newPath, renamedRepositories[oldRepoKey]+".yaml")
+		if err != nil {
+			s.resetLocalClone(ctx)
+			return openapi.ServiceDto{}, err
+		}
+	}
+
+	// rename service (possibly with further changes)
+
+	service.Repositories = transformKeys(service.Repositories, ".", "/")
+
+	oldFullPath := fmt.Sprintf("owners/%s/services/%s.yaml", ownerAlias, serviceName)
+	newPath := fmt.Sprintf("owners/%s/services", ownerAlias)
+	err = Move(ctx, s, service, oldFullPath, newPath, newServiceName+".yaml")
 	if err != nil {
 		s.resetLocalClone(ctx)
 		return openapi.ServiceDto{}, err
 	}
 
+	service.Repositories = transformKeys(service.Repositories, "/", ".")
+
+	// update references in other services
+
+	for _, dependent := range dependents {
+		dependentOwnerAlias, err := s.lookupServiceOwnerWithRefresh(ctx, dependent)
+		if err != nil {
+			s.resetLocalClone(ctx)
+			return openapi.ServiceDto{}, err
+		}
+
+		path := fmt.Sprintf("owners/%s/services", dependentOwnerAlias)
+		dependentService := openapi.ServiceDto{}
+		err = GetT[openapi.ServiceDto](ctx, s, &dependentService, path+"/"+dependent+".yaml")
+		if err != nil {
+			s.resetLocalClone(ctx)
+			return openapi.ServiceDto{}, err
+		}
+
+		if dependentService.Spec != nil {
+			for i, dependency := range dependentService.Spec.DependsOn {
+				if dependency == serviceName {
+					dependentService.Spec.DependsOn[i] = newServiceName
+				}
+			}
+		}
+		if dependentService.ReplacedBy != nil && *dependentService.ReplacedBy == serviceName {
+			dependentService.ReplacedBy = &newServiceName
+		}
+
+		err = Put(ctx, s, dependentService, path, dependent+".yaml")
+		if err != nil {
+			s.resetLocalClone(ctx)
+			return openapi.ServiceDto{}, err
+		}
+	}
+
+	// update references in other repositories
+
+	for _, dependent := range repositoryDependents {
+		dependentOwnerAlias, err := s.lookupRepositoryOwnerWithRefresh(ctx, dependent)
+		if err != nil {
+			s.resetLocalClone(ctx)
+			return openapi.ServiceDto{}, err
+		}
+
+		path := fmt.Sprintf("owners/%s/repositories", dependentOwnerAlias)
+		dependentRepository := openapi.RepositoryDto{}
+		err = GetT[openapi.RepositoryDto](ctx, s, &dependentRepository, path+"/"+dependent+".yaml")
+		if err != nil {
+			s.resetLocalClone(ctx)
+			return openapi.ServiceDto{}, err
+		}
+
+		if dependentRepository.ReplacedBy != nil {
+			if newRepoKey, ok := renamedRepositories[*dependentRepository.ReplacedBy]; ok {
+				dependentRepository.ReplacedBy = &newRepoKey
+			}
+		}
+
+		err = Put(ctx, s, dependentRepository, path, dependent+".yaml")
+		if err != nil {
+			s.resetLocalClone(ctx)
+			return openapi.ServiceDto{}, err
+		}
+	}
+
+	// commit and push
+
+	description := fmt.Sprintf("rename service %s to %s", serviceName, newServiceName)
+	commitInfo, err := s.commitAndPush(ctx, service.JiraIssue, description)
+
+	// rebuild the owner caches, which still have the old names
+	_, _ = s.GetSortedServiceNames(ctx)
+	_, _ = s.GetSortedRepositoryKeys(ctx)
+
+	if err != nil {
+		return openapi.ServiceDto{}, err
+	}
+
+	service.CommitHash = commitInfo.CommitHash
+	service.TimeStamp = timeStamp(commitInfo.TimeStamp)
+	service.JiraIssue = jiraIssue(commitInfo.Message)
+
 	return service, nil
 }
@@ -0,0 +1,29 @@
+package mapper
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+)
+
+func (s *Impl) SnapshotAt(ctx context.Context, at string) (service.Mapper, repository.CommitInfo, error) {
+	metadata, commitInfo, err := s.Metadata.SnapshotAt(ctx, at)
+	if err != nil {
+		return nil, commitInfo, err
+	}
+
+	// a separate instance, so the owner lookup caches of the current tree are left alone
+	historic := &Impl{
+		Configuration:       s.Configuration,
+		CustomConfiguration: s.CustomConfiguration,
+		Logging:             s.Logging,
+		Timestamp:           s.Timestamp,
+		Metadata:            metadata,
+		Bitbucket:           s.Bitbucket,
+	}
+	err = historic.SetupMapper(ctx)
+	if err != nil {
+		return nil, commitInfo, err
+	}
+	return historic, commitInfo, nil
+}
@@ -0,0 +1,236 @@
+package mapper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/nochangeserror"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/pmezard/go-difflib/difflib"
+	"sort"
+	"strings"
+)
+
+type transactionType int
+
+const transactionKey transactionType = 0
+
+// transaction collects the descriptions of all changes staged in the local clone, so they can go into the commit message.
+type transaction struct {
+	descriptions []string
+
+	// contents of each file touched so far, as they were before the transaction, nil if the file did not exist
+	original map[string][]byte
+}
+
+func newTransaction() *transaction {
+	return &transaction{
+		descriptions: make([]string, 0),
+		original:     make(map[string][]byte),
+	}
+}
+
+func currentTransaction(ctx context.Context) (*transaction, bool) {
+	tx, ok := ctx.Value(transactionKey).(*transaction)
+	return tx, ok
+}
+
+func (s *Impl) WithTransaction(ctx context.Context, jiraIssue string, closure func(context.Context) error) (repository.CommitInfo, error) {
+	if _, ok := currentTransaction(ctx); ok {
+		s.Logging.Logger().Ctx(ctx).Info().Print("already inside a metadata transaction")
+		return repository.CommitInfo{}, closure(ctx)
+	}
+
+	err := s.pullMetadata(ctx)
+	if err != nil {
+		return repository.CommitInfo{}, err
+	}
+
+	tx := newTransaction()
+	err = closure(context.WithValue(ctx, transactionKey, tx))
+	if err != nil {
+		s.resetLocalClone(ctx)
+		// staged moves may have made it into the owner lookup caches
+		_, _ = s.GetSortedServiceNames(ctx)
+		_, _ = s.GetSortedRepositoryKeys(ctx)
+		return repository.CommitInfo{}, err
+	}
+
+	if len(tx.descriptions) == 0 {
+		return repository.CommitInfo{}, nochangeserror.New(ctx)
+	}
+
+	return s.commitAndPush(ctx, jiraIssue, strings.Join(tx.descriptions, ", "))
+}
+
+func (s *Impl) DryRun(ctx context.Context, closure func(context.Context) error) (string, error) {
+	if _, ok := currentTransaction(ctx); ok {
+		return "", errors.New("cannot start a dry run inside a metadata transaction")
+	}
+
+	err := s.pullMetadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	tx := newTransaction()
+	err = closure(context.WithValue(ctx, transactionKey, tx))
+
+	diff := ""
+	if err == nil {
+		diff = s.diff(tx)
+	}
+
+	s.discard(ctx, tx)
+	return diff, err
+}
+
+// pull updates the local clone, except inside a transaction, where it may hold staged changes.
+func (s *Impl) pull(ctx context.Context) error {
+	if _, ok := currentTransaction(ctx); ok {
+		return nil
+	}
+	return s.pullMetadata(ctx)
+}
+
+// commitAndPush commits all changes in the local clone and pushes them. On failure, the local clone is reset.
+//
+// Inside a transaction, the changes are only staged and the description is remembered for the commit message.
+// The returned commit info then has no commit hash.
+func (s *Impl) commitAndPush(ctx context.Context, jiraIssue string, description string) (repository.CommitInfo, error) {
+	message := fmt.Sprintf("%s: %s", jiraIssue, description)
+
+	if tx, ok := currentTransaction(ctx); ok {
+		tx.descriptions = append(tx.descriptions, description)
+		return repository.CommitInfo{
+			TimeStamp: s.Timestamp.Now(),
+			Message:   message,
+		}, nil
+	}
+
+	commitInfo, err := s.Metadata.Commit(ctx, message)
+	if err != nil {
+		if !nochangeserror.Is(err) {
+			// empty commits need no re-clone
+			s.resetLocalClone(ctx)
+		}
+		return commitInfo, err
+	}
+
+	err = s.Metadata.Push(ctx)
+	if err != nil {
+		s.resetLocalClone(ctx)
+		return commitInfo, err
+	}
+
+	return commitInfo, nil
+}
+
+// writeFile writes a file to the local clone. Inside a transaction, its previous contents are remembered first.
+func (s *Impl) writeFile(ctx context.Context, filename string, contents []byte) error {
+	s.remember(ctx, filename)
+	return s.Metadata.WriteFile(filename, contents)
+}
+
+// deleteFile deletes a file from the local clone. Inside a transaction, its previous contents are remembered first.
+func (s *Impl) deleteFile(ctx context.Context, filename string) error {
+	s.remember(ctx, filename)
+	return s.Metadata.DeleteFile(filename)
+}
+
+func (s *Impl) remember(ctx context.Context, filename string) {
+	tx, ok := currentTransaction(ctx)
+	if !ok {
+		return
+	}
+	if _, seen := tx.original[filename]; seen {
+		return
+	}
+
+	contents, _, err := s.Metadata.ReadFile(filename)
+	if err != nil {
+		contents = nil
+	}
+	tx.original[filename] = contents
+}
+
+// touchedFiles lists the files touched by the transaction in sorted order.
+func (tx *transaction) touchedFiles() []string {
+	result := make([]string, 0, len(tx.original))
+	for filename := range tx.original {
+		result = append(result, filename)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// diff renders all changes staged by the transaction as a unified diff.
+func (s *Impl) diff(tx *transaction) string {
+	var result strings.Builder
+	for _, filename := range tx.touchedFiles() {
+		before := tx.original[filename]
+		after, _, err := s.Metadata.ReadFile(filename)
+		if err != nil {
+			after = nil
+		}
+		if bytes.Equal(before, after) && (before == nil) == (after == nil) {
+			continue
+		}
+
+		fromFile := "a/" + filename
+		if before == nil {
+			fromFile = "/dev/null"
+		}
+		toFile := "b/" + filename
+		if after == nil {
+			toFile = "/dev/null"
+		}
+
+		fileDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(before),
+			B:        splitLines(after),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+		if err != nil {
+			// cannot happen when writing to a strings.Builder
+			continue
+		}
+		result.WriteString(fileDiff)
+	}
+	return result.String()
+}
+
+func splitLines(contents []byte) []string {
+	lines := strings.SplitAfter(string(contents), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// discard puts back the previous contents of all files touched by the transaction.
+//
+// Should that fail, the local clone is reset instead.
+func (s *Impl) discard(ctx context.Context, tx *transaction) {
+	for _, filename := range tx.touchedFiles() {
+		var err error
+		if contents := tx.original[filename]; contents != nil {
+			err = s.Metadata.WriteFile(filename, contents)
+		} else if _, statErr := s.Metadata.Stat(filename); statErr == nil {
+			err = s.Metadata.DeleteFile(filename)
+		}
+
+		if err != nil {
+			s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("failed to discard staged change to %s - resetting local clone", filename)
+			s.resetLocalClone(ctx)
+			break
+		}
+	}
+
+	// staged moves may have made it into the owner lookup caches
+	_, _ = s.GetSortedServiceNames(ctx)
+	_, _ = s.GetSortedRepositoryKeys(ctx)
+}
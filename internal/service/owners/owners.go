@@ -4,36 +4,46 @@ import (
 	"context"
 	"fmt"
 	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Interhyp/metadata-service/internal/acorn/repository"
 	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	"github.com/Interhyp/metadata-service/internal/service/util"
+	"github.com/Interhyp/metadata-service/internal/types"
 	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
-	"strings"
 
 	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
 	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"net/url"
+	"sort"
 )
 
 type Impl struct {
-	Configuration librepo.Configuration
-	Logging       librepo.Logging
-	Timestamp     librepo.Timestamp
-	Cache         repository.Cache
-	Updater       service.Updater
+	Configuration       librepo.Configuration
+	CustomConfiguration config.CustomConfiguration
+	Logging             librepo.Logging
+	Timestamp           librepo.Timestamp
+	Cache               repository.Cache
+	Updater             service.Updater
+	Policies            service.Policies
 }
 
 func New(
 	configuration librepo.Configuration,
+	customConfig config.CustomConfiguration,
 	logging librepo.Logging,
 	timestamp librepo.Timestamp,
 	cache repository.Cache,
 	updater service.Updater,
+	policies service.Policies,
 ) service.Owners {
 	return &Impl{
-		Configuration: configuration,
-		Logging:       logging,
-		Timestamp:     timestamp,
-		Cache:         cache,
-		Updater:       updater,
+		Configuration:       configuration,
+		CustomConfiguration: customConfig,
+		Logging:             logging,
+		Timestamp:           timestamp,
+		Cache:               cache,
+		Updater:             updater,
+		Policies:            policies,
 	}
 }
 
@@ -50,7 +60,21 @@ func (s *Impl) Setup() error {
 	return nil
 }
 
-func (s *Impl) GetOwners(ctx context.Context) (openapi.OwnerListDto, error) {
+func (s *Impl) ValidOwnerAlias(ctx context.Context, ownerAlias string) apierrors.AnnotatedError {
+	if s.CustomConfiguration.OwnerAliasPermittedRegex().MatchString(ownerAlias) &&
+		!s.CustomConfiguration.OwnerAliasProhibitedRegex().MatchString(ownerAlias) &&
+		uint16(len(ownerAlias)) <= s.CustomConfiguration.OwnerAliasMaxLength() {
+		return nil
+	}
+
+	s.Logging.Logger().Ctx(ctx).Info().Printf("owner parameter %v invalid", url.QueryEscape(ownerAlias))
+	permitted := s.CustomConfiguration.OwnerAliasPermittedRegex().String()
+	prohibited := s.CustomConfiguration.OwnerAliasProhibitedRegex().String()
+	max := s.CustomConfiguration.OwnerAliasMaxLength()
+	return apierrors.NewBadRequestError("owner.invalid.alias", fmt.Sprintf("owner alias must match %s, is not allowed to match %s and may have up to %d characters", permitted, prohibited, max), nil, s.Timestamp.Now())
+}
+
+func (s *Impl) GetOwners(ctx context.Context, page types.PageRequest) (openapi.OwnerListDto, error) {
 	result := openapi.OwnerListDto{
 		Owners: make(map[string]openapi.OwnerDto),
 	}
@@ -65,7 +89,14 @@ func (s *Impl) GetOwners(ctx context.Context) (openapi.OwnerListDto, error) {
 	if err != nil {
 		return result, err
 	}
-	for _, name := range names {
+	pager, err := util.NewPager(names, stamp, page, s.Timestamp.Now())
+	if err != nil {
+		return openapi.OwnerListDto{}, err
+	}
+	for i, name := range pager.Keys() {
+		if pager.Full() {
+			break
+		}
 		owner, err := s.GetOwner(ctx, name)
 		if err != nil {
 			// owner not found errors are ok, the cache may have been changed concurrently, just drop the entry
@@ -74,8 +105,11 @@ func (s *Impl) GetOwners(ctx context.Context) (openapi.OwnerListDto, error) {
 			}
 		} else {
 			result.Owners[name] = owner
+			pager.Take(i)
 		}
 	}
+	result.Order = pager.Taken()
+	result.NextCursor = pager.NextCursor()
 	return result, nil
 }
 
@@ -83,6 +117,47 @@ func (s *Impl) GetOwner(ctx context.Context, ownerAlias string) (openapi.OwnerDt
 	return s.Cache.GetOwner(ctx, ownerAlias)
 }
 
+func (s *Impl) GetOwnersAt(ctx context.Context, at string, page types.PageRequest) (openapi.OwnerListDto, error) {
+	historic, err := s.snapshotAt(ctx, at)
+	if err != nil {
+		return openapi.OwnerListDto{}, err
+	}
+	return historic.GetOwners(ctx, page)
+}
+
+func (s *Impl) GetOwnerAt(ctx context.Context, at string, ownerAlias string) (openapi.OwnerDto, error) {
+	historic, err := s.snapshotAt(ctx, at)
+	if err != nil {
+		return openapi.OwnerDto{}, err
+	}
+	return historic.GetOwner(ctx, ownerAlias)
+}
+
+// snapshotAt gives a copy of this component that reads from the metadata as of a past commit instead of the cache.
+//
+// Only use it for reads.
+func (s *Impl) snapshotAt(ctx context.Context, at string) (*Impl, error) {
+	cache, err := s.Updater.SnapshotAt(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	historic := *s
+	historic.Cache = cache
+	return &historic, nil
+}
+
+func (s *Impl) GetOwnerHistory(ctx context.Context, ownerAlias string) (openapi.HistoryDto, error) {
+	result, err := s.Updater.GetOwnerHistory(ctx, ownerAlias)
+	if err != nil {
+		return result, err
+	}
+	if len(result.Entries) == 0 {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("owner %v not found", ownerAlias)
+		return result, apierrors.NewNotFoundError("owner.notfound", fmt.Sprintf("owner %s not found", ownerAlias), nil, s.Timestamp.Now())
+	}
+	return result, nil
+}
+
 func (s *Impl) GetAllGroupMembers(ctx context.Context, groupOwner string, groupName string) []string {
 	allGroups := make(map[string][]string, 0)
 	// iterate over cache directly
@@ -116,10 +191,16 @@ func (s *Impl) CreateOwner(ctx context.Context, ownerAlias string, ownerCreateDt
 			return apierrors.NewConflictErrorWithResponse("owner.conflict.alreadyexists", fmt.Sprintf("owner %s already exists - cannot create", ownerAlias), nil, result, s.Timestamp.Now())
 		}
 
+		warnings, err := s.Policies.CheckOwner(subCtx, ownerAlias, ownerDto)
+		if err != nil {
+			return err
+		}
+
 		ownerWritten, err := s.Updater.WriteOwner(subCtx, ownerAlias, ownerDto)
 		if err != nil {
 			return err
 		}
+		ownerWritten.PolicyWarnings = warnings
 		if err != nil {
 			s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("error publishing creation of owner %s", ownerAlias)
 		}
@@ -143,17 +224,16 @@ func (s *Impl) mapOwnerCreateDtoToOwnerDto(ownerCreateDto openapi.OwnerCreateDto
 }
 
 func (s *Impl) validateOwnerCreateDto(ctx context.Context, dto openapi.OwnerCreateDto) error {
-	messages := make([]string, 0)
+	violations := util.NewViolations("owner")
 	if dto.Contact == "" {
-		messages = append(messages, "field contact is mandatory")
+		violations.Missing("contact", "field contact is mandatory")
 	}
 	if dto.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory")
 	}
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("owner values invalid: %s", details)
-		return apierrors.NewBadRequestError("owner.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("owner values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
@@ -176,40 +256,210 @@ func (s *Impl) UpdateOwner(ctx context.Context, ownerAlias string, ownerDto open
 			return apierrors.NewNotFoundError("owner.notfound", fmt.Sprintf("owner %s not found", ownerAlias), nil, s.Timestamp.Now())
 		}
 
-		if current.TimeStamp != ownerDto.TimeStamp || current.CommitHash != ownerDto.CommitHash {
+		basedOn := util.Version{TimeStamp: ownerDto.TimeStamp, CommitHash: ownerDto.CommitHash}
+		if err := util.CheckVersion(ctx, "owner", ownerAlias, current, util.Version{TimeStamp: current.TimeStamp, CommitHash: current.CommitHash}, basedOn, s.Timestamp.Now()); err != nil {
 			result = current
-			s.Logging.Logger().Ctx(ctx).Info().Printf("owner %v was concurrently updated", ownerAlias)
-			return apierrors.NewConflictErrorWithResponse("owner.conflict.concurrentlyupdated", fmt.Sprintf("owner %v was concurrently updated", ownerAlias), nil, result, s.Timestamp.Now())
+			return err
+		}
+
+		warnings, err := s.Policies.CheckOwner(subCtx, ownerAlias, ownerDto)
+		if err != nil {
+			return err
 		}
 
 		ownerWritten, err := s.Updater.WriteOwner(subCtx, ownerAlias, ownerDto)
 		if err != nil {
 			return err
 		}
+		ownerWritten.PolicyWarnings = warnings
 		result = ownerWritten
 		return nil
 	})
 	return result, err
 }
 
-func (s *Impl) validateExistingOwnerDto(ctx context.Context, dto openapi.OwnerDto) error {
-	messages := make([]string, 0)
-	if dto.Contact == "" {
-		messages = append(messages, "field contact is mandatory")
+func (s *Impl) RevertOwner(ctx context.Context, ownerAlias string, revertInfo openapi.RevertDto) (openapi.OwnerDto, error) {
+	if err := s.validateRevertDto(ctx, revertInfo); err != nil {
+		return openapi.OwnerDto{}, err
 	}
+
+	target, err := s.GetOwnerAt(ctx, revertInfo.CommitHash, ownerAlias)
+	if err != nil {
+		return target, err
+	}
+
+	result := target
+	err = s.Updater.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		err := s.Updater.PerformFullUpdate(subCtx)
+		if err != nil {
+			return err
+		}
+
+		current, err := s.Cache.GetOwner(subCtx, ownerAlias)
+		if err != nil {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("owner %v not found", ownerAlias)
+			return apierrors.NewNotFoundError("owner.notfound", fmt.Sprintf("owner %s not found", ownerAlias), nil, s.Timestamp.Now())
+		}
+
+		// we hold the lock, so basing the update on the current version cannot conflict
+		target.TimeStamp = current.TimeStamp
+		target.CommitHash = current.CommitHash
+		target.JiraIssue = revertInfo.JiraIssue
+
+		result, err = s.UpdateOwner(subCtx, ownerAlias, target)
+		return err
+	})
+	return result, err
+}
+
+func (s *Impl) validateRevertDto(ctx context.Context, dto openapi.RevertDto) error {
+	violations := util.NewViolations("owner")
 	if dto.CommitHash == "" {
-		messages = append(messages, "field commitHash is mandatory for updates")
+		violations.Missing("commitHash", "field commitHash is mandatory")
+	}
+	if dto.JiraIssue == "" {
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory")
+	}
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("owner revert values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
+}
+
+func (s *Impl) RenameOwner(ctx context.Context, ownerAlias string, renameInfo openapi.OwnerRenameDto) (openapi.OwnerDto, error) {
+	if err := s.validateOwnerRenameDto(ctx, ownerAlias, renameInfo); err != nil {
+		return openapi.OwnerDto{}, err
+	}
+	newOwnerAlias := renameInfo.NewAlias
+
+	result := openapi.OwnerDto{}
+	// a transaction, so the policies can be checked against the renamed entities before anything is committed
+	event, err := s.Updater.WithTransaction(ctx, renameInfo.JiraIssue, func(subCtx context.Context) error {
+		current, err := s.Cache.GetOwner(subCtx, ownerAlias)
+		if err != nil {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("owner %v not found", ownerAlias)
+			return apierrors.NewNotFoundError("owner.notfound", fmt.Sprintf("owner %s not found", ownerAlias), nil, s.Timestamp.Now())
+		}
+
+		existing, err := s.Cache.GetOwner(subCtx, newOwnerAlias)
+		if err == nil {
+			result = existing
+			s.Logging.Logger().Ctx(ctx).Info().Printf("owner %v already exists", newOwnerAlias)
+			return apierrors.NewConflictErrorWithResponse("owner.conflict.alreadyexists", fmt.Sprintf("owner %s already exists - cannot rename %s", newOwnerAlias, ownerAlias), nil, result, s.Timestamp.Now())
+		}
+
+		basedOn := util.Version{TimeStamp: renameInfo.TimeStamp, CommitHash: renameInfo.CommitHash}
+		if err := util.CheckVersion(ctx, "owner", ownerAlias, current, util.Version{TimeStamp: current.TimeStamp, CommitHash: current.CommitHash}, basedOn, s.Timestamp.Now()); err != nil {
+			result = current
+			return err
+		}
+
+		result, err = s.Updater.RenameOwner(subCtx, ownerAlias, newOwnerAlias, renameInfo.JiraIssue)
+		if err != nil {
+			return err
+		}
+
+		warnings, err := s.checkOwnedPolicies(subCtx, newOwnerAlias)
+		if err != nil {
+			return err
+		}
+		result.PolicyWarnings = warnings
+		return nil
+	})
+	if err == nil && event.CommitHash != "" {
+		// the rename has only been committed when the transaction ended
+		result.CommitHash = event.CommitHash
+		result.TimeStamp = event.TimeStamp
+	}
+	return result, err
+}
+
+// checkOwnedPolicies checks an owner and all its services and repositories against the policies, after they have
+// changed hands in the current transaction. Only the warnings about the owner itself are returned, the others are
+// logged.
+func (s *Impl) checkOwnedPolicies(ctx context.Context, ownerAlias string) ([]openapi.ViolationDto, error) {
+	owner, err := s.Cache.GetOwner(ctx, ownerAlias)
+	if err != nil {
+		return nil, err
+	}
+	warnings, err := s.Policies.CheckOwner(ctx, ownerAlias, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceNames, err := s.Cache.GetSortedServiceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range serviceNames {
+		if service, err := s.Cache.GetService(ctx, name); err == nil && service.Owner == ownerAlias {
+			if _, err := s.Policies.CheckService(ctx, name, service); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	repoKeys, err := s.Cache.GetSortedRepositoryKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range repoKeys {
+		if repo, err := s.Cache.GetRepository(ctx, key); err == nil && repo.Owner == ownerAlias {
+			if _, err := s.Policies.CheckRepository(ctx, key, repo); err != nil {
+				return nil, err
+			}
+		}
 	}
-	if dto.TimeStamp == "" {
-		messages = append(messages, "field timeStamp is mandatory for updates")
+	return warnings, nil
+}
+
+func (s *Impl) validateOwnerRenameDto(ctx context.Context, ownerAlias string, dto openapi.OwnerRenameDto) error {
+	violations := util.NewViolations("owner")
+	if dto.NewAlias == "" {
+		violations.Missing("newAlias", "field newAlias is mandatory")
+	} else if dto.NewAlias == ownerAlias {
+		violations.Add("newAlias", util.ProblemInvalid, "field newAlias must differ from the current alias", nil)
+	}
+	if !util.IsConditional(ctx) {
+		if dto.CommitHash == "" {
+			violations.Missing("commitHash", "field commitHash is mandatory")
+		}
+		if dto.TimeStamp == "" {
+			violations.Missing("timeStamp", "field timeStamp is mandatory")
+		}
 	}
 	if dto.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory for updates")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory")
 	}
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("owner values invalid: %s", details)
-		return apierrors.NewBadRequestError("owner.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("owner rename values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
+}
+
+func (s *Impl) validateExistingOwnerDto(ctx context.Context, dto openapi.OwnerDto) error {
+	violations := util.NewViolations("owner")
+	if dto.Contact == "" {
+		violations.Missing("contact", "field contact is mandatory")
+	}
+	if !util.IsConditional(ctx) {
+		if dto.CommitHash == "" {
+			violations.Missing("commitHash", "field commitHash is mandatory for updates")
+		}
+		if dto.TimeStamp == "" {
+			violations.Missing("timeStamp", "field timeStamp is mandatory for updates")
+		}
+	}
+	if dto.JiraIssue == "" {
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory for updates")
+	}
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("owner values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
@@ -232,18 +482,24 @@ func (s *Impl) PatchOwner(ctx context.Context, ownerAlias string, ownerPatchDto
 			return err
 		}
 
-		if current.TimeStamp != ownerPatchDto.TimeStamp || current.CommitHash != ownerPatchDto.CommitHash {
+		basedOn := util.Version{TimeStamp: ownerPatchDto.TimeStamp, CommitHash: ownerPatchDto.CommitHash}
+		if err := util.CheckVersion(ctx, "owner", ownerAlias, current, util.Version{TimeStamp: current.TimeStamp, CommitHash: current.CommitHash}, basedOn, s.Timestamp.Now()); err != nil {
 			result = current
-			s.Logging.Logger().Ctx(ctx).Info().Printf("owner %v was concurrently updated", ownerAlias)
-			return apierrors.NewConflictErrorWithResponse("owner.conflict.concurrentlyupdated", fmt.Sprintf("owner %v was concurrently updated", ownerAlias), nil, result, s.Timestamp.Now())
+			return err
 		}
 
 		ownerDto := patchOwner(current, ownerPatchDto)
 
+		warnings, err := s.Policies.CheckOwner(subCtx, ownerAlias, ownerDto)
+		if err != nil {
+			return err
+		}
+
 		ownerWritten, err := s.Updater.WriteOwner(subCtx, ownerAlias, ownerDto)
 		if err != nil {
 			return err
 		}
+		ownerWritten.PolicyWarnings = warnings
 
 		result = ownerWritten
 		return nil
@@ -251,24 +507,56 @@ func (s *Impl) PatchOwner(ctx context.Context, ownerAlias string, ownerPatchDto
 	return result, err
 }
 
+func (s *Impl) ApplyOwnerPatch(ctx context.Context, ownerAlias string, patch types.PatchDocument) (openapi.OwnerDto, error) {
+	var result openapi.OwnerDto
+	err := s.Updater.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		err := s.Updater.PerformFullUpdate(subCtx)
+		if err != nil {
+			return err
+		}
+
+		current, err := s.Cache.GetOwner(subCtx, ownerAlias)
+		if err != nil {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("owner %v not found", ownerAlias)
+			return apierrors.NewNotFoundError("owner.notfound", fmt.Sprintf("owner %s not found", ownerAlias), nil, s.Timestamp.Now())
+		}
+		result = current
+
+		// the patch has to supply these, as for any other update
+		current.TimeStamp = ""
+		current.CommitHash = ""
+		current.JiraIssue = ""
+
+		target, err := util.ApplyPatch(subCtx, "owner", current, patch, s.Timestamp.Now())
+		if err != nil {
+			return err
+		}
+
+		result, err = s.UpdateOwner(subCtx, ownerAlias, target)
+		return err
+	})
+	return result, err
+}
+
 func (s *Impl) validateOwnerPatchDto(ctx context.Context, ownerPatchDto openapi.OwnerPatchDto) error {
-	messages := make([]string, 0)
+	violations := util.NewViolations("owner")
 	if ownerPatchDto.Contact != nil && *ownerPatchDto.Contact == "" {
-		messages = append(messages, "field contact cannot be set to empty")
+		violations.Missing("contact", "field contact cannot be set to empty")
 	}
-	if ownerPatchDto.CommitHash == "" {
-		messages = append(messages, "field commitHash is mandatory for patching")
-	}
-	if ownerPatchDto.TimeStamp == "" {
-		messages = append(messages, "field timeStamp is mandatory for patching")
+	if !util.IsConditional(ctx) {
+		if ownerPatchDto.CommitHash == "" {
+			violations.Missing("commitHash", "field commitHash is mandatory for patching")
+		}
+		if ownerPatchDto.TimeStamp == "" {
+			violations.Missing("timeStamp", "field timeStamp is mandatory for patching")
+		}
 	}
 	if ownerPatchDto.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory for patching")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory for patching")
 	}
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("owner values invalid: %s", details)
-		return apierrors.NewBadRequestError("owner.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("owner values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
@@ -350,6 +638,10 @@ func (s *Impl) DeleteOwner(ctx context.Context, ownerAlias string, deletionInfo
 			return err
 		}
 
+		if deletionInfo.TransferTo != "" {
+			return s.transferAndDeleteOwner(subCtx, ownerAlias, deletionInfo)
+		}
+
 		allowed := s.Updater.CanDeleteOwner(subCtx, ownerAlias)
 		if !allowed {
 			s.Logging.Logger().Ctx(ctx).Info().Printf("tried to delete owner %v, who still owns services or repositories", ownerAlias)
@@ -365,15 +657,151 @@ func (s *Impl) DeleteOwner(ctx context.Context, ownerAlias string, deletionInfo
 	})
 }
 
+// transferAndDeleteOwner moves all services and repositories of the owner to deletionInfo.TransferTo, then deletes
+// the owner, all in one commit.
+//
+// Services take their referenced repositories along. A repository that is referenced by a service of yet another owner
+// cannot be moved, and the whole deletion fails.
+func (s *Impl) transferAndDeleteOwner(ctx context.Context, ownerAlias string, deletionInfo openapi.DeletionDto) error {
+	newOwnerAlias := deletionInfo.TransferTo
+	if newOwnerAlias == ownerAlias {
+		violations := util.NewViolations("deletion")
+		violations.Add("transferTo", util.ProblemInvalid, "field transferTo must refer to another owner", nil)
+		s.Logging.Logger().Ctx(ctx).Info().Printf("deletion info values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	if _, err := s.Cache.GetOwner(ctx, newOwnerAlias); err != nil {
+		violations := util.NewViolations("deletion")
+		violations.Add("transferTo", util.ProblemInvalid, fmt.Sprintf("no such owner: %s", newOwnerAlias), map[string]string{
+			"owner": newOwnerAlias,
+		})
+		s.Logging.Logger().Ctx(ctx).Info().Printf("deletion info values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+
+	_, err := s.Updater.WithTransaction(ctx, deletionInfo.JiraIssue, func(txCtx context.Context) error {
+		if err := s.transferGroups(txCtx, ownerAlias, newOwnerAlias, deletionInfo.JiraIssue); err != nil {
+			return err
+		}
+
+		// remember the repositories now, as the services take some of them along
+		repoKeys, err := s.Cache.GetSortedRepositoryKeys(txCtx)
+		if err != nil {
+			return err
+		}
+		transferredRepoKeys := make([]string, 0)
+		for _, key := range repoKeys {
+			if repo, err := s.Cache.GetRepository(txCtx, key); err == nil && repo.Owner == ownerAlias {
+				transferredRepoKeys = append(transferredRepoKeys, key)
+			}
+		}
+
+		serviceNames, err := s.Cache.GetSortedServiceNames(txCtx)
+		if err != nil {
+			return err
+		}
+		for _, name := range serviceNames {
+			service, err := s.Cache.GetService(txCtx, name)
+			if err != nil || service.Owner != ownerAlias {
+				continue
+			}
+
+			service.Owner = newOwnerAlias
+			service.JiraIssue = deletionInfo.JiraIssue
+			if _, err := s.Updater.WriteService(txCtx, name, service); err != nil {
+				return err
+			}
+		}
+
+		for _, key := range transferredRepoKeys {
+			repo, err := s.Cache.GetRepository(txCtx, key)
+			if err != nil {
+				continue
+			}
+
+			configuration, renamed := util.RenameGroupOwnerInRepositoryConfiguration(repo.Configuration, ownerAlias, newOwnerAlias)
+			if repo.Owner == newOwnerAlias && !renamed {
+				// moved along with its service, and nothing to rewrite
+				continue
+			}
+			repo.Owner = newOwnerAlias
+			repo.Configuration = configuration
+			repo.JiraIssue = deletionInfo.JiraIssue
+			if _, err := s.Updater.WriteRepository(txCtx, key, repo); err != nil {
+				return err
+			}
+		}
+
+		// warnings cannot be reported, as a deletion has no response body
+		if _, err := s.checkOwnedPolicies(txCtx, newOwnerAlias); err != nil {
+			return err
+		}
+
+		return s.Updater.DeleteOwner(txCtx, ownerAlias, deletionInfo)
+	})
+	return err
+}
+
+// transferGroups copies the groups of an owner that is about to be deleted to the owner that takes over its services
+// and repositories, so the group references in transferred repositories can be rewritten to the new owner.
+//
+// A group the new owner already has with different members is a conflict, because rewriting the references would
+// silently change who approves.
+func (s *Impl) transferGroups(ctx context.Context, ownerAlias string, newOwnerAlias string, jiraIssue string) error {
+	owner, err := s.Cache.GetOwner(ctx, ownerAlias)
+	if err != nil || owner.Groups == nil {
+		return nil
+	}
+	newOwner, err := s.Cache.GetOwner(ctx, newOwnerAlias)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string][]string)
+	if newOwner.Groups != nil {
+		for groupName, members := range *newOwner.Groups {
+			groups[groupName] = members
+		}
+	}
+	changed := false
+	for _, groupName := range sortedGroupNames(*owner.Groups) {
+		members, _ := util.RenameGroupOwner((*owner.Groups)[groupName], ownerAlias, newOwnerAlias)
+		existing, ok := groups[groupName]
+		if !ok {
+			groups[groupName] = members
+			changed = true
+		} else if !util.Equal(existing, members) {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("owner %s already has a different group %s", newOwnerAlias, groupName)
+			return apierrors.NewConflictError("owner.conflict.group", fmt.Sprintf("owner %s already has a group %s with different members - cannot transfer the group of owner %s", newOwnerAlias, groupName, ownerAlias), nil, s.Timestamp.Now())
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	newOwner.Groups = &groups
+	newOwner.JiraIssue = jiraIssue
+	_, err = s.Updater.WriteOwner(ctx, newOwnerAlias, newOwner)
+	return err
+}
+
+func sortedGroupNames(groups map[string][]string) []string {
+	result := make([]string, 0, len(groups))
+	for groupName := range groups {
+		result = append(result, groupName)
+	}
+	sort.Strings(result)
+	return result
+}
+
 func (s *Impl) validateDeletionDto(ctx context.Context, deletionInfo openapi.DeletionDto) error {
-	messages := make([]string, 0)
+	violations := util.NewViolations("deletion")
 	if deletionInfo.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory for deletion")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory for deletion")
 	}
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("deletion info values invalid: %s", details)
-		return apierrors.NewBadRequestError("deletion.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("deletion info values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
@@ -0,0 +1,309 @@
+package policies
+
+import (
+	"context"
+	"fmt"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	"github.com/Interhyp/metadata-service/internal/service/query"
+	"github.com/Interhyp/metadata-service/internal/service/util"
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+)
+
+type Impl struct {
+	Configuration       librepo.Configuration
+	CustomConfiguration config.CustomConfiguration
+	Logging             librepo.Logging
+	Timestamp           librepo.Timestamp
+	Cache               repository.Cache
+
+	servicePolicies    []policy[openapi.ServiceDto]
+	repositoryPolicies []policy[openapi.RepositoryDto]
+	ownerPolicies      []policy[openapi.OwnerDto]
+}
+
+// policy is a configured policy with its queries parsed. Absent queries are nil, except for when.
+type policy[T any] struct {
+	config.Policy
+	when                query.Expression[T]
+	require             query.Expression[T]
+	requireRepositories query.Expression[openapi.RepositoryDto]
+	requireOwner        query.Expression[openapi.OwnerDto]
+}
+
+func New(
+	configuration librepo.Configuration,
+	customConfig config.CustomConfiguration,
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	cache repository.Cache,
+) service.Policies {
+	return &Impl{
+		Configuration:       configuration,
+		CustomConfiguration: customConfig,
+		Logging:             logging,
+		Timestamp:           timestamp,
+		Cache:               cache,
+	}
+}
+
+func (s *Impl) IsPolicies() bool {
+	return true
+}
+
+func (s *Impl) Setup() error {
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+
+	if err := s.SetupPolicies(ctx); err != nil {
+		s.Logging.Logger().Ctx(ctx).Error().WithErr(err).Print("failed to parse policies. BAILING OUT")
+		return err
+	}
+
+	s.Logging.Logger().Ctx(ctx).Info().Printf("successfully set up policies business component with %d policies", len(s.CustomConfiguration.Policies()))
+	return nil
+}
+
+// SetupPolicies parses the queries of the configured policies. The configuration has only checked their structure.
+func (s *Impl) SetupPolicies(_ context.Context) error {
+	s.servicePolicies = make([]policy[openapi.ServiceDto], 0)
+	s.repositoryPolicies = make([]policy[openapi.RepositoryDto], 0)
+	s.ownerPolicies = make([]policy[openapi.OwnerDto], 0)
+
+	for _, configured := range s.CustomConfiguration.Policies() {
+		switch configured.Entity {
+		case config.PolicyEntityService:
+			parsed, err := parsePolicy(configured, query.ServiceField)
+			if err != nil {
+				return err
+			}
+			s.servicePolicies = append(s.servicePolicies, parsed)
+		case config.PolicyEntityRepository:
+			parsed, err := parsePolicy(configured, query.RepositoryField)
+			if err != nil {
+				return err
+			}
+			s.repositoryPolicies = append(s.repositoryPolicies, parsed)
+		case config.PolicyEntityOwner:
+			parsed, err := parsePolicy(configured, query.OwnerField)
+			if err != nil {
+				return err
+			}
+			s.ownerPolicies = append(s.ownerPolicies, parsed)
+		default:
+			return fmt.Errorf("policy %s has invalid entity %s", configured.Name, configured.Entity)
+		}
+	}
+	return nil
+}
+
+func parsePolicy[T any](configured config.Policy, resolve query.Resolver[T]) (policy[T], error) {
+	result := policy[T]{
+		Policy: configured,
+		when:   query.All[T](),
+	}
+	var err error
+	if configured.When != "" {
+		if result.when, err = query.Parse[T](configured.When, resolve); err != nil {
+			return result, fmt.Errorf("policy %s has invalid when: %s", configured.Name, err.Error())
+		}
+	}
+	if configured.Require != "" {
+		if result.require, err = query.Parse[T](configured.Require, resolve); err != nil {
+			return result, fmt.Errorf("policy %s has invalid require: %s", configured.Name, err.Error())
+		}
+	}
+	if configured.RequireRepositories != "" {
+		if result.requireRepositories, err = query.Parse[openapi.RepositoryDto](configured.RequireRepositories, query.RepositoryField); err != nil {
+			return result, fmt.Errorf("policy %s has invalid requireRepositories: %s", configured.Name, err.Error())
+		}
+	}
+	if configured.RequireOwner != "" {
+		if result.requireOwner, err = query.Parse[openapi.OwnerDto](configured.RequireOwner, query.OwnerField); err != nil {
+			return result, fmt.Errorf("policy %s has invalid requireOwner: %s", configured.Name, err.Error())
+		}
+	}
+	return result, nil
+}
+
+// --- business logic ---
+
+func (s *Impl) CheckService(ctx context.Context, serviceName string, service openapi.ServiceDto) ([]openapi.ViolationDto, error) {
+	result := newResult("service")
+	for _, p := range s.servicePolicies {
+		if !p.when.Matches(serviceName, service) {
+			continue
+		}
+		if p.require != nil && !p.require.Matches(serviceName, service) {
+			result.add(p.Policy, "", "", nil)
+		}
+		if p.requireRepositories != nil {
+			for i, key := range service.Repositories {
+				repo, err := s.Cache.GetRepository(ctx, key)
+				if err != nil {
+					// missing repositories are reported by the validation of the service
+					continue
+				}
+				if !p.requireRepositories.Matches(key, repo) {
+					result.add(p.Policy, fmt.Sprintf("repositories.%d", i), "repository", &key)
+				}
+			}
+		}
+		if p.requireOwner != nil && !s.ownerMatches(ctx, p.requireOwner, service.Owner) {
+			result.add(p.Policy, "owner", "owner", &service.Owner)
+		}
+	}
+	return s.finish(ctx, result)
+}
+
+func (s *Impl) CheckRepository(ctx context.Context, key string, repository openapi.RepositoryDto) ([]openapi.ViolationDto, error) {
+	result := newResult("repository")
+	for _, p := range s.repositoryPolicies {
+		if !p.when.Matches(key, repository) {
+			continue
+		}
+		if p.require != nil && !p.require.Matches(key, repository) {
+			result.add(p.Policy, "", "", nil)
+		}
+		if p.requireOwner != nil && !s.ownerMatches(ctx, p.requireOwner, repository.Owner) {
+			result.add(p.Policy, "owner", "owner", &repository.Owner)
+		}
+	}
+
+	if s.anyRequireRepositories() {
+		names, err := s.Cache.GetSortedServiceNames(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			serviceDto, err := s.Cache.GetService(ctx, name)
+			if err != nil || !contains(serviceDto.Repositories, key) {
+				continue
+			}
+			for _, p := range s.servicePolicies {
+				if p.requireRepositories != nil && p.when.Matches(name, serviceDto) && !p.requireRepositories.Matches(key, repository) {
+					result.add(p.Policy, "", "service", &name)
+				}
+			}
+		}
+	}
+	return s.finish(ctx, result)
+}
+
+func (s *Impl) CheckOwner(ctx context.Context, ownerAlias string, owner openapi.OwnerDto) ([]openapi.ViolationDto, error) {
+	result := newResult("owner")
+	for _, p := range s.ownerPolicies {
+		if p.when.Matches(ownerAlias, owner) && p.require != nil && !p.require.Matches(ownerAlias, owner) {
+			result.add(p.Policy, "", "", nil)
+		}
+	}
+
+	names, err := s.Cache.GetSortedServiceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		serviceDto, err := s.Cache.GetService(ctx, name)
+		if err != nil || serviceDto.Owner != ownerAlias {
+			continue
+		}
+		for _, p := range s.servicePolicies {
+			if p.requireOwner != nil && p.when.Matches(name, serviceDto) && !p.requireOwner.Matches(ownerAlias, owner) {
+				result.add(p.Policy, "", "service", &name)
+			}
+		}
+	}
+
+	keys, err := s.Cache.GetSortedRepositoryKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		repositoryDto, err := s.Cache.GetRepository(ctx, key)
+		if err != nil || repositoryDto.Owner != ownerAlias {
+			continue
+		}
+		for _, p := range s.repositoryPolicies {
+			if p.requireOwner != nil && p.when.Matches(key, repositoryDto) && !p.requireOwner.Matches(ownerAlias, owner) {
+				result.add(p.Policy, "", "repository", &key)
+			}
+		}
+	}
+	return s.finish(ctx, result)
+}
+
+func (s *Impl) ownerMatches(ctx context.Context, require query.Expression[openapi.OwnerDto], ownerAlias string) bool {
+	ownerDto, err := s.Cache.GetOwner(ctx, ownerAlias)
+	if err != nil {
+		// a missing owner is reported by the validation of the entity
+		return true
+	}
+	return require.Matches(ownerAlias, ownerDto)
+}
+
+func (s *Impl) anyRequireRepositories() bool {
+	for _, p := range s.servicePolicies {
+		if p.requireRepositories != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkResult collects the policy violations of a single check, separated by severity.
+type checkResult struct {
+	denied *util.Violations
+	warned *util.Violations
+}
+
+func newResult(kind string) *checkResult {
+	return &checkResult{
+		denied: util.NewViolations(kind),
+		warned: util.NewViolations(kind),
+	}
+}
+
+// add records a violation of the policy. If the violation is caused by another entity, its kind and name are
+// mentioned in the message and the parameters.
+func (r *checkResult) add(p config.Policy, field string, otherKind string, otherName *string) {
+	message := p.Message
+	if message == "" {
+		message = fmt.Sprintf("violates policy %s", p.Name)
+	}
+	parameters := map[string]string{
+		"policy":   p.Name,
+		"severity": p.Severity,
+	}
+	if otherName != nil {
+		message = fmt.Sprintf("%s (%s %s)", message, otherKind, *otherName)
+		parameters[otherKind] = *otherName
+	}
+
+	if p.Severity == config.PolicySeverityDeny {
+		r.denied.Add(field, util.ProblemPolicy, message, parameters)
+	} else {
+		r.warned.Add(field, util.ProblemPolicy, message, parameters)
+	}
+}
+
+func (s *Impl) finish(ctx context.Context, r *checkResult) ([]openapi.ViolationDto, error) {
+	if !r.denied.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("denied by policy: %s", r.denied.Details())
+		return nil, r.denied.Error(s.Timestamp.Now())
+	}
+	if !r.warned.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("policy warnings: %s", r.warned.Details())
+	}
+	return r.warned.List(), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
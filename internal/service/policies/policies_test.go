@@ -0,0 +1,173 @@
+package policies
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"github.com/Interhyp/metadata-service/test/mock/cachemock"
+	"github.com/Interhyp/metadata-service/test/mock/configmock"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"github.com/StephanHCB/go-backend-service-common/repository/logging"
+	"github.com/StephanHCB/go-backend-service-common/repository/timestamp"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type tstConfig struct {
+	configmock.MockConfig
+	policies []config.Policy
+}
+
+func (c *tstConfig) Policies() []config.Policy {
+	return c.policies
+}
+
+type tstCache struct {
+	ownersmock.Mock
+}
+
+func (c *tstCache) GetSortedServiceNames(ctx context.Context) ([]string, error) {
+	return []string{"some-service"}, nil
+}
+
+func (c *tstCache) GetService(ctx context.Context, name string) (openapi.ServiceDto, error) {
+	return openapi.ServiceDto{
+		Owner:         "someOwner",
+		Repositories:  []string{"some-service/implementation"},
+		OperationType: p("PLATFORM"),
+	}, nil
+}
+
+func (c *tstCache) GetSortedRepositoryKeys(ctx context.Context) ([]string, error) {
+	return []string{"some-service/implementation"}, nil
+}
+
+func (c *tstCache) GetRepository(ctx context.Context, key string) (openapi.RepositoryDto, error) {
+	return openapi.RepositoryDto{
+		Owner: "someOwner",
+	}, nil
+}
+
+func p(v string) *string {
+	return &v
+}
+
+func tstPolicies(t *testing.T, policies ...config.Policy) *Impl {
+	impl := &Impl{
+		CustomConfiguration: &tstConfig{policies: policies},
+		Logging:             &logging.LoggingImpl{},
+		Timestamp: &timestamp.TimestampImpl{
+			Timestamp: func() time.Time {
+				return time.Date(2022, 11, 6, 18, 14, 10, 0, time.UTC)
+			},
+		},
+		Cache: &tstCache{},
+	}
+	require.Nil(t, impl.SetupPolicies(context.Background()))
+	return impl
+}
+
+func tstRequireDenied(t *testing.T, err error, expectedDetails string) {
+	require.NotNil(t, err)
+	require.True(t, apierrors.IsBadRequestError(err))
+	require.Equal(t, expectedDetails, *err.(apierrors.AnnotatedError).ApiError().Details)
+}
+
+var described = config.Policy{
+	Name:     "described",
+	Entity:   config.PolicyEntityService,
+	Severity: config.PolicySeverityWarn,
+	Message:  "services should be described",
+	When:     "operationType=PLATFORM",
+	Require:  `description!=""`,
+}
+
+func TestCheckService_NoPolicies(t *testing.T) {
+	warnings, err := tstPolicies(t).CheckService(context.Background(), "some-service", openapi.ServiceDto{})
+	require.Nil(t, err)
+	require.Nil(t, warnings)
+}
+
+func TestCheckService_Warn(t *testing.T) {
+	cut := tstPolicies(t, described)
+
+	warnings, err := cut.CheckService(context.Background(), "some-service", openapi.ServiceDto{OperationType: p("PLATFORM")})
+	require.Nil(t, err)
+	require.Equal(t, 1, len(warnings))
+	require.Equal(t, "", warnings[0].Pointer)
+	require.Equal(t, "service.policy", warnings[0].Code)
+	require.Equal(t, "services should be described", warnings[0].Message)
+	require.Equal(t, map[string]string{"policy": "described", "severity": "warn"}, warnings[0].Parameters)
+
+	warnings, err = cut.CheckService(context.Background(), "some-service", openapi.ServiceDto{OperationType: p("PLATFORM"), Description: p("described")})
+	require.Nil(t, err)
+	require.Nil(t, warnings)
+
+	warnings, err = cut.CheckService(context.Background(), "some-service", openapi.ServiceDto{OperationType: p("WORKLOAD")})
+	require.Nil(t, err)
+	require.Nil(t, warnings)
+}
+
+func TestCheckService_DenyRepositories(t *testing.T) {
+	cut := tstPolicies(t, config.Policy{
+		Name:                "builds",
+		Entity:              config.PolicyEntityService,
+		Severity:            config.PolicySeverityDeny,
+		RequireRepositories: "configuration.requireSuccessfulBuilds!=0",
+	})
+
+	_, err := cut.CheckService(context.Background(), "some-service", openapi.ServiceDto{Repositories: []string{"some-service/implementation"}})
+	tstRequireDenied(t, err, "validation error: violates policy builds (repository some-service/implementation)")
+}
+
+func TestCheckRepository_ReverseRequireRepositories(t *testing.T) {
+	cut := tstPolicies(t, config.Policy{
+		Name:                "builds",
+		Entity:              config.PolicyEntityService,
+		Severity:            config.PolicySeverityDeny,
+		Message:             "repositories of platform services must require successful builds",
+		When:                "operationType=PLATFORM",
+		RequireRepositories: "configuration.requireSuccessfulBuilds!=0",
+	})
+
+	_, err := cut.CheckRepository(context.Background(), "some-service/implementation", openapi.RepositoryDto{})
+	tstRequireDenied(t, err, "validation error: repositories of platform services must require successful builds (service some-service)")
+
+	_, err = cut.CheckRepository(context.Background(), "other-service/implementation", openapi.RepositoryDto{})
+	require.Nil(t, err)
+}
+
+func TestCheckOwner_ReverseRequireOwner(t *testing.T) {
+	cut := tstPolicies(t, config.Policy{
+		Name:         "contact",
+		Entity:       config.PolicyEntityRepository,
+		Severity:     config.PolicySeverityWarn,
+		RequireOwner: `contact!=""`,
+	})
+
+	warnings, err := cut.CheckOwner(context.Background(), "someOwner", openapi.OwnerDto{})
+	require.Nil(t, err)
+	require.Equal(t, 1, len(warnings))
+	require.Equal(t, "violates policy contact (repository some-service/implementation)", warnings[0].Message)
+
+	warnings, err = cut.CheckOwner(context.Background(), "someOwner", openapi.OwnerDto{Contact: "someone@some-organisation.com"})
+	require.Nil(t, err)
+	require.Nil(t, warnings)
+}
+
+func TestSetupPolicies_InvalidQuery(t *testing.T) {
+	cut := &Impl{
+		CustomConfiguration: &tstConfig{policies: []config.Policy{{
+			Name:     "broken",
+			Entity:   config.PolicyEntityOwner,
+			Severity: config.PolicySeverityWarn,
+			Require:  "unknown=1",
+		}}},
+		Logging: &logging.LoggingImpl{},
+	}
+
+	err := cut.SetupPolicies(context.Background())
+	require.NotNil(t, err)
+	require.Equal(t, "policy broken has invalid require: unknown field 'unknown' at position 1", err.Error())
+}
@@ -0,0 +1,172 @@
+package query
+
+import (
+	"github.com/Interhyp/metadata-service/api"
+	"strings"
+)
+
+const labelsPrefix = "labels."
+
+// ServiceField resolves the fields that can be used in a service query.
+func ServiceField(name string) (Field[openapi.ServiceDto], bool) {
+	if strings.HasPrefix(name, labelsPrefix) && len(name) > len(labelsPrefix) {
+		label := strings.TrimPrefix(name, labelsPrefix)
+		return func(_ string, service openapi.ServiceDto) []string {
+			return Label(service.Labels, label)
+		}, true
+	}
+
+	switch name {
+	case "name":
+		return func(serviceName string, _ openapi.ServiceDto) []string {
+			return Value(serviceName)
+		}, true
+	case "owner":
+		return func(_ string, service openapi.ServiceDto) []string {
+			return Value(service.Owner)
+		}, true
+	case "description":
+		return func(_ string, service openapi.ServiceDto) []string {
+			return OptionalValue(service.Description)
+		}, true
+	case "alertTarget":
+		return func(_ string, service openapi.ServiceDto) []string {
+			return Value(service.AlertTarget)
+		}, true
+	case "lifecycle":
+		return func(_ string, service openapi.ServiceDto) []string {
+			return OptionalValue(service.Lifecycle)
+		}, true
+	case "operationType":
+		return func(_ string, service openapi.ServiceDto) []string {
+			return OptionalValue(service.OperationType)
+		}, true
+	case "internetExposed":
+		return func(_ string, service openapi.ServiceDto) []string {
+			return OptionalBool(service.InternetExposed)
+		}, true
+	case "developmentOnly":
+		return func(_ string, service openapi.ServiceDto) []string {
+			return OptionalBool(service.DevelopmentOnly)
+		}, true
+	case "tags":
+		return func(_ string, service openapi.ServiceDto) []string {
+			return Values(service.Tags)
+		}, true
+	case "repositories":
+		return func(_ string, service openapi.ServiceDto) []string {
+			return Values(service.Repositories)
+		}, true
+	}
+	return nil, false
+}
+
+// RepositoryField resolves the fields that can be used in a repository query.
+func RepositoryField(name string) (Field[openapi.RepositoryDto], bool) {
+	if strings.HasPrefix(name, labelsPrefix) && len(name) > len(labelsPrefix) {
+		label := strings.TrimPrefix(name, labelsPrefix)
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return Label(repository.Labels, label)
+		}, true
+	}
+
+	switch name {
+	case "key":
+		return func(key string, _ openapi.RepositoryDto) []string {
+			return Value(key)
+		}, true
+	case "name":
+		return func(key string, _ openapi.RepositoryDto) []string {
+			name, _, _ := strings.Cut(key, ".")
+			return Value(name)
+		}, true
+	case "type":
+		return func(key string, _ openapi.RepositoryDto) []string {
+			_, repoType, _ := strings.Cut(key, ".")
+			return Value(repoType)
+		}, true
+	case "owner":
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return Value(repository.Owner)
+		}, true
+	case "url":
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return Value(repository.Url)
+		}, true
+	case "mainline":
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return Value(repository.Mainline)
+		}, true
+	case "generator":
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return OptionalValue(repository.Generator)
+		}, true
+	case "unittest":
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return OptionalBool(repository.Unittest)
+		}, true
+	case "configuration.archived":
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return OptionalBool(configuration(repository).Archived)
+		}, true
+	case "configuration.unmanaged":
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return OptionalBool(configuration(repository).Unmanaged)
+		}, true
+	case "configuration.requireIssue":
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return OptionalBool(configuration(repository).RequireIssue)
+		}, true
+	case "configuration.commitMessageType":
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return OptionalValue(configuration(repository).CommitMessageType)
+		}, true
+	case "configuration.requireSuccessfulBuilds":
+		return func(_ string, repository openapi.RepositoryDto) []string {
+			return OptionalInt(configuration(repository).RequireSuccessfulBuilds)
+		}, true
+	}
+	return nil, false
+}
+
+func configuration(repository openapi.RepositoryDto) openapi.RepositoryConfigurationDto {
+	if repository.Configuration == nil {
+		return openapi.RepositoryConfigurationDto{}
+	}
+	return *repository.Configuration
+}
+
+// OwnerField resolves the fields that can be used in an owner query.
+func OwnerField(name string) (Field[openapi.OwnerDto], bool) {
+	switch name {
+	case "alias":
+		return func(alias string, _ openapi.OwnerDto) []string {
+			return Value(alias)
+		}, true
+	case "contact":
+		return func(_ string, owner openapi.OwnerDto) []string {
+			return Value(owner.Contact)
+		}, true
+	case "displayName":
+		return func(_ string, owner openapi.OwnerDto) []string {
+			return OptionalValue(owner.DisplayName)
+		}, true
+	case "productOwner":
+		return func(_ string, owner openapi.OwnerDto) []string {
+			return OptionalValue(owner.ProductOwner)
+		}, true
+	case "teamsChannelURL":
+		return func(_ string, owner openapi.OwnerDto) []string {
+			return OptionalValue(owner.TeamsChannelURL)
+		}, true
+	case "defaultJiraProject":
+		return func(_ string, owner openapi.OwnerDto) []string {
+			return OptionalValue(owner.DefaultJiraProject)
+		}, true
+	case "promoters":
+		return func(_ string, owner openapi.OwnerDto) []string {
+			return Values(owner.Promoters)
+		}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,380 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expression is a parsed query, ready to be evaluated against cached entities.
+//
+// Syntax:
+//
+//	expression := term ( OR term )*
+//	term       := factor ( AND factor )*
+//	factor     := NOT factor | '(' expression ')' | field ( '=' | '!=' ) value
+//
+// Keywords are case-insensitive. Values can be given as bare words or in double quotes, so "" matches an absent field.
+// A field with several values (e.g. tags) is equal to a value if any of its values is.
+//
+// Parentheses and NOT may be nested at most MaxDepth levels deep.
+type Expression[T any] interface {
+	Matches(key string, entity T) bool
+}
+
+// Field gives the values of a field of an entity. Absent fields should be given as a single "".
+type Field[T any] func(key string, entity T) []string
+
+// Resolver looks up a field by its name as used in queries.
+type Resolver[T any] func(name string) (Field[T], bool)
+
+// MaxDepth limits how deeply parentheses and NOT may be nested, so a query cannot make parsing or evaluation
+// recurse without bounds.
+const MaxDepth = 32
+
+// Parse parses a query. The error describes the problem in a way suitable for the client.
+func Parse[T any](query string, resolve Resolver[T]) (Expression[T], error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser[T]{
+		tokens:  tokens,
+		resolve: resolve,
+	}
+	result, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if next := p.peek(); next.kind != tokenEnd {
+		return nil, unexpected(next)
+	}
+	return result, nil
+}
+
+// All gives an expression that matches every entity, for when no query was given.
+func All[T any]() Expression[T] {
+	return &all[T]{}
+}
+
+// --- evaluation ---
+
+type all[T any] struct{}
+
+func (e *all[T]) Matches(_ string, _ T) bool {
+	return true
+}
+
+type or[T any] struct {
+	operands []Expression[T]
+}
+
+func (e *or[T]) Matches(key string, entity T) bool {
+	for _, operand := range e.operands {
+		if operand.Matches(key, entity) {
+			return true
+		}
+	}
+	return false
+}
+
+type and[T any] struct {
+	operands []Expression[T]
+}
+
+func (e *and[T]) Matches(key string, entity T) bool {
+	for _, operand := range e.operands {
+		if !operand.Matches(key, entity) {
+			return false
+		}
+	}
+	return true
+}
+
+type not[T any] struct {
+	operand Expression[T]
+}
+
+func (e *not[T]) Matches(key string, entity T) bool {
+	return !e.operand.Matches(key, entity)
+}
+
+type equals[T any] struct {
+	field Field[T]
+	value string
+}
+
+func (e *equals[T]) Matches(key string, entity T) bool {
+	for _, value := range e.field(key, entity) {
+		if value == e.value {
+			return true
+		}
+	}
+	return false
+}
+
+// --- parsing ---
+
+type parser[T any] struct {
+	tokens  []token
+	pos     int
+	resolve Resolver[T]
+	depth   int
+}
+
+func (p *parser[T]) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser[T]) next() token {
+	result := p.tokens[p.pos]
+	if result.kind != tokenEnd {
+		p.pos++
+	}
+	return result
+}
+
+func (p *parser[T]) expression() (Expression[T], error) {
+	first, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	operands := []Expression[T]{first}
+	for p.peek().isKeyword("OR") {
+		p.next()
+		operand, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &or[T]{operands: operands}, nil
+}
+
+func (p *parser[T]) term() (Expression[T], error) {
+	first, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	operands := []Expression[T]{first}
+	for p.peek().isKeyword("AND") {
+		p.next()
+		operand, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &and[T]{operands: operands}, nil
+}
+
+// enter counts one more level of nesting, started by tok. Call leave once done with it.
+func (p *parser[T]) enter(tok token) error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return fmt.Errorf("query nested deeper than %d levels at position %d", MaxDepth, tok.offset+1)
+	}
+	return nil
+}
+
+func (p *parser[T]) leave() {
+	p.depth--
+}
+
+func (p *parser[T]) factor() (Expression[T], error) {
+	tok := p.next()
+	if tok.isKeyword("NOT") || tok.kind == tokenOpen {
+		if err := p.enter(tok); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+	}
+
+	switch {
+	case tok.isKeyword("NOT"):
+		operand, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return &not[T]{operand: operand}, nil
+	case tok.kind == tokenOpen:
+		inner, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenClose {
+			return nil, unexpected(closing)
+		}
+		return inner, nil
+	case tok.kind == tokenWord && !tok.isAnyKeyword():
+		return p.comparison(tok)
+	default:
+		return nil, unexpected(tok)
+	}
+}
+
+func (p *parser[T]) comparison(fieldToken token) (Expression[T], error) {
+	field, ok := p.resolve(fieldToken.text)
+	if !ok {
+		return nil, fmt.Errorf("unknown field '%s' at position %d", fieldToken.text, fieldToken.offset+1)
+	}
+
+	operator := p.next()
+	if operator.kind != tokenEquals && operator.kind != tokenNotEquals {
+		return nil, unexpected(operator)
+	}
+
+	value := p.next()
+	if value.kind != tokenString && (value.kind != tokenWord || value.isAnyKeyword()) {
+		return nil, unexpected(value)
+	}
+
+	var result Expression[T] = &equals[T]{field: field, value: value.text}
+	if operator.kind == tokenNotEquals {
+		result = &not[T]{operand: result}
+	}
+	return result, nil
+}
+
+func unexpected(tok token) error {
+	if tok.kind == tokenEnd {
+		return errors.New("unexpected end of query")
+	}
+	return fmt.Errorf("unexpected '%s' at position %d", tok.text, tok.offset+1)
+}
+
+// --- tokenizing ---
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOpen
+	tokenClose
+	tokenEquals
+	tokenNotEquals
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func (t token) isKeyword(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func (t token) isAnyKeyword() bool {
+	return t.isKeyword("AND") || t.isKeyword("OR") || t.isKeyword("NOT")
+}
+
+func tokenize(query string) ([]token, error) {
+	result := make([]token, 0)
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			result = append(result, token{kind: tokenOpen, text: "(", offset: i})
+			i++
+		case r == ')':
+			result = append(result, token{kind: tokenClose, text: ")", offset: i})
+			i++
+		case r == '=':
+			result = append(result, token{kind: tokenEquals, text: "=", offset: i})
+			i++
+		case r == '!':
+			if i+1 >= len(runes) || runes[i+1] != '=' {
+				return nil, fmt.Errorf("unexpected '!' at position %d, did you mean '!='", i+1)
+			}
+			result = append(result, token{kind: tokenNotEquals, text: "!=", offset: i})
+			i += 2
+		case r == '"':
+			start := i
+			var text strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				text.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string starting at position %d", start+1)
+			}
+			i++
+			result = append(result, token{kind: tokenString, text: text.String(), offset: start})
+		default:
+			start := i
+			for ; i < len(runes) && isWordRune(runes[i]); i++ {
+			}
+			result = append(result, token{kind: tokenWord, text: string(runes[start:i]), offset: start})
+		}
+	}
+	return append(result, token{kind: tokenEnd, offset: len(runes)}), nil
+}
+
+func isWordRune(r rune) bool {
+	return !unicode.IsSpace(r) && r != '(' && r != ')' && r != '=' && r != '!' && r != '"'
+}
+
+// --- helpers for fields ---
+
+// Value gives a plain string field.
+func Value(value string) []string {
+	return []string{value}
+}
+
+// OptionalValue gives an optional string field, absent as "".
+func OptionalValue(value *string) []string {
+	if value == nil {
+		return []string{""}
+	}
+	return []string{*value}
+}
+
+// OptionalBool gives an optional boolean field as "true" or "false", absent counts as false.
+func OptionalBool(value *bool) []string {
+	if value != nil && *value {
+		return []string{"true"}
+	}
+	return []string{"false"}
+}
+
+// OptionalInt gives an optional integer field, absent as "0".
+func OptionalInt(value *int32) []string {
+	if value == nil {
+		return []string{"0"}
+	}
+	return []string{strconv.Itoa(int(*value))}
+}
+
+// Values gives a list field, an empty list as "".
+func Values(values []string) []string {
+	if len(values) == 0 {
+		return []string{""}
+	}
+	return values
+}
+
+// Label gives the value of a label, absent as "".
+func Label(labels *map[string]string, name string) []string {
+	if labels == nil {
+		return []string{""}
+	}
+	return []string{(*labels)[name]}
+}
@@ -0,0 +1,97 @@
+package query
+
+import (
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+type tstEntity struct {
+	owner  string
+	tags   []string
+	labels map[string]string
+}
+
+func tstResolve(name string) (Field[tstEntity], bool) {
+	switch {
+	case name == "name":
+		return func(key string, _ tstEntity) []string { return []string{key} }, true
+	case name == "owner":
+		return func(_ string, e tstEntity) []string { return []string{e.owner} }, true
+	case name == "tags":
+		return func(_ string, e tstEntity) []string { return e.tags }, true
+	case strings.HasPrefix(name, "labels."):
+		label := strings.TrimPrefix(name, "labels.")
+		return func(_ string, e tstEntity) []string { return []string{e.labels[label]} }, true
+	}
+	return nil, false
+}
+
+var tstEntities = map[string]tstEntity{
+	"alpha": {owner: "payments", tags: []string{"java", "backend"}, labels: map[string]string{"team": "pay"}},
+	"beta":  {owner: "payments", tags: []string{"frontend"}},
+	"gamma": {owner: "search", tags: []string{"java"}, labels: map[string]string{"team": "find"}},
+}
+
+func tstMatching(t *testing.T, query string) []string {
+	expression, err := Parse[tstEntity](query, tstResolve)
+	require.Nil(t, err)
+
+	result := make([]string, 0)
+	for _, key := range []string{"alpha", "beta", "gamma"} {
+		if expression.Matches(key, tstEntities[key]) {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+func TestParse_Comparisons(t *testing.T) {
+	require.Equal(t, []string{"alpha", "beta"}, tstMatching(t, "owner=payments"))
+	require.Equal(t, []string{"gamma"}, tstMatching(t, "owner != payments"))
+	require.Equal(t, []string{"alpha", "gamma"}, tstMatching(t, "tags=java"))
+	require.Equal(t, []string{"gamma"}, tstMatching(t, `labels.team="find"`))
+	require.Equal(t, []string{"beta"}, tstMatching(t, `labels.team=""`))
+}
+
+func TestParse_Precedence(t *testing.T) {
+	// AND binds stronger than OR
+	require.Equal(t, []string{"alpha", "beta", "gamma"}, tstMatching(t, "owner=search OR owner=payments AND tags=frontend OR name=alpha"))
+	require.Equal(t, []string{"beta"}, tstMatching(t, "(owner=search OR owner=payments) AND tags=frontend"))
+	require.Equal(t, []string{"beta"}, tstMatching(t, "owner=payments and not tags=java"))
+	require.Equal(t, []string{"beta", "gamma"}, tstMatching(t, "NOT (owner=payments AND tags=java)"))
+}
+
+func TestParse_Errors(t *testing.T) {
+	for query, expected := range map[string]string{
+		"":                    "unexpected end of query",
+		"colour=blue":         "unknown field 'colour' at position 1",
+		"owner=":              "unexpected end of query",
+		"owner payments":      "unexpected 'payments' at position 7",
+		"(owner=payments":     "unexpected end of query",
+		"owner=payments)":     "unexpected ')' at position 15",
+		"owner=a AND":         "unexpected end of query",
+		`owner="payments`:     "unterminated string starting at position 7",
+		"owner!payments":      "unexpected '!' at position 6, did you mean '!='",
+		"owner=payments OR x": "unknown field 'x' at position 19",
+	} {
+		_, err := Parse[tstEntity](query, tstResolve)
+		require.NotNil(t, err, query)
+		require.Equal(t, expected, err.Error(), query)
+	}
+}
+
+func TestParse_Nesting(t *testing.T) {
+	nested := strings.Repeat("(", MaxDepth) + "owner=payments" + strings.Repeat(")", MaxDepth)
+	require.Equal(t, []string{"alpha", "beta"}, tstMatching(t, nested))
+	negated := strings.Repeat("NOT ", MaxDepth) + "owner=payments"
+	require.Equal(t, []string{"alpha", "beta"}, tstMatching(t, negated))
+
+	_, err := Parse[tstEntity]("("+nested+")", tstResolve)
+	require.NotNil(t, err)
+	require.Equal(t, "query nested deeper than 32 levels at position 33", err.Error())
+
+	_, err = Parse[tstEntity](strings.Repeat("NOT (", 10000)+"owner=payments", tstResolve)
+	require.NotNil(t, err)
+	require.Equal(t, "query nested deeper than 32 levels at position 81", err.Error())
+}
@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/service/query"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+)
+
+func (s *Impl) parseQuery(ctx context.Context, queryFilter string) (query.Expression[openapi.RepositoryDto], error) {
+	if queryFilter == "" {
+		return query.All[openapi.RepositoryDto](), nil
+	}
+	result, err := query.Parse[openapi.RepositoryDto](queryFilter, query.RepositoryField)
+	if err != nil {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("repository query invalid: %s", err.Error())
+		return nil, apierrors.NewBadRequestError("repository.invalid.query", fmt.Sprintf("query error: %s", err.Error()), nil, s.Timestamp.Now())
+	}
+	return result, nil
+}
@@ -8,10 +8,12 @@ import (
 	"github.com/Interhyp/metadata-service/internal/acorn/repository"
 	"github.com/Interhyp/metadata-service/internal/acorn/service"
 	"github.com/Interhyp/metadata-service/internal/service/util"
+	"github.com/Interhyp/metadata-service/internal/types"
 	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
 	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
 	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
 	"net/url"
+	"sort"
 	"strings"
 )
 
@@ -23,6 +25,7 @@ type Impl struct {
 	Cache               repository.Cache
 	Updater             service.Updater
 	Owners              service.Owners
+	Policies            service.Policies
 }
 
 func New(
@@ -33,6 +36,7 @@ func New(
 	cache repository.Cache,
 	updater service.Updater,
 	owners service.Owners,
+	policies service.Policies,
 ) service.Repositories {
 	return &Impl{
 		Configuration:       configuration,
@@ -42,6 +46,7 @@ func New(
 		Cache:               cache,
 		Updater:             updater,
 		Owners:              owners,
+		Policies:            policies,
 	}
 }
 
@@ -70,8 +75,24 @@ func (s *Impl) ValidRepositoryKey(ctx context.Context, key string) apierrors.Ann
 	max := s.CustomConfiguration.RepositoryNameMaxLength()
 	repoTypes := s.CustomConfiguration.RepositoryTypes()
 	separator := s.CustomConfiguration.RepositoryKeySeparator()
-	details := fmt.Sprintf("repository name must match %s, is not allowed to match %s and may have up to %d characters; repository type must be one of %v and name and type must be separated by a %s character", permitted, prohibited, max, repoTypes, separator)
-	return apierrors.NewBadRequestError("repository.invalid", details, nil, s.Timestamp.Now())
+	violations := util.NewViolations("repository")
+	violations.Add("", util.ProblemInvalid, fmt.Sprintf("repository name must match %s, is not allowed to match %s and may have up to %d characters; repository type must be one of %v and name and type must be separated by a %s character", permitted, prohibited, max, repoTypes, separator), map[string]string{
+		"key": key,
+	})
+	return violations.Error(s.Timestamp.Now()).(apierrors.AnnotatedError)
+}
+
+// validateOwnerReference checks that the owner of a repository exists. Must be called while holding the metadata lock.
+func (s *Impl) validateOwnerReference(ctx context.Context, ownerAlias string) error {
+	if _, err := s.Cache.GetOwner(ctx, ownerAlias); err != nil {
+		violations := util.NewViolations("repository")
+		violations.Add("owner", util.ProblemInvalid, fmt.Sprintf("no such owner: %s", ownerAlias), map[string]string{
+			"owner": ownerAlias,
+		})
+		s.Logging.Logger().Ctx(ctx).Info().Printf("repository values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
 }
 
 func (s *Impl) validRepositoryName(name string) bool {
@@ -91,7 +112,8 @@ func (s *Impl) validRepositoryType(repoType string) bool {
 
 func (s *Impl) GetRepositories(ctx context.Context,
 	ownerAliasFilter string, serviceNameFilter string,
-	nameFilter string, typeFilter string,
+	nameFilter string, typeFilter string, queryFilter string,
+	page types.PageRequest,
 ) (openapi.RepositoryListDto, error) {
 	result := openapi.RepositoryListDto{
 		Repositories: make(map[string]openapi.RepositoryDto),
@@ -120,7 +142,18 @@ func (s *Impl) GetRepositories(ctx context.Context,
 	if err != nil {
 		return openapi.RepositoryListDto{}, err
 	}
-	for _, key := range keys {
+	pager, err := util.NewPager(keys, stamp, page, s.Timestamp.Now())
+	if err != nil {
+		return openapi.RepositoryListDto{}, err
+	}
+	matcher, err := s.parseQuery(ctx, queryFilter)
+	if err != nil {
+		return openapi.RepositoryListDto{}, err
+	}
+	for i, key := range pager.Keys() {
+		if pager.Full() {
+			break
+		}
 		if !useReferencedRepositoriesMap || referencedRepositoriesMap[key] {
 			repository, err := s.GetRepository(ctx, key)
 			if err != nil {
@@ -139,14 +172,17 @@ func (s *Impl) GetRepositories(ctx context.Context,
 
 				if ownerAliasFilter == "" || ownerAliasFilter == repository.Owner {
 					if nameFilter == "" || nameFilter == keyName {
-						if typeFilter == "" || typeFilter == keyType {
+						if (typeFilter == "" || typeFilter == keyType) && matcher.Matches(key, repository) {
 							result.Repositories[key] = repository
+							pager.Take(i)
 						}
 					}
 				}
 			}
 		}
 	}
+	result.Order = pager.Taken()
+	result.NextCursor = pager.NextCursor()
 	return result, nil
 }
 
@@ -180,6 +216,56 @@ func (s *Impl) GetRepository(ctx context.Context, repoKey string) (openapi.Repos
 	return repositoryDto, err
 }
 
+func (s *Impl) GetRepositoriesAt(ctx context.Context, at string,
+	ownerAliasFilter string, serviceNameFilter string,
+	nameFilter string, typeFilter string, queryFilter string,
+	page types.PageRequest,
+) (openapi.RepositoryListDto, error) {
+	historic, err := s.snapshotAt(ctx, at)
+	if err != nil {
+		return openapi.RepositoryListDto{}, err
+	}
+	result, err := historic.GetRepositories(ctx, ownerAliasFilter, serviceNameFilter, nameFilter, typeFilter, queryFilter, page)
+	if apierrors.IsNotFoundError(err) {
+		// acceptable case - no matching repositories, so return empty list
+		return result, nil
+	}
+	return result, err
+}
+
+func (s *Impl) GetRepositoryAt(ctx context.Context, at string, repoKey string) (openapi.RepositoryDto, error) {
+	historic, err := s.snapshotAt(ctx, at)
+	if err != nil {
+		return openapi.RepositoryDto{}, err
+	}
+	return historic.GetRepository(ctx, repoKey)
+}
+
+// snapshotAt gives a copy of this component that reads from the metadata as of a past commit instead of the cache.
+//
+// Only use it for reads.
+func (s *Impl) snapshotAt(ctx context.Context, at string) (*Impl, error) {
+	cache, err := s.Updater.SnapshotAt(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	historic := *s
+	historic.Cache = cache
+	return &historic, nil
+}
+
+func (s *Impl) GetRepositoryHistory(ctx context.Context, repoKey string) (openapi.HistoryDto, error) {
+	result, err := s.Updater.GetRepositoryHistory(ctx, repoKey)
+	if err != nil {
+		return result, err
+	}
+	if len(result.Entries) == 0 {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("repository %v not found", repoKey)
+		return result, apierrors.NewNotFoundError("repository.notfound", fmt.Sprintf("repository %s not found", repoKey), nil, s.Timestamp.Now())
+	}
+	return result, nil
+}
+
 func (s *Impl) expandApprovers(ctx context.Context, approvers *map[string][]string) {
 	if approvers != nil {
 		for name, approverList := range *approvers {
@@ -224,17 +310,20 @@ func (s *Impl) CreateRepository(ctx context.Context, key string, repositoryCreat
 			return apierrors.NewConflictErrorWithResponse("repository.conflict.alreadyexists", fmt.Sprintf("repository %s already exists - cannot create", key), nil, result, s.Timestamp.Now())
 		}
 
-		_, err = s.Cache.GetOwner(subCtx, repositoryDto.Owner)
+		if err := s.validateOwnerReference(subCtx, repositoryDto.Owner); err != nil {
+			return err
+		}
+
+		warnings, err := s.Policies.CheckRepository(subCtx, key, repositoryDto)
 		if err != nil {
-			details := fmt.Sprintf("no such owner: %s", repositoryDto.Owner)
-			s.Logging.Logger().Ctx(ctx).Info().Printf(details)
-			return apierrors.NewBadRequestError("repository.invalid.missing.owner", details, err, s.Timestamp.Now())
+			return err
 		}
 
 		repositoryWritten, err := s.Updater.WriteRepository(subCtx, key, repositoryDto)
 		if err != nil {
 			return err
 		}
+		repositoryWritten.PolicyWarnings = warnings
 
 		result = repositoryWritten
 		return nil
@@ -257,23 +346,22 @@ func (s *Impl) mapRepoCreateDtoToRepoDto(repositoryCreateDto openapi.RepositoryC
 }
 
 func (s *Impl) validateRepositoryCreateDto(ctx context.Context, key string, dto openapi.RepositoryCreateDto) error {
-	messages := make([]string, 0)
+	violations := util.NewViolations("repository")
 
-	messages = validateOwner(messages, dto.Owner)
-	messages = validateUrl(messages, dto.Url)
-	messages = validateMainline(messages, dto.Mainline)
+	validateOwner(violations, dto.Owner)
+	validateUrl(violations, dto.Url)
+	validateMainline(violations, dto.Mainline)
 
 	if dto.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory")
 	}
 	if dto.Filecategory != nil {
-		messages = s.validateFilecategory(messages, *dto.Filecategory)
+		s.validateFilecategory(violations, *dto.Filecategory)
 	}
 
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("repository values invalid: %s", details)
-		return apierrors.NewBadRequestError("repository.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("repository values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
@@ -296,22 +384,30 @@ func (s *Impl) UpdateRepository(ctx context.Context, key string, repositoryDto o
 			return apierrors.NewNotFoundError("repository.notfound", fmt.Sprintf("repository %s not found", key), nil, s.Timestamp.Now())
 		}
 
-		_, err = s.Cache.GetOwner(subCtx, repositoryDto.Owner)
-		if err != nil {
-			s.Logging.Logger().Ctx(ctx).Info().Printf("owner %v not found", repositoryDto.Owner)
-			return apierrors.NewBadRequestError("repository.invalid.missing.owner", fmt.Sprintf("no such owner: %s", repositoryDto.Owner), nil, s.Timestamp.Now())
+		if err := s.validateOwnerReference(subCtx, repositoryDto.Owner); err != nil {
+			return err
 		}
 
-		if current.TimeStamp != repositoryDto.TimeStamp || current.CommitHash != repositoryDto.CommitHash {
+		basedOn := util.Version{TimeStamp: repositoryDto.TimeStamp, CommitHash: repositoryDto.CommitHash}
+		if err := util.CheckVersion(ctx, "repository", key, current, util.Version{TimeStamp: current.TimeStamp, CommitHash: current.CommitHash}, basedOn, s.Timestamp.Now()); err != nil {
 			result = current
-			s.Logging.Logger().Ctx(ctx).Info().Printf("repository %v was concurrently updated", key)
-			return apierrors.NewConflictErrorWithResponse("repository.conflict.concurrentlyupdated", fmt.Sprintf("repository %v was concurrently updated", key), nil, result, s.Timestamp.Now())
+			return err
+		}
+
+		if err := s.validateDeprecation(subCtx, key, repositoryDto); err != nil {
+			return err
+		}
+
+		warnings, err := s.Policies.CheckRepository(subCtx, key, repositoryDto)
+		if err != nil {
+			return err
 		}
 
 		repositoryWritten, err := s.Updater.WriteRepository(subCtx, key, repositoryDto)
 		if err != nil {
 			return err
 		}
+		repositoryWritten.PolicyWarnings = warnings
 
 		result = repositoryWritten
 		return nil
@@ -319,30 +415,92 @@ func (s *Impl) UpdateRepository(ctx context.Context, key string, repositoryDto o
 	return result, err
 }
 
-func (s *Impl) validateExistingRepositoryDto(ctx context.Context, key string, dto openapi.RepositoryDto) error {
-	messages := make([]string, 0)
+func (s *Impl) RevertRepository(ctx context.Context, key string, revertInfo openapi.RevertDto) (openapi.RepositoryDto, error) {
+	if err := s.validateRevertDto(ctx, revertInfo); err != nil {
+		return openapi.RepositoryDto{}, err
+	}
+
+	target, err := s.historicRepository(ctx, revertInfo.CommitHash, key)
+	if err != nil {
+		return target, err
+	}
+
+	result := target
+	err = s.Updater.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		err := s.Updater.PerformFullUpdate(subCtx)
+		if err != nil {
+			return err
+		}
+
+		current, err := s.Cache.GetRepository(subCtx, key)
+		if err != nil {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("repository %v not found", key)
+			return apierrors.NewNotFoundError("repository.notfound", fmt.Sprintf("repository %s not found", key), nil, s.Timestamp.Now())
+		}
 
-	messages = validateOwner(messages, dto.Owner)
-	messages = validateUrl(messages, dto.Url)
-	messages = validateMainline(messages, dto.Mainline)
+		// we hold the lock, so basing the update on the current version cannot conflict
+		target.TimeStamp = current.TimeStamp
+		target.CommitHash = current.CommitHash
+		target.JiraIssue = revertInfo.JiraIssue
+
+		result, err = s.UpdateRepository(subCtx, key, target)
+		return err
+	})
+	return result, err
+}
+
+// historicRepository reads the repository as stored at a past commit.
+//
+// Unlike GetRepositoryAt, approver groups are not expanded, so they are written back as they were.
+func (s *Impl) historicRepository(ctx context.Context, at string, key string) (openapi.RepositoryDto, error) {
+	historic, err := s.snapshotAt(ctx, at)
+	if err != nil {
+		return openapi.RepositoryDto{}, err
+	}
+	return historic.Cache.GetRepository(ctx, key)
+}
 
+func (s *Impl) validateRevertDto(ctx context.Context, dto openapi.RevertDto) error {
+	violations := util.NewViolations("repository")
 	if dto.CommitHash == "" {
-		messages = append(messages, "field commitHash is mandatory for updates")
+		violations.Missing("commitHash", "field commitHash is mandatory")
 	}
-	if dto.TimeStamp == "" {
-		messages = append(messages, "field timeStamp is mandatory for updates")
+	if dto.JiraIssue == "" {
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory")
+	}
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("repository revert values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
+}
+
+func (s *Impl) validateExistingRepositoryDto(ctx context.Context, key string, dto openapi.RepositoryDto) error {
+	violations := util.NewViolations("repository")
+
+	validateOwner(violations, dto.Owner)
+	validateUrl(violations, dto.Url)
+	validateMainline(violations, dto.Mainline)
+
+	if !util.IsConditional(ctx) {
+		if dto.CommitHash == "" {
+			violations.Missing("commitHash", "field commitHash is mandatory for updates")
+		}
+		if dto.TimeStamp == "" {
+			violations.Missing("timeStamp", "field timeStamp is mandatory for updates")
+		}
 	}
 	if dto.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory for updates")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory for updates")
 	}
 	if dto.Filecategory != nil {
-		messages = s.validateFilecategory(messages, *dto.Filecategory)
+		s.validateFilecategory(violations, *dto.Filecategory)
 	}
 
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("repository values invalid: %s", details)
-		return apierrors.NewBadRequestError("repository.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("repository values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
@@ -370,23 +528,30 @@ func (s *Impl) PatchRepository(ctx context.Context, key string, repositoryPatchD
 
 		repositoryDto := patchRepository(current, repositoryPatchDto)
 
-		_, err = s.Cache.GetOwner(subCtx, repositoryDto.Owner)
-		if err != nil {
-			details := fmt.Sprintf("no such owner: %s", repositoryDto.Owner)
-			s.Logging.Logger().Ctx(ctx).Info().Printf(details)
-			return apierrors.NewBadRequestError("repository.invalid.missing.owner", details, err, s.Timestamp.Now())
+		if err := s.validateOwnerReference(subCtx, repositoryDto.Owner); err != nil {
+			return err
 		}
 
-		if current.TimeStamp != repositoryPatchDto.TimeStamp || current.CommitHash != repositoryPatchDto.CommitHash {
+		basedOn := util.Version{TimeStamp: repositoryPatchDto.TimeStamp, CommitHash: repositoryPatchDto.CommitHash}
+		if err := util.CheckVersion(ctx, "repository", key, current, util.Version{TimeStamp: current.TimeStamp, CommitHash: current.CommitHash}, basedOn, s.Timestamp.Now()); err != nil {
 			result = current
-			s.Logging.Logger().Ctx(ctx).Info().Printf("repository %v was concurrently updated", key)
-			return apierrors.NewConflictErrorWithResponse("repository.conflict.concurrentlyupdated", fmt.Sprintf("repository %v was concurrently updated", key), nil, result, s.Timestamp.Now())
+			return err
+		}
+
+		if err := s.validateDeprecation(subCtx, key, repositoryDto); err != nil {
+			return err
+		}
+
+		warnings, err := s.Policies.CheckRepository(subCtx, key, repositoryDto)
+		if err != nil {
+			return err
 		}
 
 		repositoryWritten, err := s.Updater.WriteRepository(subCtx, key, repositoryDto)
 		if err != nil {
 			return err
 		}
+		repositoryWritten.PolicyWarnings = warnings
 
 		result = repositoryWritten
 		return nil
@@ -394,49 +559,107 @@ func (s *Impl) PatchRepository(ctx context.Context, key string, repositoryPatchD
 	return result, err
 }
 
+func (s *Impl) ApplyRepositoryPatch(ctx context.Context, key string, patch types.PatchDocument) (openapi.RepositoryDto, error) {
+	var result openapi.RepositoryDto
+	err := s.Updater.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		err := s.Updater.PerformFullUpdate(subCtx)
+		if err != nil {
+			return err
+		}
+
+		current, err := s.Cache.GetRepository(subCtx, key)
+		if err != nil {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("repository %v not found", key)
+			return apierrors.NewNotFoundError("repository.notfound", fmt.Sprintf("repository %s not found", key), nil, s.Timestamp.Now())
+		}
+		result = current
+
+		// the patch has to supply these, as for any other update
+		current.TimeStamp = ""
+		current.CommitHash = ""
+		current.JiraIssue = ""
+
+		target, err := util.ApplyPatch(subCtx, "repository", current, patch, s.Timestamp.Now())
+		if err != nil {
+			return err
+		}
+
+		result, err = s.UpdateRepository(subCtx, key, target)
+		return err
+	})
+	return result, err
+}
+
 func (s *Impl) validateRepositoryPatchDto(ctx context.Context, key string, patchDto openapi.RepositoryPatchDto, current openapi.RepositoryDto) error {
-	messages := make([]string, 0)
+	violations := util.NewViolations("repository")
 
 	dto := patchRepository(current, patchDto)
 
-	messages = validateOwner(messages, dto.Owner)
-	messages = validateUrl(messages, dto.Url)
-	messages = validateMainline(messages, dto.Mainline)
+	validateOwner(violations, dto.Owner)
+	validateUrl(violations, dto.Url)
+	validateMainline(violations, dto.Mainline)
 	if dto.Filecategory != nil {
-		messages = s.validateFilecategory(messages, *dto.Filecategory)
+		s.validateFilecategory(violations, *dto.Filecategory)
 	}
 
-	if patchDto.CommitHash == "" {
-		messages = append(messages, "field commitHash is mandatory for patching")
-	}
-	if patchDto.TimeStamp == "" {
-		messages = append(messages, "field timeStamp is mandatory for patching")
+	if !util.IsConditional(ctx) {
+		if patchDto.CommitHash == "" {
+			violations.Missing("commitHash", "field commitHash is mandatory for patching")
+		}
+		if patchDto.TimeStamp == "" {
+			violations.Missing("timeStamp", "field timeStamp is mandatory for patching")
+		}
 	}
 	if patchDto.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory for patching")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory for patching")
 	}
 
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("repository values invalid: %s", details)
-		return apierrors.NewBadRequestError("repository.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("repository values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
+}
+
+// validateDeprecation checks the deprecation fields, where replacedBy must refer to an existing repository.
+// Must be called while holding the metadata lock.
+func (s *Impl) validateDeprecation(ctx context.Context, key string, dto openapi.RepositoryDto) error {
+	violations := util.NewViolations("repository")
+
+	util.ValidateDeprecationDates(violations, dto.DeprecatedSince, dto.SunsetDate)
+	if dto.ReplacedBy != nil {
+		if *dto.ReplacedBy == key {
+			violations.Add("replacedBy", util.ProblemInvalid, "field replacedBy cannot refer to the repository itself", nil)
+		} else if _, err := s.Cache.GetRepository(ctx, *dto.ReplacedBy); err != nil {
+			violations.Add("replacedBy", util.ProblemInvalid, fmt.Sprintf("field replacedBy refers to a repository that does not exist: %s", *dto.ReplacedBy), map[string]string{
+				"repository": *dto.ReplacedBy,
+			})
+		}
+	}
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("repository values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
 
 func patchRepository(current openapi.RepositoryDto, patch openapi.RepositoryPatchDto) openapi.RepositoryDto {
 	return openapi.RepositoryDto{
-		Owner:         patchString(patch.Owner, current.Owner),
-		Url:           patchString(patch.Url, current.Url),
-		Mainline:      patchString(patch.Mainline, current.Mainline),
-		Generator:     patchStringPtr(patch.Generator, current.Generator),
-		Unittest:      patchPtr[bool](patch.Unittest, current.Unittest),
-		Configuration: patchConfiguration(patch.Configuration, current.Configuration),
-		Filecategory:  patchFilecategory(patch.Filecategory, current.Filecategory),
-		Labels:        patchLabels(patch.Labels, current.Labels),
-		TimeStamp:     patch.TimeStamp,
-		CommitHash:    patch.CommitHash,
-		JiraIssue:     patch.JiraIssue,
+		Owner:           patchString(patch.Owner, current.Owner),
+		Url:             patchString(patch.Url, current.Url),
+		Mainline:        patchString(patch.Mainline, current.Mainline),
+		Generator:       patchStringPtr(patch.Generator, current.Generator),
+		Unittest:        patchPtr[bool](patch.Unittest, current.Unittest),
+		Configuration:   patchConfiguration(patch.Configuration, current.Configuration),
+		Filecategory:    patchFilecategory(patch.Filecategory, current.Filecategory),
+		Labels:          patchLabels(patch.Labels, current.Labels),
+		DeprecatedSince: patchStringPtr(patch.DeprecatedSince, current.DeprecatedSince),
+		SunsetDate:      patchStringPtr(patch.SunsetDate, current.SunsetDate),
+		ReplacedBy:      patchStringPtr(patch.ReplacedBy, current.ReplacedBy),
+		TimeStamp:       patch.TimeStamp,
+		CommitHash:      patch.CommitHash,
+		JiraIssue:       patch.JiraIssue,
 	}
 }
 
@@ -616,6 +839,16 @@ func (s *Impl) DeleteRepository(ctx context.Context, key string, deletionInfo op
 			return apierrors.NewConflictError("repository.conflict.referenced", "this repository is still being referenced by a service and cannot be deleted", nil, s.Timestamp.Now())
 		}
 
+		replaced, err := s.repositoriesReplacedBy(subCtx, key)
+		if err != nil {
+			return err
+		}
+		if len(replaced) > 0 {
+			details := fmt.Sprintf("repository %s cannot be deleted while other repositories name it in replacedBy: %s", key, strings.Join(replaced, ", "))
+			s.Logging.Logger().Ctx(ctx).Info().Print(details)
+			return apierrors.NewConflictError("repository.conflict.referenced", details, nil, s.Timestamp.Now())
+		}
+
 		err = s.Updater.DeleteRepository(subCtx, key, deletionInfo)
 		if err != nil {
 			return err
@@ -625,61 +858,87 @@ func (s *Impl) DeleteRepository(ctx context.Context, key string, deletionInfo op
 	})
 }
 
+// repositoriesReplacedBy lists the repositories whose replacedBy refers to key, sorted.
+func (s *Impl) repositoriesReplacedBy(ctx context.Context, key string) ([]string, error) {
+	keys, err := s.Cache.GetSortedRepositoryKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	for _, candidate := range keys {
+		repo, err := s.Cache.GetRepository(ctx, candidate)
+		if err != nil || repo.ReplacedBy == nil {
+			continue
+		}
+		if *repo.ReplacedBy == key {
+			result = append(result, candidate)
+		}
+	}
+	return result, nil
+}
+
 func (s *Impl) validateDeletionDto(ctx context.Context, deletionInfo openapi.DeletionDto) error {
-	messages := make([]string, 0)
+	violations := util.NewViolations("deletion")
 	if deletionInfo.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory for deletion")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory for deletion")
 	}
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("deletion info values invalid: %s", details)
-		return apierrors.NewBadRequestError("deletion.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("deletion info values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
 
 // -- validation --
 
-func validateOwner(messages []string, ownerAlias string) []string {
+func validateOwner(violations *util.Violations, ownerAlias string) {
 	if ownerAlias == "" {
-		messages = append(messages, "field owner is mandatory")
+		violations.Missing("owner", "field owner is mandatory")
 	}
-
-	return messages
 }
 
-func validateUrl(messages []string, repoUrl string) []string {
+func validateUrl(violations *util.Violations, repoUrl string) {
 	if repoUrl == "" {
-		messages = append(messages, "field url is mandatory")
+		violations.Missing("url", "field url is mandatory")
 	} else {
 		if !strings.HasPrefix(repoUrl, "ssh://") {
-			messages = append(messages, "field url must contain ssh git url")
+			violations.Add("url", util.ProblemInvalid, "field url must contain ssh git url", map[string]string{"prefix": "ssh://"})
 		}
 	}
-	return messages
 }
 
-func validateMainline(messages []string, mainline string) []string {
+var validMainlines = []string{"master", "main", "develop"}
+
+func validateMainline(violations *util.Violations, mainline string) {
 	if mainline == "" {
-		messages = append(messages, "field mainline is mandatory")
+		violations.Missing("mainline", "field mainline is mandatory")
 	} else {
-		if mainline != "master" && mainline != "main" && mainline != "develop" {
-			messages = append(messages, "mainline must be one of master, main, develop")
+		if !sliceContains(validMainlines, mainline) {
+			violations.Add("mainline", util.ProblemInvalid, fmt.Sprintf("mainline must be one of %s", strings.Join(validMainlines, ", ")), map[string]string{
+				"allowed": strings.Join(validMainlines, ","),
+			})
 		}
 	}
-	return messages
 }
 
-func (s *Impl) validateFilecategory(messages []string, filecategories map[string][]string) []string {
+func (s *Impl) validateFilecategory(violations *util.Violations, filecategories map[string][]string) {
 	allowedCategories := s.CustomConfiguration.AllowedFileCategories()
 
-	for category, _ := range filecategories {
+	categories := make([]string, 0, len(filecategories))
+	for category := range filecategories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
 		if !sliceContains(allowedCategories, category) {
-			messages = append(messages, fmt.Sprintf("filecategory keys must be one of %s", strings.Join(allowedCategories, ",")))
+			violations.Add("filecategory", util.ProblemInvalid, fmt.Sprintf("filecategory keys must be one of %s", strings.Join(allowedCategories, ",")), map[string]string{
+				"category": category,
+				"allowed":  strings.Join(allowedCategories, ","),
+			})
 		}
 	}
-
-	return messages
 }
 
 func sliceContains[T comparable](haystack []T, needle T) bool {
@@ -0,0 +1,96 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	"strings"
+	"unicode"
+
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+)
+
+type Impl struct {
+	Configuration librepo.Configuration
+	Logging       librepo.Logging
+	Timestamp     librepo.Timestamp
+	SearchIndex   repository.SearchIndex
+}
+
+func New(
+	configuration librepo.Configuration,
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	searchIndex repository.SearchIndex,
+) service.Search {
+	return &Impl{
+		Configuration: configuration,
+		Logging:       logging,
+		Timestamp:     timestamp,
+		SearchIndex:   searchIndex,
+	}
+}
+
+func (s *Impl) IsSearch() bool {
+	return true
+}
+
+func (s *Impl) Setup() error {
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+
+	// nothing to do
+
+	s.Logging.Logger().Ctx(ctx).Info().Print("successfully set up search business component")
+	return nil
+}
+
+var searchTypes = []string{repository.SearchTypeOwner, repository.SearchTypeService, repository.SearchTypeRepository}
+
+func (s *Impl) Search(ctx context.Context, query string, entityType string, limit int) (openapi.SearchResultDto, error) {
+	if err := s.validate(ctx, query, entityType); err != nil {
+		return openapi.SearchResultDto{}, err
+	}
+
+	result := openapi.SearchResultDto{
+		Hits: s.SearchIndex.Search(ctx, query, entityType),
+	}
+	if limit > 0 && len(result.Hits) > limit {
+		result.Hits = result.Hits[:limit]
+		result.Truncated = true
+	}
+	return result, nil
+}
+
+func (s *Impl) validate(ctx context.Context, query string, entityType string) error {
+	messages := make([]string, 0)
+	if strings.TrimFunc(query, isSeparator) == "" {
+		messages = append(messages, "query must contain at least one word")
+	}
+	if entityType != "" && !contains(searchTypes, entityType) {
+		messages = append(messages, fmt.Sprintf("type must be one of %s", strings.Join(searchTypes, ", ")))
+	}
+
+	if len(messages) > 0 {
+		details := strings.Join(messages, ", ")
+		s.Logging.Logger().Ctx(ctx).Info().Printf("search values invalid: %s", details)
+		return apierrors.NewBadRequestError("search.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	}
+	return nil
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
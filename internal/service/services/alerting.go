@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+)
+
+func (s *Impl) GetServiceAlertRouting(ctx context.Context, serviceName string) (openapi.AlertRoutingDto, error) {
+	serviceDto, err := s.GetService(ctx, serviceName)
+	if err != nil {
+		return openapi.AlertRoutingDto{}, err
+	}
+
+	target, err := s.AlertTargets.Parse(serviceDto.AlertTarget)
+	if err != nil {
+		details := fmt.Sprintf("alert target of service %s is invalid: %s", serviceName, err.Error())
+		s.Logging.Logger().Ctx(ctx).Info().Print(details)
+		return openapi.AlertRoutingDto{}, apierrors.NewConflictError("service.conflict.alerttarget", details, err, s.Timestamp.Now())
+	}
+
+	return openapi.AlertRoutingDto{
+		Service: serviceName,
+		Owner:   serviceDto.Owner,
+		Kind:    target.Kind,
+		Target:  target.Value,
+	}, nil
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"github.com/Interhyp/metadata-service/internal/service/util"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+)
+
+const (
+	DirectionUpstream   = "upstream"
+	DirectionDownstream = "downstream"
+	DirectionBoth       = "both"
+)
+
+// dependencyGraph is the adjacency list representation of spec.dependsOn, built from the cache.
+//
+// An edge from -> to means that service "from" depends on service "to", so "to" is upstream of "from".
+type dependencyGraph struct {
+	nodes      map[string]bool
+	upstream   map[string][]string
+	downstream map[string][]string
+	services   map[string]openapi.ServiceDto
+}
+
+func (s *Impl) GetServiceDependencyGraph(ctx context.Context) (openapi.ServiceDependencyGraphDto, error) {
+	stamp, err := s.Cache.GetServiceListTimestamp(ctx)
+	if err != nil {
+		return openapi.ServiceDependencyGraphDto{}, err
+	}
+
+	graph, err := s.buildDependencyGraph(ctx)
+	if err != nil {
+		return openapi.ServiceDependencyGraphDto{}, err
+	}
+
+	return graph.toDto(graph.nodes, func(string, string) bool { return true }, stamp), nil
+}
+
+func (s *Impl) GetServiceDependencyClosure(ctx context.Context, serviceName string, direction string) (openapi.ServiceDependencyGraphDto, error) {
+	if direction == "" {
+		direction = DirectionBoth
+	}
+	if direction != DirectionUpstream && direction != DirectionDownstream && direction != DirectionBoth {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("invalid dependency direction %v", direction)
+		return openapi.ServiceDependencyGraphDto{}, apierrors.NewBadRequestError("service.invalid.direction", fmt.Sprintf("direction must be one of %s, %s, %s", DirectionUpstream, DirectionDownstream, DirectionBoth), nil, s.Timestamp.Now())
+	}
+
+	stamp, err := s.Cache.GetServiceListTimestamp(ctx)
+	if err != nil {
+		return openapi.ServiceDependencyGraphDto{}, err
+	}
+
+	graph, err := s.buildDependencyGraph(ctx)
+	if err != nil {
+		return openapi.ServiceDependencyGraphDto{}, err
+	}
+
+	if _, ok := graph.services[serviceName]; !ok {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service %v not found", serviceName)
+		return openapi.ServiceDependencyGraphDto{}, apierrors.NewNotFoundError("service.notfound", fmt.Sprintf("service %s not found", serviceName), nil, s.Timestamp.Now())
+	}
+
+	upstream := map[string]bool{}
+	downstream := map[string]bool{}
+	if direction != DirectionDownstream {
+		upstream = graph.closure(serviceName, graph.upstream)
+	}
+	if direction != DirectionUpstream {
+		downstream = graph.closure(serviceName, graph.downstream)
+	}
+
+	nodes := map[string]bool{serviceName: true}
+	for name := range upstream {
+		nodes[name] = true
+	}
+	for name := range downstream {
+		nodes[name] = true
+	}
+
+	edgeFilter := func(from string, to string) bool {
+		return (direction != DirectionDownstream && (from == serviceName || upstream[from])) ||
+			(direction != DirectionUpstream && (to == serviceName || downstream[to]))
+	}
+	return graph.toDto(nodes, edgeFilter, stamp), nil
+}
+
+func (s *Impl) GetServiceApiIndex(ctx context.Context) (openapi.ServiceApiIndexDto, error) {
+	stamp, err := s.Cache.GetServiceListTimestamp(ctx)
+	if err != nil {
+		return openapi.ServiceApiIndexDto{}, err
+	}
+
+	graph, err := s.buildDependencyGraph(ctx)
+	if err != nil {
+		return openapi.ServiceApiIndexDto{}, err
+	}
+
+	result := openapi.ServiceApiIndexDto{
+		Apis:      make(map[string]openapi.ServiceApiUsageDto),
+		TimeStamp: stamp,
+	}
+	usage := func(api string) openapi.ServiceApiUsageDto {
+		entry, ok := result.Apis[api]
+		if !ok {
+			entry = openapi.ServiceApiUsageDto{
+				Providers: make([]string, 0),
+				Consumers: make([]string, 0),
+			}
+		}
+		return entry
+	}
+
+	for _, name := range sortedKeys(graph.services) {
+		spec := graph.services[name].Spec
+		if spec == nil {
+			continue
+		}
+		for _, api := range uniqueStrings(spec.ProvidesApis) {
+			entry := usage(api)
+			entry.Providers = append(entry.Providers, name)
+			result.Apis[api] = entry
+		}
+		for _, api := range uniqueStrings(spec.ConsumesApis) {
+			entry := usage(api)
+			entry.Consumers = append(entry.Consumers, name)
+			result.Apis[api] = entry
+		}
+	}
+	return result, nil
+}
+
+// --- helpers
+
+func (s *Impl) buildDependencyGraph(ctx context.Context) (*dependencyGraph, error) {
+	graph := &dependencyGraph{
+		nodes:      make(map[string]bool),
+		upstream:   make(map[string][]string),
+		downstream: make(map[string][]string),
+		services:   make(map[string]openapi.ServiceDto),
+	}
+
+	names, err := s.Cache.GetSortedServiceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		theService, err := s.Cache.GetService(ctx, name)
+		if err != nil {
+			// service not found errors are ok, the cache may have been changed concurrently, just drop the entry
+			if !apierrors.IsNotFoundError(err) {
+				return nil, err
+			}
+			continue
+		}
+		graph.services[name] = theService
+		graph.nodes[name] = true
+	}
+
+	for _, name := range sortedKeys(graph.services) {
+		spec := graph.services[name].Spec
+		if spec == nil {
+			continue
+		}
+		for _, dependency := range uniqueStrings(spec.DependsOn) {
+			// dependencies on unknown services are kept, so they show up in the graph
+			graph.nodes[dependency] = true
+			graph.upstream[name] = append(graph.upstream[name], dependency)
+			graph.downstream[dependency] = append(graph.downstream[dependency], name)
+		}
+	}
+	return graph, nil
+}
+
+// closure returns all nodes transitively reachable from start along the given edges, excluding start itself.
+func (g *dependencyGraph) closure(start string, edges map[string][]string) map[string]bool {
+	result := make(map[string]bool)
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range edges[current] {
+			if !result[next] {
+				result[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	delete(result, start)
+	return result
+}
+
+func (g *dependencyGraph) toDto(nodes map[string]bool, edgeFilter func(string, string) bool, stamp string) openapi.ServiceDependencyGraphDto {
+	result := openapi.ServiceDependencyGraphDto{
+		Services:     make([]openapi.ServiceDependencyNodeDto, 0),
+		Dependencies: make([]openapi.ServiceDependencyEdgeDto, 0),
+		TimeStamp:    stamp,
+	}
+	for _, name := range sortedKeys(nodes) {
+		node := openapi.ServiceDependencyNodeDto{
+			Name: name,
+		}
+		if theService, ok := g.services[name]; ok {
+			owner := theService.Owner
+			node.Owner = &owner
+			node.Lifecycle = theService.Lifecycle
+		}
+		result.Services = append(result.Services, node)
+	}
+	for _, from := range sortedKeys(nodes) {
+		for _, to := range g.upstream[from] {
+			if nodes[to] && edgeFilter(from, to) {
+				result.Dependencies = append(result.Dependencies, openapi.ServiceDependencyEdgeDto{
+					From: from,
+					To:   to,
+				})
+			}
+		}
+	}
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// addedStrings returns the values that are not in previous, in order.
+func addedStrings(values []string, previous []string) []string {
+	known := make(map[string]bool)
+	for _, v := range previous {
+		known[v] = true
+	}
+	result := make([]string, 0)
+	for _, v := range values {
+		if !known[v] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// validateSpecReferences checks spec.dependsOn and spec.consumesApis of a service that is about to be written
+// against the cache. Must be called while holding the metadata lock.
+//
+// Only references added by the write are checked, against the spec of the service before the write (nil for
+// new services), so services that already have dangling references can still be changed.
+//
+// Dependencies must refer to existing services whose lifecycle state allows depending on them, and must
+// not introduce a cycle. Consumed apis that no service provides are ignored, logged or rejected depending
+// on configuration.
+func (s *Impl) validateSpecReferences(ctx context.Context, serviceName string, previous *openapi.ServiceSpecDto, spec *openapi.ServiceSpecDto) error {
+	if spec == nil {
+		return nil
+	}
+	previousDependsOn := make([]string, 0)
+	previousConsumesApis := make([]string, 0)
+	if previous != nil {
+		previousDependsOn = previous.DependsOn
+		previousConsumesApis = previous.ConsumesApis
+	}
+
+	violations := util.NewViolations("service")
+
+	dependsOn := uniqueStrings(spec.DependsOn)
+	addedDependsOn := addedStrings(dependsOn, previousDependsOn)
+	for _, dependency := range addedDependsOn {
+		if dependency == serviceName {
+			continue // reported as a cycle below
+		}
+		field := fmt.Sprintf("spec.dependsOn.%d", indexOf(spec.DependsOn, dependency))
+		dependencyService, err := s.Cache.GetService(ctx, dependency)
+		if err != nil {
+			violations.Add(field, util.ProblemInvalid, fmt.Sprintf("you referenced a service that does not exist: no such instance: %s", dependency), map[string]string{
+				"service": dependency,
+			})
+		} else if s.lifecycleState(dependencyService.Lifecycle).ProhibitsDependents {
+			violations.Add(field, util.ProblemInvalid, fmt.Sprintf("you referenced a service that cannot be depended on in lifecycle %s: %s", *dependencyService.Lifecycle, dependency), map[string]string{
+				"service":   dependency,
+				"lifecycle": *dependencyService.Lifecycle,
+			})
+		}
+	}
+
+	graph, err := s.buildDependencyGraph(ctx)
+	if err != nil {
+		return err
+	}
+	// replace any cached dependencies of this service with the ones about to be written
+	graph.upstream[serviceName] = dependsOn
+	if cycle := graph.cycle(serviceName, addedDependsOn); cycle != nil {
+		violations.Add(fmt.Sprintf("spec.dependsOn.%d", indexOf(spec.DependsOn, cycle[1])), util.ProblemInvalid, fmt.Sprintf("dependency cycle detected: %s", strings.Join(cycle, " -> ")), map[string]string{
+			"cycle": strings.Join(cycle, ","),
+		})
+	}
+
+	mode := s.CustomConfiguration.ServiceConsumedApiValidation()
+	if mode != config.ConsumedApiValidationIgnore {
+		provided := make(map[string]bool)
+		for _, api := range spec.ProvidesApis {
+			provided[api] = true
+		}
+		for name, theService := range graph.services {
+			if name != serviceName && theService.Spec != nil {
+				for _, api := range theService.Spec.ProvidesApis {
+					provided[api] = true
+				}
+			}
+		}
+		unprovided := make([]string, 0)
+		for _, api := range addedStrings(uniqueStrings(spec.ConsumesApis), previousConsumesApis) {
+			if !provided[api] {
+				unprovided = append(unprovided, api)
+			}
+		}
+		if mode == config.ConsumedApiValidationReject {
+			for _, api := range unprovided {
+				violations.Add(fmt.Sprintf("spec.consumesApis.%d", indexOf(spec.ConsumesApis, api)), util.ProblemInvalid, fmt.Sprintf("you referenced an api that no service provides: %s", api), map[string]string{
+					"api": api,
+				})
+			}
+		} else if len(unprovided) > 0 {
+			s.Logging.Logger().Ctx(ctx).Warn().Printf("service %s consumes apis that no service provides: %s", serviceName, strings.Join(unprovided, ", "))
+		}
+	}
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
+}
+
+// indexOf returns the position of the first occurrence of value in values, or -1.
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// cycle returns a dependency cycle through start that leaves start via one of the given dependencies, as a
+// list of service names beginning and ending with start, or nil if there is none.
+func (g *dependencyGraph) cycle(start string, via []string) []string {
+	parent := make(map[string]string)
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		dependencies := g.upstream[current]
+		if current == start {
+			dependencies = via
+		}
+		for _, next := range dependencies {
+			if next == start {
+				path := []string{start}
+				for node := current; node != start; node = parent[node] {
+					path = append([]string{node}, path...)
+				}
+				return append([]string{start}, path...)
+			}
+			if _, seen := parent[next]; !seen {
+				parent[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"github.com/Interhyp/metadata-service/internal/service/util"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+)
+
+func (s *Impl) TransitionServiceLifecycle(ctx context.Context, serviceName string, transition openapi.ServiceLifecycleTransitionDto) (openapi.ServiceDto, error) {
+	if err := s.validateServiceLifecycleTransitionDto(ctx, transition); err != nil {
+		return openapi.ServiceDto{}, err
+	}
+
+	result := openapi.ServiceDto{}
+	err := s.Updater.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		err := s.Updater.PerformFullUpdate(subCtx)
+		if err != nil {
+			return err
+		}
+
+		current, err := s.Cache.GetService(subCtx, serviceName)
+		if err != nil {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("service %v not found", serviceName)
+			return apierrors.NewNotFoundError("service.notfound", fmt.Sprintf("service %s not found", serviceName), nil, s.Timestamp.Now())
+		}
+
+		basedOn := util.Version{TimeStamp: transition.TimeStamp, CommitHash: transition.CommitHash}
+		if err := util.CheckVersion(ctx, "service", serviceName, current, util.Version{TimeStamp: current.TimeStamp, CommitHash: current.CommitHash}, basedOn, s.Timestamp.Now()); err != nil {
+			result = current
+			return err
+		}
+
+		if err := s.validateLifecycleTransition(ctx, current.Lifecycle, transition.Lifecycle); err != nil {
+			result = current
+			return err
+		}
+
+		target := current
+		target.Lifecycle = &transition.Lifecycle
+		target.DeprecatedSince = patchStringPtr(transition.DeprecatedSince, current.DeprecatedSince)
+		target.SunsetDate = patchStringPtr(transition.SunsetDate, current.SunsetDate)
+		target.ReplacedBy = patchStringPtr(transition.ReplacedBy, current.ReplacedBy)
+		target.JiraIssue = transition.JiraIssue
+
+		if err := s.validateLifecycleRules(subCtx, serviceName, target); err != nil {
+			return err
+		}
+
+		if s.lifecycleState(target.Lifecycle).ProhibitsDependents {
+			graph, err := s.buildDependencyGraph(subCtx)
+			if err != nil {
+				return err
+			}
+			dependents := uniqueStrings(graph.downstream[serviceName])
+			if len(dependents) > 0 {
+				sort.Strings(dependents)
+				details := fmt.Sprintf("service %s cannot become %s while other services depend on it: %s", serviceName, transition.Lifecycle, strings.Join(dependents, ", "))
+				s.Logging.Logger().Ctx(ctx).Info().Print(details)
+				return apierrors.NewConflictError("service.conflict.dependents", details, nil, s.Timestamp.Now())
+			}
+		}
+
+		warnings, err := s.Policies.CheckService(subCtx, serviceName, target)
+		if err != nil {
+			return err
+		}
+
+		result, err = s.Updater.WriteService(subCtx, serviceName, target)
+		result.PolicyWarnings = warnings
+		return err
+	})
+	return result, err
+}
+
+func (s *Impl) validateServiceLifecycleTransitionDto(ctx context.Context, dto openapi.ServiceLifecycleTransitionDto) error {
+	violations := util.NewViolations("service")
+	if dto.Lifecycle == "" {
+		violations.Missing("lifecycle", "field lifecycle is mandatory")
+	} else if _, ok := s.CustomConfiguration.ServiceLifecycleModel().States[dto.Lifecycle]; !ok {
+		violations.Add("lifecycle", util.ProblemInvalid, "field lifecycle must be one of the configured lifecycle states", map[string]string{
+			"allowed": strings.Join(sortedKeys(s.CustomConfiguration.ServiceLifecycleModel().States), ","),
+		})
+	}
+	if !util.IsConditional(ctx) {
+		if dto.CommitHash == "" {
+			violations.Missing("commitHash", "field commitHash is mandatory")
+		}
+		if dto.TimeStamp == "" {
+			violations.Missing("timeStamp", "field timeStamp is mandatory")
+		}
+	}
+	if dto.JiraIssue == "" {
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory")
+	}
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service lifecycle transition values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
+}
+
+// validateLifecycleTransition checks that the lifecycle model allows the transition.
+//
+// Services without a lifecycle, or with one the model does not know, may transition to any state, so they can be
+// brought into the model.
+func (s *Impl) validateLifecycleTransition(ctx context.Context, from *string, to string) error {
+	model := s.CustomConfiguration.ServiceLifecycleModel()
+	if from == nil {
+		return nil
+	}
+	state, ok := model.States[*from]
+	if !ok {
+		return nil
+	}
+	for _, next := range state.Next {
+		if next == to {
+			return nil
+		}
+	}
+
+	violations := util.NewViolations("service")
+	violations.Add("lifecycle", util.ProblemInvalid, fmt.Sprintf("a service cannot transition from lifecycle %s to %s", *from, to), map[string]string{
+		"from":    *from,
+		"allowed": strings.Join(state.Next, ","),
+	})
+	s.Logging.Logger().Ctx(ctx).Info().Printf("service lifecycle transition invalid: %s", violations.Details())
+	return violations.Error(s.Timestamp.Now())
+}
+
+// validateLifecycleUnchanged rejects any lifecycle change outside of TransitionServiceLifecycle.
+//
+// The only exception is a service without a lifecycle, which may be given the initial state.
+func (s *Impl) validateLifecycleUnchanged(ctx context.Context, current openapi.ServiceDto, dto openapi.ServiceDto) error {
+	if util.EqualStringPtr(current.Lifecycle, dto.Lifecycle) {
+		return nil
+	}
+	if current.Lifecycle == nil && dto.Lifecycle != nil && *dto.Lifecycle == s.CustomConfiguration.ServiceLifecycleModel().Initial {
+		return nil
+	}
+
+	violations := util.NewViolations("service")
+	violations.Add("lifecycle", util.ProblemInvalid, "field lifecycle can only be changed using the lifecycle endpoint", nil)
+	s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", violations.Details())
+	return violations.Error(s.Timestamp.Now())
+}
+
+// lifecycleFieldsChanged tells whether a write touches any of the fields validateLifecycleRules checks.
+func lifecycleFieldsChanged(current openapi.ServiceDto, dto openapi.ServiceDto) bool {
+	return !util.EqualStringPtr(current.Lifecycle, dto.Lifecycle) ||
+		!util.EqualStringPtr(current.DeprecatedSince, dto.DeprecatedSince) ||
+		!util.EqualStringPtr(current.SunsetDate, dto.SunsetDate) ||
+		!util.EqualStringPtr(current.ReplacedBy, dto.ReplacedBy)
+}
+
+// validateLifecycleRules checks the rules of the lifecycle state the service is about to be written in, and the
+// deprecation fields, where replacedBy must refer to an existing service. Must be called while holding the metadata lock.
+func (s *Impl) validateLifecycleRules(ctx context.Context, serviceName string, dto openapi.ServiceDto) error {
+	violations := util.NewViolations("service")
+	state := s.lifecycleState(dto.Lifecycle)
+
+	util.ValidateDeprecationDates(violations, dto.DeprecatedSince, dto.SunsetDate)
+	if dto.SunsetDate == nil && state.RequiresSunsetDate {
+		violations.Missing("sunsetDate", fmt.Sprintf("field sunsetDate is mandatory for lifecycle %s", *dto.Lifecycle))
+	}
+
+	if dto.ReplacedBy != nil {
+		if *dto.ReplacedBy == serviceName {
+			violations.Add("replacedBy", util.ProblemInvalid, "field replacedBy cannot refer to the service itself", nil)
+		} else if _, err := s.Cache.GetService(ctx, *dto.ReplacedBy); err != nil {
+			violations.Add("replacedBy", util.ProblemInvalid, fmt.Sprintf("field replacedBy refers to a service that does not exist: %s", *dto.ReplacedBy), map[string]string{
+				"service": *dto.ReplacedBy,
+			})
+		}
+	} else if state.RequiresReplacedBy {
+		violations.Missing("replacedBy", fmt.Sprintf("field replacedBy is mandatory for lifecycle %s", *dto.Lifecycle))
+	}
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
+}
+
+// lifecycleState looks up a lifecycle state in the configured model. Unknown states have no rules.
+func (s *Impl) lifecycleState(lifecycle *string) config.ServiceLifecycleState {
+	if lifecycle == nil {
+		return config.ServiceLifecycleState{}
+	}
+	return s.CustomConfiguration.ServiceLifecycleModel().States[*lifecycle]
+}
@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/service/query"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+)
+
+func (s *Impl) parseQuery(ctx context.Context, queryFilter string) (query.Expression[openapi.ServiceDto], error) {
+	if queryFilter == "" {
+		return query.All[openapi.ServiceDto](), nil
+	}
+	result, err := query.Parse[openapi.ServiceDto](queryFilter, query.ServiceField)
+	if err != nil {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service query invalid: %s", err.Error())
+		return nil, apierrors.NewBadRequestError("service.invalid.query", fmt.Sprintf("query error: %s", err.Error()), nil, s.Timestamp.Now())
+	}
+	return result, nil
+}
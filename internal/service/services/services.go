@@ -5,7 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/service/alerttargets"
+	"github.com/Interhyp/metadata-service/internal/service/util"
+	"github.com/Interhyp/metadata-service/internal/types"
 	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/Interhyp/metadata-service/api"
@@ -23,7 +28,11 @@ type Impl struct {
 	Timestamp           librepo.Timestamp
 	Cache               repository.Cache
 	Updater             service.Updater
+	Owners              service.Owners
 	Repositories        service.Repositories
+	Bitbucket           repository.Bitbucket
+	Policies            service.Policies
+	AlertTargets        *alerttargets.Registry
 }
 
 func New(
@@ -33,7 +42,10 @@ func New(
 	timestamp librepo.Timestamp,
 	cache repository.Cache,
 	updater service.Updater,
+	owners service.Owners,
 	repositories service.Repositories,
+	bitbucket repository.Bitbucket,
+	policies service.Policies,
 ) service.Services {
 	return &Impl{
 		Configuration:       configuration,
@@ -42,7 +54,11 @@ func New(
 		Timestamp:           timestamp,
 		Cache:               cache,
 		Updater:             updater,
+		Owners:              owners,
 		Repositories:        repositories,
+		Bitbucket:           bitbucket,
+		Policies:            policies,
+		AlertTargets:        alerttargets.NewRegistry(customConfig),
 	}
 }
 
@@ -59,9 +75,21 @@ func (s *Impl) Setup() error {
 	return nil
 }
 
-var initialServiceLifecycle = "experimental"
+func (s *Impl) ValidServiceName(ctx context.Context, serviceName string) apierrors.AnnotatedError {
+	if s.CustomConfiguration.ServiceNamePermittedRegex().MatchString(serviceName) &&
+		!s.CustomConfiguration.ServiceNameProhibitedRegex().MatchString(serviceName) &&
+		uint16(len(serviceName)) <= s.CustomConfiguration.ServiceNameMaxLength() {
+		return nil
+	}
+
+	s.Logging.Logger().Ctx(ctx).Info().Printf("service parameter %v invalid", serviceName)
+	permitted := s.CustomConfiguration.ServiceNamePermittedRegex().String()
+	prohibited := s.CustomConfiguration.ServiceNameProhibitedRegex().String()
+	max := s.CustomConfiguration.ServiceNameMaxLength()
+	return apierrors.NewBadRequestError("service.invalid.name", fmt.Sprintf("service name must match %s, is not allowed to match %s and may have up to %d characters", permitted, prohibited, max), nil, s.Timestamp.Now())
+}
 
-func (s *Impl) GetServices(ctx context.Context, ownerAliasFilter string) (openapi.ServiceListDto, error) {
+func (s *Impl) GetServices(ctx context.Context, ownerAliasFilter string, queryFilter string, page types.PageRequest) (openapi.ServiceListDto, error) {
 	stamp, err := s.Cache.GetServiceListTimestamp(ctx)
 	if err != nil {
 		return openapi.ServiceListDto{}, err
@@ -74,7 +102,18 @@ func (s *Impl) GetServices(ctx context.Context, ownerAliasFilter string) (openap
 	if err != nil {
 		return openapi.ServiceListDto{}, err
 	}
-	for _, name := range names {
+	pager, err := util.NewPager(names, stamp, page, s.Timestamp.Now())
+	if err != nil {
+		return openapi.ServiceListDto{}, err
+	}
+	matcher, err := s.parseQuery(ctx, queryFilter)
+	if err != nil {
+		return openapi.ServiceListDto{}, err
+	}
+	for i, name := range pager.Keys() {
+		if pager.Full() {
+			break
+		}
 		theService, err := s.GetService(ctx, name)
 		if err != nil {
 			// service not found errors are ok, the cache may have been changed concurrently, just drop the entry
@@ -82,11 +121,14 @@ func (s *Impl) GetServices(ctx context.Context, ownerAliasFilter string) (openap
 				return openapi.ServiceListDto{}, err
 			}
 		} else {
-			if ownerAliasFilter == "" || ownerAliasFilter == theService.Owner {
+			if (ownerAliasFilter == "" || ownerAliasFilter == theService.Owner) && matcher.Matches(name, theService) {
 				result.Services[name] = theService
+				pager.Take(i)
 			}
 		}
 	}
+	result.Order = pager.Taken()
+	result.NextCursor = pager.NextCursor()
 	return result, nil
 }
 
@@ -94,6 +136,47 @@ func (s *Impl) GetService(ctx context.Context, serviceName string) (openapi.Serv
 	return s.Cache.GetService(ctx, serviceName)
 }
 
+func (s *Impl) GetServicesAt(ctx context.Context, at string, ownerAliasFilter string, queryFilter string, page types.PageRequest) (openapi.ServiceListDto, error) {
+	historic, err := s.snapshotAt(ctx, at)
+	if err != nil {
+		return openapi.ServiceListDto{}, err
+	}
+	return historic.GetServices(ctx, ownerAliasFilter, queryFilter, page)
+}
+
+func (s *Impl) GetServiceAt(ctx context.Context, at string, serviceName string) (openapi.ServiceDto, error) {
+	historic, err := s.snapshotAt(ctx, at)
+	if err != nil {
+		return openapi.ServiceDto{}, err
+	}
+	return historic.GetService(ctx, serviceName)
+}
+
+// snapshotAt gives a copy of this component that reads from the metadata as of a past commit instead of the cache.
+//
+// Only use it for reads.
+func (s *Impl) snapshotAt(ctx context.Context, at string) (*Impl, error) {
+	cache, err := s.Updater.SnapshotAt(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	historic := *s
+	historic.Cache = cache
+	return &historic, nil
+}
+
+func (s *Impl) GetServiceHistory(ctx context.Context, serviceName string) (openapi.HistoryDto, error) {
+	result, err := s.Updater.GetServiceHistory(ctx, serviceName)
+	if err != nil {
+		return result, err
+	}
+	if len(result.Entries) == 0 {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service %v not found", serviceName)
+		return result, apierrors.NewNotFoundError("service.notfound", fmt.Sprintf("service %s not found", serviceName), nil, s.Timestamp.Now())
+	}
+	return result, nil
+}
+
 func (s *Impl) CreateService(ctx context.Context, serviceName string, serviceCreateDto openapi.ServiceCreateDto) (openapi.ServiceDto, error) {
 	serviceDto := s.mapServiceCreateDtoToServiceDto(serviceCreateDto)
 	ctx = context.WithValue(ctx, "configuration", s.CustomConfiguration)
@@ -116,25 +199,25 @@ func (s *Impl) CreateService(ctx context.Context, serviceName string, serviceCre
 			return apierrors.NewConflictErrorWithResponse("owner.conflict.alreadyexists", fmt.Sprintf("service %s already exists - cannot create", serviceName), nil, result, s.Timestamp.Now())
 		}
 
-		_, err = s.Cache.GetOwner(subCtx, serviceDto.Owner)
-		if err != nil {
-			details := fmt.Sprintf("no such owner: %s", serviceDto.Owner)
-			s.Logging.Logger().Ctx(ctx).Info().Printf(details)
-			return apierrors.NewBadRequestError("service.invalid.missing.owner", details, err, s.Timestamp.Now())
+		if err := s.validateReferences(subCtx, serviceDto); err != nil {
+			return err
 		}
 
-		for _, repoKey := range serviceDto.Repositories {
-			_, err = s.Cache.GetRepository(subCtx, repoKey)
-			if err != nil {
-				s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", repoKey)
-				return apierrors.NewBadRequestError("service.invalid.missing.repository", "validation error: you referenced a repository that does not exist: no such instance: "+repoKey, nil, s.Timestamp.Now())
-			}
+		if err := s.validateSpecReferences(subCtx, serviceName, nil, serviceDto.Spec); err != nil {
+			return err
+		}
+
+		serviceDto.AlertTarget = s.normalizeAlertTarget(serviceDto.AlertTarget)
+		warnings, err := s.Policies.CheckService(subCtx, serviceName, serviceDto)
+		if err != nil {
+			return err
 		}
 
 		serviceWritten, err := s.Updater.WriteService(subCtx, serviceName, serviceDto)
 		if err != nil {
 			return err
 		}
+		serviceWritten.PolicyWarnings = warnings
 
 		result = serviceWritten
 		return nil
@@ -143,6 +226,7 @@ func (s *Impl) CreateService(ctx context.Context, serviceName string, serviceCre
 }
 
 func (s *Impl) mapServiceCreateDtoToServiceDto(serviceCreateDto openapi.ServiceCreateDto) openapi.ServiceDto {
+	initialLifecycle := s.CustomConfiguration.ServiceLifecycleModel().Initial
 	return openapi.ServiceDto{
 		AlertTarget:     serviceCreateDto.AlertTarget,
 		JiraIssue:       serviceCreateDto.JiraIssue,
@@ -152,7 +236,7 @@ func (s *Impl) mapServiceCreateDtoToServiceDto(serviceCreateDto openapi.ServiceC
 		DevelopmentOnly: serviceCreateDto.DevelopmentOnly,
 		Quicklinks:      serviceCreateDto.Quicklinks,
 		Description:     serviceCreateDto.Description,
-		Lifecycle:       &initialServiceLifecycle,
+		Lifecycle:       &initialLifecycle,
 		InternetExposed: serviceCreateDto.InternetExposed,
 		Spec:            serviceCreateDto.Spec,
 		Tags:            serviceCreateDto.Tags,
@@ -161,22 +245,21 @@ func (s *Impl) mapServiceCreateDtoToServiceDto(serviceCreateDto openapi.ServiceC
 }
 
 func (s *Impl) validateNewServiceDto(ctx context.Context, serviceName string, dto openapi.ServiceCreateDto) error {
-	messages := make([]string, 0)
+	violations := util.NewViolations("service")
 
-	messages = validateOwner(messages, dto.Owner)
-	messages = validateDescription(messages, dto.Description)
-	messages = s.validateRepositories(ctx, messages, serviceName, dto.Repositories)
-	messages = s.validateAlertTarget(messages, dto.AlertTarget)
-	messages = validateOperationType(messages, dto.OperationType)
+	validateOwner(violations, dto.Owner)
+	validateDescription(violations, dto.Description)
+	s.validateRepositories(ctx, violations, serviceName, dto.Repositories)
+	s.validateAlertTarget(violations, dto.AlertTarget)
+	validateOperationType(violations, dto.OperationType)
 
 	if dto.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory")
 	}
 
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", details)
-		return apierrors.NewBadRequestError("service.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
@@ -199,30 +282,40 @@ func (s *Impl) UpdateService(ctx context.Context, serviceName string, serviceDto
 			return apierrors.NewNotFoundError("service.notfound", fmt.Sprintf("service %s not found", serviceName), nil, s.Timestamp.Now())
 		}
 
-		_, err = s.Cache.GetOwner(subCtx, serviceDto.Owner)
-		if err != nil {
-			s.Logging.Logger().Ctx(ctx).Info().Printf("owner %v not found", serviceDto.Owner)
-			return apierrors.NewBadRequestError("service.invalid.missing.owner", fmt.Sprintf("no such owner: %s", serviceDto.Owner), nil, s.Timestamp.Now())
+		if err := s.validateReferences(subCtx, serviceDto); err != nil {
+			return err
 		}
 
-		for _, repoKey := range serviceDto.Repositories {
-			_, err = s.Cache.GetRepository(subCtx, repoKey)
-			if err != nil {
-				s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", repoKey)
-				return apierrors.NewBadRequestError("service.invalid.missing.repository", "validation error: you referenced a repository that does not exist: no such instance: "+repoKey, err, s.Timestamp.Now())
-			}
+		if err := s.validateSpecReferences(subCtx, serviceName, current.Spec, serviceDto.Spec); err != nil {
+			return err
 		}
 
-		if current.TimeStamp != serviceDto.TimeStamp || current.CommitHash != serviceDto.CommitHash {
+		basedOn := util.Version{TimeStamp: serviceDto.TimeStamp, CommitHash: serviceDto.CommitHash}
+		if err := util.CheckVersion(ctx, "service", serviceName, current, util.Version{TimeStamp: current.TimeStamp, CommitHash: current.CommitHash}, basedOn, s.Timestamp.Now()); err != nil {
 			result = current
-			s.Logging.Logger().Ctx(ctx).Info().Printf("service %v was concurrently updated", serviceName)
-			return apierrors.NewConflictErrorWithResponse("service.conflict.concurrentlyupdated", fmt.Sprintf("service %v was concurrently updated", serviceName), nil, result, s.Timestamp.Now())
+			return err
+		}
+
+		if err := s.validateLifecycleUnchanged(ctx, current, serviceDto); err != nil {
+			return err
+		}
+		if lifecycleFieldsChanged(current, serviceDto) {
+			if err := s.validateLifecycleRules(subCtx, serviceName, serviceDto); err != nil {
+				return err
+			}
+		}
+
+		serviceDto.AlertTarget = s.normalizeAlertTarget(serviceDto.AlertTarget)
+		warnings, err := s.Policies.CheckService(subCtx, serviceName, serviceDto)
+		if err != nil {
+			return err
 		}
 
 		serviceWritten, err := s.Updater.WriteService(subCtx, serviceName, serviceDto)
 		if err != nil {
 			return err
 		}
+		serviceWritten.PolicyWarnings = warnings
 
 		result = serviceWritten
 		return nil
@@ -230,29 +323,201 @@ func (s *Impl) UpdateService(ctx context.Context, serviceName string, serviceDto
 	return result, err
 }
 
-func (s *Impl) validateExistingServiceDto(ctx context.Context, serviceName string, dto openapi.ServiceDto) error {
-	messages := make([]string, 0)
+func (s *Impl) RevertService(ctx context.Context, serviceName string, revertInfo openapi.RevertDto) (openapi.ServiceDto, error) {
+	if err := s.validateRevertDto(ctx, revertInfo); err != nil {
+		return openapi.ServiceDto{}, err
+	}
+
+	target, err := s.GetServiceAt(ctx, revertInfo.CommitHash, serviceName)
+	if err != nil {
+		return target, err
+	}
+
+	result := target
+	err = s.Updater.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		err := s.Updater.PerformFullUpdate(subCtx)
+		if err != nil {
+			return err
+		}
+
+		current, err := s.Cache.GetService(subCtx, serviceName)
+		if err != nil {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("service %v not found", serviceName)
+			return apierrors.NewNotFoundError("service.notfound", fmt.Sprintf("service %s not found", serviceName), nil, s.Timestamp.Now())
+		}
+
+		// we hold the lock, so basing the update on the current version cannot conflict
+		target.TimeStamp = current.TimeStamp
+		target.CommitHash = current.CommitHash
+		target.JiraIssue = revertInfo.JiraIssue
+		// lifecycle changes must go through the lifecycle endpoint
+		target.Lifecycle = current.Lifecycle
 
-	messages = validateOwner(messages, dto.Owner)
-	messages = validateDescription(messages, dto.Description)
-	messages = s.validateRepositories(ctx, messages, serviceName, dto.Repositories)
-	messages = s.validateAlertTarget(messages, dto.AlertTarget)
-	messages = validateOperationType(messages, dto.OperationType)
+		result, err = s.UpdateService(subCtx, serviceName, target)
+		return err
+	})
+	return result, err
+}
 
+func (s *Impl) validateRevertDto(ctx context.Context, dto openapi.RevertDto) error {
+	violations := util.NewViolations("service")
 	if dto.CommitHash == "" {
-		messages = append(messages, "field commitHash is mandatory for updates")
+		violations.Missing("commitHash", "field commitHash is mandatory")
+	}
+	if dto.JiraIssue == "" {
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory")
+	}
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service revert values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
+}
+
+// renamedRepositoryTypes are the repository types whose keys must match the service name.
+var renamedRepositoryTypes = []string{"helm-deployment", "none"}
+
+func (s *Impl) RenameService(ctx context.Context, serviceName string, renameInfo openapi.ServiceRenameDto) (openapi.ServiceDto, error) {
+	if err := s.validateServiceRenameDto(ctx, serviceName, renameInfo); err != nil {
+		return openapi.ServiceDto{}, err
+	}
+	newServiceName := renameInfo.NewName
+
+	result := openapi.ServiceDto{}
+	// a transaction, so the policies can be checked against the renamed entities before anything is committed
+	event, err := s.Updater.WithTransaction(ctx, renameInfo.JiraIssue, func(subCtx context.Context) error {
+		current, err := s.Cache.GetService(subCtx, serviceName)
+		if err != nil {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("service %v not found", serviceName)
+			return apierrors.NewNotFoundError("service.notfound", fmt.Sprintf("service %s not found", serviceName), nil, s.Timestamp.Now())
+		}
+
+		existing, err := s.Cache.GetService(subCtx, newServiceName)
+		if err == nil {
+			result = existing
+			s.Logging.Logger().Ctx(ctx).Info().Printf("service %v already exists", newServiceName)
+			return apierrors.NewConflictErrorWithResponse("service.conflict.alreadyexists", fmt.Sprintf("service %s already exists - cannot rename %s", newServiceName, serviceName), nil, result, s.Timestamp.Now())
+		}
+
+		basedOn := util.Version{TimeStamp: renameInfo.TimeStamp, CommitHash: renameInfo.CommitHash}
+		if err := util.CheckVersion(ctx, "service", serviceName, current, util.Version{TimeStamp: current.TimeStamp, CommitHash: current.CommitHash}, basedOn, s.Timestamp.Now()); err != nil {
+			result = current
+			return err
+		}
+
+		renamed := current
+		renamed.Repositories = make([]string, len(current.Repositories))
+		renamed.JiraIssue = renameInfo.JiraIssue
+		renamedRepositories := make(map[string]string)
+		violations := util.NewViolations("service")
+		for i, repoKey := range current.Repositories {
+			renamed.Repositories[i] = repoKey
+			for _, repoType := range renamedRepositoryTypes {
+				if repoKey != serviceName+"."+repoType {
+					continue
+				}
+				if !renameInfo.RenameRepositories {
+					violations.Add("renameRepositories", util.ProblemInvalid, fmt.Sprintf("repository %s must be renamed along with the service", repoKey), map[string]string{
+						"repository": repoKey,
+					})
+					continue
+				}
+
+				newRepoKey := newServiceName + "." + repoType
+				if _, err := s.Cache.GetRepository(subCtx, newRepoKey); err == nil {
+					s.Logging.Logger().Ctx(ctx).Info().Printf("repository %v already exists", newRepoKey)
+					return apierrors.NewConflictError("repository.conflict.alreadyexists", fmt.Sprintf("repository %s already exists - cannot rename %s", newRepoKey, repoKey), nil, s.Timestamp.Now())
+				}
+				renamedRepositories[repoKey] = newRepoKey
+				renamed.Repositories[i] = newRepoKey
+			}
+		}
+		if !violations.Empty() {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("service rename values invalid: %s", violations.Details())
+			return violations.Error(s.Timestamp.Now())
+		}
+
+		result, err = s.Updater.RenameService(subCtx, serviceName, newServiceName, renamed, renamedRepositories)
+		if err != nil {
+			return err
+		}
+
+		warnings, err := s.Policies.CheckService(subCtx, newServiceName, result)
+		if err != nil {
+			return err
+		}
+		for _, newRepoKey := range renamedRepositories {
+			repo, err := s.Cache.GetRepository(subCtx, newRepoKey)
+			if err != nil {
+				return err
+			}
+			// warnings about the repositories are logged, the response only carries those about the service
+			if _, err := s.Policies.CheckRepository(subCtx, newRepoKey, repo); err != nil {
+				return err
+			}
+		}
+		result.PolicyWarnings = warnings
+		return nil
+	})
+	if err == nil && event.CommitHash != "" {
+		// the rename has only been committed when the transaction ended
+		result.CommitHash = event.CommitHash
+		result.TimeStamp = event.TimeStamp
+	}
+	return result, err
+}
+
+func (s *Impl) validateServiceRenameDto(ctx context.Context, serviceName string, dto openapi.ServiceRenameDto) error {
+	violations := util.NewViolations("service")
+	if dto.NewName == "" {
+		violations.Missing("newName", "field newName is mandatory")
+	} else if dto.NewName == serviceName {
+		violations.Add("newName", util.ProblemInvalid, "field newName must differ from the current name", nil)
 	}
-	if dto.TimeStamp == "" {
-		messages = append(messages, "field timeStamp is mandatory for updates")
+	if !util.IsConditional(ctx) {
+		if dto.CommitHash == "" {
+			violations.Missing("commitHash", "field commitHash is mandatory")
+		}
+		if dto.TimeStamp == "" {
+			violations.Missing("timeStamp", "field timeStamp is mandatory")
+		}
+	}
+	if dto.JiraIssue == "" {
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory")
+	}
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service rename values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
+}
+
+func (s *Impl) validateExistingServiceDto(ctx context.Context, serviceName string, dto openapi.ServiceDto) error {
+	violations := util.NewViolations("service")
+
+	validateOwner(violations, dto.Owner)
+	validateDescription(violations, dto.Description)
+	s.validateRepositories(ctx, violations, serviceName, dto.Repositories)
+	s.validateAlertTarget(violations, dto.AlertTarget)
+	validateOperationType(violations, dto.OperationType)
+
+	if !util.IsConditional(ctx) {
+		if dto.CommitHash == "" {
+			violations.Missing("commitHash", "field commitHash is mandatory for updates")
+		}
+		if dto.TimeStamp == "" {
+			violations.Missing("timeStamp", "field timeStamp is mandatory for updates")
+		}
 	}
 	if dto.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory for updates")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory for updates")
 	}
 
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", details)
-		return apierrors.NewBadRequestError("service.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
@@ -280,32 +545,40 @@ func (s *Impl) PatchService(ctx context.Context, serviceName string, servicePatc
 
 		serviceDto := patchService(current, servicePatchDto)
 
-		_, err = s.Cache.GetOwner(subCtx, serviceDto.Owner)
-		if err != nil {
-			details := fmt.Sprintf("no such owner: %s", serviceDto.Owner)
-			s.Logging.Logger().Ctx(ctx).Info().Printf(details)
-			return apierrors.NewBadRequestError("service.invalid.missing.owner", details, err, s.Timestamp.Now())
+		if err := s.validateReferences(subCtx, serviceDto); err != nil {
+			return err
 		}
 
-		for _, repoKey := range serviceDto.Repositories {
-			_, err = s.Cache.GetRepository(subCtx, repoKey)
-			if err != nil {
-				details := fmt.Sprintf("validation error: you referenced a repository that does not exist: no such instance: %s", repoKey)
-				s.Logging.Logger().Ctx(ctx).Info().Printf(details)
-				return apierrors.NewBadRequestError("service.invalid.missing.repository", details, err, s.Timestamp.Now())
-			}
+		if err := s.validateSpecReferences(subCtx, serviceName, current.Spec, serviceDto.Spec); err != nil {
+			return err
 		}
 
-		if current.TimeStamp != servicePatchDto.TimeStamp || current.CommitHash != servicePatchDto.CommitHash {
+		basedOn := util.Version{TimeStamp: servicePatchDto.TimeStamp, CommitHash: servicePatchDto.CommitHash}
+		if err := util.CheckVersion(ctx, "service", serviceName, current, util.Version{TimeStamp: current.TimeStamp, CommitHash: current.CommitHash}, basedOn, s.Timestamp.Now()); err != nil {
 			result = current
-			s.Logging.Logger().Ctx(ctx).Info().Printf("service %v was concurrently updated", serviceName)
-			return apierrors.NewConflictErrorWithResponse("service.conflict.concurrentlyupdated", fmt.Sprintf("service %v was concurrently updated", serviceName), nil, result, s.Timestamp.Now())
+			return err
+		}
+
+		if err := s.validateLifecycleUnchanged(ctx, current, serviceDto); err != nil {
+			return err
+		}
+		if lifecycleFieldsChanged(current, serviceDto) {
+			if err := s.validateLifecycleRules(subCtx, serviceName, serviceDto); err != nil {
+				return err
+			}
+		}
+
+		serviceDto.AlertTarget = s.normalizeAlertTarget(serviceDto.AlertTarget)
+		warnings, err := s.Policies.CheckService(subCtx, serviceName, serviceDto)
+		if err != nil {
+			return err
 		}
 
 		serviceWritten, err := s.Updater.WriteService(subCtx, serviceName, serviceDto)
 		if err != nil {
 			return err
 		}
+		serviceWritten.PolicyWarnings = warnings
 
 		result = serviceWritten
 		return nil
@@ -313,30 +586,62 @@ func (s *Impl) PatchService(ctx context.Context, serviceName string, servicePatc
 	return result, err
 }
 
+func (s *Impl) ApplyServicePatch(ctx context.Context, serviceName string, patch types.PatchDocument) (openapi.ServiceDto, error) {
+	var result openapi.ServiceDto
+	err := s.Updater.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		err := s.Updater.PerformFullUpdate(subCtx)
+		if err != nil {
+			return err
+		}
+
+		current, err := s.Cache.GetService(subCtx, serviceName)
+		if err != nil {
+			s.Logging.Logger().Ctx(ctx).Info().Printf("service %v not found", serviceName)
+			return apierrors.NewNotFoundError("service.notfound", fmt.Sprintf("service %s not found", serviceName), nil, s.Timestamp.Now())
+		}
+		result = current
+
+		// the patch has to supply these, as for any other update
+		current.TimeStamp = ""
+		current.CommitHash = ""
+		current.JiraIssue = ""
+
+		target, err := util.ApplyPatch(subCtx, "service", current, patch, s.Timestamp.Now())
+		if err != nil {
+			return err
+		}
+
+		result, err = s.UpdateService(subCtx, serviceName, target)
+		return err
+	})
+	return result, err
+}
+
 func (s *Impl) validateServicePatchDto(ctx context.Context, serviceName string, patchDto openapi.ServicePatchDto, current openapi.ServiceDto) error {
-	messages := make([]string, 0)
+	violations := util.NewViolations("service")
 
 	dto := patchService(current, patchDto)
 
-	messages = validateOwner(messages, dto.Owner)
-	messages = validateDescription(messages, dto.Description)
-	messages = s.validateRepositories(ctx, messages, serviceName, dto.Repositories)
-	messages = s.validateAlertTarget(messages, dto.AlertTarget)
-	messages = validateOperationType(messages, dto.OperationType)
+	validateOwner(violations, dto.Owner)
+	validateDescription(violations, dto.Description)
+	s.validateRepositories(ctx, violations, serviceName, dto.Repositories)
+	s.validateAlertTarget(violations, dto.AlertTarget)
+	validateOperationType(violations, dto.OperationType)
 
-	if patchDto.CommitHash == "" {
-		messages = append(messages, "field commitHash is mandatory for patching")
-	}
-	if patchDto.TimeStamp == "" {
-		messages = append(messages, "field timeStamp is mandatory for patching")
+	if !util.IsConditional(ctx) {
+		if patchDto.CommitHash == "" {
+			violations.Missing("commitHash", "field commitHash is mandatory for patching")
+		}
+		if patchDto.TimeStamp == "" {
+			violations.Missing("timeStamp", "field timeStamp is mandatory for patching")
+		}
 	}
 	if patchDto.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory for patching")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory for patching")
 	}
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", details)
-		return apierrors.NewBadRequestError("service.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
@@ -354,6 +659,9 @@ func patchService(current openapi.ServiceDto, patch openapi.ServicePatchDto) ope
 		JiraIssue:       patch.JiraIssue,
 		Description:     patchStringPtr(patch.Description, current.Description),
 		Lifecycle:       patchStringPtr(patch.Lifecycle, current.Lifecycle),
+		DeprecatedSince: patchStringPtr(patch.DeprecatedSince, current.DeprecatedSince),
+		SunsetDate:      patchStringPtr(patch.SunsetDate, current.SunsetDate),
+		ReplacedBy:      patchStringPtr(patch.ReplacedBy, current.ReplacedBy),
 		InternetExposed: patchPtr[bool](patch.InternetExposed, current.InternetExposed),
 		Spec:            patchServiceSpec(patch.Spec, current.Spec),
 		Tags:            patchStringSlice(patch.Tags, current.Tags),
@@ -463,6 +771,16 @@ func (s *Impl) DeleteService(ctx context.Context, serviceName string, deletionIn
 			return err
 		}
 
+		replaced, err := s.servicesReplacedBy(subCtx, serviceName)
+		if err != nil {
+			return err
+		}
+		if len(replaced) > 0 {
+			details := fmt.Sprintf("service %s cannot be deleted while other services name it in replacedBy: %s", serviceName, strings.Join(replaced, ", "))
+			s.Logging.Logger().Ctx(ctx).Info().Print(details)
+			return apierrors.NewConflictError("service.conflict.referenced", details, nil, s.Timestamp.Now())
+		}
+
 		err = s.Updater.DeleteService(subCtx, serviceName, deletionInfo)
 		if err != nil {
 			return err
@@ -472,19 +790,98 @@ func (s *Impl) DeleteService(ctx context.Context, serviceName string, deletionIn
 	})
 }
 
+// servicesReplacedBy lists the services whose replacedBy refers to serviceName, sorted.
+func (s *Impl) servicesReplacedBy(ctx context.Context, serviceName string) ([]string, error) {
+	names, err := s.Cache.GetSortedServiceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	for _, name := range names {
+		service, err := s.Cache.GetService(ctx, name)
+		if err != nil || service.ReplacedBy == nil {
+			continue
+		}
+		if *service.ReplacedBy == serviceName {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
 func (s *Impl) validateDeletionDto(ctx context.Context, deletionInfo openapi.DeletionDto) error {
-	messages := make([]string, 0)
+	violations := util.NewViolations("deletion")
 	if deletionInfo.JiraIssue == "" {
-		messages = append(messages, "field jiraIssue is mandatory for deletion")
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory for deletion")
 	}
-	if len(messages) > 0 {
-		details := strings.Join(messages, ", ")
-		s.Logging.Logger().Ctx(ctx).Info().Printf("deletion info values invalid: %s", details)
-		return apierrors.NewBadRequestError("deletion.invalid.values", fmt.Sprintf("validation error: %s", details), nil, s.Timestamp.Now())
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("deletion info values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
 	}
 	return nil
 }
 
+func (s *Impl) GetServicePromoters(ctx context.Context, serviceOwnerAlias string) (openapi.ServicePromotersDto, error) {
+	resultSet := make(map[string]bool)
+
+	serviceOwner, err := s.Cache.GetOwner(ctx, serviceOwnerAlias)
+	if err != nil {
+		return openapi.ServicePromotersDto{}, err
+	}
+
+	for _, promoter := range serviceOwner.Promoters {
+		isGroup, groupOwner, groupName := util.ParseGroupOwnerAndGroupName(promoter)
+		if isGroup {
+			for _, member := range s.Owners.GetAllGroupMembers(ctx, groupOwner, groupName) {
+				resultSet[member] = true
+			}
+		} else {
+			resultSet[promoter] = true
+		}
+	}
+
+	err = s.addAllProductOwners(ctx, resultSet)
+	if err != nil {
+		return openapi.ServicePromotersDto{}, err
+	}
+
+	promoters := make([]string, 0, len(resultSet))
+	for promoter := range resultSet {
+		// group members may themselves be group references, which we do not expand recursively
+		if isGroup, _, _ := util.ParseGroupOwnerAndGroupName(promoter); !isGroup && promoter != "" {
+			promoters = append(promoters, promoter)
+		}
+	}
+
+	promoters = s.filterExistingUsernames(ctx, promoters)
+	sort.Strings(promoters)
+
+	return openapi.ServicePromotersDto{Promoters: promoters}, nil
+}
+
+// filterExistingUsernames drops users that are unknown to bitbucket.
+//
+// If bitbucket is not wired up or the lookup fails, the list is returned unfiltered, so a downstream
+// outage does not lock everyone out of promoting.
+func (s *Impl) filterExistingUsernames(ctx context.Context, usernames []string) []string {
+	if s.Bitbucket == nil || len(usernames) == 0 {
+		return usernames
+	}
+
+	existing, err := s.Bitbucket.FilterExistingUsernames(ctx, usernames)
+	if err != nil {
+		s.Logging.Logger().Ctx(ctx).Warn().Printf("failed to check promoters against bitbucket users - returning unfiltered list: %s", err.Error())
+		return usernames
+	}
+
+	unknown := util.Difference(usernames, existing)
+	if len(unknown) > 0 {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("dropping unknown users from promoters: %v", unknown)
+	}
+	return existing
+}
+
 func (s *Impl) addAllProductOwners(ctx context.Context, resultSet map[string]bool) error {
 	names, err := s.Cache.GetSortedOwnerAliases(ctx)
 	if err != nil {
@@ -508,52 +905,90 @@ func (s *Impl) addAllProductOwners(ctx context.Context, resultSet map[string]boo
 
 // -- validation --
 
-func validateOwner(messages []string, ownerAlias string) []string {
+func validateOwner(violations *util.Violations, ownerAlias string) {
 	if ownerAlias == "" {
-		messages = append(messages, "field owner is mandatory")
+		violations.Missing("owner", "field owner is mandatory")
 	}
-	return messages
 }
 
-func (s *Impl) validateRepositories(ctx context.Context, messages []string, serviceName string, repoKeys []string) []string {
-	if repoKeys != nil {
-		for _, repo := range repoKeys {
-			if err := s.validRepoKey(ctx, repo, serviceName); err != nil {
-				messages = append(messages, err.Error())
-			}
+func (s *Impl) validateRepositories(ctx context.Context, violations *util.Violations, serviceName string, repoKeys []string) {
+	for i, repo := range repoKeys {
+		if err := s.validRepoKey(ctx, repo, serviceName); err != nil {
+			violations.Add(fmt.Sprintf("repositories.%d", i), util.ProblemInvalid, err.Error(), map[string]string{"key": repo})
 		}
 	}
-	return messages
 }
 
-func (s *Impl) validateAlertTarget(messages []string, alertTarget string) []string {
+func (s *Impl) validateAlertTarget(violations *util.Violations, alertTarget string) {
 	if alertTarget == "" {
-		messages = append(messages, "field alertTarget is mandatory")
-	} else {
-		if !s.validAlertTarget(alertTarget) {
-			messages = append(messages, "field alertTarget must either be an email address @some-organisation.com or a Teams webhook")
-		}
+		violations.Missing("alertTarget", "field alertTarget is mandatory")
+		return
+	}
+
+	target, err := s.AlertTargets.Parse(alertTarget)
+	if errors.Is(err, alerttargets.ErrUnrecognized) {
+		kinds := strings.Join(s.AlertTargets.KindNames(), ", ")
+		violations.Add("alertTarget", util.ProblemInvalid, fmt.Sprintf("field alertTarget is not of a known kind, give it as kind:value with kind one of %s", kinds), map[string]string{
+			"prefix": s.CustomConfiguration.AlertTargetPrefix(),
+			"suffix": s.CustomConfiguration.AlertTargetSuffix(),
+			"kinds":  strings.Join(s.AlertTargets.KindNames(), ","),
+		})
+	} else if err != nil {
+		violations.Add("alertTarget", util.ProblemInvalid, fmt.Sprintf("field alertTarget is not a valid %s alert target: %s", target.Kind, err.Error()), map[string]string{
+			"kind": target.Kind,
+		})
+	}
+}
+
+// normalizeAlertTarget brings a valid alert target into canonical form, keeping the notation it was given in.
+func (s *Impl) normalizeAlertTarget(alertTarget string) string {
+	target, err := s.AlertTargets.Parse(alertTarget)
+	if err != nil {
+		return alertTarget
 	}
-	return messages
+	return target.String()
 }
 
-func validateOperationType(messages []string, operationType *string) []string {
+func validateOperationType(violations *util.Violations, operationType *string) {
 	if !validOperationType(operationType) {
-		messages = append(messages, "optional field operationType must be WORKLOAD (default if unset), PLATFORM, LIBRARY, or APPLICATION")
+		violations.Add("operationType", util.ProblemInvalid, "optional field operationType must be WORKLOAD (default if unset), PLATFORM, LIBRARY, or APPLICATION", map[string]string{
+			"allowed": strings.Join(validOperationTypesForService, ","),
+		})
 	}
-	return messages
 }
 
-func validateDescription(messages []string, description *string) []string {
-	if description != nil && len(*description) > 500 {
-		messages = append(messages, "allowed length of the service description is 500 characters")
+const maxDescriptionLength = 500
+
+// validateReferences checks that the owner and the repositories of a service exist. Must be called while holding
+// the metadata lock.
+func (s *Impl) validateReferences(ctx context.Context, serviceDto openapi.ServiceDto) error {
+	violations := util.NewViolations("service")
+	if _, err := s.Cache.GetOwner(ctx, serviceDto.Owner); err != nil {
+		violations.Add("owner", util.ProblemInvalid, fmt.Sprintf("no such owner: %s", serviceDto.Owner), map[string]string{
+			"owner": serviceDto.Owner,
+		})
+	}
+	for i, repoKey := range serviceDto.Repositories {
+		if _, err := s.Cache.GetRepository(ctx, repoKey); err != nil {
+			violations.Add(fmt.Sprintf("repositories.%d", i), util.ProblemInvalid, fmt.Sprintf("you referenced a repository that does not exist: no such instance: %s", repoKey), map[string]string{
+				"repository": repoKey,
+			})
+		}
 	}
-	return messages
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("service values invalid: %s", violations.Details())
+		return violations.Error(s.Timestamp.Now())
+	}
+	return nil
 }
 
-func (s *Impl) validAlertTarget(candidate string) bool {
-	return strings.HasPrefix(candidate, s.CustomConfiguration.AlertTargetPrefix()) ||
-		strings.HasSuffix(candidate, s.CustomConfiguration.AlertTargetSuffix())
+func validateDescription(violations *util.Violations, description *string) {
+	if description != nil && len(*description) > maxDescriptionLength {
+		violations.Add("description", util.ProblemTooLong, fmt.Sprintf("allowed length of the service description is %d characters", maxDescriptionLength), map[string]string{
+			"maxLength": strconv.Itoa(maxDescriptionLength),
+		})
+	}
 }
 
 func (s *Impl) validRepoKey(ctx context.Context, candidate string, serviceName string) error {
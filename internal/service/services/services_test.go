@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/service/alerttargets"
+	"github.com/Interhyp/metadata-service/internal/service/owners"
+	"github.com/Interhyp/metadata-service/test/mock/cachemock"
 	"github.com/Interhyp/metadata-service/test/mock/configmock"
 
 	auloggingapi "github.com/StephanHCB/go-autumn-logging/api"
@@ -107,6 +110,8 @@ func TestPatchService_ReplaceAll(t *testing.T) {
 		TimeStamp:       "newts",
 		CommitHash:      "newhash",
 		Lifecycle:       p("deprecated"),
+		SunsetDate:      p("2023-06-30"),
+		ReplacedBy:      p("newservice"),
 	}, openapi.ServiceDto{
 		Owner: "newowner",
 		Quicklinks: []openapi.Quicklink{
@@ -123,6 +128,8 @@ func TestPatchService_ReplaceAll(t *testing.T) {
 		TimeStamp:       "newts",
 		CommitHash:      "newhash",
 		Lifecycle:       p("deprecated"),
+		SunsetDate:      p("2023-06-30"),
+		ReplacedBy:      p("newservice"),
 	})
 }
 
@@ -320,6 +327,7 @@ func tstValidationTestcaseAllOps(t *testing.T, expectedMessage string, data open
 		Cache:               nil,
 		Updater:             nil,
 		Timestamp:           &timestampImpl,
+		AlertTargets:        alerttargets.NewRegistry(&mockConfig),
 	}
 
 	err := (*Impl).validateNewServiceDto(impl, context.TODO(), "any", create)
@@ -352,11 +360,70 @@ func TestValidate_AlertTarget(t *testing.T) {
 		AlertTarget: p("somethingother"),
 	}
 
-	expectedMessage := "validation error: field alertTarget must either be an email address @some-organisation.com or a Teams webhook"
+	expectedMessage := "validation error: field alertTarget is not of a known kind, give it as kind:value with kind one of email, slack, teams, pagerduty"
 
 	tstValidationTestcaseAllOps(t, expectedMessage, data, create, patch)
 }
 
+func TestValidate_AlertTargetKind(t *testing.T) {
+	docs.Description("alert targets that are invalid for their kind are correctly rejected on all operations")
+
+	data := tstValid()
+	data.AlertTarget = "slack:no-channel"
+
+	create := tstCreateValid()
+	create.AlertTarget = "slack:no-channel"
+
+	patch := openapi.ServicePatchDto{
+		AlertTarget: p("slack:no-channel"),
+	}
+
+	expectedMessage := "validation error: field alertTarget is not a valid slack alert target: must be a channel like #some-channel or a webhook url starting with https://hooks.slack.com/"
+
+	tstValidationTestcaseAllOps(t, expectedMessage, data, create, patch)
+}
+
+type tstUnsetAlertTargetConfig struct {
+	configmock.MockConfig
+}
+
+func (c *tstUnsetAlertTargetConfig) AlertTargetPrefix() string {
+	return ""
+}
+
+func (c *tstUnsetAlertTargetConfig) AlertTargetSuffix() string {
+	return ""
+}
+
+func TestValidate_AlertTargetLegacy(t *testing.T) {
+	docs.Description("without alert target prefix and suffix, untyped alert targets are accepted on all operations as before")
+	mockConfig := tstUnsetAlertTargetConfig{}
+	impl := &Impl{
+		CustomConfiguration: &mockConfig,
+		Logging:             &MockLogging{},
+		Timestamp:           &timestamp.TimestampImpl{Timestamp: fakeNow},
+		AlertTargets:        alerttargets.NewRegistry(&mockConfig),
+	}
+
+	data := tstValid()
+	data.AlertTarget = "somethingelse"
+
+	create := tstCreateValid()
+	create.AlertTarget = "somethingelse"
+
+	patch := openapi.ServicePatchDto{
+		AlertTarget: p("somethingother"),
+		TimeStamp:   "newts",
+		CommitHash:  "newhash",
+		JiraIssue:   "newjiraissue",
+	}
+
+	require.Nil(t, (*Impl).validateNewServiceDto(impl, context.TODO(), "any", create))
+	require.Nil(t, (*Impl).validateExistingServiceDto(impl, context.TODO(), "any", data))
+	require.Nil(t, (*Impl).validateServicePatchDto(impl, context.TODO(), "any", patch, data))
+	require.Equal(t, "somethingother", impl.normalizeAlertTarget("somethingother"))
+}
+
 func TestValidate_OperationType(t *testing.T) {
 	docs.Description("invalid operation types are correctly rejected on all operations")
 
@@ -374,3 +441,57 @@ func TestValidate_OperationType(t *testing.T) {
 
 	tstValidationTestcaseAllOps(t, expectedMessage, data, create, patch)
 }
+
+func TestGetServicePromoters(t *testing.T) {
+	docs.Description("service promoters consist of the owner's promoters with groups expanded plus all product owners")
+
+	cache := &ownersmock.Mock{}
+	impl := &Impl{
+		Logging: &MockLogging{},
+		Cache:   cache,
+		Owners:  &owners.Impl{Cache: cache},
+	}
+
+	actual, err := impl.GetServicePromoters(context.TODO(), "ownerWithGroup")
+	require.Nil(t, err)
+	require.Equal(t, []string{"productOwner1", "productOwner2", "promoter1", "username1", "username2"}, actual.Promoters)
+
+	actual, err = impl.GetServicePromoters(context.TODO(), "someOwner")
+	require.Nil(t, err)
+	require.Equal(t, []string{"productOwner1", "productOwner2"}, actual.Promoters)
+}
+
+func TestDependencyGraphClosure(t *testing.T) {
+	docs.Description("the transitive closure follows all edges, terminates on cycles and excludes the start node")
+
+	graph := &dependencyGraph{
+		upstream: map[string][]string{
+			"a": {"b"},
+			"b": {"c", "d"},
+			"c": {"a"},
+		},
+	}
+
+	require.Equal(t, map[string]bool{"b": true, "c": true, "d": true}, graph.closure("a", graph.upstream))
+	require.Equal(t, map[string]bool{}, graph.closure("d", graph.upstream))
+}
+
+func TestDependencyGraphCycle(t *testing.T) {
+	docs.Description("dependency cycles through a service are detected and reported as a path")
+
+	graph := &dependencyGraph{
+		upstream: map[string][]string{
+			"a": {"b", "e"},
+			"b": {"c", "d"},
+			"c": {"a"},
+			"e": {"e"},
+		},
+	}
+
+	require.Equal(t, []string{"a", "b", "c", "a"}, graph.cycle("a", graph.upstream["a"]))
+	require.Equal(t, []string{"e", "e"}, graph.cycle("e", graph.upstream["e"]))
+	require.Nil(t, graph.cycle("d", graph.upstream["d"]))
+
+	// only cycles leaving through the given dependencies count
+	require.Nil(t, graph.cycle("a", []string{"e"}))
+}
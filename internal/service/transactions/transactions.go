@@ -0,0 +1,358 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/validationerror"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	"github.com/Interhyp/metadata-service/internal/service/util"
+	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
+
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"strings"
+)
+
+const (
+	operationCreate = "create"
+	operationUpdate = "update"
+	operationPatch  = "patch"
+	operationDelete = "delete"
+
+	kindOwner      = "owner"
+	kindService    = "service"
+	kindRepository = "repository"
+)
+
+type Impl struct {
+	Configuration       librepo.Configuration
+	CustomConfiguration config.CustomConfiguration
+	Logging             librepo.Logging
+	Timestamp           librepo.Timestamp
+	Cache               repository.Cache
+	Updater             service.Updater
+	Owners              service.Owners
+	Services            service.Services
+	Repositories        service.Repositories
+}
+
+func New(
+	configuration librepo.Configuration,
+	customConfig config.CustomConfiguration,
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	cache repository.Cache,
+	updater service.Updater,
+	owners service.Owners,
+	services service.Services,
+	repositories service.Repositories,
+) service.Transactions {
+	return &Impl{
+		Configuration:       configuration,
+		CustomConfiguration: customConfig,
+		Logging:             logging,
+		Timestamp:           timestamp,
+		Cache:               cache,
+		Updater:             updater,
+		Owners:              owners,
+		Services:            services,
+		Repositories:        repositories,
+	}
+}
+
+func (s *Impl) IsTransactions() bool {
+	return true
+}
+
+func (s *Impl) Setup() error {
+	ctx := auzerolog.AddLoggerToCtx(context.Background())
+
+	// nothing to do
+
+	s.Logging.Logger().Ctx(ctx).Info().Print("successfully set up transactions business component")
+	return nil
+}
+
+// step is a single validated operation, ready to be applied. Applying it gives the policy warnings of the operation.
+type step struct {
+	description string
+	apply       func(ctx context.Context) ([]openapi.ViolationDto, error)
+}
+
+func (s *Impl) ApplyTransaction(ctx context.Context, transaction openapi.TransactionDto) (openapi.TransactionResultDto, error) {
+	steps, err := s.prepareSteps(ctx, transaction)
+	if err != nil {
+		return openapi.TransactionResultDto{}, err
+	}
+
+	warnings := make([]openapi.ViolationDto, 0)
+	event, err := s.Updater.WithTransaction(ctx, transaction.JiraIssue, func(subCtx context.Context) error {
+		for i, st := range steps {
+			stepWarnings, err := st.apply(subCtx)
+			if err != nil {
+				s.Logging.Logger().Ctx(ctx).Info().Printf("transaction failed at operation %d (%s): %s", i+1, st.description, err.Error())
+				return withOperationDetails(err, i+1, st.description)
+			}
+			warnings = append(warnings, belowOperation(stepWarnings, i+1)...)
+		}
+		return nil
+	})
+	if err != nil {
+		return openapi.TransactionResultDto{}, err
+	}
+
+	result := openapi.TransactionResultDto{
+		CommitHash:   event.CommitHash,
+		TimeStamp:    event.TimeStamp,
+		Owners:       make(map[string]openapi.OwnerDto),
+		Services:     make(map[string]openapi.ServiceDto),
+		Repositories: make(map[string]openapi.RepositoryDto),
+	}
+	if len(warnings) > 0 {
+		result.PolicyWarnings = warnings
+	}
+	for _, op := range transaction.Operations {
+		// entities deleted by a later operation are simply not found
+		switch op.Kind {
+		case kindOwner:
+			if owner, err := s.Cache.GetOwner(ctx, op.Key); err == nil {
+				result.Owners[op.Key] = owner
+			}
+		case kindService:
+			if theService, err := s.Cache.GetService(ctx, op.Key); err == nil {
+				result.Services[op.Key] = theService
+			}
+		case kindRepository:
+			if repo, err := s.Cache.GetRepository(ctx, op.Key); err == nil {
+				result.Repositories[op.Key] = repo
+			}
+		}
+	}
+	return result, nil
+}
+
+// prepareSteps validates the whole transaction up front, reporting all problems at once.
+func (s *Impl) prepareSteps(ctx context.Context, transaction openapi.TransactionDto) ([]step, error) {
+	violations := util.NewViolations("transaction")
+	if transaction.JiraIssue == "" {
+		violations.Missing("jiraIssue", "field jiraIssue is mandatory")
+	}
+	if len(transaction.Operations) == 0 {
+		violations.Missing("operations", "field operations must contain at least one operation")
+	}
+
+	steps := make([]step, 0, len(transaction.Operations))
+	for i, op := range transaction.Operations {
+		st, field, err := s.prepareStep(ctx, op, transaction.JiraIssue)
+		if err != nil {
+			violations.Add(fmt.Sprintf("operations.%d.%s", i, field), util.ProblemInvalid, fmt.Sprintf("operation %d: %s", i+1, err.Error()), nil)
+		} else {
+			steps = append(steps, st)
+		}
+	}
+
+	if !violations.Empty() {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("transaction values invalid: %s", violations.Details())
+		return nil, violations.Error(s.Timestamp.Now())
+	}
+	return steps, nil
+}
+
+// prepareStep checks an operation and binds it to the service call that performs it. If the operation is invalid,
+// it also gives the field of the operation the problem is about.
+func (s *Impl) prepareStep(ctx context.Context, op openapi.TransactionOperationDto, jiraIssue string) (step, string, error) {
+	if op.Key == "" {
+		return step{}, "key", errors.New("field key is mandatory")
+	}
+	switch op.Operation {
+	case operationCreate, operationUpdate, operationPatch:
+		if op.Body == nil {
+			return step{}, "body", fmt.Errorf("field body is mandatory for %s", op.Operation)
+		}
+	case operationDelete:
+		if op.Body != nil {
+			return step{}, "body", errors.New("delete takes no body")
+		}
+	default:
+		return step{}, "operation", fmt.Errorf("operation must be one of %s, %s, %s, %s", operationCreate, operationUpdate, operationPatch, operationDelete)
+	}
+
+	description := fmt.Sprintf("%s %s %s", op.Operation, op.Kind, op.Key)
+	deletion := openapi.DeletionDto{JiraIssue: jiraIssue}
+
+	var apply func(ctx context.Context) ([]openapi.ViolationDto, error)
+	var err error
+	switch op.Kind {
+	case kindOwner:
+		if op.Operation == operationCreate {
+			if err := s.Owners.ValidOwnerAlias(ctx, op.Key); err != nil {
+				return step{}, "key", keyError(err)
+			}
+		}
+		apply, err = prepareOperation(op, jiraIssue,
+			func(ctx context.Context, dto openapi.OwnerCreateDto) ([]openapi.ViolationDto, error) {
+				written, err := s.Owners.CreateOwner(ctx, op.Key, dto)
+				return written.PolicyWarnings, err
+			},
+			func(ctx context.Context, dto openapi.OwnerDto) ([]openapi.ViolationDto, error) {
+				written, err := s.Owners.UpdateOwner(ctx, op.Key, dto)
+				return written.PolicyWarnings, err
+			},
+			func(ctx context.Context, dto openapi.OwnerPatchDto) ([]openapi.ViolationDto, error) {
+				written, err := s.Owners.PatchOwner(ctx, op.Key, dto)
+				return written.PolicyWarnings, err
+			},
+			func(ctx context.Context) ([]openapi.ViolationDto, error) {
+				return nil, s.Owners.DeleteOwner(ctx, op.Key, deletion)
+			})
+	case kindService:
+		if op.Operation == operationCreate {
+			if err := s.Services.ValidServiceName(ctx, op.Key); err != nil {
+				return step{}, "key", keyError(err)
+			}
+		}
+		apply, err = prepareOperation(op, jiraIssue,
+			func(ctx context.Context, dto openapi.ServiceCreateDto) ([]openapi.ViolationDto, error) {
+				written, err := s.Services.CreateService(ctx, op.Key, dto)
+				return written.PolicyWarnings, err
+			},
+			func(ctx context.Context, dto openapi.ServiceDto) ([]openapi.ViolationDto, error) {
+				written, err := s.Services.UpdateService(ctx, op.Key, dto)
+				return written.PolicyWarnings, err
+			},
+			func(ctx context.Context, dto openapi.ServicePatchDto) ([]openapi.ViolationDto, error) {
+				written, err := s.Services.PatchService(ctx, op.Key, dto)
+				return written.PolicyWarnings, err
+			},
+			func(ctx context.Context) ([]openapi.ViolationDto, error) {
+				return nil, s.Services.DeleteService(ctx, op.Key, deletion)
+			})
+	case kindRepository:
+		if op.Operation == operationCreate {
+			if err := s.Repositories.ValidRepositoryKey(ctx, op.Key); err != nil {
+				return step{}, "key", keyError(err)
+			}
+		}
+		apply, err = prepareOperation(op, jiraIssue,
+			func(ctx context.Context, dto openapi.RepositoryCreateDto) ([]openapi.ViolationDto, error) {
+				written, err := s.Repositories.CreateRepository(ctx, op.Key, dto)
+				return written.PolicyWarnings, err
+			},
+			func(ctx context.Context, dto openapi.RepositoryDto) ([]openapi.ViolationDto, error) {
+				written, err := s.Repositories.UpdateRepository(ctx, op.Key, dto)
+				return written.PolicyWarnings, err
+			},
+			func(ctx context.Context, dto openapi.RepositoryPatchDto) ([]openapi.ViolationDto, error) {
+				written, err := s.Repositories.PatchRepository(ctx, op.Key, dto)
+				return written.PolicyWarnings, err
+			},
+			func(ctx context.Context) ([]openapi.ViolationDto, error) {
+				return nil, s.Repositories.DeleteRepository(ctx, op.Key, deletion)
+			})
+	default:
+		return step{}, "kind", fmt.Errorf("kind must be one of %s, %s, %s", kindOwner, kindService, kindRepository)
+	}
+	if err != nil {
+		return step{}, "body", err
+	}
+
+	return step{
+		description: description,
+		apply:       apply,
+	}, "", nil
+}
+
+// prepareOperation decodes the body into the dto the chosen operation expects, and binds it to the call.
+//
+// The operation must already have been checked.
+func prepareOperation[C any, D any, P any](
+	op openapi.TransactionOperationDto,
+	jiraIssue string,
+	create func(context.Context, C) ([]openapi.ViolationDto, error),
+	update func(context.Context, D) ([]openapi.ViolationDto, error),
+	patch func(context.Context, P) ([]openapi.ViolationDto, error),
+	del func(context.Context) ([]openapi.ViolationDto, error),
+) (func(context.Context) ([]openapi.ViolationDto, error), error) {
+	switch op.Operation {
+	case operationCreate:
+		dto, err := decodeBody[C](op.Body, jiraIssue)
+		return func(ctx context.Context) ([]openapi.ViolationDto, error) { return create(ctx, dto) }, err
+	case operationUpdate:
+		dto, err := decodeBody[D](op.Body, jiraIssue)
+		return func(ctx context.Context) ([]openapi.ViolationDto, error) { return update(ctx, dto) }, err
+	case operationPatch:
+		dto, err := decodeBody[P](op.Body, jiraIssue)
+		return func(ctx context.Context) ([]openapi.ViolationDto, error) { return patch(ctx, dto) }, err
+	default:
+		return del, nil
+	}
+}
+
+// decodeBody converts the generic body into the given dto. The jira issue is filled in unless the body has its own.
+func decodeBody[T any](body map[string]interface{}, jiraIssue string) (T, error) {
+	var result T
+
+	withJiraIssue := map[string]interface{}{
+		"jiraIssue": jiraIssue,
+	}
+	for k, v := range body {
+		withJiraIssue[k] = v
+	}
+
+	raw, err := json.Marshal(withJiraIssue)
+	if err != nil {
+		return result, errors.New("body failed to parse")
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return result, errors.New("body failed to parse")
+	}
+	return result, nil
+}
+
+// withOperationDetails tells the caller which operation failed, keeping the kind of error.
+//
+// Violations are moved below the body of the operation, so their pointers refer to the transaction.
+func withOperationDetails(err error, number int, description string) error {
+	var annotated *apierrors.AnnotatedErrorImpl
+	if errors.As(err, &annotated) && annotated.VApiError.Details != nil {
+		details := fmt.Sprintf("operation %d (%s): %s", number, description, *annotated.VApiError.Details)
+		annotated.VApiError.Details = &details
+
+		if response, ok := annotated.VResponseObject.(openapi.ErrorDto); ok {
+			response.Details = &details
+			response.Violations = belowOperation(response.Violations, number)
+			annotated.VResponseObject = response
+		}
+	}
+	return err
+}
+
+// belowOperation moves violations below the body of an operation, so their pointers refer to the transaction.
+func belowOperation(violations []openapi.ViolationDto, number int) []openapi.ViolationDto {
+	result := make([]openapi.ViolationDto, 0, len(violations))
+	for _, violation := range violations {
+		violation.Pointer = fmt.Sprintf("/operations/%d/body%s", number-1, violation.Pointer)
+		result = append(result, violation)
+	}
+	return result
+}
+
+// keyError gives the reason a key is invalid, without the error code.
+func keyError(err apierrors.AnnotatedError) error {
+	if violations := validationerror.Violations(err); len(violations) > 0 {
+		messages := make([]string, 0, len(violations))
+		for _, violation := range violations {
+			messages = append(messages, violation.Message)
+		}
+		return errors.New(strings.Join(messages, ", "))
+	}
+	if details := err.ApiError().Details; details != nil {
+		return errors.New(*details)
+	}
+	return err
+}
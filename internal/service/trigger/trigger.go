@@ -19,6 +19,8 @@ type Impl struct {
 	Logging             librepo.Logging
 	Timestamp           librepo.Timestamp
 	Updater             service.Updater
+	Links               service.Links
+	Consistency         service.Consistency
 
 	LoggingCtx context.Context
 	Cron       *cron.Cron
@@ -32,6 +34,8 @@ func New(
 	logging librepo.Logging,
 	timestamp librepo.Timestamp,
 	updater service.Updater,
+	links service.Links,
+	consistency service.Consistency,
 ) service.Trigger {
 	return &Impl{
 		Configuration:       configuration,
@@ -39,6 +43,8 @@ func New(
 		Logging:             logging,
 		Timestamp:           timestamp,
 		Updater:             updater,
+		Links:               links,
+		Consistency:         consistency,
 	}
 }
 
@@ -136,6 +142,18 @@ func (s *Impl) SetupTriggerCronjob(ctx context.Context) error {
 
 	cronSpec := fmt.Sprintf("*/%s * * * *", s.CustomConfiguration.UpdateJobIntervalCronPart())
 	_, err := s.Cron.AddFunc(cronSpec, func() { _ = s.PerformWithCancel(context.Background()) })
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Cron.AddFunc("@daily", func() { _ = s.NotifySunsetsWithCancel(context.Background()) })
+	if err != nil {
+		return err
+	}
+
+	if minutes := s.CustomConfiguration.LinkCheckIntervalMinutes(); minutes > 0 {
+		_, err = s.Cron.AddFunc(fmt.Sprintf("@every %dm", minutes), func() { _ = s.CheckLinksWithCancel(context.Background()) })
+	}
 	return err
 }
 
@@ -181,5 +199,61 @@ func (s *Impl) PerformWithCancel(ctx context.Context) error {
 	} else {
 		s.Logging.Logger().Ctx(ctx).Info().Printf("finished update OK (%d ms runtime)", tookMs)
 	}
+
+	// keep the consistency metrics current, a failed check must not fail the update
+	if _, checkErr := s.Consistency.CheckConsistency(ctx); checkErr != nil {
+		s.Logging.Logger().Ctx(ctx).Warn().WithErr(checkErr).Print("consistency check after update failed - metrics were not updated")
+	}
+	return err
+}
+
+func (s *Impl) NotifySunsetsWithCancel(ctx context.Context) error {
+	// add custom request id
+	requestId := requestid.NewRequestID()
+	ctx = context.WithValue(ctx, requestid.RequestIDKey, requestId)
+
+	// add logger
+	loggerWithReqId := log.Logger.With().Str("trace.id", requestId).Logger()
+	ctx = loggerWithReqId.WithContext(ctx)
+
+	// add timeout
+	seconds := s.CustomConfiguration.UpdateJobTimeoutSeconds()
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(seconds)*time.Second)
+	defer cancel()
+
+	s.Logging.Logger().Ctx(ctx).Info().Print("starting sunset notifications")
+	err := s.Updater.NotifySunsets(ctx)
+	if err != nil {
+		s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Print("finished sunset notifications with errors - not all notifications were sent")
+	} else {
+		s.Logging.Logger().Ctx(ctx).Info().Print("finished sunset notifications OK")
+	}
+	return err
+}
+
+func (s *Impl) CheckLinksWithCancel(ctx context.Context) error {
+	// add custom request id
+	requestId := requestid.NewRequestID()
+	ctx = context.WithValue(ctx, requestid.RequestIDKey, requestId)
+
+	// add logger
+	loggerWithReqId := log.Logger.With().Str("trace.id", requestId).Logger()
+	ctx = loggerWithReqId.WithContext(ctx)
+
+	// add timeout, a round of checks must be done before the next one is due
+	minutes := s.CustomConfiguration.LinkCheckIntervalMinutes()
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(minutes)*time.Minute)
+	defer cancel()
+
+	started := time.Now()
+
+	s.Logging.Logger().Ctx(ctx).Info().Print("starting link checks")
+	err := s.Links.CheckAllLinks(ctx)
+	tookMs := time.Now().Sub(started).Milliseconds()
+	if err != nil {
+		s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("finished link checks with errors (%d ms runtime) - not all links were checked", tookMs)
+	} else {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("finished link checks OK (%d ms runtime)", tookMs)
+	}
 	return err
 }
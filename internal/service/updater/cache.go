@@ -0,0 +1,41 @@
+package updater
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+)
+
+// --- cache writes ---
+
+// all cache writes go through these, so the search index always matches the cache
+
+func (s *Impl) putOwner(ctx context.Context, alias string, owner openapi.OwnerDto) error {
+	s.SearchIndex.IndexOwner(ctx, alias, owner)
+	return s.Cache.PutOwner(ctx, alias, owner)
+}
+
+func (s *Impl) deleteOwner(ctx context.Context, alias string) error {
+	s.SearchIndex.Remove(ctx, repository.SearchTypeOwner, alias)
+	return s.Cache.DeleteOwner(ctx, alias)
+}
+
+func (s *Impl) putService(ctx context.Context, name string, service openapi.ServiceDto) error {
+	s.SearchIndex.IndexService(ctx, name, service)
+	return s.Cache.PutService(ctx, name, service)
+}
+
+func (s *Impl) deleteService(ctx context.Context, name string) error {
+	s.SearchIndex.Remove(ctx, repository.SearchTypeService, name)
+	return s.Cache.DeleteService(ctx, name)
+}
+
+func (s *Impl) putRepository(ctx context.Context, key string, repo openapi.RepositoryDto) error {
+	s.SearchIndex.IndexRepository(ctx, key, repo)
+	return s.Cache.PutRepository(ctx, key, repo)
+}
+
+func (s *Impl) deleteRepository(ctx context.Context, key string) error {
+	s.SearchIndex.Remove(ctx, repository.SearchTypeRepository, key)
+	return s.Cache.DeleteRepository(ctx, key)
+}
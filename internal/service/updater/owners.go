@@ -26,7 +26,12 @@ func (s *Impl) WriteOwner(ctx context.Context, ownerAlias string, owner openapi.
 		}
 		result = ownerWritten
 
-		s.fireAndForgetKafkaNotification(subCtx, s.ownerKafkaEvent(ownerAlias, ownerWritten.TimeStamp, ownerWritten.CommitHash))
+		if tx, ok := currentTransaction(subCtx); ok {
+			tx.stageOwner(subCtx, s, ownerAlias, &ownerWritten)
+			return nil
+		}
+
+		s.sendUpdateEvent(subCtx, s.ownerKafkaEvent(ownerAlias, ownerWritten.TimeStamp, ownerWritten.CommitHash))
 
 		// cache update
 		err = s.updateOwners(subCtx)
@@ -50,7 +55,12 @@ func (s *Impl) DeleteOwner(ctx context.Context, ownerAlias string, deletionInfo
 			return err
 		}
 
-		s.fireAndForgetKafkaNotification(subCtx, s.ownerKafkaEvent(ownerAlias, ownerWritten.TimeStamp, ownerWritten.CommitHash))
+		if tx, ok := currentTransaction(subCtx); ok {
+			tx.stageOwner(subCtx, s, ownerAlias, nil)
+			return nil
+		}
+
+		s.sendUpdateEvent(subCtx, s.ownerKafkaEvent(ownerAlias, ownerWritten.TimeStamp, ownerWritten.CommitHash))
 
 		// cache update
 		err = s.updateOwners(subCtx)
@@ -62,6 +72,63 @@ func (s *Impl) DeleteOwner(ctx context.Context, ownerAlias string, deletionInfo
 	})
 }
 
+func (s *Impl) RenameOwner(ctx context.Context, ownerAlias string, newOwnerAlias string, jiraIssue string) (openapi.OwnerDto, error) {
+	result := openapi.OwnerDto{}
+	err := s.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		ownerWritten, affected, err := s.Mapper.RenameOwner(subCtx, ownerAlias, newOwnerAlias, jiraIssue)
+		if err != nil {
+			return err
+		}
+		result = ownerWritten
+
+		if tx, ok := currentTransaction(subCtx); ok {
+			tx.stageOwner(subCtx, s, ownerAlias, nil)
+			tx.stageOwner(subCtx, s, newOwnerAlias, &ownerWritten)
+			for _, alias := range affected.OwnerAliases {
+				if alias == ownerAlias || alias == newOwnerAlias {
+					continue
+				}
+				if owner, err := s.Mapper.GetOwner(subCtx, alias); err == nil {
+					tx.stageOwner(subCtx, s, alias, &owner)
+				}
+			}
+			for _, name := range affected.ServiceNames {
+				if service, err := s.Mapper.GetService(subCtx, name); err == nil {
+					tx.stageService(subCtx, s, name, &service)
+				}
+			}
+			for _, key := range affected.RepositoryKeys {
+				if repo, err := s.Mapper.GetRepository(subCtx, key); err == nil {
+					tx.stageRepository(subCtx, s, key, &repo)
+				}
+			}
+			return nil
+		}
+
+		s.sendUpdateEvent(subCtx, repository.UpdateEvent{
+			Affected:   affected,
+			TimeStamp:  ownerWritten.TimeStamp,
+			CommitHash: ownerWritten.CommitHash,
+		})
+
+		// cache updates (incl. services and repositories)
+		if err := s.updateOwners(subCtx); err != nil {
+			return err
+		}
+
+		if err := s.updateServices(subCtx); err != nil {
+			return err
+		}
+
+		return s.updateRepositories(subCtx)
+	})
+	return result, err
+}
+
+func (s *Impl) GetOwnerHistory(ctx context.Context, ownerAlias string) (openapi.HistoryDto, error) {
+	return s.Mapper.GetOwnerHistory(ctx, ownerAlias)
+}
+
 func (s *Impl) CanDeleteOwner(ctx context.Context, ownerAlias string) bool {
 	return s.Mapper.IsOwnerEmpty(ctx, ownerAlias)
 }
@@ -139,7 +206,7 @@ func (s *Impl) updateIndividualOwners(ctx context.Context, ownerAliasesMap map[s
 	for alias, activity := range ownerAliasesMap {
 		if activity == removeExisting {
 			s.Logging.Logger().Ctx(ctx).Info().Printf("owner %s is no longer current, removing it from the cache", alias)
-			s.Cache.DeleteOwner(ctx, alias)
+			s.deleteOwner(ctx, alias)
 			s.Notifier.PublishDeletion(ctx, alias, types.OwnerPayload)
 		} else if activity == addNew {
 			owner, err := s.Mapper.GetOwner(ctx, alias)
@@ -150,7 +217,7 @@ func (s *Impl) updateIndividualOwners(ctx context.Context, ownerAliasesMap map[s
 				s.Logging.Logger().Ctx(ctx).Warn().Printf("failed to get initial info for owner %s from metadata - owner will NOT be present until next run: %s", alias, err.Error())
 				s.totalErrorCounter.Inc()
 			} else {
-				s.Cache.PutOwner(ctx, alias, owner)
+				s.putOwner(ctx, alias, owner)
 				err = s.Notifier.PublishCreation(ctx, alias, notifier.AsPayload(owner))
 				if err != nil {
 					s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("error publishing creation of owner %s", alias)
@@ -168,7 +235,7 @@ func (s *Impl) updateIndividualOwners(ctx context.Context, ownerAliasesMap map[s
 			} else {
 				cached, cacheErr := s.Cache.GetOwner(ctx, alias)
 
-				s.Cache.PutOwner(ctx, alias, owner)
+				s.putOwner(ctx, alias, owner)
 				if cacheErr == nil && !equalExceptCacheInfo(cached, owner) {
 					err = s.Notifier.PublishModification(ctx, alias, notifier.AsPayload(owner))
 					if err != nil {
@@ -17,7 +17,8 @@ func (s *Impl) WriteRepository(ctx context.Context, key string, repository opena
 	result := repository
 	err := s.WithMetadataLock(ctx, func(subCtx context.Context) error {
 		current, err := s.Cache.GetRepository(ctx, key)
-		if err == nil && current.Owner != repository.Owner {
+		ownerChanged := err == nil && current.Owner != repository.Owner
+		if ownerChanged {
 
 			allowed, err := s.CanMoveOrDeleteRepository(subCtx, key)
 			if err != nil {
@@ -51,7 +52,15 @@ func (s *Impl) WriteRepository(ctx context.Context, key string, repository opena
 			result = repositoryWritten
 		}
 
-		s.fireAndForgetKafkaNotification(subCtx, s.repositoryKafkaEvent(key, result.TimeStamp, result.CommitHash))
+		if tx, ok := currentTransaction(subCtx); ok {
+			if ownerChanged {
+				tx.affectOwners(current.Owner, result.Owner)
+			}
+			tx.stageRepository(subCtx, s, key, &result)
+			return nil
+		}
+
+		s.sendUpdateEvent(subCtx, s.repositoryKafkaEvent(key, result.TimeStamp, result.CommitHash))
 
 		// cache update
 		if err := s.updateRepositories(subCtx); err != nil {
@@ -74,7 +83,12 @@ func (s *Impl) DeleteRepository(ctx context.Context, key string, deletionInfo op
 			return err
 		}
 
-		s.fireAndForgetKafkaNotification(subCtx, s.repositoryKafkaEvent(key, repositoryWritten.TimeStamp, repositoryWritten.CommitHash))
+		if tx, ok := currentTransaction(subCtx); ok {
+			tx.stageRepository(subCtx, s, key, nil)
+			return nil
+		}
+
+		s.sendUpdateEvent(subCtx, s.repositoryKafkaEvent(key, repositoryWritten.TimeStamp, repositoryWritten.CommitHash))
 
 		// cache update
 		err = s.updateRepositories(subCtx)
@@ -86,6 +100,10 @@ func (s *Impl) DeleteRepository(ctx context.Context, key string, deletionInfo op
 	})
 }
 
+func (s *Impl) GetRepositoryHistory(ctx context.Context, key string) (openapi.HistoryDto, error) {
+	return s.Mapper.GetRepositoryHistory(ctx, key)
+}
+
 func (s *Impl) repositoryKafkaEvent(key string, timeStamp string, commitHash string) repository.UpdateEvent {
 	return repository.UpdateEvent{
 		Affected: repository.EventAffects{
@@ -164,7 +182,7 @@ func (s *Impl) updateIndividualRepositories(ctx context.Context, repositoryKeysM
 	for key, activity := range repositoryKeysMap {
 		if activity == removeExisting {
 			s.Logging.Logger().Ctx(ctx).Info().Printf("repository %s is no longer current, removing it from the cache", key)
-			s.Cache.DeleteRepository(ctx, key)
+			s.deleteRepository(ctx, key)
 			s.Notifier.PublishDeletion(ctx, key, types.RepositoryPayload)
 		} else {
 			isNew := activity == addNew
@@ -185,7 +203,7 @@ func (s *Impl) RefreshRepository(ctx context.Context, key string) error {
 		return err
 	}
 
-	s.Cache.PutRepository(ctx, key, repository)
+	s.putRepository(ctx, key, repository)
 	s.Logging.Logger().Ctx(ctx).Debug().Printf("repository %s updated in cache per request", key)
 
 	return nil
@@ -204,7 +222,7 @@ func (s *Impl) updateIndividualRepository(ctx context.Context, key string, isNew
 		return err
 	} else {
 		cached, cacheErr := s.Cache.GetRepository(ctx, key)
-		s.Cache.PutRepository(ctx, key, repository)
+		s.putRepository(ctx, key, repository)
 		if isNew {
 			err = s.Notifier.PublishCreation(ctx, key, notifier.AsPayload(repository))
 			if err != nil {
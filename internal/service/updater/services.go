@@ -7,7 +7,9 @@ import (
 	"github.com/Interhyp/metadata-service/internal/acorn/errors/nochangeserror"
 	"github.com/Interhyp/metadata-service/internal/acorn/repository"
 	"github.com/Interhyp/metadata-service/internal/repository/notifier"
+	"github.com/Interhyp/metadata-service/internal/service/util"
 	"github.com/Interhyp/metadata-service/internal/types"
+	"sort"
 )
 
 // --- business logic ---
@@ -28,7 +30,19 @@ func (s *Impl) WriteService(ctx context.Context, serviceName string, service ope
 			}
 			result = serviceWritten
 
-			s.fireAndForgetKafkaNotification(subCtx, s.serviceAndReposKafkaEvent(serviceName, service.Repositories, serviceWritten.TimeStamp, serviceWritten.CommitHash))
+			if tx, ok := currentTransaction(subCtx); ok {
+				tx.affectOwners(current.Owner, serviceWritten.Owner)
+				tx.stageService(subCtx, s, serviceName, &serviceWritten)
+				for _, repoKey := range serviceWritten.Repositories {
+					if repo, err := s.Cache.GetRepository(subCtx, repoKey); err == nil {
+						repo.Owner = serviceWritten.Owner
+						tx.stageRepository(subCtx, s, repoKey, &repo)
+					}
+				}
+				return nil
+			}
+
+			s.sendUpdateEvent(subCtx, s.serviceAndReposKafkaEvent(serviceName, service.Repositories, serviceWritten.TimeStamp, serviceWritten.CommitHash))
 
 			// cache updates (incl. repositories)
 			if err := s.updateServices(subCtx); err != nil {
@@ -50,7 +64,12 @@ func (s *Impl) WriteService(ctx context.Context, serviceName string, service ope
 			}
 			result = serviceWritten
 
-			s.fireAndForgetKafkaNotification(subCtx, s.serviceKafkaEvent(serviceName, serviceWritten.TimeStamp, serviceWritten.CommitHash))
+			if tx, ok := currentTransaction(subCtx); ok {
+				tx.stageService(subCtx, s, serviceName, &serviceWritten)
+				return nil
+			}
+
+			s.sendUpdateEvent(subCtx, s.serviceKafkaEvent(serviceName, serviceWritten.TimeStamp, serviceWritten.CommitHash))
 
 			// cache update
 			if err := s.updateServices(subCtx); err != nil {
@@ -74,7 +93,12 @@ func (s *Impl) DeleteService(ctx context.Context, serviceName string, deletionIn
 			return err
 		}
 
-		s.fireAndForgetKafkaNotification(subCtx, s.serviceKafkaEvent(serviceName, serviceWritten.TimeStamp, serviceWritten.CommitHash))
+		if tx, ok := currentTransaction(subCtx); ok {
+			tx.stageService(subCtx, s, serviceName, nil)
+			return nil
+		}
+
+		s.sendUpdateEvent(subCtx, s.serviceKafkaEvent(serviceName, serviceWritten.TimeStamp, serviceWritten.CommitHash))
 
 		// cache update
 		err = s.updateServices(subCtx)
@@ -86,6 +110,142 @@ func (s *Impl) DeleteService(ctx context.Context, serviceName string, deletionIn
 	})
 }
 
+func (s *Impl) RenameService(ctx context.Context, serviceName string, newServiceName string, service openapi.ServiceDto, renamedRepositories map[string]string) (openapi.ServiceDto, error) {
+	result := service
+	err := s.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		dependents, err := s.serviceDependents(subCtx, serviceName)
+		if err != nil {
+			return err
+		}
+		replaced, err := s.servicesReplacedBy(subCtx, serviceName)
+		if err != nil {
+			return err
+		}
+		dependents = mergeSorted(dependents, replaced)
+		repositoryDependents, err := s.repositoriesReplacedBy(subCtx, renamedRepositories)
+		if err != nil {
+			return err
+		}
+
+		serviceWritten, err := s.Mapper.RenameService(subCtx, serviceName, newServiceName, service, renamedRepositories, dependents, repositoryDependents)
+		if err != nil {
+			return err
+		}
+		result = serviceWritten
+
+		if tx, ok := currentTransaction(subCtx); ok {
+			tx.stageService(subCtx, s, serviceName, nil)
+			tx.stageService(subCtx, s, newServiceName, &serviceWritten)
+			for oldRepoKey, newRepoKey := range renamedRepositories {
+				if repo, err := s.Mapper.GetRepository(subCtx, newRepoKey); err == nil {
+					tx.stageRepository(subCtx, s, oldRepoKey, nil)
+					tx.stageRepository(subCtx, s, newRepoKey, &repo)
+				}
+			}
+			for _, dependent := range dependents {
+				if dependentService, err := s.Mapper.GetService(subCtx, dependent); err == nil {
+					tx.stageService(subCtx, s, dependent, &dependentService)
+				}
+			}
+			for _, dependent := range repositoryDependents {
+				if dependentRepo, err := s.Mapper.GetRepository(subCtx, dependent); err == nil {
+					tx.stageRepository(subCtx, s, dependent, &dependentRepo)
+				}
+			}
+			return nil
+		}
+
+		s.sendUpdateEvent(subCtx, s.serviceRenameKafkaEvent(serviceName, newServiceName, renamedRepositories, dependents, repositoryDependents, serviceWritten.TimeStamp, serviceWritten.CommitHash))
+
+		// cache updates (incl. repositories)
+		if err := s.updateServices(subCtx); err != nil {
+			return err
+		}
+
+		return s.updateRepositories(subCtx)
+	})
+	return result, err
+}
+
+// serviceDependents lists the services whose spec.dependsOn refers to serviceName, from the cache.
+func (s *Impl) serviceDependents(ctx context.Context, serviceName string) ([]string, error) {
+	names, err := s.Cache.GetSortedServiceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	for _, name := range names {
+		if name == serviceName {
+			continue
+		}
+		service, err := s.Cache.GetService(ctx, name)
+		if err != nil || service.Spec == nil {
+			continue
+		}
+		for _, dependency := range service.Spec.DependsOn {
+			if dependency == serviceName {
+				result = append(result, name)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// servicesReplacedBy lists the services whose replacedBy refers to serviceName, from the cache.
+func (s *Impl) servicesReplacedBy(ctx context.Context, serviceName string) ([]string, error) {
+	names, err := s.Cache.GetSortedServiceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	for _, name := range names {
+		service, err := s.Cache.GetService(ctx, name)
+		if err != nil || service.ReplacedBy == nil {
+			continue
+		}
+		if *service.ReplacedBy == serviceName {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+// repositoriesReplacedBy lists the repositories whose replacedBy refers to one of the renamed repositories, from the cache.
+//
+// Renamed repositories are not included, they are rewritten as they are moved.
+func (s *Impl) repositoriesReplacedBy(ctx context.Context, renamedRepositories map[string]string) ([]string, error) {
+	result := make([]string, 0)
+	if len(renamedRepositories) == 0 {
+		return result, nil
+	}
+
+	keys, err := s.Cache.GetSortedRepositoryKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if _, renamed := renamedRepositories[key]; renamed {
+			continue
+		}
+		repo, err := s.Cache.GetRepository(ctx, key)
+		if err != nil || repo.ReplacedBy == nil {
+			continue
+		}
+		if _, ok := renamedRepositories[*repo.ReplacedBy]; ok {
+			result = append(result, key)
+		}
+	}
+	return result, nil
+}
+
+func (s *Impl) GetServiceHistory(ctx context.Context, serviceName string) (openapi.HistoryDto, error) {
+	return s.Mapper.GetServiceHistory(ctx, serviceName)
+}
+
 func (s *Impl) serviceKafkaEvent(serviceName string, timeStamp string, commitHash string) repository.UpdateEvent {
 	return repository.UpdateEvent{
 		Affected: repository.EventAffects{
@@ -110,6 +270,26 @@ func (s *Impl) serviceAndReposKafkaEvent(serviceName string, repoKeys []string,
 	}
 }
 
+func (s *Impl) serviceRenameKafkaEvent(serviceName string, newServiceName string, renamedRepositories map[string]string, dependents []string, repositoryDependents []string, timeStamp string, commitHash string) repository.UpdateEvent {
+	serviceNames := append([]string{serviceName, newServiceName}, dependents...)
+	repoKeys := make([]string, 0, 2*len(renamedRepositories)+len(repositoryDependents))
+	for oldRepoKey, newRepoKey := range renamedRepositories {
+		repoKeys = append(repoKeys, oldRepoKey, newRepoKey)
+	}
+	repoKeys = append(repoKeys, repositoryDependents...)
+	sort.Strings(repoKeys)
+
+	return repository.UpdateEvent{
+		Affected: repository.EventAffects{
+			OwnerAliases:   []string{},
+			ServiceNames:   serviceNames,
+			RepositoryKeys: repoKeys,
+		},
+		TimeStamp:  timeStamp,
+		CommitHash: commitHash,
+	}
+}
+
 func (s *Impl) updateServices(ctx context.Context) error {
 	s.Logging.Logger().Ctx(ctx).Info().Print("updating services")
 
@@ -176,7 +356,7 @@ func (s *Impl) updateIndividualServices(ctx context.Context, serviceNamesMap map
 	for name, activity := range serviceNamesMap {
 		if activity == removeExisting {
 			s.Logging.Logger().Ctx(ctx).Info().Printf("service %s is no longer current, removing it from the cache", name)
-			s.Cache.DeleteService(ctx, name)
+			s.deleteService(ctx, name)
 			s.Notifier.PublishDeletion(ctx, name, types.ServicePayload)
 		} else {
 			isNew := activity == addNew
@@ -197,7 +377,7 @@ func (s *Impl) RefreshService(ctx context.Context, serviceName string) error {
 		return err
 	}
 
-	s.Cache.PutService(ctx, serviceName, service)
+	s.putService(ctx, serviceName, service)
 	s.Logging.Logger().Ctx(ctx).Debug().Printf("service %s updated in cache per request", serviceName)
 
 	return nil
@@ -216,7 +396,7 @@ func (s *Impl) updateIndividualService(ctx context.Context, name string, isNew b
 		return err
 	} else {
 		cached, cacheErr := s.Cache.GetService(ctx, name)
-		s.Cache.PutService(ctx, name, service)
+		s.putService(ctx, name, service)
 		if isNew {
 			err = s.Notifier.PublishCreation(ctx, name, notifier.AsPayload(service))
 			if err != nil {
@@ -229,6 +409,12 @@ func (s *Impl) updateIndividualService(ctx context.Context, name string, isNew b
 				if err != nil {
 					s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("error publishing modification of service %s", service)
 				}
+				if !util.EqualStringPtr(cached.Lifecycle, service.Lifecycle) {
+					err = s.Notifier.PublishLifecycleChange(ctx, name, notifier.AsPayload(service))
+					if err != nil {
+						s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("error publishing lifecycle change of service %s", name)
+					}
+				}
 			}
 
 			s.Logging.Logger().Ctx(ctx).Debug().Printf("service %s updated in cache", name)
@@ -236,3 +422,17 @@ func (s *Impl) updateIndividualService(ctx context.Context, name string, isNew b
 	}
 	return nil
 }
+
+// mergeSorted combines two lists of names into one sorted list without duplicates.
+func mergeSorted(names []string, more []string) []string {
+	seen := make(map[string]bool, len(names)+len(more))
+	result := make([]string, 0, len(names)+len(more))
+	for _, name := range append(append([]string{}, names...), more...) {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,202 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/nosuchcommiterror"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"regexp"
+	"sort"
+	"time"
+)
+
+var commitHashRegex = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+var errReadOnlySnapshot = errors.New("cache snapshot is read only")
+
+func (s *Impl) SnapshotAt(ctx context.Context, at string) (repository.Cache, error) {
+	if _, err := time.Parse(time.RFC3339, at); err != nil && !commitHashRegex.MatchString(at) {
+		s.Logging.Logger().Ctx(ctx).Info().Printf("invalid point in time %v", at)
+		return nil, apierrors.NewBadRequestError("at.invalid", "at must be a commit hash of at least 7 characters or an RFC 3339 timestamp", nil, s.Timestamp.Now())
+	}
+
+	mapper, commitInfo, err := s.Mapper.SnapshotAt(ctx, at)
+	if err != nil {
+		if nosuchcommiterror.Is(err) {
+			s.Logging.Logger().Ctx(ctx).Info().Print(err.Error())
+			return nil, apierrors.NewNotFoundError("commit.notfound", err.Error(), nil, s.Timestamp.Now())
+		}
+		return nil, err
+	}
+
+	return &snapshotCache{
+		Mapper:        mapper,
+		Timestamp:     s.Timestamp,
+		ListTimeStamp: timeStamp(commitInfo.TimeStamp),
+	}, nil
+}
+
+// snapshotCache implements repository.Cache on top of a read only Mapper for a past commit.
+//
+// Entries are read on demand, only the sorted key lists are remembered. Not safe for concurrent use.
+type snapshotCache struct {
+	Mapper        service.Mapper
+	Timestamp     librepo.Timestamp
+	ListTimeStamp string
+
+	ownerAliases   []string
+	serviceNames   []string
+	repositoryKeys []string
+}
+
+func (c *snapshotCache) IsCache() bool {
+	return true
+}
+
+func (c *snapshotCache) Setup() error {
+	return nil
+}
+
+// --- owners ---
+
+func (c *snapshotCache) SetOwnerListTimestamp(_ context.Context, _ string) error {
+	return errReadOnlySnapshot
+}
+
+func (c *snapshotCache) GetOwnerListTimestamp(_ context.Context) (string, error) {
+	return c.ListTimeStamp, nil
+}
+
+func (c *snapshotCache) GetSortedOwnerAliases(ctx context.Context) ([]string, error) {
+	return sortedKeysOnce(ctx, &c.ownerAliases, c.Mapper.GetSortedOwnerAliases)
+}
+
+func (c *snapshotCache) GetOwner(ctx context.Context, alias string) (openapi.OwnerDto, error) {
+	return snapshotEntry(ctx, c, "owner", alias, c.GetSortedOwnerAliases, c.Mapper.GetOwner)
+}
+
+func (c *snapshotCache) PutOwner(_ context.Context, _ string, _ openapi.OwnerDto) error {
+	return errReadOnlySnapshot
+}
+
+func (c *snapshotCache) DeleteOwner(_ context.Context, _ string) error {
+	return errReadOnlySnapshot
+}
+
+// --- services ---
+
+func (c *snapshotCache) SetServiceListTimestamp(_ context.Context, _ string) error {
+	return errReadOnlySnapshot
+}
+
+func (c *snapshotCache) GetServiceListTimestamp(_ context.Context) (string, error) {
+	return c.ListTimeStamp, nil
+}
+
+func (c *snapshotCache) GetSortedServiceNames(ctx context.Context) ([]string, error) {
+	return sortedKeysOnce(ctx, &c.serviceNames, c.Mapper.GetSortedServiceNames)
+}
+
+func (c *snapshotCache) GetService(ctx context.Context, name string) (openapi.ServiceDto, error) {
+	return snapshotEntry(ctx, c, "service", name, c.GetSortedServiceNames, c.Mapper.GetService)
+}
+
+func (c *snapshotCache) PutService(_ context.Context, _ string, _ openapi.ServiceDto) error {
+	return errReadOnlySnapshot
+}
+
+func (c *snapshotCache) DeleteService(_ context.Context, _ string) error {
+	return errReadOnlySnapshot
+}
+
+// --- repositories ---
+
+func (c *snapshotCache) SetRepositoryListTimestamp(_ context.Context, _ string) error {
+	return errReadOnlySnapshot
+}
+
+func (c *snapshotCache) GetRepositoryListTimestamp(_ context.Context) (string, error) {
+	return c.ListTimeStamp, nil
+}
+
+func (c *snapshotCache) GetSortedRepositoryKeys(ctx context.Context) ([]string, error) {
+	return sortedKeysOnce(ctx, &c.repositoryKeys, c.Mapper.GetSortedRepositoryKeys)
+}
+
+func (c *snapshotCache) GetRepository(ctx context.Context, key string) (openapi.RepositoryDto, error) {
+	return snapshotEntry(ctx, c, "repository", key, c.GetSortedRepositoryKeys, c.Mapper.GetRepository)
+}
+
+func (c *snapshotCache) PutRepository(_ context.Context, _ string, _ openapi.RepositoryDto) error {
+	return errReadOnlySnapshot
+}
+
+func (c *snapshotCache) DeleteRepository(_ context.Context, _ string) error {
+	return errReadOnlySnapshot
+}
+
+// --- overlay ---
+
+func (c *snapshotCache) WithOverlay(ctx context.Context) context.Context {
+	return ctx
+}
+
+// --- notifications ---
+
+func (c *snapshotCache) WasNotificationSent(_ context.Context, _ string) (bool, error) {
+	return false, errReadOnlySnapshot
+}
+
+func (c *snapshotCache) MarkNotificationSent(_ context.Context, _ string) error {
+	return errReadOnlySnapshot
+}
+
+func (c *snapshotCache) ObtainLock(ctx context.Context, _ string) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, errReadOnlySnapshot
+}
+
+// --- helpers ---
+
+func sortedKeysOnce(ctx context.Context, remembered *[]string, load func(context.Context) ([]string, error)) ([]string, error) {
+	if *remembered == nil {
+		keys, err := load(ctx)
+		if err != nil {
+			return []string{}, err
+		}
+		sort.Strings(keys)
+		*remembered = keys
+	}
+
+	// hand out a copy, just like the real cache
+	result := make([]string, len(*remembered))
+	copy(result, *remembered)
+	return result, nil
+}
+
+// snapshotEntry mirrors the not found behaviour of the real cache, the mapper would just fail to read the file.
+func snapshotEntry[E any](
+	ctx context.Context,
+	c *snapshotCache,
+	what string,
+	key string,
+	keys func(context.Context) ([]string, error),
+	get func(context.Context, string) (E, error),
+) (E, error) {
+	var empty E
+
+	existing, err := keys(ctx)
+	if err != nil {
+		return empty, err
+	}
+	index := sort.SearchStrings(existing, key)
+	if index >= len(existing) || existing[index] != key {
+		return empty, apierrors.NewNotFoundError(fmt.Sprintf("%s.notfound", what), fmt.Sprintf("%s %s not found", what, key), nil, c.Timestamp.Now())
+	}
+
+	return get(ctx, key)
+}
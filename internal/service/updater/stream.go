@@ -0,0 +1,98 @@
+package updater
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+)
+
+// --- event stream ---
+
+type entityKey struct {
+	entityType string
+	key        string
+}
+
+// pendingChange is an update event waiting to be published on the event stream until the cache reflects it.
+type pendingChange struct {
+	event repository.UpdateEvent
+	// owners of the affected entities before the change, absent if the entity did not exist
+	ownersBefore map[entityKey]string
+}
+
+// sendUpdateEvent notifies other instances via kafka, and subscribers of the event stream once the cache is updated.
+//
+// Must be called while holding the lock, before the cache update.
+func (s *Impl) sendUpdateEvent(ctx context.Context, event repository.UpdateEvent) {
+	s.queueChangeEvent(ctx, event)
+	s.fireAndForgetKafkaNotification(ctx, event)
+}
+
+// queueChangeEvent remembers the event for publishing when the lock is released. An event for a commit
+// that is already queued is dropped, so a commit announced via kafka is not published again once it is pulled.
+//
+// Must be called while holding the lock, before the cache update.
+func (s *Impl) queueChangeEvent(ctx context.Context, event repository.UpdateEvent) {
+	if event.CommitHash != "" {
+		for _, queued := range s.pendingChanges {
+			if queued.event.CommitHash == event.CommitHash {
+				return
+			}
+		}
+	}
+
+	change := pendingChange{
+		event:        event,
+		ownersBefore: make(map[entityKey]string),
+	}
+	s.forEachAffected(ctx, event, func(id entityKey, owner string, exists bool) {
+		if exists {
+			change.ownersBefore[id] = owner
+		}
+	})
+	s.pendingChanges = append(s.pendingChanges, change)
+}
+
+// publishChangeEvents is called by WithMetadataLock just before it releases the lock.
+func (s *Impl) publishChangeEvents(ctx context.Context) {
+	for _, change := range s.pendingChanges {
+		result := openapi.ChangeEventDto{
+			CommitHash: change.event.CommitHash,
+			TimeStamp:  change.event.TimeStamp,
+			Entities:   make([]openapi.ChangedEntityDto, 0),
+		}
+		s.forEachAffected(ctx, change.event, func(id entityKey, owner string, exists bool) {
+			before, existed := change.ownersBefore[id]
+			entity := openapi.ChangedEntityDto{
+				Type:    id.entityType,
+				Key:     id.key,
+				Owner:   owner,
+				Deleted: !exists,
+			}
+			if !exists {
+				entity.Owner = before
+			} else if existed && before != owner {
+				entity.PreviousOwner = &before
+			}
+			result.Entities = append(result.Entities, entity)
+		})
+		s.EventStream.Publish(ctx, result)
+	}
+	s.pendingChanges = nil
+}
+
+// forEachAffected looks up the current owner of each entity affected by the event in the cache.
+func (s *Impl) forEachAffected(ctx context.Context, event repository.UpdateEvent, callback func(id entityKey, owner string, exists bool)) {
+	for _, alias := range event.Affected.OwnerAliases {
+		_, err := s.Cache.GetOwner(ctx, alias)
+		callback(entityKey{entityType: repository.SearchTypeOwner, key: alias}, alias, err == nil)
+	}
+	for _, name := range event.Affected.ServiceNames {
+		service, err := s.Cache.GetService(ctx, name)
+		callback(entityKey{entityType: repository.SearchTypeService, key: name}, service.Owner, err == nil)
+	}
+	for _, key := range event.Affected.RepositoryKeys {
+		repo, err := s.Cache.GetRepository(ctx, key)
+		callback(entityKey{entityType: repository.SearchTypeRepository, key: key}, repo.Owner, err == nil)
+	}
+}
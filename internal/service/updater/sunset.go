@@ -0,0 +1,136 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/repository/notifier"
+	"github.com/Interhyp/metadata-service/internal/types"
+)
+
+// sunsetLockKey makes sure only one instance sends sunset notifications at a time.
+const sunsetLockKey = "sunset-notifications"
+
+func (s *Impl) NotifySunsets(ctx context.Context) error {
+	ctx, unlock, err := s.Cache.ObtainLock(ctx, sunsetLockKey)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	now := s.Timestamp.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	serviceNames, err := s.Cache.GetSortedServiceNames(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range serviceNames {
+		service, err := s.Cache.GetService(ctx, name)
+		if err != nil {
+			continue
+		}
+		event, ok := s.sunsetEvent(today, service.SunsetDate)
+		if !ok {
+			continue
+		}
+		dependents, err := s.serviceDependents(ctx, name)
+		if err != nil {
+			return err
+		}
+		s.notifySunset(ctx, name, event, *service.SunsetDate, notifier.AsPayload(service), service.Owner, dependents)
+	}
+
+	repoKeys, err := s.Cache.GetSortedRepositoryKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range repoKeys {
+		repo, err := s.Cache.GetRepository(ctx, key)
+		if err != nil {
+			continue
+		}
+		event, ok := s.sunsetEvent(today, repo.SunsetDate)
+		if !ok {
+			continue
+		}
+		dependents, err := s.repositoryDependents(ctx, key)
+		if err != nil {
+			return err
+		}
+		s.notifySunset(ctx, key, event, *repo.SunsetDate, notifier.AsPayload(repo), repo.Owner, dependents)
+	}
+	return nil
+}
+
+// sunsetEvent decides which event is due for an entity with the given sunset date, if any.
+func (s *Impl) sunsetEvent(today time.Time, sunsetDate *string) (types.NotificationEventType, bool) {
+	if sunsetDate == nil {
+		return 0, false
+	}
+	sunset, err := time.Parse(time.DateOnly, *sunsetDate)
+	if err != nil {
+		return 0, false
+	}
+
+	days := int(sunset.Sub(today).Hours() / 24)
+	if days <= 0 {
+		return types.SunsetPassedEvent, true
+	}
+	if days <= int(s.CustomConfiguration.SunsetWarningDays()) {
+		return types.SunsetApproachingEvent, true
+	}
+	return 0, false
+}
+
+// notifySunset sends the event to the owner and each dependent, unless it has already been sent to them for this sunset date.
+//
+// What has been sent is remembered in the cache, and only once sending has succeeded, so failures are retried on the next run.
+func (s *Impl) notifySunset(ctx context.Context, name string, event types.NotificationEventType, sunsetDate string, payload openapi.NotificationPayload, ownerAlias string, dependents []string) {
+	kind := types.RepositoryPayload
+	if payload.Service != nil {
+		kind = types.ServicePayload
+	}
+
+	recipients := []openapi.NotificationRecipient{{Name: ownerAlias, Type: types.OwnerPayload.String()}}
+	for _, dependent := range dependents {
+		recipients = append(recipients, openapi.NotificationRecipient{Name: dependent, Type: types.ServicePayload.String()})
+	}
+	for _, recipient := range recipients {
+		sentKey := fmt.Sprintf("sunset %s %s %s %s %s %s", kind.String(), name, event.String(), sunsetDate, recipient.Type, recipient.Name)
+		sent, err := s.Cache.WasNotificationSent(ctx, sentKey)
+		if err != nil || sent {
+			continue
+		}
+		if err := s.Notifier.PublishSunset(ctx, name, event, payload, recipient); err != nil {
+			s.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Printf("failed to publish %s notification for %s to %s %s", event.String(), name, recipient.Type, recipient.Name)
+			continue
+		}
+		_ = s.Cache.MarkNotificationSent(ctx, sentKey)
+	}
+}
+
+// repositoryDependents lists the services that refer to the repository.
+func (s *Impl) repositoryDependents(ctx context.Context, key string) ([]string, error) {
+	names, err := s.Cache.GetSortedServiceNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	for _, name := range names {
+		service, err := s.Cache.GetService(ctx, name)
+		if err != nil {
+			continue
+		}
+		for _, repoKey := range service.Repositories {
+			if repoKey == key {
+				result = append(result, name)
+				break
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,147 @@
+package updater
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/nochangeserror"
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+)
+
+type transactionType int
+
+const transactionKey transactionType = 0
+
+// transaction tracks everything written or deleted while it is open.
+//
+// The written entities are put into a cache overlay that only the context of the transaction sees, so later
+// steps of the same transaction see them, while the shared cache and the search index stay untouched until commit.
+type transaction struct {
+	affected repository.EventAffects
+}
+
+func newTransaction() *transaction {
+	return &transaction{
+		affected: repository.EventAffects{
+			OwnerAliases:   []string{},
+			ServiceNames:   []string{},
+			RepositoryKeys: []string{},
+		},
+	}
+}
+
+func currentTransaction(ctx context.Context) (*transaction, bool) {
+	tx, ok := ctx.Value(transactionKey).(*transaction)
+	return tx, ok
+}
+
+func (s *Impl) WithTransaction(ctx context.Context, jiraIssue string, closure func(context.Context) error) (repository.UpdateEvent, error) {
+	result := repository.UpdateEvent{}
+	err := s.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		if _, ok := currentTransaction(subCtx); ok {
+			s.Logging.Logger().Ctx(ctx).Info().Print("already inside a transaction")
+			return closure(subCtx)
+		}
+
+		if err := s.PerformFullUpdate(subCtx); err != nil {
+			return err
+		}
+
+		tx := newTransaction()
+		commitInfo, err := s.Mapper.WithTransaction(s.transactionContext(subCtx, tx), jiraIssue, closure)
+		if err != nil {
+			if nochangeserror.Is(err) {
+				// there were no actual changes, this is acceptable
+				return nil
+			}
+			return err
+		}
+
+		result = repository.UpdateEvent{
+			Affected:   tx.affected,
+			TimeStamp:  timeStamp(commitInfo.TimeStamp),
+			CommitHash: commitInfo.CommitHash,
+		}
+		s.sendUpdateEvent(subCtx, result)
+
+		// cache updates
+		if err := s.updateOwners(subCtx); err != nil {
+			return err
+		}
+
+		if err := s.updateServices(subCtx); err != nil {
+			return err
+		}
+
+		return s.updateRepositories(subCtx)
+	})
+	return result, err
+}
+
+func (s *Impl) DryRun(ctx context.Context, closure func(context.Context) error) (string, error) {
+	diff := ""
+	err := s.WithMetadataLock(ctx, func(subCtx context.Context) error {
+		if err := s.PerformFullUpdate(subCtx); err != nil {
+			return err
+		}
+
+		var err error
+		diff, err = s.Mapper.DryRun(s.transactionContext(subCtx, newTransaction()), closure)
+		return err
+	})
+	return diff, err
+}
+
+// transactionContext marks the child context as inside tx, and gives it its own cache overlay.
+//
+// Once the transaction ends, the overlay is dropped along with the context, so nothing needs to be undone.
+func (s *Impl) transactionContext(ctx context.Context, tx *transaction) context.Context {
+	return s.Cache.WithOverlay(context.WithValue(ctx, transactionKey, tx))
+}
+
+// stageOwner puts the owner into the cache overlay of ctx, or removes it if nil.
+func (tx *transaction) stageOwner(ctx context.Context, s *Impl, alias string, owner *openapi.OwnerDto) {
+	tx.affected.OwnerAliases = appendUnique(tx.affected.OwnerAliases, alias)
+	stage(ctx, alias, owner, s.Cache.PutOwner, s.Cache.DeleteOwner)
+}
+
+// stageService puts the service into the cache overlay of ctx, or removes it if nil.
+func (tx *transaction) stageService(ctx context.Context, s *Impl, name string, service *openapi.ServiceDto) {
+	tx.affected.ServiceNames = appendUnique(tx.affected.ServiceNames, name)
+	stage(ctx, name, service, s.Cache.PutService, s.Cache.DeleteService)
+}
+
+// stageRepository puts the repository into the cache overlay of ctx, or removes it if nil.
+func (tx *transaction) stageRepository(ctx context.Context, s *Impl, key string, repo *openapi.RepositoryDto) {
+	tx.affected.RepositoryKeys = appendUnique(tx.affected.RepositoryKeys, key)
+	stage(ctx, key, repo, s.Cache.PutRepository, s.Cache.DeleteRepository)
+}
+
+// affectOwners lists owners whose services or repositories changed hands, without the owners themselves being written.
+func (tx *transaction) affectOwners(aliases ...string) {
+	for _, alias := range aliases {
+		tx.affected.OwnerAliases = appendUnique(tx.affected.OwnerAliases, alias)
+	}
+}
+
+func stage[E any](
+	ctx context.Context,
+	key string,
+	value *E,
+	put func(context.Context, string, E) error,
+	del func(context.Context, string) error,
+) {
+	if value != nil {
+		_ = put(ctx, key, *value)
+	} else {
+		_ = del(ctx, key)
+	}
+}
+
+func appendUnique(keys []string, key string) []string {
+	for _, existing := range keys {
+		if existing == key {
+			return keys
+		}
+	}
+	return append(keys, key)
+}
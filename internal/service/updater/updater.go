@@ -24,8 +24,12 @@ type Impl struct {
 	Notifier            repository.Notifier
 	Mapper              service.Mapper
 	Cache               repository.Cache
+	SearchIndex         repository.SearchIndex
+	EventStream         repository.EventStream
 
 	mu sync.Mutex
+	// only accessed while holding the lock
+	pendingChanges []pendingChange
 
 	totalErrorCounter    prometheus.Counter
 	metadataErrorCounter prometheus.Counter
@@ -43,6 +47,8 @@ func New(
 	notifier repository.Notifier,
 	mapper service.Mapper,
 	cache repository.Cache,
+	searchIndex repository.SearchIndex,
+	eventStream repository.EventStream,
 ) service.Updater {
 	return &Impl{
 		Configuration:       configuration,
@@ -53,6 +59,8 @@ func New(
 		Notifier:            notifier,
 		Mapper:              mapper,
 		Cache:               cache,
+		SearchIndex:         searchIndex,
+		EventStream:         eventStream,
 	}
 }
 
@@ -160,6 +168,8 @@ func (s *Impl) WithMetadataLock(ctx context.Context, closure func(context.Contex
 
 		subCtx := context.WithValue(ctx, lockKey, true)
 		err := closure(subCtx)
+		// the cache now reflects everything that happened while holding the lock
+		s.publishChangeEvents(subCtx)
 		return err
 	} else {
 		s.Logging.Logger().Ctx(ctx).Info().Print("thread already holds metadata lock")
@@ -170,9 +180,20 @@ func (s *Impl) WithMetadataLock(ctx context.Context, closure func(context.Contex
 
 func (s *Impl) PerformFullUpdate(ctx context.Context) error {
 	return s.WithMetadataLock(ctx, func(subCtx context.Context) error {
-		if _, err := s.updateMetadata(subCtx); err != nil {
+		if _, ok := currentTransaction(subCtx); ok {
+			// the local clone holds staged changes, and the cache overlay of the transaction reflects them
+			s.Logging.Logger().Ctx(ctx).Info().Print("inside a transaction, skipping full update")
+			return nil
+		}
+
+		events, err := s.updateMetadata(subCtx)
+		if err != nil {
 			return err
 		}
+		// other instances have been notified by whoever made these commits
+		for _, event := range events {
+			s.queueChangeEvent(subCtx, event)
+		}
 
 		if err := s.updateOwners(subCtx); err != nil {
 			return err
@@ -196,6 +217,9 @@ func (s *Impl) PerformFullUpdateWithNotifications(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		for _, event := range events {
+			s.queueChangeEvent(subCtx, event)
+		}
 
 		if err := s.updateOwners(subCtx); err != nil {
 			return err
@@ -246,6 +270,7 @@ func (s *Impl) kafkaReceiverCallback(event repository.UpdateEvent) {
 	err := s.WithMetadataLock(ctx, func(subCtx context.Context) error {
 		if s.Mapper.ContainsNewInformation(subCtx, event) {
 			s.Logging.Logger().Ctx(subCtx).Info().Printf("received kafka event for new commit hash %s - updating local caches", event.CommitHash)
+			s.queueChangeEvent(subCtx, event)
 			return s.PerformFullUpdate(subCtx)
 		}
 		return nil
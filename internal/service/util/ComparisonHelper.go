@@ -0,0 +1,9 @@
+package util
+
+// EqualStringPtr compares two optional strings by value. Two nil pointers are equal.
+func EqualStringPtr(first *string, second *string) bool {
+	if first == nil || second == nil {
+		return first == second
+	}
+	return *first == *second
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"time"
+)
+
+// ValidateDeprecationDates checks that deprecatedSince and sunsetDate are ISO-8601 dates, and that a service or
+// repository is not sunset before it is deprecated.
+func ValidateDeprecationDates(violations *Violations, deprecatedSince *string, sunsetDate *string) {
+	since, sinceOk := parseDeprecationDate(violations, "deprecatedSince", deprecatedSince)
+	sunset, sunsetOk := parseDeprecationDate(violations, "sunsetDate", sunsetDate)
+	if sinceOk && sunsetOk && sunset.Before(since) {
+		violations.Add("sunsetDate", ProblemInvalid, "field sunsetDate must not be before deprecatedSince", map[string]string{
+			"deprecatedSince": *deprecatedSince,
+		})
+	}
+}
+
+func parseDeprecationDate(violations *Violations, field string, value *string) (time.Time, bool) {
+	if value == nil {
+		return time.Time{}, false
+	}
+	parsed, err := time.Parse(time.DateOnly, *value)
+	if err != nil {
+		violations.Add(field, ProblemInvalid, "field "+field+" must be an ISO-8601 date like 2023-12-31", nil)
+		return time.Time{}, false
+	}
+	return parsed, true
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func p(v string) *string {
+	return &v
+}
+
+func TestValidateDeprecationDates_Valid(t *testing.T) {
+	violations := NewViolations("service")
+	ValidateDeprecationDates(violations, nil, nil)
+	ValidateDeprecationDates(violations, p("2023-01-31"), nil)
+	ValidateDeprecationDates(violations, nil, p("2023-06-30"))
+	ValidateDeprecationDates(violations, p("2023-01-31"), p("2023-06-30"))
+	ValidateDeprecationDates(violations, p("2023-06-30"), p("2023-06-30"))
+	require.True(t, violations.Empty())
+}
+
+func TestValidateDeprecationDates_Invalid(t *testing.T) {
+	violations := NewViolations("service")
+	ValidateDeprecationDates(violations, p("yesterday"), p("2023-06-30T00:00:00Z"))
+	require.Equal(t, "field deprecatedSince must be an ISO-8601 date like 2023-12-31, field sunsetDate must be an ISO-8601 date like 2023-12-31", violations.Details())
+}
+
+func TestValidateDeprecationDates_Unordered(t *testing.T) {
+	violations := NewViolations("service")
+	ValidateDeprecationDates(violations, p("2023-07-01"), p("2023-06-30"))
+	require.Equal(t, "field sunsetDate must not be before deprecatedSince", violations.Details())
+}
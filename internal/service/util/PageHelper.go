@@ -0,0 +1,126 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/Interhyp/metadata-service/internal/types"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"sort"
+	"time"
+)
+
+// cursor is handed out to clients, base64 encoded, to continue a listing on the next page.
+type cursor struct {
+	// TimeStamp is the list timestamp the listing was started with
+	TimeStamp string `json:"timeStamp"`
+	// After is the last key on the previous page
+	After string `json:"after"`
+}
+
+// Pager walks through a sorted list of keys, page by page.
+//
+// Entries may be filtered out after the pager has handed out their key, so call Take for every entry
+// that actually made it into the page, and stop once Full.
+type Pager struct {
+	stamp     string
+	limit     int
+	remaining []string
+	taken     []string
+	lastIndex int
+}
+
+// NewPager starts the page requested by page over the ascending sorted keys.
+//
+// Cursors are only valid for the list timestamp they were created with. Once the list has changed,
+// a conflict error is returned, so the client can start over.
+func NewPager(keys []string, stamp string, page types.PageRequest, now time.Time) (*Pager, error) {
+	if page.Limit < 0 {
+		return nil, apierrors.NewBadRequestError("page.invalid.limit", "limit must not be negative", nil, now)
+	}
+
+	start := 0
+	end := len(keys)
+	if page.Cursor != "" {
+		parsed, err := parseCursor(page.Cursor)
+		if err != nil {
+			return nil, apierrors.NewBadRequestError("page.invalid.cursor", "cursor is invalid, use the nextCursor from a previous page", err, now)
+		}
+		if parsed.TimeStamp != stamp {
+			return nil, apierrors.NewConflictError("page.cursor.outdated",
+				fmt.Sprintf("the list has changed since %s, please start over", parsed.TimeStamp), nil, now)
+		}
+
+		// works even if the key has since been removed
+		position := sort.SearchStrings(keys, parsed.After)
+		if page.Descending {
+			end = position
+		} else {
+			start = position
+			if start < len(keys) && keys[start] == parsed.After {
+				start++
+			}
+		}
+	}
+
+	remaining := make([]string, 0, end-start)
+	if page.Descending {
+		for i := end - 1; i >= start; i-- {
+			remaining = append(remaining, keys[i])
+		}
+	} else {
+		remaining = append(remaining, keys[start:end]...)
+	}
+
+	return &Pager{
+		stamp:     stamp,
+		limit:     page.Limit,
+		remaining: remaining,
+		lastIndex: -1,
+	}, nil
+}
+
+// Keys gives the keys that may go into the page, in page order.
+func (p *Pager) Keys() []string {
+	return p.remaining
+}
+
+// Take records that the entry with the given index in Keys has made it into the page.
+func (p *Pager) Take(index int) {
+	p.taken = append(p.taken, p.remaining[index])
+	p.lastIndex = index
+}
+
+// Taken gives the keys of the entries that made it into the page, in page order.
+//
+// Map keys are always sorted in the JSON output, so this is the only place clients can see the order.
+func (p *Pager) Taken() []string {
+	return p.taken
+}
+
+// Full is true once the page has reached its limit.
+func (p *Pager) Full() bool {
+	return p.limit > 0 && len(p.taken) >= p.limit
+}
+
+// NextCursor gives the cursor for the next page, or an empty string if this was the last page.
+func (p *Pager) NextCursor() string {
+	if !p.Full() || p.lastIndex+1 >= len(p.remaining) {
+		return ""
+	}
+	raw, _ := json.Marshal(cursor{
+		TimeStamp: p.stamp,
+		After:     p.remaining[p.lastIndex],
+	})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func parseCursor(encoded string) (cursor, error) {
+	result := cursor{}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(raw, &result)
+	return result, err
+}
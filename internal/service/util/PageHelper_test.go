@@ -0,0 +1,85 @@
+package util
+
+import (
+	"github.com/Interhyp/metadata-service/internal/types"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var pageKeys = []string{"a", "b", "c", "d", "e"}
+
+const pageStamp = "2022-11-06T18:14:10Z"
+
+func collectPage(t *testing.T, page types.PageRequest) ([]string, string) {
+	pager, err := NewPager(pageKeys, pageStamp, page, time.Now())
+	require.Nil(t, err)
+	result := make([]string, 0)
+	for i, key := range pager.Keys() {
+		if pager.Full() {
+			break
+		}
+		result = append(result, key)
+		pager.Take(i)
+	}
+	return result, pager.NextCursor()
+}
+
+func TestPager_NoLimit(t *testing.T) {
+	keys, next := collectPage(t, types.PageRequest{})
+	require.Equal(t, pageKeys, keys)
+	require.Equal(t, "", next)
+}
+
+func TestPager_Ascending(t *testing.T) {
+	keys, next := collectPage(t, types.PageRequest{Limit: 2})
+	require.Equal(t, []string{"a", "b"}, keys)
+
+	keys, next = collectPage(t, types.PageRequest{Limit: 2, Cursor: next})
+	require.Equal(t, []string{"c", "d"}, keys)
+
+	keys, next = collectPage(t, types.PageRequest{Limit: 2, Cursor: next})
+	require.Equal(t, []string{"e"}, keys)
+	require.Equal(t, "", next)
+}
+
+func TestPager_Descending(t *testing.T) {
+	keys, next := collectPage(t, types.PageRequest{Limit: 3, Descending: true})
+	require.Equal(t, []string{"e", "d", "c"}, keys)
+
+	keys, next = collectPage(t, types.PageRequest{Limit: 3, Descending: true, Cursor: next})
+	require.Equal(t, []string{"b", "a"}, keys)
+	require.Equal(t, "", next)
+}
+
+func TestPager_ExactlyFullLastPage(t *testing.T) {
+	keys, next := collectPage(t, types.PageRequest{Limit: 5})
+	require.Equal(t, pageKeys, keys)
+	require.Equal(t, "", next)
+}
+
+func TestPager_InvalidCursor(t *testing.T) {
+	_, err := NewPager(pageKeys, pageStamp, types.PageRequest{Cursor: "not-a-cursor!"}, time.Now())
+	require.True(t, apierrors.IsBadRequestError(err))
+}
+
+func TestPager_OutdatedCursor(t *testing.T) {
+	pager, err := NewPager(pageKeys, pageStamp, types.PageRequest{Limit: 1}, time.Now())
+	require.Nil(t, err)
+	pager.Take(0)
+
+	_, err = NewPager(pageKeys, "2022-11-07T00:00:00Z", types.PageRequest{Cursor: pager.NextCursor()}, time.Now())
+	require.True(t, apierrors.IsConflictError(err))
+}
+
+func TestPager_TakenSkipsFilteredEntries(t *testing.T) {
+	pager, err := NewPager(pageKeys, pageStamp, types.PageRequest{Descending: true}, time.Now())
+	require.Nil(t, err)
+	for i, key := range pager.Keys() {
+		if key != "c" {
+			pager.Take(i)
+		}
+	}
+	require.Equal(t, []string{"e", "d", "b", "a"}, pager.Taken())
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Interhyp/metadata-service/internal/types"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"time"
+)
+
+// ApplyPatch applies a JSON Merge Patch or JSON Patch to the json representation of an entity.
+//
+// A patch that does not apply, or whose result is not a valid entity, is a bad request.
+func ApplyPatch[T any](ctx context.Context, kind string, current T, patch types.PatchDocument, now time.Time) (T, error) {
+	var result T
+
+	original, err := json.Marshal(current)
+	if err != nil {
+		return result, err
+	}
+
+	var patched []byte
+	switch patch.Format {
+	case types.MergePatch:
+		patched, err = jsonpatch.MergePatch(original, patch.Body)
+	case types.JsonPatch:
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(patch.Body)
+		if err == nil {
+			patched, err = operations.Apply(original)
+		}
+	default:
+		err = fmt.Errorf("unsupported patch format %s", patch.Format)
+	}
+	if err != nil {
+		aulogging.Logger.Ctx(ctx).Info().Printf("%s patch failed to apply: %s", kind, err.Error())
+		return result, apierrors.NewBadRequestError(kind+".invalid.patch", fmt.Sprintf("patch failed to apply: %s", err.Error()), err, now)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&result); err != nil {
+		aulogging.Logger.Ctx(ctx).Info().Printf("%s patch result invalid: %s", kind, err.Error())
+		return result, apierrors.NewBadRequestError(kind+".invalid.patch", fmt.Sprintf("patch result is not a valid %s: %s", kind, err.Error()), err, now)
+	}
+	return result, nil
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"github.com/Interhyp/metadata-service/api"
 	"strings"
 )
 
@@ -27,6 +28,55 @@ func SplitUsersAndGroups(userAndGroups []string) ([]string, []string) {
 	return users, groups
 }
 
+// RenameGroupOwner changes all group references @oldOwner.group to @newOwner.group.
+//
+// Returns a new slice, and whether anything was changed.
+func RenameGroupOwner(userAndGroups []string, oldOwner string, newOwner string) ([]string, bool) {
+	changed := false
+	result := make([]string, len(userAndGroups))
+	for i, userOrGroup := range userAndGroups {
+		isGroup, groupOwner, groupName := ParseGroupOwnerAndGroupName(userOrGroup)
+		if isGroup && groupOwner == oldOwner {
+			result[i] = "@" + newOwner + "." + groupName
+			changed = true
+		} else {
+			result[i] = userOrGroup
+		}
+	}
+	return result, changed
+}
+
+// RenameGroupOwnerInRepositoryConfiguration changes all group references @oldOwner.group to @newOwner.group in the
+// approvers and watchers of a repository.
+//
+// Returns a copy if anything was changed, leaving the given configuration untouched, and whether anything was changed.
+func RenameGroupOwnerInRepositoryConfiguration(configuration *openapi.RepositoryConfigurationDto, oldOwner string, newOwner string) (*openapi.RepositoryConfigurationDto, bool) {
+	if configuration == nil {
+		return nil, false
+	}
+
+	changed := false
+	result := *configuration
+	if configuration.Approvers != nil {
+		approvers := make(map[string][]string, len(*configuration.Approvers))
+		for groupName, groupApprovers := range *configuration.Approvers {
+			renamed, ok := RenameGroupOwner(groupApprovers, oldOwner, newOwner)
+			approvers[groupName] = renamed
+			changed = changed || ok
+		}
+		result.Approvers = &approvers
+	}
+	if renamed, ok := RenameGroupOwner(configuration.Watchers, oldOwner, newOwner); ok {
+		result.Watchers = renamed
+		changed = true
+	}
+
+	if !changed {
+		return configuration, false
+	}
+	return &result, true
+}
+
 func Equal(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
@@ -22,3 +22,15 @@ func TestParseGroupOwnerAndGroupName(t *testing.T) {
 	require.Equal(t, "", ownerOfGroup)
 	require.Equal(t, "", nameOfGroup)
 }
+
+func TestRenameGroupOwner(t *testing.T) {
+	original := []string{"someUser", "@oldOwner.someGroupName", "@otherOwner.someGroupName", "@oldOwner"}
+
+	renamed, changed := RenameGroupOwner(original, "oldOwner", "newOwner")
+	require.True(t, changed)
+	require.Equal(t, []string{"someUser", "@newOwner.someGroupName", "@otherOwner.someGroupName", "@oldOwner"}, renamed)
+	require.Equal(t, "@oldOwner.someGroupName", original[1])
+
+	_, changed = RenameGroupOwner(original, "unknownOwner", "newOwner")
+	require.False(t, changed)
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"fmt"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/validationerror"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProblemMissing = "missing"
+	ProblemInvalid = "invalid"
+	ProblemTooLong = "tooLong"
+	ProblemPolicy  = "policy"
+)
+
+// Violations collects the problems found while validating an owner, service or repository, so they can be
+// reported together, each attached to the field it is about.
+type Violations struct {
+	kind string
+	list []openapi.ViolationDto
+}
+
+// NewViolations starts validating an entity of the given kind (owner, service, repository, deletion).
+func NewViolations(kind string) *Violations {
+	return &Violations{
+		kind: kind,
+		list: make([]openapi.ViolationDto, 0),
+	}
+}
+
+// Add records a problem with a field.
+//
+// The field is a dot separated path, such as spec.dependsOn or repositories.1. The code is made from kind, field
+// and problem, leaving out list indices, e.g. service.repositories.invalid. An empty field refers to the whole entity.
+func (v *Violations) Add(field string, problem string, message string, parameters map[string]string) {
+	segments := make([]string, 0)
+	pointer := ""
+	if field != "" {
+		segments = strings.Split(field, ".")
+		pointer = "/" + strings.Join(segments, "/")
+	}
+	codeSegments := []string{v.kind}
+	for _, segment := range segments {
+		if _, err := strconv.Atoi(segment); err != nil {
+			codeSegments = append(codeSegments, segment)
+		}
+	}
+	codeSegments = append(codeSegments, problem)
+
+	v.list = append(v.list, openapi.ViolationDto{
+		Pointer:    pointer,
+		Code:       strings.Join(codeSegments, "."),
+		Message:    message,
+		Parameters: parameters,
+	})
+}
+
+// Missing records that a mandatory field has no value.
+func (v *Violations) Missing(field string, message string) {
+	v.Add(field, ProblemMissing, message, nil)
+}
+
+func (v *Violations) Empty() bool {
+	return len(v.list) == 0
+}
+
+// List gives the violations recorded so far, or nil if there are none.
+func (v *Violations) List() []openapi.ViolationDto {
+	if v.Empty() {
+		return nil
+	}
+	return v.list
+}
+
+// Details joins the messages of all violations.
+func (v *Violations) Details() string {
+	messages := make([]string, 0, len(v.list))
+	for _, violation := range v.list {
+		messages = append(messages, violation.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Error gives a bad request error listing all violations, or nil if there are none.
+func (v *Violations) Error(now time.Time) error {
+	if v.Empty() {
+		return nil
+	}
+	return validationerror.New(v.kind+".invalid.values", fmt.Sprintf("validation error: %s", v.Details()), v.list, now)
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/validationerror"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestViolations_None(t *testing.T) {
+	violations := NewViolations("service")
+	require.True(t, violations.Empty())
+	require.Nil(t, violations.Error(time.Now()))
+}
+
+func TestViolations_Error(t *testing.T) {
+	violations := NewViolations("service")
+	violations.Missing("alertTarget", "field alertTarget is mandatory")
+	violations.Add("repositories.2", ProblemInvalid, "repository key invalid", map[string]string{"key": "x.y"})
+	violations.Add("spec.dependsOn", ProblemInvalid, "no such service", nil)
+
+	err := violations.Error(time.Now())
+	require.True(t, apierrors.IsBadRequestError(err))
+	require.Equal(t, "service.invalid.values", err.Error())
+	require.Equal(t, "validation error: field alertTarget is mandatory, repository key invalid, no such service", *err.(apierrors.AnnotatedError).ApiError().Details)
+	require.Equal(t, []openapi.ViolationDto{
+		{Pointer: "/alertTarget", Code: "service.alertTarget.missing", Message: "field alertTarget is mandatory"},
+		{Pointer: "/repositories/2", Code: "service.repositories.invalid", Message: "repository key invalid", Parameters: map[string]string{"key": "x.y"}},
+		{Pointer: "/spec/dependsOn", Code: "service.spec.dependsOn.invalid", Message: "no such service"},
+	}, validationerror.Violations(err))
+}
+
+func TestViolations_WholeEntity(t *testing.T) {
+	violations := NewViolations("service")
+	violations.Add("", ProblemPolicy, "violates policy", nil)
+
+	require.Equal(t, []openapi.ViolationDto{
+		{Pointer: "", Code: "service.policy", Message: "violates policy"},
+	}, violations.List())
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/preconditionerror"
+	"github.com/Interhyp/metadata-service/internal/types"
+	aulogging "github.com/StephanHCB/go-autumn-logging"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"time"
+)
+
+// Version identifies the state of an entity a change is based on.
+type Version struct {
+	TimeStamp  string
+	CommitHash string
+}
+
+// IsConditional tells whether writes in this context carry an If-Match precondition, which
+// makes timeStamp and commitHash in the body optional.
+func IsConditional(ctx context.Context) bool {
+	_, ok := types.IfMatch(ctx)
+	return ok
+}
+
+// CheckVersion ensures a change to an entity is based on its current version.
+//
+// The client gives the version either in the body, as an If-Match precondition, or both. An outdated version in
+// the body is a conflict, a failed precondition gives status 412. Both errors carry the current entity as response.
+func CheckVersion(ctx context.Context, kind string, name string, current any, currentVersion Version, basedOn Version, now time.Time) error {
+	if ifMatch, ok := types.IfMatch(ctx); ok {
+		if !types.MatchesEntityTag(ifMatch, types.EntityTag(currentVersion.CommitHash), false) {
+			aulogging.Logger.Ctx(ctx).Info().Printf("%s %v does not match If-Match %s", kind, name, ifMatch)
+			return preconditionerror.New(kind+".precondition.failed", fmt.Sprintf("%s %v does not match the If-Match precondition", kind, name), current, now)
+		}
+		if basedOn.TimeStamp == "" && basedOn.CommitHash == "" {
+			return nil
+		}
+	}
+
+	if currentVersion != basedOn {
+		aulogging.Logger.Ctx(ctx).Info().Printf("%s %v was concurrently updated", kind, name)
+		return apierrors.NewConflictErrorWithResponse(kind+".conflict.concurrentlyupdated", fmt.Sprintf("%s %v was concurrently updated", kind, name), nil, current, now)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// EntityTag gives the strong entity tag of an owner, service or repository, derived from its commit hash.
+func EntityTag(commitHash string) string {
+	return `"` + commitHash + `"`
+}
+
+// ListTag gives the weak entity tag of a list, derived from a hash of its contents.
+//
+// The list timestamp only has a resolution of one second, so it cannot tell apart two changes within the same second.
+func ListTag(list any) string {
+	raw, _ := json.Marshal(list)
+	hash := sha256.Sum256(raw)
+	return `W/"` + hex.EncodeToString(hash[:20]) + `"`
+}
+
+// MatchesEntityTag checks whether an If-Match or If-None-Match header value lists the given entity tag.
+//
+// If-None-Match uses the weak comparison, If-Match the strong one, where weak tags never match.
+func MatchesEntityTag(header string, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if !strings.HasPrefix(candidate, "W/") && candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+type ifMatchKeyType struct{}
+
+var ifMatchKey = ifMatchKeyType{}
+
+// WithIfMatch makes writes in the returned context conditional on the value of an If-Match header.
+func WithIfMatch(ctx context.Context, header string) context.Context {
+	return context.WithValue(ctx, ifMatchKey, header)
+}
+
+// IfMatch gives the If-Match header value the writes in this context are conditional on, if any.
+func IfMatch(ctx context.Context) (string, bool) {
+	header, ok := ctx.Value(ifMatchKey).(string)
+	return header, ok
+}
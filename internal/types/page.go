@@ -0,0 +1,15 @@
+package types
+
+// PageRequest selects a page of a list of entities, ordered by their keys.
+//
+// The zero value selects the whole list.
+type PageRequest struct {
+	// Limit is the maximum number of entries on the page, 0 means no limit.
+	Limit int
+
+	// Cursor continues a previous listing where its page ended, empty to start at the beginning.
+	Cursor string
+
+	// Descending reverses the order of the keys.
+	Descending bool
+}
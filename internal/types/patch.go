@@ -0,0 +1,17 @@
+package types
+
+// PatchDocument is a standards based patch of an owner, service or repository.
+type PatchDocument struct {
+	// Format is the media type of Body, one of MergePatch or JsonPatch.
+	Format string
+
+	Body []byte
+}
+
+const (
+	// MergePatch is a JSON Merge Patch according to RFC 7396.
+	MergePatch = "application/merge-patch+json"
+
+	// JsonPatch is a JSON Patch according to RFC 6902.
+	JsonPatch = "application/json-patch+json"
+)
@@ -23,6 +23,9 @@ const (
 	CreatedEvent NotificationEventType = iota
 	ModifiedEvent
 	DeletedEvent
+	LifecycleChangedEvent
+	SunsetApproachingEvent
+	SunsetPassedEvent
 )
 
 func (p NotificationEventType) String() string {
@@ -33,6 +36,12 @@ func (p NotificationEventType) String() string {
 		return "MODIFIED"
 	case DeletedEvent:
 		return "DELETED"
+	case LifecycleChangedEvent:
+		return "LIFECYCLE_CHANGED"
+	case SunsetApproachingEvent:
+		return "SUNSET_APPROACHING"
+	case SunsetPassedEvent:
+		return "SUNSET_PASSED"
 	default:
 		return ""
 	}
@@ -9,21 +9,36 @@ import (
 	"github.com/Interhyp/metadata-service/internal/repository/bitbucket"
 	"github.com/Interhyp/metadata-service/internal/repository/cache"
 	"github.com/Interhyp/metadata-service/internal/repository/config"
+	"github.com/Interhyp/metadata-service/internal/repository/eventstream"
 	"github.com/Interhyp/metadata-service/internal/repository/hostip"
 	"github.com/Interhyp/metadata-service/internal/repository/idp"
 	"github.com/Interhyp/metadata-service/internal/repository/kafka"
+	"github.com/Interhyp/metadata-service/internal/repository/linkchecker"
 	"github.com/Interhyp/metadata-service/internal/repository/metadata"
 	"github.com/Interhyp/metadata-service/internal/repository/notifier"
+	"github.com/Interhyp/metadata-service/internal/repository/searchindex"
 	"github.com/Interhyp/metadata-service/internal/repository/sshAuthProvider"
+	"github.com/Interhyp/metadata-service/internal/service/consistency"
+	"github.com/Interhyp/metadata-service/internal/service/events"
+	"github.com/Interhyp/metadata-service/internal/service/graphql"
+	"github.com/Interhyp/metadata-service/internal/service/links"
 	"github.com/Interhyp/metadata-service/internal/service/mapper"
 	"github.com/Interhyp/metadata-service/internal/service/owners"
+	"github.com/Interhyp/metadata-service/internal/service/policies"
 	"github.com/Interhyp/metadata-service/internal/service/repositories"
+	"github.com/Interhyp/metadata-service/internal/service/search"
 	"github.com/Interhyp/metadata-service/internal/service/services"
+	"github.com/Interhyp/metadata-service/internal/service/transactions"
 	"github.com/Interhyp/metadata-service/internal/service/trigger"
 	"github.com/Interhyp/metadata-service/internal/service/updater"
+	"github.com/Interhyp/metadata-service/internal/web/controller/eventsctl"
+	"github.com/Interhyp/metadata-service/internal/web/controller/graphqlctl"
+	"github.com/Interhyp/metadata-service/internal/web/controller/managementctl"
 	"github.com/Interhyp/metadata-service/internal/web/controller/ownerctl"
 	"github.com/Interhyp/metadata-service/internal/web/controller/repositoryctl"
+	"github.com/Interhyp/metadata-service/internal/web/controller/searchctl"
 	"github.com/Interhyp/metadata-service/internal/web/controller/servicectl"
+	"github.com/Interhyp/metadata-service/internal/web/controller/transactionctl"
 	"github.com/Interhyp/metadata-service/internal/web/controller/webhookctl"
 	"github.com/Interhyp/metadata-service/internal/web/server"
 	libcontroller "github.com/StephanHCB/go-backend-service-common/acorns/controller"
@@ -51,6 +66,9 @@ type ApplicationImpl struct {
 	SshAuthProvider  repository.SshAuthProvider
 	Notifier         repository.Notifier
 	Cache            repository.Cache
+	SearchIndex      repository.SearchIndex
+	EventStream      repository.EventStream
+	LinkChecker      repository.LinkChecker
 
 	// services (business logic)
 	Mapper       service.Mapper
@@ -59,14 +77,26 @@ type ApplicationImpl struct {
 	Owners       service.Owners
 	Services     service.Services
 	Repositories service.Repositories
+	Transactions service.Transactions
+	Search       service.Search
+	Events       service.Events
+	Graphql      service.Graphql
+	Links        service.Links
+	Policies     service.Policies
+	Consistency  service.Consistency
 
 	// controllers (incoming connectors)
-	HealthCtl     libcontroller.HealthController
-	SwaggerCtl    libcontroller.SwaggerController
-	OwnerCtl      controller.OwnerController
-	ServiceCtl    controller.ServiceController
-	RepositoryCtl controller.RepositoryController
-	WebhookCtl    controller.WebhookController
+	HealthCtl      libcontroller.HealthController
+	SwaggerCtl     libcontroller.SwaggerController
+	OwnerCtl       controller.OwnerController
+	ServiceCtl     controller.ServiceController
+	RepositoryCtl  controller.RepositoryController
+	WebhookCtl     controller.WebhookController
+	TransactionCtl controller.TransactionController
+	SearchCtl      controller.SearchController
+	EventsCtl      controller.EventsController
+	GraphqlCtl     controller.GraphqlController
+	ManagementCtl  controller.ManagementController
 
 	// server/web stack
 	Server application.Server
@@ -192,6 +222,21 @@ func (a *ApplicationImpl) ConstructRepositories() error {
 		return err
 	}
 
+	a.SearchIndex = searchindex.New(a.Logging)
+	if err := a.SearchIndex.Setup(); err != nil {
+		return err
+	}
+
+	a.EventStream = eventstream.New(a.Logging)
+	if err := a.EventStream.Setup(); err != nil {
+		return err
+	}
+
+	a.LinkChecker = linkchecker.New(a.Config, a.CustomConfig, a.Logging)
+	if err := a.LinkChecker.Setup(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -203,31 +248,66 @@ func (a *ApplicationImpl) ConstructServices() error {
 		return err
 	}
 
-	a.Updater = updater.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Kafka, a.Notifier, a.Mapper, a.Cache)
+	a.Updater = updater.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Kafka, a.Notifier, a.Mapper, a.Cache, a.SearchIndex, a.EventStream)
 	if err := a.Updater.Setup(); err != nil {
 		return err
 	}
 
-	a.Trigger = trigger.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Updater)
+	a.Links = links.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Cache, a.LinkChecker)
+	if err := a.Links.Setup(); err != nil {
+		return err
+	}
+
+	a.Consistency = consistency.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Cache)
+	if err := a.Consistency.Setup(); err != nil {
+		return err
+	}
+
+	a.Trigger = trigger.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Updater, a.Links, a.Consistency)
 	if err := a.Trigger.Setup(); err != nil {
 		return err
 	}
 
-	a.Owners = owners.New(a.Config, a.Logging, a.Timestamp, a.Cache, a.Updater)
+	a.Policies = policies.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Cache)
+	if err := a.Policies.Setup(); err != nil {
+		return err
+	}
+
+	a.Owners = owners.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Cache, a.Updater, a.Policies)
 	if err := a.Owners.Setup(); err != nil {
 		return err
 	}
 
-	a.Repositories = repositories.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Cache, a.Updater, a.Owners)
+	a.Repositories = repositories.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Cache, a.Updater, a.Owners, a.Policies)
 	if err := a.Repositories.Setup(); err != nil {
 		return err
 	}
 
-	a.Services = services.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Cache, a.Updater, a.Repositories)
+	a.Services = services.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Cache, a.Updater, a.Owners, a.Repositories, a.Bitbucket, a.Policies)
 	if err := a.Services.Setup(); err != nil {
 		return err
 	}
 
+	a.Transactions = transactions.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Cache, a.Updater, a.Owners, a.Services, a.Repositories)
+	if err := a.Transactions.Setup(); err != nil {
+		return err
+	}
+
+	a.Search = search.New(a.Config, a.Logging, a.Timestamp, a.SearchIndex)
+	if err := a.Search.Setup(); err != nil {
+		return err
+	}
+
+	a.Events = events.New(a.Config, a.Logging, a.Timestamp, a.EventStream)
+	if err := a.Events.Setup(); err != nil {
+		return err
+	}
+
+	a.Graphql = graphql.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Cache)
+	if err := a.Graphql.Setup(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -236,13 +316,18 @@ func (a *ApplicationImpl) ConstructControllers() error {
 
 	a.HealthCtl = healthctl.NewNoAcorn()
 	a.SwaggerCtl = swaggerctl.NewNoAcorn()
-	a.OwnerCtl = ownerctl.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Owners)
-	a.ServiceCtl = servicectl.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Services)
-	a.RepositoryCtl = repositoryctl.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Repositories)
+	a.OwnerCtl = ownerctl.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Owners, a.Updater)
+	a.ServiceCtl = servicectl.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Services, a.Updater, a.Links)
+	a.RepositoryCtl = repositoryctl.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Repositories, a.Updater)
 	a.WebhookCtl = webhookctl.New(a.Logging, a.Timestamp, a.Updater)
+	a.TransactionCtl = transactionctl.New(a.Config, a.CustomConfig, a.Logging, a.Timestamp, a.Transactions)
+	a.SearchCtl = searchctl.New(a.Logging, a.Timestamp, a.Search)
+	a.EventsCtl = eventsctl.New(a.Logging, a.Timestamp, a.Events)
+	a.GraphqlCtl = graphqlctl.New(a.Logging, a.Timestamp, a.Graphql)
+	a.ManagementCtl = managementctl.New(a.Logging, a.Timestamp, a.Consistency)
 
 	a.Server = server.New(a.Config, a.CustomConfig, a.Logging, a.IdentityProvider,
-		a.HealthCtl, a.SwaggerCtl, a.OwnerCtl, a.ServiceCtl, a.RepositoryCtl, a.WebhookCtl)
+		a.HealthCtl, a.SwaggerCtl, a.OwnerCtl, a.ServiceCtl, a.RepositoryCtl, a.WebhookCtl, a.TransactionCtl, a.SearchCtl, a.EventsCtl, a.GraphqlCtl, a.ManagementCtl)
 	if err := a.Server.Setup(); err != nil {
 		return err
 	}
@@ -0,0 +1,121 @@
+package eventsctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Interhyp/metadata-service/internal/acorn/controller"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	"github.com/Interhyp/metadata-service/internal/web/util"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-http-utils/headers"
+	"net/http"
+	"time"
+)
+
+const typeParam = "type"
+const ownerParam = "owner"
+
+const lastEventIdHeader = "Last-Event-ID"
+
+const (
+	eventChange = "change"
+	eventReset  = "reset"
+)
+
+// keeps proxies from closing idle connections
+const keepAliveInterval = 20 * time.Second
+
+type Impl struct {
+	Logging   librepo.Logging
+	Timestamp librepo.Timestamp
+	Events    service.Events
+}
+
+func New(
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	events service.Events,
+) controller.EventsController {
+	return &Impl{
+		Logging:   logging,
+		Timestamp: timestamp,
+		Events:    events,
+	}
+}
+
+func (c *Impl) IsEventsController() bool {
+	return true
+}
+
+func (c *Impl) WireUp(_ context.Context, router chi.Router) {
+	router.Get("/rest/api/v1/events/stream", c.Stream)
+}
+
+// --- handlers ---
+
+func (c *Impl) Stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	entityType := util.StringQueryParam(r, typeParam)
+	owner := util.StringQueryParam(r, ownerParam)
+	lastEventId := r.Header.Get(lastEventIdHeader)
+
+	subscription, cancel, err := c.Events.Subscribe(ctx, entityType, owner, lastEventId)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+	defer cancel()
+
+	responseController := http.NewResponseController(w)
+	w.Header().Set(headers.ContentType, "text/event-stream")
+	w.Header().Set(headers.CacheControl, "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	if !subscription.Resumed {
+		c.write(ctx, w, eventReset, "", struct{}{})
+	}
+	for _, event := range subscription.Replay {
+		c.write(ctx, w, eventChange, event.CommitHash, event)
+	}
+	if err := responseController.Flush(); err != nil {
+		c.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Print("event stream does not support flushing, giving up")
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// dropped because we could not keep up, the client reconnects with the last event id
+				return
+			}
+			c.write(ctx, w, eventChange, event.CommitHash, event)
+		}
+		if err := responseController.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// --- helpers
+
+func (c *Impl) write(ctx context.Context, w http.ResponseWriter, eventType string, id string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		c.Logging.Logger().Ctx(ctx).Warn().WithErr(err).Print("failed to marshal event, skipping it")
+		return
+	}
+	if id != "" {
+		_, _ = fmt.Fprintf(w, "id: %s\n", id)
+	}
+	_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, payload)
+}
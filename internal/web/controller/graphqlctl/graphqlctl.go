@@ -0,0 +1,98 @@
+package graphqlctl
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/controller"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	"github.com/Interhyp/metadata-service/internal/web/util"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+const queryParam = "query"
+const operationNameParam = "operationName"
+const variablesParam = "variables"
+
+type Impl struct {
+	Logging   librepo.Logging
+	Timestamp librepo.Timestamp
+	Graphql   service.Graphql
+}
+
+func New(
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	graphql service.Graphql,
+) controller.GraphqlController {
+	return &Impl{
+		Logging:   logging,
+		Timestamp: timestamp,
+		Graphql:   graphql,
+	}
+}
+
+func (c *Impl) IsGraphqlController() bool {
+	return true
+}
+
+func (c *Impl) WireUp(_ context.Context, router chi.Router) {
+	router.Get("/graphql", c.QueryGet)
+	router.Post("/graphql", c.QueryPost)
+}
+
+// --- handlers ---
+
+func (c *Impl) QueryGet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	request := openapi.GraphqlRequestDto{
+		Query:         util.StringQueryParam(r, queryParam),
+		OperationName: util.StringQueryParam(r, operationNameParam),
+	}
+	if variables := util.StringQueryParam(r, variablesParam); variables != "" {
+		if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+			c.Logging.Logger().Ctx(ctx).Info().Printf("graphql variables invalid: %s", err.Error())
+			apierrors.HandleError(ctx, w, r, apierrors.NewBadRequestError("graphql.invalid.variables", "variables failed to parse", err, c.Timestamp.Now()), apierrors.IsBadRequestError)
+			return
+		}
+	}
+
+	c.execute(ctx, w, r, request)
+}
+
+func (c *Impl) QueryPost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	request, err := c.parseBodyToGraphqlRequestDto(ctx, r)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	c.execute(ctx, w, r, request)
+}
+
+// --- helpers
+
+func (c *Impl) execute(ctx context.Context, w http.ResponseWriter, r *http.Request, request openapi.GraphqlRequestDto) {
+	response := c.Graphql.Execute(ctx, request)
+	if response.Data == nil && len(response.Errors) > 0 {
+		// the query was not executed at all
+		util.SuccessWithStatus(ctx, w, r, response, http.StatusBadRequest)
+	} else {
+		util.Success(ctx, w, r, response)
+	}
+}
+
+func (c *Impl) parseBodyToGraphqlRequestDto(ctx context.Context, r *http.Request) (openapi.GraphqlRequestDto, error) {
+	decoder := json.NewDecoder(r.Body)
+	dto := openapi.GraphqlRequestDto{}
+	err := decoder.Decode(&dto)
+	if err != nil {
+		c.Logging.Logger().Ctx(ctx).Info().Printf("graphql body invalid: %s", err.Error())
+		return openapi.GraphqlRequestDto{}, apierrors.NewBadRequestError("graphql.invalid.body", "body failed to parse", err, c.Timestamp.Now())
+	}
+	return dto, nil
+}
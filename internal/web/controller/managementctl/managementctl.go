@@ -0,0 +1,51 @@
+package managementctl
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/internal/acorn/controller"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	"github.com/Interhyp/metadata-service/internal/web/util"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+type Impl struct {
+	Logging     librepo.Logging
+	Timestamp   librepo.Timestamp
+	Consistency service.Consistency
+}
+
+func New(
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	consistency service.Consistency,
+) controller.ManagementController {
+	return &Impl{
+		Logging:     logging,
+		Timestamp:   timestamp,
+		Consistency: consistency,
+	}
+}
+
+func (c *Impl) IsManagementController() bool {
+	return true
+}
+
+func (c *Impl) WireUp(_ context.Context, router chi.Router) {
+	router.Get("/rest/api/v1/management/consistency", c.GetConsistencyReport)
+}
+
+// --- handlers ---
+
+func (c *Impl) GetConsistencyReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	report, err := c.Consistency.CheckConsistency(ctx)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err)
+	} else {
+		util.Success(ctx, w, r, report)
+	}
+}
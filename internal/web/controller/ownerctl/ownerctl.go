@@ -7,22 +7,28 @@ import (
 	"github.com/Interhyp/metadata-service/api"
 	"github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Interhyp/metadata-service/internal/acorn/controller"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/preconditionerror"
 	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	"github.com/Interhyp/metadata-service/internal/types"
 	"github.com/Interhyp/metadata-service/internal/web/util"
 	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
 	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
 	"github.com/StephanHCB/go-backend-service-common/web/middleware/security"
 	"github.com/go-chi/chi/v5"
 	"net/http"
-	"net/url"
 )
 
+const atParam = "at"
+
+const ownersField = "owners"
+
 type Impl struct {
 	Configuration       librepo.Configuration
 	CustomConfiguration config.CustomConfiguration
 	Logging             librepo.Logging
 	Timestamp           librepo.Timestamp
 	Owners              service.Owners
+	Updater             service.Updater
 }
 
 func New(
@@ -31,6 +37,7 @@ func New(
 	logging librepo.Logging,
 	timestamp librepo.Timestamp,
 	owners service.Owners,
+	updater service.Updater,
 ) controller.OwnerController {
 	return &Impl{
 		Configuration:       configuration,
@@ -38,6 +45,7 @@ func New(
 		Logging:             logging,
 		Timestamp:           timestamp,
 		Owners:              owners,
+		Updater:             updater,
 	}
 }
 
@@ -55,6 +63,9 @@ func (c *Impl) WireUp(_ context.Context, router chi.Router) {
 	router.Put(ownerEndpoint, c.UpdateOwner)
 	router.Patch(ownerEndpoint, c.PatchOwner)
 	router.Delete(ownerEndpoint, c.DeleteOwner)
+	router.Get(ownerEndpoint+"/history", c.GetOwnerHistory)
+	router.Post(ownerEndpoint+"/revert", c.RevertOwner)
+	router.Post(ownerEndpoint+"/rename", c.RenameOwner)
 }
 
 // --- handlers ---
@@ -62,23 +73,64 @@ func (c *Impl) WireUp(_ context.Context, router chi.Router) {
 func (c *Impl) GetOwners(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	owners, err := c.Owners.GetOwners(ctx)
+	at := util.StringQueryParam(r, atParam)
+	page, err := util.PageQueryParams(ctx, r, c.Timestamp.Now())
 	if err != nil {
-		apierrors.HandleError(ctx, w, r, err)
-	} else {
-		util.Success(ctx, w, r, owners)
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+	fields, err := util.FieldsQueryParam(ctx, r, openapi.OwnerDto{}, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	if at != "" {
+		owners, err := c.Owners.GetOwnersAt(ctx, at, page)
+		if err != nil {
+			apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError, apierrors.IsNotFoundError, apierrors.IsConflictError)
+		} else if !util.NotModified(w, r, types.ListTag(owners)) {
+			util.SuccessWithFields(ctx, w, r, owners, ownersField, fields)
+		}
+		return
+	}
+
+	owners, err := c.Owners.GetOwners(ctx, page)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError, apierrors.IsConflictError)
+	} else if !util.NotModified(w, r, types.ListTag(owners)) {
+		util.SuccessWithFields(ctx, w, r, owners, ownersField, fields)
 	}
 }
 
 func (c *Impl) GetSingleOwner(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	owner := util.StringPathParam(r, "owner")
+	at := util.StringQueryParam(r, atParam)
 
-	ownerDto, err := c.Owners.GetOwner(ctx, owner)
+	var ownerDto openapi.OwnerDto
+	var err error
+	if at != "" {
+		ownerDto, err = c.Owners.GetOwnerAt(ctx, at, owner)
+	} else {
+		ownerDto, err = c.Owners.GetOwner(ctx, owner)
+	}
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError, apierrors.IsNotFoundError)
+	} else if !util.NotModified(w, r, types.EntityTag(ownerDto.CommitHash)) {
+		util.Success(ctx, w, r, ownerDto)
+	}
+}
+
+func (c *Impl) GetOwnerHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	owner := util.StringPathParam(r, "owner")
+
+	history, err := c.Owners.GetOwnerHistory(ctx, owner)
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err, apierrors.IsNotFoundError)
 	} else {
-		util.Success(ctx, w, r, ownerDto)
+		util.Success(ctx, w, r, history)
 	}
 }
 
@@ -93,8 +145,14 @@ func (c *Impl) CreateOwner(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	alias := util.StringPathParam(r, "owner")
-	if err := c.validOwnerAlias(ctx, alias); err != nil {
+	if err := c.Owners.ValidOwnerAlias(ctx, alias); err != nil {
 		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
 		return
 	}
@@ -104,13 +162,18 @@ func (c *Impl) CreateOwner(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ownerWritten, err := c.Owners.CreateOwner(ctx, alias, ownerCreateDto)
+	ownerWritten, diff, err := util.WriteOrDryRun(ctx, c.Updater, dryRun, func(subCtx context.Context) (openapi.OwnerDto, error) {
+		return c.Owners.CreateOwner(subCtx, alias, ownerCreateDto)
+	})
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsConflictError,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Owner: &ownerWritten, Diff: diff})
 	} else {
+		util.SetETag(w, ownerWritten.CommitHash)
 		util.SuccessWithStatus(ctx, w, r, ownerWritten, http.StatusCreated)
 	}
 }
@@ -126,6 +189,12 @@ func (c *Impl) UpdateOwner(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	alias := util.StringPathParam(r, "owner")
 	ownerDto, err := c.parseBodyToOwnerDto(ctx, r)
 	if err != nil {
@@ -133,14 +202,20 @@ func (c *Impl) UpdateOwner(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ownerWritten, err := c.Owners.UpdateOwner(ctx, alias, ownerDto)
+	ownerWritten, diff, err := util.WriteOrDryRun(util.WithIfMatch(ctx, r), c.Updater, dryRun, func(subCtx context.Context) (openapi.OwnerDto, error) {
+		return c.Owners.UpdateOwner(subCtx, alias, ownerDto)
+	})
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsNotFoundError,
 			apierrors.IsConflictError,
+			preconditionerror.Is,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Owner: &ownerWritten, Diff: diff})
 	} else {
+		util.SetETag(w, ownerWritten.CommitHash)
 		util.Success(ctx, w, r, ownerWritten)
 	}
 }
@@ -156,21 +231,31 @@ func (c *Impl) PatchOwner(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	alias := util.StringPathParam(r, "owner")
-	ownerPatch, err := c.parseBodyToOwnerPatchDto(ctx, r)
+	write, err := c.ownerPatchWrite(ctx, r, alias)
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
 		return
 	}
 
-	ownerWritten, err := c.Owners.PatchOwner(ctx, alias, ownerPatch)
+	ownerWritten, diff, err := util.WriteOrDryRun(util.WithIfMatch(ctx, r), c.Updater, dryRun, write)
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsNotFoundError,
 			apierrors.IsConflictError,
+			preconditionerror.Is,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Owner: &ownerWritten, Diff: diff})
 	} else {
+		util.SetETag(w, ownerWritten.CommitHash)
 		util.Success(ctx, w, r, ownerWritten)
 	}
 }
@@ -186,6 +271,12 @@ func (c *Impl) DeleteOwner(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	alias := util.StringPathParam(r, "owner")
 	info, err := util.ParseBodyToDeletionDto(ctx, r, c.Timestamp.Now())
 	if err != nil {
@@ -193,34 +284,112 @@ func (c *Impl) DeleteOwner(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.Owners.DeleteOwner(ctx, alias, info)
+	_, diff, err := util.WriteOrDryRun(ctx, c.Updater, dryRun, func(subCtx context.Context) (struct{}, error) {
+		return struct{}{}, c.Owners.DeleteOwner(subCtx, alias, info)
+	})
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsNotFoundError,
 			apierrors.IsConflictError,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Diff: diff})
 	} else {
 		util.SuccessNoBody(ctx, w, r, http.StatusNoContent)
 	}
 }
 
-// --- helpers
+func (c *Impl) RevertOwner(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := security.IsAuthenticated(ctx, "anonymous tried RevertOwner", c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsUnauthorisedError)
+		return
+	}
+	if err := security.HasGroup(ctx, c.CustomConfiguration.AuthGroupWrite(), fmt.Sprintf("%s tried RevertOwner", security.Subject(ctx)), c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsForbiddenError)
+		return
+	}
+
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	alias := util.StringPathParam(r, "owner")
+	info, err := util.ParseBodyToRevertDto(ctx, r, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	reverted, diff, err := util.WriteOrDryRun(ctx, c.Updater, dryRun, func(subCtx context.Context) (openapi.OwnerDto, error) {
+		return c.Owners.RevertOwner(subCtx, alias, info)
+	})
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err,
+			apierrors.IsBadRequestError,
+			apierrors.IsNotFoundError,
+			apierrors.IsConflictError,
+			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Owner: &reverted, Diff: diff})
+	} else {
+		util.Success(ctx, w, r, reverted)
+	}
+}
+
+func (c *Impl) RenameOwner(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := security.IsAuthenticated(ctx, "anonymous tried RenameOwner", c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsUnauthorisedError)
+		return
+	}
+	if err := security.HasGroup(ctx, c.CustomConfiguration.AuthGroupWrite(), fmt.Sprintf("%s tried RenameOwner", security.Subject(ctx)), c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsForbiddenError)
+		return
+	}
+
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
 
-func (c *Impl) validOwnerAlias(ctx context.Context, owner string) apierrors.AnnotatedError {
-	if c.CustomConfiguration.OwnerAliasPermittedRegex().MatchString(owner) &&
-		!c.CustomConfiguration.OwnerAliasProhibitedRegex().MatchString(owner) &&
-		uint16(len(owner)) <= c.CustomConfiguration.OwnerAliasMaxLength() {
-		return nil
+	alias := util.StringPathParam(r, "owner")
+	info, err := c.parseBodyToOwnerRenameDto(ctx, r)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+	if info.NewAlias != "" {
+		if err := c.Owners.ValidOwnerAlias(ctx, info.NewAlias); err != nil {
+			apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+			return
+		}
 	}
 
-	c.Logging.Logger().Ctx(ctx).Info().Printf("owner parameter %v invalid", url.QueryEscape(owner))
-	permitted := c.CustomConfiguration.OwnerAliasPermittedRegex().String()
-	prohibited := c.CustomConfiguration.OwnerAliasProhibitedRegex().String()
-	max := c.CustomConfiguration.OwnerAliasMaxLength()
-	return apierrors.NewBadRequestError("owner.invalid.alias", fmt.Sprintf("owner alias must match %s, is not allowed to match %s and may have up to %d characters", permitted, prohibited, max), nil, c.Timestamp.Now())
+	renamed, diff, err := util.WriteOrDryRun(util.WithIfMatch(ctx, r), c.Updater, dryRun, func(subCtx context.Context) (openapi.OwnerDto, error) {
+		return c.Owners.RenameOwner(subCtx, alias, info)
+	})
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err,
+			apierrors.IsBadRequestError,
+			apierrors.IsNotFoundError,
+			apierrors.IsConflictError,
+			preconditionerror.Is,
+			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Owner: &renamed, Diff: diff})
+	} else {
+		util.SetETag(w, renamed.CommitHash)
+		util.Success(ctx, w, r, renamed)
+	}
 }
 
+// --- helpers
+
 func (c *Impl) parseBodyToOwnerDto(ctx context.Context, r *http.Request) (openapi.OwnerDto, error) {
 	decoder := json.NewDecoder(r.Body)
 	dto := openapi.OwnerDto{}
@@ -243,6 +412,31 @@ func (c *Impl) parseBodyToOwnerCreateDto(ctx context.Context, r *http.Request) (
 	return dto, nil
 }
 
+func (c *Impl) parseBodyToOwnerRenameDto(ctx context.Context, r *http.Request) (openapi.OwnerRenameDto, error) {
+	decoder := json.NewDecoder(r.Body)
+	dto := openapi.OwnerRenameDto{}
+	err := decoder.Decode(&dto)
+	if err != nil {
+		c.Logging.Logger().Ctx(ctx).Info().Printf("owner rename body invalid: %s", err.Error())
+		return openapi.OwnerRenameDto{}, apierrors.NewBadRequestError("owner.invalid.body", "body failed to parse", err, c.Timestamp.Now())
+	}
+	return dto, nil
+}
+
+// ownerPatchWrite parses the body of a PATCH request, which is either a standard patch document or a owner patch dto.
+func (c *Impl) ownerPatchWrite(ctx context.Context, r *http.Request, alias string) (func(context.Context) (openapi.OwnerDto, error), error) {
+	if patch, ok, err := util.ParseBodyToPatchDocument(ctx, r, c.Timestamp.Now()); ok {
+		return func(subCtx context.Context) (openapi.OwnerDto, error) {
+			return c.Owners.ApplyOwnerPatch(subCtx, alias, patch)
+		}, err
+	}
+
+	ownerPatch, err := c.parseBodyToOwnerPatchDto(ctx, r)
+	return func(subCtx context.Context) (openapi.OwnerDto, error) {
+		return c.Owners.PatchOwner(subCtx, alias, ownerPatch)
+	}, err
+}
+
 func (c *Impl) parseBodyToOwnerPatchDto(ctx context.Context, r *http.Request) (openapi.OwnerPatchDto, error) {
 	decoder := json.NewDecoder(r.Body)
 	dto := openapi.OwnerPatchDto{}
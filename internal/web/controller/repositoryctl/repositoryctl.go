@@ -7,7 +7,9 @@ import (
 	"github.com/Interhyp/metadata-service/api"
 	"github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Interhyp/metadata-service/internal/acorn/controller"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/preconditionerror"
 	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	"github.com/Interhyp/metadata-service/internal/types"
 	"github.com/Interhyp/metadata-service/internal/web/util"
 	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
 	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
@@ -20,6 +22,10 @@ const ownerParam = "owner"
 const serviceParam = "service"
 const nameParam = "name"
 const typeParam = "type"
+const atParam = "at"
+const queryParam = "q"
+
+const repositoriesField = "repositories"
 
 type Impl struct {
 	Configuration       librepo.Configuration
@@ -27,6 +33,7 @@ type Impl struct {
 	Logging             librepo.Logging
 	Timestamp           librepo.Timestamp
 	Repositories        service.Repositories
+	Updater             service.Updater
 }
 
 func New(
@@ -35,6 +42,7 @@ func New(
 	logging librepo.Logging,
 	timestamp librepo.Timestamp,
 	repositories service.Repositories,
+	updater service.Updater,
 ) controller.RepositoryController {
 	return &Impl{
 		Configuration:       configuration,
@@ -42,6 +50,7 @@ func New(
 		Logging:             logging,
 		Timestamp:           timestamp,
 		Repositories:        repositories,
+		Updater:             updater,
 	}
 }
 
@@ -59,6 +68,8 @@ func (c *Impl) WireUp(_ context.Context, router chi.Router) {
 	router.Put(repositoryEndpoint, c.UpdateRepository)
 	router.Patch(repositoryEndpoint, c.PatchRepository)
 	router.Delete(repositoryEndpoint, c.DeleteRepository)
+	router.Get(repositoryEndpoint+"/history", c.GetRepositoryHistory)
+	router.Post(repositoryEndpoint+"/revert", c.RevertRepository)
 }
 
 // --- handlers ---
@@ -69,31 +80,75 @@ func (c *Impl) GetRepositories(w http.ResponseWriter, r *http.Request) {
 	serviceNameFilter := util.StringQueryParam(r, serviceParam)
 	nameFilter := util.StringQueryParam(r, nameParam)
 	typeFilter := util.StringQueryParam(r, typeParam)
+	queryFilter := util.StringQueryParam(r, queryParam)
+	at := util.StringQueryParam(r, atParam)
+	page, err := util.PageQueryParams(ctx, r, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+	fields, err := util.FieldsQueryParam(ctx, r, openapi.RepositoryDto{}, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	if at != "" {
+		repositories, err := c.Repositories.GetRepositoriesAt(ctx, at,
+			ownerAliasFilter, serviceNameFilter,
+			nameFilter, typeFilter, queryFilter, page)
+		if err != nil {
+			apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError, apierrors.IsNotFoundError, apierrors.IsConflictError)
+		} else if !util.NotModified(w, r, types.ListTag(repositories)) {
+			util.SuccessWithFields(ctx, w, r, repositories, repositoriesField, fields)
+		}
+		return
+	}
 
 	repositories, err := c.Repositories.GetRepositories(ctx,
 		ownerAliasFilter, serviceNameFilter,
-		nameFilter, typeFilter)
+		nameFilter, typeFilter, queryFilter, page)
 	if err != nil {
 		if apierrors.IsNotFoundError(err) {
 			// acceptable case - no matching repositories, so return empty list
-			util.Success(ctx, w, r, repositories)
+			util.SuccessWithFields(ctx, w, r, repositories, repositoriesField, fields)
 		} else {
-			apierrors.HandleError(ctx, w, r, err)
+			apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError, apierrors.IsConflictError)
 		}
-	} else {
-		util.Success(ctx, w, r, repositories)
+	} else if !util.NotModified(w, r, types.ListTag(repositories)) {
+		util.SuccessWithFields(ctx, w, r, repositories, repositoriesField, fields)
 	}
 }
 
 func (c *Impl) GetSingleRepository(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	key := util.StringPathParam(r, "repository")
+	at := util.StringQueryParam(r, atParam)
+
+	var repositoryDto openapi.RepositoryDto
+	var err error
+	if at != "" {
+		repositoryDto, err = c.Repositories.GetRepositoryAt(ctx, at, key)
+	} else {
+		repositoryDto, err = c.Repositories.GetRepository(ctx, key)
+	}
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError, apierrors.IsNotFoundError)
+	} else if !util.NotModified(w, r, types.EntityTag(repositoryDto.CommitHash)) {
+		util.SetDeprecationHeaders(w, repositoryDto.DeprecatedSince, repositoryDto.SunsetDate)
+		util.Success(ctx, w, r, repositoryDto)
+	}
+}
+
+func (c *Impl) GetRepositoryHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	key := util.StringPathParam(r, "repository")
 
-	repositoryDto, err := c.Repositories.GetRepository(ctx, key)
+	history, err := c.Repositories.GetRepositoryHistory(ctx, key)
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err, apierrors.IsNotFoundError)
 	} else {
-		util.Success(ctx, w, r, repositoryDto)
+		util.Success(ctx, w, r, history)
 	}
 }
 
@@ -108,6 +163,12 @@ func (c *Impl) CreateRepository(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	key := util.StringPathParam(r, "repository")
 	if err := c.Repositories.ValidRepositoryKey(ctx, key); err != nil {
 		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
@@ -119,13 +180,18 @@ func (c *Impl) CreateRepository(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repositoryWritten, err := c.Repositories.CreateRepository(ctx, key, repositoryCreateDto)
+	repositoryWritten, diff, err := util.WriteOrDryRun(ctx, c.Updater, dryRun, func(subCtx context.Context) (openapi.RepositoryDto, error) {
+		return c.Repositories.CreateRepository(subCtx, key, repositoryCreateDto)
+	})
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsConflictError,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Repository: &repositoryWritten, Diff: diff})
 	} else {
+		util.SetETag(w, repositoryWritten.CommitHash)
 		util.SuccessWithStatus(ctx, w, r, repositoryWritten, http.StatusCreated)
 	}
 }
@@ -141,6 +207,12 @@ func (c *Impl) UpdateRepository(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	key := util.StringPathParam(r, "repository")
 	repositoryDto, err := c.parseBodyToRepositoryDto(ctx, r)
 	if err != nil {
@@ -148,14 +220,20 @@ func (c *Impl) UpdateRepository(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repositoryWritten, err := c.Repositories.UpdateRepository(ctx, key, repositoryDto)
+	repositoryWritten, diff, err := util.WriteOrDryRun(util.WithIfMatch(ctx, r), c.Updater, dryRun, func(subCtx context.Context) (openapi.RepositoryDto, error) {
+		return c.Repositories.UpdateRepository(subCtx, key, repositoryDto)
+	})
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsNotFoundError,
 			apierrors.IsConflictError,
+			preconditionerror.Is,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Repository: &repositoryWritten, Diff: diff})
 	} else {
+		util.SetETag(w, repositoryWritten.CommitHash)
 		util.Success(ctx, w, r, repositoryWritten)
 	}
 }
@@ -171,21 +249,31 @@ func (c *Impl) PatchRepository(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	key := util.StringPathParam(r, "repository")
-	repositoryPatch, err := c.parseBodyToRepositoryPatchDto(ctx, r)
+	write, err := c.repositoryPatchWrite(ctx, r, key)
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
 		return
 	}
 
-	repositoryWritten, err := c.Repositories.PatchRepository(ctx, key, repositoryPatch)
+	repositoryWritten, diff, err := util.WriteOrDryRun(util.WithIfMatch(ctx, r), c.Updater, dryRun, write)
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsNotFoundError,
 			apierrors.IsConflictError,
+			preconditionerror.Is,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Repository: &repositoryWritten, Diff: diff})
 	} else {
+		util.SetETag(w, repositoryWritten.CommitHash)
 		util.Success(ctx, w, r, repositoryWritten)
 	}
 }
@@ -201,6 +289,12 @@ func (c *Impl) DeleteRepository(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	key := util.StringPathParam(r, "repository")
 	info, err := util.ParseBodyToDeletionDto(ctx, r, c.Timestamp.Now())
 	if err != nil {
@@ -208,18 +302,62 @@ func (c *Impl) DeleteRepository(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.Repositories.DeleteRepository(ctx, key, info)
+	_, diff, err := util.WriteOrDryRun(ctx, c.Updater, dryRun, func(subCtx context.Context) (struct{}, error) {
+		return struct{}{}, c.Repositories.DeleteRepository(subCtx, key, info)
+	})
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsNotFoundError,
 			apierrors.IsConflictError,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Diff: diff})
 	} else {
 		util.SuccessNoBody(ctx, w, r, http.StatusNoContent)
 	}
 }
 
+func (c *Impl) RevertRepository(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := security.IsAuthenticated(ctx, "anonymous tried RevertRepository", c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsUnauthorisedError)
+		return
+	}
+	if err := security.HasGroup(ctx, c.CustomConfiguration.AuthGroupWrite(), fmt.Sprintf("%s tried RevertRepository", security.Subject(ctx)), c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsForbiddenError)
+		return
+	}
+
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	key := util.StringPathParam(r, "repository")
+	info, err := util.ParseBodyToRevertDto(ctx, r, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	reverted, diff, err := util.WriteOrDryRun(ctx, c.Updater, dryRun, func(subCtx context.Context) (openapi.RepositoryDto, error) {
+		return c.Repositories.RevertRepository(subCtx, key, info)
+	})
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err,
+			apierrors.IsBadRequestError,
+			apierrors.IsNotFoundError,
+			apierrors.IsConflictError,
+			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Repository: &reverted, Diff: diff})
+	} else {
+		util.Success(ctx, w, r, reverted)
+	}
+}
+
 // --- helpers
 
 func (c *Impl) parseBodyToRepositoryDto(ctx context.Context, r *http.Request) (openapi.RepositoryDto, error) {
@@ -243,6 +381,20 @@ func (c *Impl) parseBodyToRepositoryCreateDto(ctx context.Context, r *http.Reque
 	return dto, nil
 }
 
+// repositoryPatchWrite parses the body of a PATCH request, which is either a standard patch document or a repository patch dto.
+func (c *Impl) repositoryPatchWrite(ctx context.Context, r *http.Request, key string) (func(context.Context) (openapi.RepositoryDto, error), error) {
+	if patch, ok, err := util.ParseBodyToPatchDocument(ctx, r, c.Timestamp.Now()); ok {
+		return func(subCtx context.Context) (openapi.RepositoryDto, error) {
+			return c.Repositories.ApplyRepositoryPatch(subCtx, key, patch)
+		}, err
+	}
+
+	repositoryPatch, err := c.parseBodyToRepositoryPatchDto(ctx, r)
+	return func(subCtx context.Context) (openapi.RepositoryDto, error) {
+		return c.Repositories.PatchRepository(subCtx, key, repositoryPatch)
+	}, err
+}
+
 func (c *Impl) parseBodyToRepositoryPatchDto(ctx context.Context, r *http.Request) (openapi.RepositoryPatchDto, error) {
 	decoder := json.NewDecoder(r.Body)
 	dto := openapi.RepositoryPatchDto{}
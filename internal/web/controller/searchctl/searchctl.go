@@ -0,0 +1,61 @@
+package searchctl
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/internal/acorn/controller"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+	"github.com/Interhyp/metadata-service/internal/web/util"
+	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
+	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+)
+
+const queryParam = "q"
+const typeParam = "type"
+
+type Impl struct {
+	Logging   librepo.Logging
+	Timestamp librepo.Timestamp
+	Search    service.Search
+}
+
+func New(
+	logging librepo.Logging,
+	timestamp librepo.Timestamp,
+	search service.Search,
+) controller.SearchController {
+	return &Impl{
+		Logging:   logging,
+		Timestamp: timestamp,
+		Search:    search,
+	}
+}
+
+func (c *Impl) IsSearchController() bool {
+	return true
+}
+
+func (c *Impl) WireUp(_ context.Context, router chi.Router) {
+	router.Get("/rest/api/v1/search", c.Find)
+}
+
+// --- handlers ---
+
+func (c *Impl) Find(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := util.StringQueryParam(r, queryParam)
+	entityType := util.StringQueryParam(r, typeParam)
+	limit, err := util.PositiveIntQueryParam(ctx, r, util.LimitParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	result, err := c.Search.Search(ctx, query, entityType, limit)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+	} else {
+		util.Success(ctx, w, r, result)
+	}
+}
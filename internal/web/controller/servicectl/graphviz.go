@@ -0,0 +1,58 @@
+package servicectl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Interhyp/metadata-service/api"
+)
+
+const contentTypeGraphviz = "text/vnd.graphviz"
+
+func dependencyGraphToDot(graph openapi.ServiceDependencyGraphDto) string {
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, node := range graph.Services {
+		if node.Owner == nil {
+			b.WriteString(fmt.Sprintf("  %s [style=dashed];\n", dotId(node.Name)))
+		} else {
+			b.WriteString(fmt.Sprintf("  %s [tooltip=%s];\n", dotId(node.Name), dotId("owner: "+*node.Owner)))
+		}
+	}
+	for _, edge := range graph.Dependencies {
+		b.WriteString(fmt.Sprintf("  %s -> %s;\n", dotId(edge.From), dotId(edge.To)))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func apiIndexToDot(index openapi.ServiceApiIndexDto) string {
+	apis := make([]string, 0, len(index.Apis))
+	for api := range index.Apis {
+		apis = append(apis, api)
+	}
+	sort.Strings(apis)
+
+	var b strings.Builder
+	b.WriteString("digraph apis {\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, api := range apis {
+		apiNode := dotId("api:" + api)
+		b.WriteString(fmt.Sprintf("  %s [shape=ellipse, label=%s];\n", apiNode, dotId(api)))
+		for _, provider := range index.Apis[api].Providers {
+			b.WriteString(fmt.Sprintf("  %s -> %s [label=provides];\n", dotId(provider), apiNode))
+		}
+		for _, consumer := range index.Apis[api].Consumers {
+			b.WriteString(fmt.Sprintf("  %s -> %s [label=consumes];\n", dotId(consumer), apiNode))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotId quotes a string for use as a DOT identifier.
+func dotId(value string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), `"`, `\"`) + `"`
+}
@@ -7,9 +7,11 @@ import (
 	"github.com/Interhyp/metadata-service/api"
 	"github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Interhyp/metadata-service/internal/acorn/controller"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/preconditionerror"
 	"github.com/Interhyp/metadata-service/internal/acorn/service"
 	"net/http"
 
+	"github.com/Interhyp/metadata-service/internal/types"
 	"github.com/Interhyp/metadata-service/internal/web/util"
 	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
 	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
@@ -18,6 +20,17 @@ import (
 )
 
 const ownerParam = "owner"
+const directionParam = "direction"
+const formatParam = "format"
+const atParam = "at"
+const queryParam = "q"
+
+const servicesField = "services"
+
+const (
+	formatJson = "json"
+	formatDot  = "dot"
+)
 
 type Impl struct {
 	Configuration       librepo.Configuration
@@ -25,6 +38,8 @@ type Impl struct {
 	Logging             librepo.Logging
 	Timestamp           librepo.Timestamp
 	Services            service.Services
+	Updater             service.Updater
+	Links               service.Links
 }
 
 func New(
@@ -33,6 +48,8 @@ func New(
 	logging librepo.Logging,
 	timestamp librepo.Timestamp,
 	services service.Services,
+	updater service.Updater,
+	links service.Links,
 ) controller.ServiceController {
 	return &Impl{
 		Configuration:       configuration,
@@ -40,6 +57,8 @@ func New(
 		Logging:             logging,
 		Timestamp:           timestamp,
 		Services:            services,
+		Updater:             updater,
+		Links:               links,
 	}
 }
 
@@ -51,6 +70,15 @@ func (c *Impl) WireUp(_ context.Context, router chi.Router) {
 	baseEndpoint := "/rest/api/v1/services"
 	serviceEndpoint := baseEndpoint + "/{service}"
 	promotersEndpoint := baseEndpoint + "/{service}/promoters"
+	dependenciesEndpoint := baseEndpoint + "/{service}/dependencies"
+	historyEndpoint := baseEndpoint + "/{service}/history"
+	revertEndpoint := baseEndpoint + "/{service}/revert"
+	renameEndpoint := baseEndpoint + "/{service}/rename"
+	lifecycleEndpoint := baseEndpoint + "/{service}/lifecycle"
+	quicklinkStatusEndpoint := baseEndpoint + "/{service}/quicklinks/status"
+	alertRoutingEndpoint := baseEndpoint + "/{service}/alerting"
+	graphEndpoint := "/rest/api/v1/dependencies"
+	apisEndpoint := graphEndpoint + "/apis"
 
 	router.Get(baseEndpoint, c.GetServices)
 	router.Get(serviceEndpoint, c.GetSingleService)
@@ -59,6 +87,15 @@ func (c *Impl) WireUp(_ context.Context, router chi.Router) {
 	router.Patch(serviceEndpoint, c.PatchService)
 	router.Delete(serviceEndpoint, c.DeleteService)
 	router.Get(promotersEndpoint, c.GetServicePromoters)
+	router.Get(dependenciesEndpoint, c.GetServiceDependencies)
+	router.Get(historyEndpoint, c.GetServiceHistory)
+	router.Post(revertEndpoint, c.RevertService)
+	router.Post(renameEndpoint, c.RenameService)
+	router.Post(lifecycleEndpoint, c.TransitionServiceLifecycle)
+	router.Get(quicklinkStatusEndpoint, c.GetServiceQuicklinkStatus)
+	router.Get(alertRoutingEndpoint, c.GetServiceAlertRouting)
+	router.Get(graphEndpoint, c.GetServiceDependencyGraph)
+	router.Get(apisEndpoint, c.GetServiceApiIndex)
 }
 
 // --- handlers ---
@@ -66,24 +103,66 @@ func (c *Impl) WireUp(_ context.Context, router chi.Router) {
 func (c *Impl) GetServices(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	ownerAliasFilter := util.StringQueryParam(r, ownerParam)
+	queryFilter := util.StringQueryParam(r, queryParam)
+	at := util.StringQueryParam(r, atParam)
+	page, err := util.PageQueryParams(ctx, r, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+	fields, err := util.FieldsQueryParam(ctx, r, openapi.ServiceDto{}, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	if at != "" {
+		services, err := c.Services.GetServicesAt(ctx, at, ownerAliasFilter, queryFilter, page)
+		if err != nil {
+			apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError, apierrors.IsNotFoundError, apierrors.IsConflictError)
+		} else if !util.NotModified(w, r, types.ListTag(services)) {
+			util.SuccessWithFields(ctx, w, r, services, servicesField, fields)
+		}
+		return
+	}
 
-	services, err := c.Services.GetServices(ctx, ownerAliasFilter)
+	services, err := c.Services.GetServices(ctx, ownerAliasFilter, queryFilter, page)
 	if err != nil {
-		apierrors.HandleError(ctx, w, r, err)
-	} else {
-		util.Success(ctx, w, r, services)
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError, apierrors.IsConflictError)
+	} else if !util.NotModified(w, r, types.ListTag(services)) {
+		util.SuccessWithFields(ctx, w, r, services, servicesField, fields)
 	}
 }
 
 func (c *Impl) GetSingleService(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	serviceName := util.StringPathParam(r, "service")
+	at := util.StringQueryParam(r, atParam)
 
-	serviceDto, err := c.Services.GetService(ctx, serviceName)
+	var serviceDto openapi.ServiceDto
+	var err error
+	if at != "" {
+		serviceDto, err = c.Services.GetServiceAt(ctx, at, serviceName)
+	} else {
+		serviceDto, err = c.Services.GetService(ctx, serviceName)
+	}
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError, apierrors.IsNotFoundError)
+	} else if !util.NotModified(w, r, types.EntityTag(serviceDto.CommitHash)) {
+		util.SetDeprecationHeaders(w, serviceDto.DeprecatedSince, serviceDto.SunsetDate)
+		util.Success(ctx, w, r, serviceDto)
+	}
+}
+
+func (c *Impl) GetServiceHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := util.StringPathParam(r, "service")
+
+	history, err := c.Services.GetServiceHistory(ctx, serviceName)
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err, apierrors.IsNotFoundError)
 	} else {
-		util.Success(ctx, w, r, serviceDto)
+		util.Success(ctx, w, r, history)
 	}
 }
 
@@ -98,8 +177,14 @@ func (c *Impl) CreateService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	name := util.StringPathParam(r, "service")
-	if err := c.validServiceName(ctx, name); err != nil {
+	if err := c.Services.ValidServiceName(ctx, name); err != nil {
 		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
 		return
 	}
@@ -109,13 +194,18 @@ func (c *Impl) CreateService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	serviceWritten, err := c.Services.CreateService(ctx, name, serviceCreateDto)
+	serviceWritten, diff, err := util.WriteOrDryRun(ctx, c.Updater, dryRun, func(subCtx context.Context) (openapi.ServiceDto, error) {
+		return c.Services.CreateService(subCtx, name, serviceCreateDto)
+	})
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsConflictError,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Service: &serviceWritten, Diff: diff})
 	} else {
+		util.SetETag(w, serviceWritten.CommitHash)
 		util.SuccessWithStatus(ctx, w, r, serviceWritten, http.StatusCreated)
 	}
 }
@@ -131,6 +221,12 @@ func (c *Impl) UpdateService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	name := util.StringPathParam(r, "service")
 	serviceDto, err := c.parseBodyToServiceDto(ctx, r)
 	if err != nil {
@@ -138,14 +234,20 @@ func (c *Impl) UpdateService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	serviceWritten, err := c.Services.UpdateService(ctx, name, serviceDto)
+	serviceWritten, diff, err := util.WriteOrDryRun(util.WithIfMatch(ctx, r), c.Updater, dryRun, func(subCtx context.Context) (openapi.ServiceDto, error) {
+		return c.Services.UpdateService(subCtx, name, serviceDto)
+	})
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsNotFoundError,
 			apierrors.IsConflictError,
+			preconditionerror.Is,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Service: &serviceWritten, Diff: diff})
 	} else {
+		util.SetETag(w, serviceWritten.CommitHash)
 		util.Success(ctx, w, r, serviceWritten)
 	}
 }
@@ -161,21 +263,31 @@ func (c *Impl) PatchService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	name := util.StringPathParam(r, "service")
-	servicePatch, err := c.parseBodyToServicePatchDto(ctx, r)
+	write, err := c.servicePatchWrite(ctx, r, name)
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
 		return
 	}
 
-	serviceWritten, err := c.Services.PatchService(ctx, name, servicePatch)
+	serviceWritten, diff, err := util.WriteOrDryRun(util.WithIfMatch(ctx, r), c.Updater, dryRun, write)
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsNotFoundError,
 			apierrors.IsConflictError,
+			preconditionerror.Is,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Service: &serviceWritten, Diff: diff})
 	} else {
+		util.SetETag(w, serviceWritten.CommitHash)
 		util.Success(ctx, w, r, serviceWritten)
 	}
 }
@@ -191,6 +303,12 @@ func (c *Impl) DeleteService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
 	name := util.StringPathParam(r, "service")
 	info, err := util.ParseBodyToDeletionDto(ctx, r, c.Timestamp.Now())
 	if err != nil {
@@ -198,44 +316,264 @@ func (c *Impl) DeleteService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = c.Services.DeleteService(ctx, name, info)
+	_, diff, err := util.WriteOrDryRun(ctx, c.Updater, dryRun, func(subCtx context.Context) (struct{}, error) {
+		return struct{}{}, c.Services.DeleteService(subCtx, name, info)
+	})
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err,
 			apierrors.IsBadRequestError,
 			apierrors.IsNotFoundError,
 			apierrors.IsConflictError,
 			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Diff: diff})
 	} else {
 		util.SuccessNoBody(ctx, w, r, http.StatusNoContent)
 	}
 }
 
+func (c *Impl) RevertService(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := security.IsAuthenticated(ctx, "anonymous tried RevertService", c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsUnauthorisedError)
+		return
+	}
+	if err := security.HasGroup(ctx, c.CustomConfiguration.AuthGroupWrite(), fmt.Sprintf("%s tried RevertService", security.Subject(ctx)), c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsForbiddenError)
+		return
+	}
+
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	name := util.StringPathParam(r, "service")
+	info, err := util.ParseBodyToRevertDto(ctx, r, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	reverted, diff, err := util.WriteOrDryRun(ctx, c.Updater, dryRun, func(subCtx context.Context) (openapi.ServiceDto, error) {
+		return c.Services.RevertService(subCtx, name, info)
+	})
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err,
+			apierrors.IsBadRequestError,
+			apierrors.IsNotFoundError,
+			apierrors.IsConflictError,
+			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Service: &reverted, Diff: diff})
+	} else {
+		util.Success(ctx, w, r, reverted)
+	}
+}
+
+func (c *Impl) RenameService(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := security.IsAuthenticated(ctx, "anonymous tried RenameService", c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsUnauthorisedError)
+		return
+	}
+	if err := security.HasGroup(ctx, c.CustomConfiguration.AuthGroupWrite(), fmt.Sprintf("%s tried RenameService", security.Subject(ctx)), c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsForbiddenError)
+		return
+	}
+
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	name := util.StringPathParam(r, "service")
+	info, err := c.parseBodyToServiceRenameDto(ctx, r)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+	if info.NewName != "" {
+		if err := c.Services.ValidServiceName(ctx, info.NewName); err != nil {
+			apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+			return
+		}
+	}
+
+	renamed, diff, err := util.WriteOrDryRun(util.WithIfMatch(ctx, r), c.Updater, dryRun, func(subCtx context.Context) (openapi.ServiceDto, error) {
+		return c.Services.RenameService(subCtx, name, info)
+	})
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err,
+			apierrors.IsBadRequestError,
+			apierrors.IsNotFoundError,
+			apierrors.IsConflictError,
+			preconditionerror.Is,
+			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Service: &renamed, Diff: diff})
+	} else {
+		util.SetETag(w, renamed.CommitHash)
+		util.Success(ctx, w, r, renamed)
+	}
+}
+
+func (c *Impl) TransitionServiceLifecycle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := security.IsAuthenticated(ctx, "anonymous tried TransitionServiceLifecycle", c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsUnauthorisedError)
+		return
+	}
+	if err := security.HasGroup(ctx, c.CustomConfiguration.AuthGroupWrite(), fmt.Sprintf("%s tried TransitionServiceLifecycle", security.Subject(ctx)), c.Timestamp.Now()); err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsForbiddenError)
+		return
+	}
+
+	dryRun, err := util.BoolQueryParam(ctx, r, util.DryRunParam, c.Timestamp.Now())
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	name := util.StringPathParam(r, "service")
+	transition, err := c.parseBodyToServiceLifecycleTransitionDto(ctx, r)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	transitioned, diff, err := util.WriteOrDryRun(util.WithIfMatch(ctx, r), c.Updater, dryRun, func(subCtx context.Context) (openapi.ServiceDto, error) {
+		return c.Services.TransitionServiceLifecycle(subCtx, name, transition)
+	})
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err,
+			apierrors.IsBadRequestError,
+			apierrors.IsNotFoundError,
+			apierrors.IsConflictError,
+			preconditionerror.Is,
+			apierrors.IsBadGatewayError)
+	} else if dryRun {
+		util.Success(ctx, w, r, openapi.DryRunResultDto{Service: &transitioned, Diff: diff})
+	} else {
+		util.SetETag(w, transitioned.CommitHash)
+		util.Success(ctx, w, r, transitioned)
+	}
+}
+
 func (c *Impl) GetServicePromoters(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	serviceName := util.StringPathParam(r, "service")
 
-	_, err := c.Services.GetService(ctx, serviceName)
+	serviceDto, err := c.Services.GetService(ctx, serviceName)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsNotFoundError)
+		return
+	}
+
+	promoters, err := c.Services.GetServicePromoters(ctx, serviceDto.Owner)
 	if err != nil {
 		apierrors.HandleError(ctx, w, r, err, apierrors.IsNotFoundError)
 	} else {
-		util.Success(ctx, w, r, openapi.ServicePromotersDto{Promoters: []string{}})
+		util.Success(ctx, w, r, promoters)
 	}
 }
 
-// --- helpers
+func (c *Impl) GetServiceQuicklinkStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := util.StringPathParam(r, "service")
+
+	status, err := c.Links.GetServiceQuicklinkStatus(ctx, serviceName)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsNotFoundError)
+	} else {
+		util.Success(ctx, w, r, status)
+	}
+}
+
+func (c *Impl) GetServiceAlertRouting(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := util.StringPathParam(r, "service")
+
+	routing, err := c.Services.GetServiceAlertRouting(ctx, serviceName)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsNotFoundError, apierrors.IsConflictError)
+	} else {
+		util.Success(ctx, w, r, routing)
+	}
+}
+
+func (c *Impl) GetServiceDependencies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	serviceName := util.StringPathParam(r, "service")
+	direction := util.StringQueryParam(r, directionParam)
+
+	format, err := c.validFormat(ctx, util.StringQueryParam(r, formatParam))
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	graph, err := c.Services.GetServiceDependencyClosure(ctx, serviceName, direction)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError, apierrors.IsNotFoundError)
+	} else if format == formatDot {
+		util.SuccessText(ctx, w, r, contentTypeGraphviz, dependencyGraphToDot(graph))
+	} else {
+		util.Success(ctx, w, r, graph)
+	}
+}
+
+func (c *Impl) GetServiceDependencyGraph(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-func (c *Impl) validServiceName(ctx context.Context, name string) apierrors.AnnotatedError {
-	if c.CustomConfiguration.ServiceNamePermittedRegex().MatchString(name) &&
-		!c.CustomConfiguration.ServiceNameProhibitedRegex().MatchString(name) &&
-		uint16(len(name)) <= c.CustomConfiguration.ServiceNameMaxLength() {
-		return nil
+	format, err := c.validFormat(ctx, util.StringQueryParam(r, formatParam))
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
 	}
 
-	c.Logging.Logger().Ctx(ctx).Info().Printf("service parameter %v invalid", name)
-	permitted := c.CustomConfiguration.ServiceNamePermittedRegex().String()
-	prohibited := c.CustomConfiguration.ServiceNameProhibitedRegex().String()
-	max := c.CustomConfiguration.ServiceNameMaxLength()
-	return apierrors.NewBadRequestError("service.invalid.name", fmt.Sprintf("service name must match %s, is not allowed to match %s and may have up to %d characters", permitted, prohibited, max), nil, c.Timestamp.Now())
+	graph, err := c.Services.GetServiceDependencyGraph(ctx)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err)
+	} else if format == formatDot {
+		util.SuccessText(ctx, w, r, contentTypeGraphviz, dependencyGraphToDot(graph))
+	} else {
+		util.Success(ctx, w, r, graph)
+	}
+}
+
+func (c *Impl) GetServiceApiIndex(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	format, err := c.validFormat(ctx, util.StringQueryParam(r, formatParam))
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err, apierrors.IsBadRequestError)
+		return
+	}
+
+	index, err := c.Services.GetServiceApiIndex(ctx)
+	if err != nil {
+		apierrors.HandleError(ctx, w, r, err)
+	} else if format == formatDot {
+		util.SuccessText(ctx, w, r, contentTypeGraphviz, apiIndexToDot(index))
+	} else {
+		util.Success(ctx, w, r, index)
+	}
+}
+
+// --- helpers
+
+func (c *Impl) validFormat(ctx context.Context, format string) (string, error) {
+	if format == "" {
+		return formatJson, nil
+	}
+	if format == formatJson || format == formatDot {
+		return format, nil
+	}
+	c.Logging.Logger().Ctx(ctx).Info().Printf("format parameter %v invalid", format)
+	return "", apierrors.NewBadRequestError("service.invalid.format", fmt.Sprintf("format must be one of %s, %s", formatJson, formatDot), nil, c.Timestamp.Now())
 }
 
 func (c *Impl) parseBodyToServiceDto(ctx context.Context, r *http.Request) (openapi.ServiceDto, error) {
@@ -261,6 +599,42 @@ func (c *Impl) parseBodyToServiceCreateDto(ctx context.Context, r *http.Request)
 	return dto, nil
 }
 
+func (c *Impl) parseBodyToServiceRenameDto(ctx context.Context, r *http.Request) (openapi.ServiceRenameDto, error) {
+	decoder := json.NewDecoder(r.Body)
+	dto := openapi.ServiceRenameDto{}
+	err := decoder.Decode(&dto)
+	if err != nil {
+		c.Logging.Logger().Ctx(ctx).Info().Printf("service rename body invalid: %s", err.Error())
+		return openapi.ServiceRenameDto{}, apierrors.NewBadRequestError("service.invalid.body", "body failed to parse", err, c.Timestamp.Now())
+	}
+	return dto, nil
+}
+
+func (c *Impl) parseBodyToServiceLifecycleTransitionDto(ctx context.Context, r *http.Request) (openapi.ServiceLifecycleTransitionDto, error) {
+	decoder := json.NewDecoder(r.Body)
+	dto := openapi.ServiceLifecycleTransitionDto{}
+	err := decoder.Decode(&dto)
+	if err != nil {
+		c.Logging.Logger().Ctx(ctx).Info().Printf("service lifecycle transition body invalid: %s", err.Error())
+		return openapi.ServiceLifecycleTransitionDto{}, apierrors.NewBadRequestError("service.invalid.body", "body failed to parse", err, c.Timestamp.Now())
+	}
+	return dto, nil
+}
+
+// servicePatchWrite parses the body of a PATCH request, which is either a standard patch document or a service patch dto.
+func (c *Impl) servicePatchWrite(ctx context.Context, r *http.Request, name string) (func(context.Context) (openapi.ServiceDto, error), error) {
+	if patch, ok, err := util.ParseBodyToPatchDocument(ctx, r, c.Timestamp.Now()); ok {
+		return func(subCtx context.Context) (openapi.ServiceDto, error) {
+			return c.Services.ApplyServicePatch(subCtx, name, patch)
+		}, err
+	}
+
+	servicePatch, err := c.parseBodyToServicePatchDto(ctx, r)
+	return func(subCtx context.Context) (openapi.ServiceDto, error) {
+		return c.Services.PatchService(subCtx, name, servicePatch)
+	}, err
+}
+
 func (c *Impl) parseBodyToServicePatchDto(ctx context.Context, r *http.Request) (openapi.ServicePatchDto, error) {
 	decoder := json.NewDecoder(r.Body)
 	dto := openapi.ServicePatchDto{}
@@ -13,6 +13,7 @@ import (
 	libcontroller "github.com/StephanHCB/go-backend-service-common/acorns/controller"
 	librepo "github.com/StephanHCB/go-backend-service-common/acorns/repository"
 	libmiddleware "github.com/StephanHCB/go-backend-service-common/web/middleware"
+	"github.com/StephanHCB/go-backend-service-common/web/middleware/cancellogger"
 	"github.com/StephanHCB/go-backend-service-common/web/middleware/requestlogging"
 	"github.com/StephanHCB/go-backend-service-common/web/middleware/security"
 	"github.com/go-chi/chi/v5"
@@ -36,6 +37,11 @@ type Impl struct {
 	ServiceCtl          controller.ServiceController
 	RepositoryCtl       controller.RepositoryController
 	WebhookCtl          controller.WebhookController
+	TransactionCtl      controller.TransactionController
+	SearchCtl           controller.SearchController
+	EventsCtl           controller.EventsController
+	GraphqlCtl          controller.GraphqlController
+	ManagementCtl       controller.ManagementController
 
 	Router chi.Router
 
@@ -56,6 +62,11 @@ func New(
 	serviceCtl controller.ServiceController,
 	repositoryCtl controller.RepositoryController,
 	webhookCtl controller.WebhookController,
+	transactionCtl controller.TransactionController,
+	searchCtl controller.SearchController,
+	eventsCtl controller.EventsController,
+	graphqlCtl controller.GraphqlController,
+	managementCtl controller.ManagementController,
 ) application.Server {
 	return &Impl{
 		Configuration:       configuration,
@@ -68,6 +79,11 @@ func New(
 		ServiceCtl:          serviceCtl,
 		RepositoryCtl:       repositoryCtl,
 		WebhookCtl:          webhookCtl,
+		TransactionCtl:      transactionCtl,
+		SearchCtl:           searchCtl,
+		EventsCtl:           eventsCtl,
+		GraphqlCtl:          graphqlCtl,
+		ManagementCtl:       managementCtl,
 
 		RequestTimeoutSeconds:     60,
 		ServerWriteTimeoutSeconds: 60,
@@ -93,14 +109,16 @@ func (s *Impl) WireUp(ctx context.Context) {
 	if s.Router == nil {
 		s.Logging.Logger().Ctx(ctx).Info().Print("creating router and setting up filter chain")
 		s.Router = chi.NewRouter()
+		// must come first, later middlewares wrap the response writer so the write deadline cannot be reached
+		s.Router.Use(s.addRequestTimeout)
 
 		keysetPEM := s.IdentityProvider.GetKeySet(ctx)
 
 		options := libmiddleware.MiddlewareStackOptions{
-			ElasticApmEnabled:          s.CustomConfiguration.ElasticApmEnabled(),
-			PlainLogging:               s.Configuration.PlainLogging(),
-			CorsAllowOrigin:            "*", // CORS ok for unauthorized requests
-			RequestTimeoutSeconds:      s.RequestTimeoutSeconds,
+			ElasticApmEnabled: s.CustomConfiguration.ElasticApmEnabled(),
+			PlainLogging:      s.Configuration.PlainLogging(),
+			CorsAllowOrigin:   "*", // CORS ok for unauthorized requests
+			// request timeout is added above, because streaming endpoints must not have one
 			HasJwtIdTokenAuthorization: true,
 			JwtPublicKeyPEMs:           keysetPEM,
 			HasBasicAuthAuthorization:  true,
@@ -116,6 +134,11 @@ func (s *Impl) WireUp(ctx context.Context) {
 				"GET /rest/api/v1/owners.*",
 				"GET /rest/api/v1/services.*",
 				"GET /rest/api/v1/repositories.*",
+				"GET /rest/api/v1/dependencies.*",
+				"GET /rest/api/v1/search.*",
+				"GET /rest/api/v1/events.*",
+				"GET /graphql",
+				"POST /graphql",
 				"POST /webhook",
 				// health (provides just up)
 				"GET /",
@@ -137,6 +160,7 @@ func (s *Impl) WireUp(ctx context.Context) {
 		if err != nil {
 			aulogging.Logger.Ctx(ctx).Fatal().WithErr(err).Printf("failed to set up middleware stack - BAILING OUT: %s", err.Error())
 		}
+		s.Router.Use(cancellogger.ConstructContextCancellationLoggerMiddleware("AddRequestTimeout"))
 	}
 
 	s.HealthCtl.WireUp(ctx, s.Router)
@@ -145,6 +169,39 @@ func (s *Impl) WireUp(ctx context.Context) {
 	s.ServiceCtl.WireUp(ctx, s.Router)
 	s.RepositoryCtl.WireUp(ctx, s.Router)
 	s.WebhookCtl.WireUp(ctx, s.Router)
+	s.TransactionCtl.WireUp(ctx, s.Router)
+	s.SearchCtl.WireUp(ctx, s.Router)
+	s.EventsCtl.WireUp(ctx, s.Router)
+	s.GraphqlCtl.WireUp(ctx, s.Router)
+	s.ManagementCtl.WireUp(ctx, s.Router)
+}
+
+// streamingPaths are kept open for as long as the client wants, so they are exempt from the request timeout.
+var streamingPaths = map[string]bool{
+	"/rest/api/v1/events/stream": true,
+}
+
+// addRequestTimeout is the request timeout middleware of the standard middleware stack, except that streamingPaths
+// have neither a request timeout nor the server write timeout.
+func (s *Impl) addRequestTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if streamingPaths[r.URL.Path] {
+			if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+				aulogging.Logger.Ctx(r.Context()).Warn().WithErr(err).Printf("failed to clear write deadline, stream will end with the server write timeout: %s", err.Error())
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.RequestTimeoutSeconds <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(s.RequestTimeoutSeconds)*time.Second)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
 }
 
 func (s *Impl) NewServer(ctx context.Context, address string, router http.Handler) *http.Server {
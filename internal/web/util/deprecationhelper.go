@@ -0,0 +1,34 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	headerDeprecation = "Deprecation"
+	headerSunset      = "Sunset"
+)
+
+// SetDeprecationHeaders sets the Deprecation (RFC 9745) and Sunset (RFC 8594) headers of a response containing
+// a single service or repository.
+//
+// Both dates are validated on write, so a date that does not parse was committed to the metadata repository
+// directly, and is silently left out.
+func SetDeprecationHeaders(w http.ResponseWriter, deprecatedSince *string, sunsetDate *string) {
+	if since, ok := parseDate(deprecatedSince); ok {
+		w.Header().Set(headerDeprecation, fmt.Sprintf("@%d", since.Unix()))
+	}
+	if sunset, ok := parseDate(sunsetDate); ok {
+		w.Header().Set(headerSunset, sunset.Format(http.TimeFormat))
+	}
+}
+
+func parseDate(value *string) (time.Time, bool) {
+	if value == nil {
+		return time.Time{}, false
+	}
+	parsed, err := time.Parse(time.DateOnly, *value)
+	return parsed, err == nil
+}
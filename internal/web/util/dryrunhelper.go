@@ -0,0 +1,25 @@
+package util
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/internal/acorn/service"
+)
+
+const DryRunParam = "dryRun"
+
+// WriteOrDryRun calls write. If dryRun is set, write is instead called inside a dry run, so nothing is committed,
+// and the diff of what would have been committed is also returned.
+func WriteOrDryRun[T any](ctx context.Context, updater service.Updater, dryRun bool, write func(context.Context) (T, error)) (T, string, error) {
+	if !dryRun {
+		result, err := write(ctx)
+		return result, "", err
+	}
+
+	var result T
+	diff, err := updater.DryRun(ctx, func(subCtx context.Context) error {
+		var err error
+		result, err = write(subCtx)
+		return err
+	})
+	return result, diff, err
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"context"
+	"github.com/Interhyp/metadata-service/internal/types"
+	"github.com/go-http-utils/headers"
+	"net/http"
+)
+
+// NotModified sets the ETag header, and if the client already has this version according to If-None-Match,
+// answers with 304.
+//
+// Returns true if the response has been sent.
+func NotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set(headers.ETag, etag)
+	if ifNoneMatch := r.Header.Get(headers.IfNoneMatch); ifNoneMatch != "" && types.MatchesEntityTag(ifNoneMatch, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// SetETag sets the ETag header of a response containing a single owner, service or repository.
+func SetETag(w http.ResponseWriter, commitHash string) {
+	if commitHash != "" {
+		w.Header().Set(headers.ETag, types.EntityTag(commitHash))
+	}
+}
+
+// WithIfMatch makes the writes done with the returned context conditional on the If-Match header, if present.
+func WithIfMatch(ctx context.Context, r *http.Request) context.Context {
+	if ifMatch := r.Header.Get(headers.IfMatch); ifMatch != "" {
+		return types.WithIfMatch(ctx, ifMatch)
+	}
+	return ctx
+}
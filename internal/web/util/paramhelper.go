@@ -3,14 +3,32 @@ package util
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/types"
 	aulogging "github.com/StephanHCB/go-autumn-logging"
 	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-http-utils/headers"
+	"io"
+	"mime"
 	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	LimitParam  = "limit"
+	CursorParam = "cursor"
+	SortParam   = "sort"
+	FieldsParam = "fields"
+
+	sortAscending  = "asc"
+	sortDescending = "desc"
+)
+
 func StringPathParam(r *http.Request, key string) string {
 	return chi.URLParam(r, key)
 }
@@ -20,6 +38,89 @@ func StringQueryParam(r *http.Request, key string) string {
 	return query.Get(key)
 }
 
+func BoolQueryParam(ctx context.Context, r *http.Request, key string, timestamp time.Time) (bool, error) {
+	value := StringQueryParam(r, key)
+	if value == "" {
+		return false, nil
+	}
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		aulogging.Logger.Ctx(ctx).Info().Printf("query parameter %s invalid: %s", key, err.Error())
+		return false, apierrors.NewBadRequestError("parameter.invalid.value", fmt.Sprintf("query parameter %s must be true or false", key), err, timestamp)
+	}
+	return result, nil
+}
+
+// PositiveIntQueryParam reads an optional numeric query parameter, giving 0 if it is absent.
+func PositiveIntQueryParam(ctx context.Context, r *http.Request, key string, timestamp time.Time) (int, error) {
+	value := StringQueryParam(r, key)
+	if value == "" {
+		return 0, nil
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil || result < 1 {
+		aulogging.Logger.Ctx(ctx).Info().Printf("query parameter %s invalid: %s", key, value)
+		return 0, apierrors.NewBadRequestError("parameter.invalid.value", fmt.Sprintf("query parameter %s must be a positive number", key), err, timestamp)
+	}
+	return result, nil
+}
+
+// PageQueryParams reads the limit, cursor and sort query parameters for a list endpoint.
+func PageQueryParams(ctx context.Context, r *http.Request, timestamp time.Time) (types.PageRequest, error) {
+	page := types.PageRequest{
+		Cursor: StringQueryParam(r, CursorParam),
+	}
+
+	limit, err := PositiveIntQueryParam(ctx, r, LimitParam, timestamp)
+	if err != nil {
+		return types.PageRequest{}, err
+	}
+	page.Limit = limit
+
+	switch StringQueryParam(r, SortParam) {
+	case "", sortAscending:
+	case sortDescending:
+		page.Descending = true
+	default:
+		aulogging.Logger.Ctx(ctx).Info().Printf("query parameter %s invalid: %s", SortParam, StringQueryParam(r, SortParam))
+		return types.PageRequest{}, apierrors.NewBadRequestError("parameter.invalid.value", fmt.Sprintf("query parameter %s must be %s or %s", SortParam, sortAscending, sortDescending), nil, timestamp)
+	}
+
+	return page, nil
+}
+
+// FieldsQueryParam reads the comma separated fields query parameter, checking each field against the json field
+// names of entryType. Gives nil if all fields were requested.
+func FieldsQueryParam(ctx context.Context, r *http.Request, entryType any, timestamp time.Time) ([]string, error) {
+	value := StringQueryParam(r, FieldsParam)
+	if value == "" {
+		return nil, nil
+	}
+
+	allowed := jsonFieldNames(reflect.TypeOf(entryType))
+	result := make([]string, 0)
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if !allowed[field] {
+			aulogging.Logger.Ctx(ctx).Info().Printf("query parameter %s invalid: unknown field '%s'", FieldsParam, field)
+			return nil, apierrors.NewBadRequestError("parameter.invalid.value", fmt.Sprintf("query parameter %s contains unknown field '%s'", FieldsParam, field), nil, timestamp)
+		}
+		result = append(result, field)
+	}
+	return result, nil
+}
+
+func jsonFieldNames(structType reflect.Type) map[string]bool {
+	result := make(map[string]bool)
+	for i := 0; i < structType.NumField(); i++ {
+		name, _, _ := strings.Cut(structType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			result[name] = true
+		}
+	}
+	return result
+}
+
 func ParseBodyToDeletionDto(ctx context.Context, r *http.Request, timestamp time.Time) (openapi.DeletionDto, error) {
 	decoder := json.NewDecoder(r.Body)
 	dto := openapi.DeletionDto{}
@@ -30,3 +131,31 @@ func ParseBodyToDeletionDto(ctx context.Context, r *http.Request, timestamp time
 	}
 	return dto, nil
 }
+
+func ParseBodyToRevertDto(ctx context.Context, r *http.Request, timestamp time.Time) (openapi.RevertDto, error) {
+	decoder := json.NewDecoder(r.Body)
+	dto := openapi.RevertDto{}
+	err := decoder.Decode(&dto)
+	if err != nil {
+		aulogging.Logger.Ctx(ctx).Info().Printf("revert body invalid: %s", err.Error())
+		return openapi.RevertDto{}, apierrors.NewBadRequestError("revert.invalid.body", "body failed to parse", err, timestamp)
+	}
+	return dto, nil
+}
+
+// ParseBodyToPatchDocument reads a JSON Merge Patch or JSON Patch, as told by the Content-Type.
+//
+// Returns false for any other content type, leaving the body to be parsed as a patch dto.
+func ParseBodyToPatchDocument(ctx context.Context, r *http.Request, timestamp time.Time) (types.PatchDocument, bool, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get(headers.ContentType))
+	if mediaType != types.MergePatch && mediaType != types.JsonPatch {
+		return types.PatchDocument{}, false, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		aulogging.Logger.Ctx(ctx).Info().Printf("patch body invalid: %s", err.Error())
+		return types.PatchDocument{}, true, apierrors.NewBadRequestError("patch.invalid.body", "body failed to read", err, timestamp)
+	}
+	return types.PatchDocument{Format: mediaType, Body: body}, true, nil
+}
@@ -22,6 +22,54 @@ func SuccessWithStatus(ctx context.Context, w http.ResponseWriter, _ *http.Reque
 	WriteJson(ctx, w, response)
 }
 
+// SuccessWithFields reduces every entry of the list under listField to the given fields, unless fields is nil.
+func SuccessWithFields(ctx context.Context, w http.ResponseWriter, r *http.Request, response interface{}, listField string, fields []string) {
+	if fields == nil {
+		Success(ctx, w, r, response)
+		return
+	}
+
+	projected, err := projectFields(response, listField, fields)
+	if err != nil {
+		aulogging.Logger.Ctx(ctx).Warn().WithErr(err).Printf("error while projecting fields, sending full response: %v", err)
+		Success(ctx, w, r, response)
+		return
+	}
+	Success(ctx, w, r, projected)
+}
+
+func projectFields(response interface{}, listField string, fields []string) (map[string]interface{}, error) {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+
+	entries, _ := result[listField].(map[string]interface{})
+	for key, entry := range entries {
+		entryFields, _ := entry.(map[string]interface{})
+		reduced := make(map[string]interface{})
+		for _, field := range fields {
+			if value, ok := entryFields[field]; ok {
+				reduced[field] = value
+			}
+		}
+		entries[key] = reduced
+	}
+	return result, nil
+}
+
+func SuccessText(ctx context.Context, w http.ResponseWriter, _ *http.Request, contentType string, response string) {
+	w.Header().Set(headers.ContentType, contentType)
+	_, err := w.Write([]byte(response))
+	if err != nil {
+		aulogging.Logger.Ctx(ctx).Warn().WithErr(err).Printf("error while writing text response: %v", err)
+	}
+}
+
 func SuccessNoBody(ctx context.Context, w http.ResponseWriter, _ *http.Request, status int) {
 	w.WriteHeader(status)
 }
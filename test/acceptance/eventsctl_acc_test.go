@@ -0,0 +1,225 @@
+package acceptance
+
+import (
+	"github.com/Interhyp/metadata-service/internal/acorn/repository"
+	"github.com/Interhyp/metadata-service/internal/service/trigger"
+	"github.com/Interhyp/metadata-service/internal/web/server"
+	"github.com/StephanHCB/go-backend-service-common/docs"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// event stream
+
+func TestGETEventStream_Change(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user watching owner changes for some-owner")
+	stream, err := tstOpenEventStream("/rest/api/v1/events/stream?type=owner&owner=some-owner", "")
+	require.Nil(t, err)
+	defer stream.close()
+	require.Equal(t, http.StatusOK, stream.status)
+	require.Equal(t, "text/event-stream", stream.response.Header.Get("Content-Type"))
+
+	docs.When("When an admin patches a service and then the owner")
+	token := tstValidAdminToken()
+	serviceBody := tstServicePatch()
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &serviceBody)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	ownerBody := tstOwnerPatch()
+	response, err = tstPerformPatch("/rest/api/v1/owners/some-owner", token, &ownerBody)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Then("Then only the owner change is streamed, with the commit hash as event id")
+	event, err := stream.next()
+	require.Nil(t, err)
+	require.Equal(t, "change", event.event)
+	require.Equal(t, "6c8ac2c35791edf9979623c717a2430000000000", event.id)
+	require.Equal(t, `{"commitHash":"6c8ac2c35791edf9979623c717a2430000000000","timeStamp":"2022-11-06T18:14:10Z",`+
+		`"entities":[{"type":"owner","key":"some-owner","owner":"some-owner","deleted":false}]}`, event.data)
+}
+
+func TestGETEventStream_OutlivesTimeouts(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a server with a request timeout and a write timeout of one second")
+	serverImpl := application.Server.(*server.Impl)
+	originalTimeout := serverImpl.RequestTimeoutSeconds
+	serverImpl.RequestTimeoutSeconds = 1
+	defer func() { serverImpl.RequestTimeoutSeconds = originalTimeout }()
+	shortTs := httptest.NewUnstartedServer(serverImpl.Router)
+	shortTs.Config.WriteTimeout = time.Second
+	shortTs.Start()
+	defer shortTs.Close()
+	originalTs := ts
+	ts = shortTs
+	defer func() { ts = originalTs }()
+
+	docs.Given("And an unauthenticated user who has been watching owner changes for longer than that")
+	stream, err := tstOpenEventStream("/rest/api/v1/events/stream?type=owner&owner=some-owner", "")
+	require.Nil(t, err)
+	defer stream.close()
+	require.Equal(t, http.StatusOK, stream.status)
+	time.Sleep(1500 * time.Millisecond)
+
+	docs.When("When an admin patches the owner")
+	body := tstOwnerPatch()
+	response, err := tstPerformPatch("/rest/api/v1/owners/some-owner", tstValidAdminToken(), &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Then("Then the change is still streamed")
+	event, err := stream.next()
+	require.Nil(t, err)
+	require.Equal(t, "change", event.event)
+}
+
+func TestGETEventStream_Deletion(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user watching changes for the owner deleteme")
+	stream, err := tstOpenEventStream("/rest/api/v1/events/stream?owner=deleteme", "")
+	require.Nil(t, err)
+	defer stream.close()
+
+	docs.When("When an admin deletes the owner")
+	body := tstDelete()
+	response, err := tstPerformDelete("/rest/api/v1/owners/deleteme", tstValidAdminToken(), &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNoContent, response.status)
+
+	docs.Then("Then the deletion is streamed")
+	event, err := stream.next()
+	require.Nil(t, err)
+	require.Equal(t, "change", event.event)
+	require.Equal(t, `{"commitHash":"6c8ac2c35791edf9979623c717a2430000000000","timeStamp":"2022-11-06T18:14:10Z",`+
+		`"entities":[{"type":"owner","key":"deleteme","owner":"deleteme","deleted":true}]}`, event.data)
+}
+
+const tstRemoteCommitHash = "6c8ac2c35791edf9979623c717a24300000f00ba"
+
+func tstRemoteServiceCommit() repository.CommitInfo {
+	return repository.CommitInfo{
+		CommitHash:   tstRemoteCommitHash,
+		TimeStamp:    time.Date(2022, 11, 6, 18, 14, 10, 0, time.UTC),
+		Message:      "ISSUE-2345: made by another instance",
+		FilesChanged: []string{"owners/some-owner/services/some-service-backend.yaml"},
+	}
+}
+
+func TestGETEventStream_PulledByTrigger(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user watching service changes")
+	stream, err := tstOpenEventStream("/rest/api/v1/events/stream?type=service", "")
+	require.Nil(t, err)
+	defer stream.close()
+
+	docs.Given("And a commit made by another instance")
+	metadataImpl.SimulateRemoteCommits = []repository.CommitInfo{tstRemoteServiceCommit()}
+
+	docs.When("When the periodic update pulls it")
+	err = application.Trigger.(*trigger.Impl).PerformWithCancel(appCtx)
+	require.Nil(t, err)
+
+	docs.Then("Then the change is streamed, with the pulled commit hash as event id")
+	event, err := stream.next()
+	require.Nil(t, err)
+	require.Equal(t, "change", event.event)
+	require.Equal(t, tstRemoteCommitHash, event.id)
+	require.Equal(t, `{"commitHash":"`+tstRemoteCommitHash+`","timeStamp":"2022-11-06T18:14:10Z",`+
+		`"entities":[{"type":"service","key":"some-service-backend","owner":"some-owner","deleted":false}]}`, event.data)
+
+	docs.When("When the kafka event of the other instance for the same commit arrives late, and an admin then patches a service")
+	kafkaImpl.Receive(repository.UpdateEvent{
+		Affected: repository.EventAffects{
+			OwnerAliases:   []string{},
+			ServiceNames:   []string{"some-service-backend"},
+			RepositoryKeys: []string{},
+		},
+		TimeStamp:  "2022-11-06T18:14:10Z",
+		CommitHash: tstRemoteCommitHash,
+	})
+	body := tstServicePatch()
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", tstValidAdminToken(), &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Then("Then the commit is not streamed again, only the patch")
+	event, err = stream.next()
+	require.Nil(t, err)
+	require.Equal(t, "6c8ac2c35791edf9979623c717a2430000000000", event.id)
+}
+
+func TestGETEventStream_KafkaEventForPulledCommit(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user watching service changes")
+	stream, err := tstOpenEventStream("/rest/api/v1/events/stream?type=service", "")
+	require.Nil(t, err)
+	defer stream.close()
+
+	docs.Given("And a commit made by another instance")
+	metadataImpl.SimulateRemoteCommits = []repository.CommitInfo{tstRemoteServiceCommit()}
+
+	docs.When("When the kafka event of the other instance arrives, which makes this instance pull the commit")
+	kafkaImpl.Receive(repository.UpdateEvent{
+		Affected: repository.EventAffects{
+			OwnerAliases:   []string{},
+			ServiceNames:   []string{"some-service-backend"},
+			RepositoryKeys: []string{},
+		},
+		TimeStamp:  "2022-11-06T18:14:10Z",
+		CommitHash: tstRemoteCommitHash,
+	})
+
+	docs.When("And an admin then patches a service")
+	body := tstServicePatch()
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", tstValidAdminToken(), &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Then("Then the commit is streamed once, followed by the patch")
+	event, err := stream.next()
+	require.Nil(t, err)
+	require.Equal(t, tstRemoteCommitHash, event.id)
+	event, err = stream.next()
+	require.Nil(t, err)
+	require.Equal(t, "6c8ac2c35791edf9979623c717a2430000000000", event.id)
+}
+
+func TestGETEventStream_UnknownLastEventId(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+
+	docs.When("When they resume watching changes after a commit that is no longer buffered")
+	stream, err := tstOpenEventStream("/rest/api/v1/events/stream", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	require.Nil(t, err)
+	defer stream.close()
+
+	docs.Then("Then they are told to reset, because events may have been missed")
+	require.Equal(t, http.StatusOK, stream.status)
+	event, err := stream.next()
+	require.Nil(t, err)
+	require.Equal(t, "reset", event.event)
+	require.Equal(t, "", event.id)
+}
+
+func TestGETEventStream_InvalidType(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they try to watch changes of an unknown type of entity")
+	response, err := tstPerformGet("/rest/api/v1/events/stream?type=colour", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "events-invalid-type.json")
+}
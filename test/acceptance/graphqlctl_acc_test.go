@@ -0,0 +1,103 @@
+package acceptance
+
+import (
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/StephanHCB/go-backend-service-common/docs"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// graphql
+
+func TestPOSTGraphql_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they query an owner with its services, their repositories and dependencies")
+	body := openapi.GraphqlRequestDto{
+		Query: `query ($alias: String!) {
+			owner(alias: $alias) {
+				alias
+				contact
+				services {
+					name
+					lifecycle
+					labels { key value }
+					repositories { key type owner { alias } service { name } }
+					dependsOn { name }
+					dependents { name }
+				}
+			}
+		}`,
+		Variables: map[string]interface{}{"alias": "some-owner"},
+	}
+	response, err := tstPerformPost("/graphql", token, &body)
+
+	docs.Then("Then the request is successful and the response contains the requested graph")
+	tstAssert(t, response, err, http.StatusOK, "graphql-owner.json")
+}
+
+func TestGETGraphql_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they query the repositories of an owner using GET, including one that does not belong to a service")
+	query := url.QueryEscape(`{ repositories(owner: "some-owner") { key service { name } } repository(key: "does-not-exist.implementation") { key } }`)
+	response, err := tstPerformGet("/graphql?query="+query, token)
+
+	docs.Then("Then the request is successful and the missing repository is null")
+	tstAssert(t, response, err, http.StatusOK, "graphql-repositories.json")
+}
+
+func TestPOSTGraphql_DepthExceeded(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they send a query that is nested deeper than allowed")
+	body := openapi.GraphqlRequestDto{
+		Query: `{ owners { services { owner { services { owner { services { owner { services { name } } } } } } } } }`,
+	}
+	response, err := tstPerformPost("/graphql", token, &body)
+
+	docs.Then("Then the request fails and the query has not been executed")
+	tstAssert(t, response, err, http.StatusBadRequest, "graphql-depth-exceeded.json")
+}
+
+func TestPOSTGraphql_ComplexityExceeded(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they send a query that lists too many entities")
+	body := openapi.GraphqlRequestDto{
+		Query: `{ owners { services { repositories { key } dependents { repositories { key } } } } }`,
+	}
+	response, err := tstPerformPost("/graphql", token, &body)
+
+	docs.Then("Then the request fails and the query has not been executed")
+	tstAssert(t, response, err, http.StatusBadRequest, "graphql-complexity-exceeded.json")
+}
+
+func TestPOSTGraphql_Invalid(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they query a field that does not exist")
+	body := openapi.GraphqlRequestDto{
+		Query: `{ owners { alias budget } }`,
+	}
+	response, err := tstPerformPost("/graphql", token, &body)
+
+	docs.Then("Then the request fails and the error names the unknown field")
+	tstAssert(t, response, err, http.StatusBadRequest, "graphql-invalid.json")
+}
@@ -0,0 +1,64 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/StephanHCB/go-backend-service-common/docs"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+// consistency report
+
+func TestGETConsistency_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated user")
+	token := tstValidUserToken()
+
+	docs.When("When they request the consistency report")
+	response, err := tstPerformGet("/rest/api/v1/management/consistency", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "consistency.json")
+}
+
+func TestGETConsistency_MissingRepository(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a repository of a service has been removed from the metadata repository by hand")
+	require.Nil(t, metadataImpl.DeleteFile("owners/some-owner/repositories/some-service-backend.helm-deployment.yaml"))
+	require.Nil(t, application.Updater.PerformFullUpdate(appCtx))
+	defer tstReset()
+
+	docs.When("When an authenticated user requests the consistency report")
+	response, err := tstPerformGet("/rest/api/v1/management/consistency", tstValidUserToken())
+
+	docs.Then("Then the request is successful and the service is reported with the dangling reference")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	report := openapi.ConsistencyReportDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &report))
+	require.Contains(t, report.Issues, openapi.ConsistencyIssueDto{
+		Type:         "missing-repository",
+		EntityType:   "service",
+		Entity:       "some-service-backend",
+		Field:        p("repositories.0"),
+		Reference:    p("some-service-backend.helm-deployment"),
+		SuggestedFix: "remove repository some-service-backend.helm-deployment from service some-service-backend, or create the repository",
+	})
+}
+
+func TestGETConsistency_Unauthenticated(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the consistency report")
+	response, err := tstPerformGet("/rest/api/v1/management/consistency", token)
+
+	docs.Then("Then the request fails as unauthorized")
+	tstAssert(t, response, err, http.StatusUnauthorized, "unauthorized.json")
+}
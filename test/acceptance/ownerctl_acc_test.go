@@ -1,11 +1,16 @@
 package acceptance
 
 import (
+	"context"
 	"encoding/json"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Interhyp/metadata-service/internal/types"
 	"github.com/StephanHCB/go-backend-service-common/docs"
+	"github.com/go-http-utils/headers"
 	"github.com/stretchr/testify/require"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -26,6 +31,19 @@ func TestGETOwners_Success(t *testing.T) {
 
 // get owner
 
+func TestGETOwners_PagedWithFields(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the first page of the list of owners, one per page, with only the contact field")
+	response, err := tstPerformGet("/rest/api/v1/owners?limit=1&fields=contact", token)
+
+	docs.Then("Then the request is successful and the response contains the contact of the first owner and a cursor")
+	tstAssert(t, response, err, http.StatusOK, "owners-page1-fields.json")
+}
+
 func TestGETOwner_Success(t *testing.T) {
 	tstReset()
 
@@ -52,6 +70,58 @@ func TestGETOwner_NotFound(t *testing.T) {
 	tstAssert(t, response, err, http.StatusNotFound, "owner-notfound-migration-excellence.json")
 }
 
+func TestGETOwners_AtOriginalCommit(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the list of owners as of an abbreviated commit hash")
+	response, err := tstPerformGet("/rest/api/v1/owners?at=6c8ac2c", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "owners.json")
+}
+
+func TestGETOwner_AtOriginalCommit(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a single owner as of a commit")
+	response, err := tstPerformGet("/rest/api/v1/owners/some-owner?at=6c8ac2c35791edf9979623c717a243fc53400000", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "owner.json")
+}
+
+func TestGETOwnerHistory_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the history of an existing owner")
+	response, err := tstPerformGet("/rest/api/v1/owners/some-owner/history", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "owner-history.json")
+}
+
+func TestGETOwnerHistory_NotFound(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the history of an owner that does not exist")
+	response, err := tstPerformGet("/rest/api/v1/owners/migration-excellence/history", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusNotFound, "owner-notfound-migration-excellence.json")
+}
+
 // create owner
 
 func TestPOSTOwner_Success(t *testing.T) {
@@ -524,6 +594,30 @@ func TestPATCHOwner_Success(t *testing.T) {
 	hasSentNotification(t, "receivesOwner", "some-owner", types.ModifiedEvent, types.OwnerPayload, &payload)
 }
 
+func TestPATCHOwner_PolicyWarning(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a policy that warns about services whose owner has no product owner")
+	tstSetupPolicies(config.Policy{
+		Name:         "productowner",
+		Entity:       config.PolicyEntityService,
+		Severity:     config.PolicySeverityWarn,
+		RequireOwner: `productOwner!=""`,
+	})
+	defer tstSetupPolicies()
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they remove the product owner of an owner of services")
+	body := tstOwnerPatch()
+	body.ProductOwner = p("")
+	response, err := tstPerformPatch("/rest/api/v1/owners/some-owner", token, &body)
+
+	docs.Then("Then the request is successful and the response lists a policy warning for each of its services")
+	tstAssert(t, response, err, http.StatusOK, "owner-patch-policy-warning.json")
+}
+
 func TestPATCHOwner_NoChangeSuccess(t *testing.T) {
 	tstReset()
 
@@ -848,6 +942,169 @@ func TestDELETEOwner_StillHasStuffConflict(t *testing.T) {
 	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
 }
 
+func TestDELETEOwner_TransferTo(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they delete an existing owner that still has services and repositories, transferring them to another owner")
+	body := tstDelete()
+	body.TransferTo = "deleteme"
+	response, err := tstPerformDelete("/rest/api/v1/owners/some-owner", token, &body)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssertNoBody(t, response, err, http.StatusNoContent)
+
+	docs.Then("And the services and repositories have been moved and the owner deleted, committed and pushed")
+	require.Equal(t, "<notfound>", metadataImpl.ReadContents("owners/some-owner/owner.info.yaml"))
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/owner.info.yaml"])
+	for _, filename := range []string{
+		"services/some-service-backend.yaml",
+		"repositories/some-service-backend.helm-deployment.yaml",
+		"repositories/some-service-backend.implementation.yaml",
+		"repositories/whatever.helm-deployment.yaml",
+		"repositories/whatever.implementation.yaml",
+		"repositories/karma-wrapper.helm-chart.yaml",
+	} {
+		require.Equal(t, "<notfound>", metadataImpl.ReadContents("owners/some-owner/"+filename))
+		require.NotEqual(t, "<notfound>", metadataImpl.ReadContents("owners/deleteme/"+filename))
+		require.True(t, metadataImpl.FilesCommitted["owners/deleteme/"+filename])
+	}
+	require.True(t, metadataImpl.Pushed)
+
+	docs.Then("And the owner has been removed from the cache, and its service now belongs to the other owner")
+	readAgain, err := tstPerformGet("/rest/api/v1/owners/some-owner", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNotFound, readAgain.status)
+	readService, err := tstPerformGet("/rest/api/v1/services/some-service-backend", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Contains(t, readService.body, `"owner":"deleteme"`)
+
+	docs.Then("And a single kafka message covering every affected entity has been sent")
+	require.Equal(t, 1, len(kafkaImpl.Recording))
+	actual, _ := json.Marshal(kafkaImpl.Recording[0])
+	require.Equal(t, `{"affected":{"ownerAliases":["some-owner","deleteme"],"serviceNames":["some-service-backend"],`+
+		`"repositoryKeys":["some-service-backend.helm-deployment","some-service-backend.implementation",`+
+		`"karma-wrapper.helm-chart","whatever.helm-deployment","whatever.implementation"]},`+
+		`"timeStamp":"2022-11-06T18:14:10Z","commitHash":"6c8ac2c35791edf9979623c717a2430000000000"}`, string(actual))
+
+	docs.Then("And a deletion notification has been sent for the owner")
+	hasSentNotification(t, "receivesDelete", "some-owner", types.DeletedEvent, types.OwnerPayload, nil)
+}
+
+func TestDELETEOwner_TransferToWithGroups(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an existing owner with a group")
+	ownerPatch := tstOwnerPatch()
+	ownerPatch.Groups = &map[string][]string{"developers": {"some-user"}}
+	response, err := tstPerformPatch("/rest/api/v1/owners/some-owner", tstValidAdminToken(), &ownerPatch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given a repository of the owner whose watchers refer to the group")
+	repositoryPatch := tstRepositoryPatch()
+	repositoryPatch.Configuration = &openapi.RepositoryConfigurationDto{Watchers: []string{"@some-owner.developers"}}
+	response, err = tstPerformPatch("/rest/api/v1/repositories/karma-wrapper.helm-chart", tstValidAdminToken(), &repositoryPatch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they delete the owner, transferring its services and repositories to another owner")
+	body := tstDelete()
+	body.TransferTo = "deleteme"
+	response, err = tstPerformDelete("/rest/api/v1/owners/some-owner", token, &body)
+
+	docs.Then("Then the request is successful")
+	tstAssertNoBody(t, response, err, http.StatusNoContent)
+
+	docs.Then("And the group has been copied to the other owner, and the repository refers to the copy")
+	require.Contains(t, metadataImpl.ReadContents("owners/deleteme/owner.info.yaml"), "developers:")
+	require.Contains(t, metadataImpl.ReadContents("owners/deleteme/repositories/karma-wrapper.helm-chart.yaml"), "'@deleteme.developers'")
+	require.NotContains(t, metadataImpl.ReadContents("owners/deleteme/repositories/karma-wrapper.helm-chart.yaml"), "@some-owner")
+}
+
+func TestDELETEOwner_TransferToConflictingGroup(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given two existing owners with a group of the same name but different members")
+	ownerPatch := tstOwnerPatch()
+	ownerPatch.Groups = &map[string][]string{"developers": {"some-user"}}
+	response, err := tstPerformPatch("/rest/api/v1/owners/some-owner", tstValidAdminToken(), &ownerPatch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	ownerPatch.Groups = &map[string][]string{"developers": {"other-user"}}
+	response, err = tstPerformPatch("/rest/api/v1/owners/deleteme", tstValidAdminToken(), &ownerPatch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they delete the first owner, transferring its services and repositories to the second")
+	body := tstDelete()
+	body.TransferTo = "deleteme"
+	response, err = tstPerformDelete("/rest/api/v1/owners/some-owner", token, &body)
+
+	docs.Then("Then the request fails with a conflict, because the group references would change their meaning")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusConflict, response.status)
+	require.NotEqual(t, "<notfound>", metadataImpl.ReadContents("owners/some-owner/owner.info.yaml"))
+}
+
+func TestDELETEOwner_TransferToPolicyDenied(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a policy that denies services owned by a certain owner")
+	tstSetupPolicies(config.Policy{
+		Name:     "frozen",
+		Entity:   config.PolicyEntityService,
+		Severity: config.PolicySeverityDeny,
+		Message:  "owner deleteme takes no new services",
+		Require:  "owner!=deleteme",
+	})
+	defer tstSetupPolicies()
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they delete an existing owner, transferring its services to that owner")
+	body := tstDelete()
+	body.TransferTo = "deleteme"
+	response, err := tstPerformDelete("/rest/api/v1/owners/some-owner", token, &body)
+
+	docs.Then("Then the request fails, because the policy is checked against the transferred services")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusBadRequest, response.status)
+	require.Contains(t, response.body, "owner deleteme takes no new services")
+
+	docs.Then("And the owner has not been deleted")
+	require.NotEqual(t, "<notfound>", metadataImpl.ReadContents("owners/some-owner/owner.info.yaml"))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestDELETEOwner_TransferToUnknownOwner(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they delete an existing owner, transferring its services and repositories to an owner that does not exist")
+	body := tstDelete()
+	body.TransferTo = "unknown-owner"
+	response, err := tstPerformDelete("/rest/api/v1/owners/some-owner", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "owner-delete-transfer-unknown.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
 func TestDELETEOwner_GitServerDown(t *testing.T) {
 	tstReset()
 
@@ -868,3 +1125,494 @@ func TestDELETEOwner_GitServerDown(t *testing.T) {
 	require.Equal(t, 0, len(metadataImpl.FilesWritten))
 	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
 }
+
+// revert owner
+
+func TestPOSTOwnerRevert_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an owner that has been patched")
+	patch := tstOwnerPatch()
+	response, err := tstPerformPatch("/rest/api/v1/owners/some-owner", tstValidAdminToken(), &patch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they revert the owner to the commit before the patch")
+	body := tstRevert()
+	response, err = tstPerformPost("/rest/api/v1/owners/some-owner/revert", token, &body)
+
+	docs.Then("Then the request is successful and the response shows the owner before the patch")
+	tstAssert(t, response, err, http.StatusOK, "owner-revert.json")
+
+	docs.Then("And the owner has been committed and pushed")
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/owner.info.yaml"])
+	require.True(t, metadataImpl.Pushed)
+}
+
+func TestPOSTOwnerRevert_NotFound(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to revert an owner that did not exist at the given commit")
+	body := tstRevert()
+	response, err := tstPerformPost("/rest/api/v1/owners/migration-excellence/revert", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusNotFound, "owner-notfound-migration-excellence.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTOwner_DryRun(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request a dry run of the creation of a valid owner that does not exist")
+	body := tstOwner()
+	response, err := tstPerformPost("/rest/api/v1/owners/post-owner-dryrun?dryRun=true", token, &body)
+
+	docs.Then("Then the request is successful and the response contains the owner and the diff that would be committed")
+	tstAssert(t, response, err, http.StatusOK, "owner-create-dryrun.json")
+
+	docs.Then("And nothing has been written, committed or pushed")
+	filename := "owners/post-owner-dryrun/owner.info.yaml"
+	require.Equal(t, "<notfound>", metadataImpl.ReadContents(filename))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+	require.False(t, metadataImpl.Pushed)
+
+	docs.Then("And the owner has not been cached")
+	readAgain, err := tstPerformGet("/rest/api/v1/owners/post-owner-dryrun", tstUnauthenticated())
+	tstAssert(t, readAgain, err, http.StatusNotFound, "owner-notfound-dryrun.json")
+
+	docs.Then("And no kafka messages have been sent")
+	require.Equal(t, 0, len(kafkaImpl.Recording))
+}
+
+func TestDryRun_StagedOwnerOnlyVisibleInside(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a dry run that stages the creation of an owner that does not exist")
+	_, err := application.Updater.DryRun(appCtx, func(subCtx context.Context) error {
+		_, err := application.Updater.WriteOwner(subCtx, "staged-owner", tstOwner())
+		require.Nil(t, err)
+
+		docs.Then("Then the owner can be read inside the dry run")
+		_, err = application.Cache.GetOwner(subCtx, "staged-owner")
+		require.Nil(t, err)
+		aliases, err := application.Cache.GetSortedOwnerAliases(subCtx)
+		require.Nil(t, err)
+		require.Contains(t, aliases, "staged-owner")
+
+		docs.When("When another request reads the owners while the dry run is still open")
+		readOutside, err := tstPerformGet("/rest/api/v1/owners/staged-owner", tstUnauthenticated())
+		require.Nil(t, err)
+		listOutside, err := tstPerformGet("/rest/api/v1/owners", tstUnauthenticated())
+		require.Nil(t, err)
+		searchOutside, err := tstPerformGet("/rest/api/v1/search?q=staged", tstUnauthenticated())
+		require.Nil(t, err)
+
+		docs.Then("Then it does not see the staged owner")
+		require.Equal(t, http.StatusNotFound, readOutside.status)
+		require.NotContains(t, listOutside.body, "staged-owner")
+		require.NotContains(t, searchOutside.body, "staged-owner")
+		return nil
+	})
+	require.Nil(t, err)
+
+	docs.Then("And the owner is not cached once the dry run has ended")
+	_, err = application.Cache.GetOwner(appCtx, "staged-owner")
+	require.NotNil(t, err)
+}
+
+func TestPOSTOwner_InvalidDryRun(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request the creation of a valid owner with an invalid dryRun parameter")
+	body := tstOwner()
+	response, err := tstPerformPost("/rest/api/v1/owners/post-owner-dryrun?dryRun=maybe", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "dryrun-invalid.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestDELETEOwner_DryRun(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request a dry run of the deletion of an existing owner that has no services and repositories")
+	body := tstDelete()
+	response, err := tstPerformDelete("/rest/api/v1/owners/deleteme?dryRun=true", token, &body)
+
+	docs.Then("Then the request is successful and the response contains the diff that would be committed")
+	tstAssert(t, response, err, http.StatusOK, "owner-delete-dryrun.json")
+
+	docs.Then("And the owner is still present and nothing has been committed or pushed")
+	filename := "owners/deleteme/owner.info.yaml"
+	require.NotEqual(t, "<notfound>", metadataImpl.ReadContents(filename))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+	require.False(t, metadataImpl.Pushed)
+
+	docs.Then("And the owner can still be read")
+	readAgain, err := tstPerformGet("/rest/api/v1/owners/deleteme", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, readAgain.status)
+
+	docs.Then("And no kafka messages have been sent")
+	require.Equal(t, 0, len(kafkaImpl.Recording))
+}
+
+// entity tags
+
+func TestGETOwner_ETag(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a single existing owner")
+	response, err := tstPerformGet("/rest/api/v1/owners/some-owner", token)
+
+	docs.Then("Then the request is successful and the response carries the commit hash as entity tag")
+	tstAssert(t, response, err, http.StatusOK, "owner.json")
+	require.Equal(t, `"6c8ac2c35791edf9979623c717a243fc53400000"`, response.etag)
+}
+
+func TestGETOwner_NotModified(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a single existing owner, giving its current entity tag in If-None-Match")
+	response, err := tstPerformGetWithHeader("/rest/api/v1/owners/some-owner", token, "If-None-Match", `"6c8ac2c35791edf9979623c717a243fc53400000"`)
+
+	docs.Then("Then the response is not modified without a body")
+	tstAssertNoBody(t, response, err, http.StatusNotModified)
+	require.Equal(t, `"6c8ac2c35791edf9979623c717a243fc53400000"`, response.etag)
+}
+
+func TestGETOwner_Modified(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a single existing owner, giving an outdated entity tag in If-None-Match")
+	response, err := tstPerformGetWithHeader("/rest/api/v1/owners/some-owner", token, "If-None-Match", `"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"`)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "owner.json")
+}
+
+func TestGETOwners_NotModified(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the list of owners, giving the entity tag of the list in If-None-Match")
+	first, err := tstPerformGet("/rest/api/v1/owners", token)
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(first.etag, `W/"`))
+	response, err := tstPerformGetWithHeader("/rest/api/v1/owners", token, "If-None-Match", first.etag)
+
+	docs.Then("Then the response is not modified without a body")
+	tstAssertNoBody(t, response, err, http.StatusNotModified)
+}
+
+func TestGETOwners_ModifiedWithinTheSameSecond(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user who has obtained the entity tag of the list of owners")
+	token := tstUnauthenticated()
+	first, err := tstPerformGet("/rest/api/v1/owners", token)
+	require.Nil(t, err)
+
+	docs.Given("And an owner that has been patched without changing the list timestamp")
+	body := tstOwnerPatch()
+	patch, err := tstPerformPatch("/rest/api/v1/owners/some-owner", tstValidAdminToken(), &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, patch.status)
+
+	docs.When("When they request the list of owners, giving the entity tag in If-None-Match")
+	response, err := tstPerformGetWithHeader("/rest/api/v1/owners", token, "If-None-Match", first.etag)
+
+	docs.Then("Then the request is successful with the same timestamp but a new entity tag")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	require.NotEqual(t, first.etag, response.etag)
+	before := openapi.OwnerListDto{}
+	after := openapi.OwnerListDto{}
+	require.Nil(t, json.Unmarshal([]byte(first.body), &before))
+	require.Nil(t, json.Unmarshal([]byte(response.body), &after))
+	require.Equal(t, before.TimeStamp, after.TimeStamp)
+}
+
+func TestPATCHOwner_IfMatch(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they perform a valid patch of an existing owner with If-Match instead of commit hash and timestamp")
+	body := tstOwnerPatch()
+	body.CommitHash = ""
+	body.TimeStamp = ""
+	response, err := tstPerformPatchWithHeader("/rest/api/v1/owners/some-owner", token, &body, "If-Match", `"6c8ac2c35791edf9979623c717a243fc53400000"`)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "owner-patch.json")
+	require.NotEqual(t, "", response.etag)
+
+	docs.Then("And the owner has been correctly written, committed and pushed")
+	filename := "owners/some-owner/owner.info.yaml"
+	require.Equal(t, tstOwnerPatchExpectedYaml(), metadataImpl.ReadContents(filename))
+	require.True(t, metadataImpl.FilesCommitted[filename])
+	require.True(t, metadataImpl.Pushed)
+}
+
+func TestPATCHOwner_PreconditionFailed(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request a patch of an owner with an outdated entity tag in If-Match")
+	body := tstOwnerPatch()
+	response, err := tstPerformPatchWithHeader("/rest/api/v1/owners/some-owner", token, &body, "If-Match", `"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"`)
+
+	docs.Then("Then the request fails and the error response contains the current owner")
+	tstAssert(t, response, err, http.StatusPreconditionFailed, "owner-patch-precondition.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPUTOwner_PreconditionFailed(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request an update of an owner with an outdated entity tag in If-Match")
+	body := tstOwner()
+	response, err := tstPerformPutWithHeader("/rest/api/v1/owners/some-owner", token, &body, "If-Match", `"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"`)
+
+	docs.Then("Then the request fails with a failed precondition")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusPreconditionFailed, response.status)
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPATCHOwner_MergePatch(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they send a JSON merge patch that changes the display name and removes the teams channel")
+	response, err := tstPerformPatchDocument("/rest/api/v1/owners/some-owner", token, types.MergePatch, `{
+		"displayName": "Some Owner",
+		"teamsChannelURL": null,
+		"timeStamp": "2022-11-06T18:14:10Z",
+		"commitHash": "6c8ac2c35791edf9979623c717a243fc53400000",
+		"jiraIssue": "ISSUE-2345"
+	}`)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "owner-merge-patch.json")
+
+	docs.Then("And the owner has been correctly written, committed and pushed")
+	filename := "owners/some-owner/owner.info.yaml"
+	require.Equal(t, `contact: somebody@some-organisation.com
+productOwner: kschlangenheldt
+defaultJiraProject: ISSUE
+displayName: Some Owner
+`, metadataImpl.ReadContents(filename))
+	require.True(t, metadataImpl.FilesCommitted[filename])
+	require.True(t, metadataImpl.Pushed)
+}
+
+func TestPATCHOwner_JsonPatch(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they send a JSON patch that adds a link, with the version given by If-Match")
+	response, err := tstPerformRawWithBodyAndHeaders(http.MethodPatch, "/rest/api/v1/owners/some-owner", token, []byte(`[
+		{"op": "test", "path": "/contact", "value": "somebody@some-organisation.com"},
+		{"op": "add", "path": "/links", "value": []},
+		{"op": "add", "path": "/links/-", "value": {"url": "https://wiki.some-organisation.com/some-owner", "title": "Wiki"}},
+		{"op": "replace", "path": "/jiraIssue", "value": "ISSUE-2345"}
+	]`), map[string]string{
+		headers.ContentType: types.JsonPatch,
+		headers.IfMatch:     `"6c8ac2c35791edf9979623c717a243fc53400000"`,
+	})
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "owner-json-patch.json")
+
+	docs.Then("And the owner has been committed and pushed")
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/owner.info.yaml"])
+	require.True(t, metadataImpl.Pushed)
+}
+
+func TestPATCHOwner_JsonPatchTestFails(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they send a JSON patch whose test operation does not hold")
+	response, err := tstPerformPatchDocument("/rest/api/v1/owners/some-owner", token, types.JsonPatch, `[
+		{"op": "test", "path": "/contact", "value": "nobody@some-organisation.com"},
+		{"op": "replace", "path": "/contact", "value": "changed@some-organisation.com"}
+	]`)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "owner-json-patch-test-failed.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPATCHOwner_MergePatchInvalidValues(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they send a JSON merge patch that removes the contact and does not give the version")
+	response, err := tstPerformPatchDocument("/rest/api/v1/owners/some-owner", token, types.MergePatch, `{"contact": null}`)
+
+	docs.Then("Then the request fails and the error response lists all problems")
+	tstAssert(t, response, err, http.StatusBadRequest, "owner-merge-patch-invalid.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTOwnerRename_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given another owner with a group that refers to a group of an existing owner")
+	ownerPatch := tstOwnerPatch()
+	ownerPatch.Groups = &map[string][]string{"reviewers": {"@some-owner.developers", "some-user"}}
+	response, err := tstPerformPatch("/rest/api/v1/owners/deleteme", tstValidAdminToken(), &ownerPatch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given a repository of the existing owner whose watchers refer to the same group")
+	repositoryPatch := tstRepositoryPatch()
+	repositoryPatch.Configuration = &openapi.RepositoryConfigurationDto{Watchers: []string{"@some-owner.developers"}}
+	response, err = tstPerformPatch("/rest/api/v1/repositories/karma-wrapper.helm-chart", tstValidAdminToken(), &repositoryPatch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they rename the existing owner")
+	body := tstOwnerRename("renamed-owner")
+	response, err = tstPerformPost("/rest/api/v1/owners/some-owner/rename", token, &body)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "owner-rename.json")
+
+	docs.Then("And the owner directory has been moved, committed and pushed")
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/owner.info.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/renamed-owner/owner.info.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/services/some-service-backend.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/renamed-owner/services/some-service-backend.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/renamed-owner/repositories/some-service-backend.helm-deployment.yaml"])
+	require.True(t, metadataImpl.Pushed)
+
+	docs.Then("And all group references have been changed to the new alias")
+	require.Contains(t, metadataImpl.ReadContents("owners/deleteme/owner.info.yaml"), "'@renamed-owner.developers'")
+	require.Contains(t, metadataImpl.ReadContents("owners/renamed-owner/repositories/karma-wrapper.helm-chart.yaml"), "'@renamed-owner.developers'")
+
+	docs.Then("And the owner can only be read under its new alias")
+	readAgain, err := tstPerformGet("/rest/api/v1/owners/renamed-owner", tstUnauthenticated())
+	tstAssert(t, readAgain, err, http.StatusOK, "owner-rename.json")
+	readOld, err := tstPerformGet("/rest/api/v1/owners/some-owner", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNotFound, readOld.status)
+
+	docs.Then("And its services have moved along")
+	readService, err := tstPerformGet("/rest/api/v1/services/some-service-backend", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Contains(t, readService.body, `"owner":"renamed-owner"`)
+
+	docs.Then("And a single kafka message covering every affected entity has been sent for the rename")
+	require.Equal(t, 3, len(kafkaImpl.Recording))
+	actual, _ := json.Marshal(kafkaImpl.Recording[2])
+	require.Equal(t, `{"affected":{"ownerAliases":["some-owner","renamed-owner","deleteme"],"serviceNames":["some-service-backend"],`+
+		`"repositoryKeys":["karma-wrapper.helm-chart","some-service-backend.helm-deployment","some-service-backend.implementation",`+
+		`"whatever.helm-deployment","whatever.implementation"]},`+
+		`"timeStamp":"2022-11-06T18:14:10Z","commitHash":"6c8ac2c35791edf9979623c717a2430000000000"}`, string(actual))
+
+	docs.Then("And a deletion notification has been sent for the old alias")
+	hasSentNotification(t, "receivesDelete", "some-owner", types.DeletedEvent, types.OwnerPayload, nil)
+}
+
+func TestPOSTOwnerRename_AlreadyExists(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to rename an owner to the alias of another existing owner")
+	body := tstOwnerRename("deleteme")
+	response, err := tstPerformPost("/rest/api/v1/owners/some-owner/rename", token, &body)
+
+	docs.Then("Then the request fails with a conflict and the error response is as expected")
+	tstAssert(t, response, err, http.StatusConflict, "owner-rename-conflict.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTOwnerRename_InvalidValues(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to rename an owner without giving a new alias or jira issue")
+	body := tstOwnerRename("")
+	body.JiraIssue = ""
+	response, err := tstPerformPost("/rest/api/v1/owners/some-owner/rename", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "owner-rename-invalid-values.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
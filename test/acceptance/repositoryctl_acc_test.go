@@ -1,11 +1,15 @@
 package acceptance
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Interhyp/metadata-service/internal/types"
 	"github.com/StephanHCB/go-backend-service-common/docs"
 	"github.com/stretchr/testify/require"
 	"net/http"
+	"net/url"
 	"testing"
 )
 
@@ -78,6 +82,109 @@ func TestGETRepositories_Filtered_Type(t *testing.T) {
 
 // get repository
 
+func TestGETRepositories_Query(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the list of repositories filtered by a query expression")
+	query := url.QueryEscape(`configuration.requireIssue=true OR (name=whatever AND NOT type="helm-deployment")`)
+	response, err := tstPerformGet("/rest/api/v1/repositories?q="+query, token)
+
+	docs.Then("Then the request is successful and the response contains exactly the matching repositories")
+	tstAssert(t, response, err, http.StatusOK, "repositories-query.json")
+}
+
+func TestGETRepositories_InvalidQuery(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the list of repositories filtered by a query expression with an unknown field")
+	query := url.QueryEscape("owner=some-owner AND colour=blue")
+	response, err := tstPerformGet("/rest/api/v1/repositories?q="+query, token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "repositories-query-invalid.json")
+}
+
+func TestGETRepositories_Paged(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the first page of the list of repositories")
+	response, err := tstPerformGet("/rest/api/v1/repositories?limit=2", token)
+
+	docs.Then("Then the request is successful and the response contains the first two repositories and a cursor")
+	tstAssert(t, response, err, http.StatusOK, "repositories-page1.json")
+
+	docs.When("When they request the next page using that cursor")
+	firstPage := openapi.RepositoryListDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &firstPage))
+	response, err = tstPerformGet("/rest/api/v1/repositories?limit=2&cursor="+firstPage.NextCursor, token)
+
+	docs.Then("Then the request is successful and the response contains the next two repositories")
+	tstAssert(t, response, err, http.StatusOK, "repositories-page2.json")
+
+	docs.When("When they keep following the cursor")
+	secondPage := openapi.RepositoryListDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &secondPage))
+	response, err = tstPerformGet("/rest/api/v1/repositories?limit=2&cursor="+secondPage.NextCursor, token)
+
+	docs.Then("Then the last page contains the remaining repository and no cursor")
+	tstAssert(t, response, err, http.StatusOK, "repositories-page3.json")
+}
+
+func TestGETRepositories_DescendingWithFields(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the first page of repositories in descending order with only some fields")
+	response, err := tstPerformGet("/rest/api/v1/repositories?limit=3&sort=desc&fields=owner,url", token)
+
+	docs.Then("Then the request is successful and the response contains only those fields of the last three repositories")
+	tstAssert(t, response, err, http.StatusOK, "repositories-desc-fields.json")
+}
+
+func TestGETRepositories_InvalidPaging(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the list of repositories with an invalid limit")
+	response, err := tstPerformGet("/rest/api/v1/repositories?limit=0", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "repositories-invalid-limit.json")
+
+	docs.When("When they request the list of repositories with an unknown field")
+	response, err = tstPerformGet("/rest/api/v1/repositories?fields=owner,colour", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "repositories-invalid-fields.json")
+}
+
+func TestGETRepositories_OutdatedCursor(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a page with a cursor from before the list last changed")
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(`{"timeStamp":"2020-01-01T00:00:00Z","after":"karma-wrapper.helm-chart"}`))
+	response, err := tstPerformGet("/rest/api/v1/repositories?limit=2&cursor="+cursor, token)
+
+	docs.Then("Then the request fails with a conflict, so they can start over")
+	tstAssert(t, response, err, http.StatusConflict, "repositories-cursor-outdated.json")
+}
+
 func TestGETRepository_Success(t *testing.T) {
 	tstReset()
 
@@ -104,6 +211,58 @@ func TestGETRepository_NotFound(t *testing.T) {
 	tstAssert(t, response, err, http.StatusNotFound, "repository-notfound.json")
 }
 
+func TestGETRepositories_AtOriginalCommit(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the list of repositories as of a commit")
+	response, err := tstPerformGet("/rest/api/v1/repositories?at=6c8ac2c35791edf9979623c717a243fc53400000", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "repositories.json")
+}
+
+func TestGETRepository_AtOriginalCommit(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a single repository as of a commit")
+	response, err := tstPerformGet("/rest/api/v1/repositories/some-service-backend.helm-deployment?at=6c8ac2c35791edf9979623c717a243fc53400000", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "repository.json")
+}
+
+func TestGETRepositoryHistory_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the history of an existing repository")
+	response, err := tstPerformGet("/rest/api/v1/repositories/karma-wrapper.helm-chart/history", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "repository-history.json")
+}
+
+func TestGETRepositoryHistory_NotFound(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the history of a repository that does not exist")
+	response, err := tstPerformGet("/rest/api/v1/repositories/unicorn.helm-chart/history", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusNotFound, "repository-notfound.json")
+}
+
 // create repository
 
 func TestPOSTRepository_Success(t *testing.T) {
@@ -632,6 +791,30 @@ func TestPATCHRepository_Success(t *testing.T) {
 	hasSentNotification(t, "receivesRepository", "karma-wrapper.helm-chart", types.ModifiedEvent, types.RepositoryPayload, &payload)
 }
 
+func TestPATCHRepository_PolicyDenied(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a policy that requires the mainline of helm chart repositories to be master")
+	tstSetupPolicies(config.Policy{
+		Name:     "mainline",
+		Entity:   config.PolicyEntityRepository,
+		Severity: config.PolicySeverityDeny,
+		When:     "type=helm-chart",
+		Require:  "mainline=master",
+	})
+	defer tstSetupPolicies()
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they patch the mainline of a helm chart repository to main")
+	body := tstRepositoryPatch()
+	response, err := tstPerformPatch("/rest/api/v1/repositories/karma-wrapper.helm-chart", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "repository-patch-policy-denied.json")
+}
+
 func TestPATCHRepository_NoChangeSuccess(t *testing.T) {
 	tstReset()
 
@@ -1052,3 +1235,245 @@ func TestDELETERepository_Referenced(t *testing.T) {
 	docs.Then("And no kafka messages have been sent")
 	require.Equal(t, 0, len(kafkaImpl.Recording))
 }
+
+func TestDELETERepository_ReplacedByReference(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a repository that names another repository in replacedBy")
+	patch := tstRepositoryUnchangedPatch()
+	patch.ReplacedBy = p("whatever.implementation")
+	response, err := tstPerformPatch("/rest/api/v1/repositories/karma-wrapper.helm-chart", tstValidAdminToken(), &patch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to delete the repository named in replacedBy")
+	body := tstDelete()
+	response, err = tstPerformDelete("/rest/api/v1/repositories/whatever.implementation", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusConflict, "repository-delete-replacedby.json")
+
+	docs.Then("And the repository has not been deleted")
+	filename := "owners/some-owner/repositories/whatever.implementation.yaml"
+	require.NotEqual(t, "<notfound>", metadataImpl.ReadContents(filename))
+	require.False(t, metadataImpl.FilesCommitted[filename])
+
+	docs.Then("And no kafka message has been sent for the deletion")
+	require.Equal(t, 1, len(kafkaImpl.Recording))
+}
+
+// revert repository
+
+func TestPOSTRepositoryRevert_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a repository that has been patched")
+	patch := tstRepositoryPatch()
+	response, err := tstPerformPatch("/rest/api/v1/repositories/karma-wrapper.helm-chart", tstValidAdminToken(), &patch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they revert the repository to the commit before the patch")
+	body := tstRevert()
+	response, err = tstPerformPost("/rest/api/v1/repositories/karma-wrapper.helm-chart/revert", token, &body)
+
+	docs.Then("Then the request is successful and the response shows the repository before the patch")
+	tstAssert(t, response, err, http.StatusOK, "repository-revert.json")
+
+	docs.Then("And the repository has been committed and pushed")
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/repositories/karma-wrapper.helm-chart.yaml"])
+	require.True(t, metadataImpl.Pushed)
+}
+
+func TestPUTRepository_DryRun(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request a dry run of a valid update of an existing repository")
+	body := tstRepository()
+	response, err := tstPerformPut("/rest/api/v1/repositories/karma-wrapper.helm-chart?dryRun=true", token, &body)
+
+	docs.Then("Then the request is successful and the response contains the updated repository and the diff that would be committed")
+	tstAssert(t, response, err, http.StatusOK, "repository-update-dryrun.json")
+
+	docs.Then("And the repository file is unchanged and nothing has been committed or pushed")
+	filename := "owners/some-owner/repositories/karma-wrapper.helm-chart.yaml"
+	require.NotEqual(t, tstRepositoryExpectedYaml(), metadataImpl.ReadContents(filename))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+	require.False(t, metadataImpl.Pushed)
+
+	docs.Then("And the cached repository is unchanged")
+	readAgain, err := tstPerformGet("/rest/api/v1/repositories/karma-wrapper.helm-chart", tstUnauthenticated())
+	tstAssert(t, readAgain, err, http.StatusOK, "repository-karmawrapper.json")
+
+	docs.Then("And no kafka messages have been sent")
+	require.Equal(t, 0, len(kafkaImpl.Recording))
+}
+
+// entity tags
+
+func TestGETRepository_ETag(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a single existing repository")
+	response, err := tstPerformGet("/rest/api/v1/repositories/some-service-backend.helm-deployment", token)
+
+	docs.Then("Then the request is successful and the response carries the commit hash as entity tag")
+	tstAssert(t, response, err, http.StatusOK, "repository.json")
+	require.Equal(t, `"6c8ac2c35791edf9979623c717a243fc53400000"`, response.etag)
+}
+
+func TestPATCHRepository_IfMatch(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they perform a valid patch of an existing repository with If-Match instead of commit hash and timestamp")
+	body := tstRepositoryPatch()
+	body.CommitHash = ""
+	body.TimeStamp = ""
+	response, err := tstPerformPatchWithHeader("/rest/api/v1/repositories/karma-wrapper.helm-chart", token, &body, "If-Match", `"6c8ac2c35791edf9979623c717a243fc53400000"`)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "repository-patch.json")
+}
+
+func TestPATCHRepository_PreconditionFailed(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request a patch of a repository with an outdated entity tag in If-Match")
+	body := tstRepositoryPatch()
+	response, err := tstPerformPatchWithHeader("/rest/api/v1/repositories/karma-wrapper.helm-chart", token, &body, "If-Match", `"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"`)
+
+	docs.Then("Then the request fails with a failed precondition")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusPreconditionFailed, response.status)
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPATCHRepository_JsonPatch(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they send a JSON patch that changes the mainline")
+	response, err := tstPerformPatchDocument("/rest/api/v1/repositories/karma-wrapper.helm-chart", token, types.JsonPatch, `[
+		{"op": "replace", "path": "/mainline", "value": "main"},
+		{"op": "replace", "path": "/timeStamp", "value": "2022-11-06T18:14:10Z"},
+		{"op": "replace", "path": "/commitHash", "value": "6c8ac2c35791edf9979623c717a243fc53400000"},
+		{"op": "replace", "path": "/jiraIssue", "value": "ISSUE-2345"}
+	]`)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "repository-json-patch.json")
+
+	docs.Then("And the repository has been committed and pushed")
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/repositories/karma-wrapper.helm-chart.yaml"])
+	require.True(t, metadataImpl.Pushed)
+}
+
+func TestPATCHRepository_MergePatchUnknownField(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they send a JSON merge patch that adds a field repositories do not have")
+	response, err := tstPerformPatchDocument("/rest/api/v1/repositories/karma-wrapper.helm-chart", token, types.MergePatch, `{
+		"color": "blue",
+		"timeStamp": "2022-11-06T18:14:10Z",
+		"commitHash": "6c8ac2c35791edf9979623c717a243fc53400000",
+		"jiraIssue": "ISSUE-2345"
+	}`)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "repository-merge-patch-unknown-field.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPATCHRepository_Deprecate(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they deprecate an existing repository with a sunset date and its replacement")
+	body := tstRepositoryUnchangedPatch()
+	body.DeprecatedSince = p("2022-11-06")
+	body.SunsetDate = p("2023-06-30")
+	body.ReplacedBy = p("some-service-backend.helm-deployment")
+	response, err := tstPerformPatch("/rest/api/v1/repositories/karma-wrapper.helm-chart", token, &body)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "repository-patch-deprecated.json")
+
+	docs.Then("And the repository can be read again, with the deprecation and sunset dates as response headers")
+	readAgain, err := tstPerformGet("/rest/api/v1/repositories/karma-wrapper.helm-chart", tstUnauthenticated())
+	tstAssert(t, readAgain, err, http.StatusOK, "repository-patch-deprecated.json")
+	require.Equal(t, "@1667692800", readAgain.header.Get("Deprecation"))
+	require.Equal(t, "Fri, 30 Jun 2023 00:00:00 GMT", readAgain.header.Get("Sunset"))
+}
+
+func TestPATCHRepository_DeprecateInvalid(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they deprecate a repository with a sunset date before the deprecation date and an unknown replacement")
+	body := tstRepositoryUnchangedPatch()
+	body.DeprecatedSince = p("2023-06-30")
+	body.SunsetDate = p("2022-11-06")
+	body.ReplacedBy = p("does-not.exist")
+	response, err := tstPerformPatch("/rest/api/v1/repositories/karma-wrapper.helm-chart", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "repository-patch-deprecated-invalid.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestNotifySunsets_RepositoryPassed(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a repository referenced by a service, whose sunset date has passed")
+	patch := tstRepositoryUnchangedPatch()
+	patch.SunsetDate = p("2022-11-01")
+	response, err := tstPerformPatch("/rest/api/v1/repositories/some-service-backend.helm-deployment", tstValidAdminToken(), &patch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	payload := openapi.RepositoryDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &payload))
+
+	docs.When("When the daily sunset notifications are sent")
+	require.Nil(t, application.Updater.NotifySunsets(appCtx))
+
+	docs.Then("Then both its owner and the referencing service have been notified")
+	hasSentSunsetNotification(t, "receivesSunset", "some-service-backend.helm-deployment", types.SunsetPassedEvent, types.RepositoryPayload, &openapi.NotificationPayload{Repository: &payload},
+		openapi.NotificationRecipient{Name: "some-owner", Type: "Owner"})
+	hasSentSunsetNotification(t, "receivesSunset", "some-service-backend.helm-deployment", types.SunsetPassedEvent, types.RepositoryPayload, &openapi.NotificationPayload{Repository: &payload},
+		openapi.NotificationRecipient{Name: "some-service-backend", Type: "Service"})
+}
@@ -0,0 +1,98 @@
+package acceptance
+
+import (
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/StephanHCB/go-backend-service-common/docs"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+// search
+
+func TestGETSearch_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they search for a word from a quicklink title")
+	response, err := tstPerformGet("/rest/api/v1/search?q=swagger", token)
+
+	docs.Then("Then the request is successful and the service with that quicklink is found")
+	tstAssert(t, response, err, http.StatusOK, "search-swagger.json")
+
+	docs.When("When they search for the beginning of a name")
+	response, err = tstPerformGet("/rest/api/v1/search?q=what", token)
+
+	docs.Then("Then the request is successful and the matching repositories are found")
+	tstAssert(t, response, err, http.StatusOK, "search-prefix.json")
+}
+
+func TestGETSearch_TypeAndLimit(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they search repositories only, with a limit lower than the number of hits")
+	response, err := tstPerformGet("/rest/api/v1/search?q=some+service&type=repository&limit=1", token)
+
+	docs.Then("Then the request is successful and the best matching repository is found")
+	tstAssert(t, response, err, http.StatusOK, "search-limited.json")
+}
+
+func TestGETSearch_AfterPatch(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.Given("Given a service whose description has been changed")
+	body := openapi.ServicePatchDto{
+		Description: p("Handles payouts to our customers"),
+		TimeStamp:   "2022-11-06T18:14:10Z",
+		CommitHash:  "6c8ac2c35791edf9979623c717a243fc53400000",
+		JiraIssue:   "ISSUE-2345",
+	}
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.When("When they search for a word from the new description")
+	response, err = tstPerformGet("/rest/api/v1/search?q=payout", tstUnauthenticated())
+
+	docs.Then("Then the request is successful and the service is found")
+	tstAssert(t, response, err, http.StatusOK, "search-description.json")
+}
+
+func TestGETSearch_AfterDelete(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.Given("Given a repository that has been deleted")
+	body := tstDelete()
+	response, err := tstPerformDelete("/rest/api/v1/repositories/karma-wrapper.helm-chart", token, &body)
+	tstAssertNoBody(t, response, err, http.StatusNoContent)
+
+	docs.When("When they search for its name")
+	response, err = tstPerformGet("/rest/api/v1/search?q=karma", tstUnauthenticated())
+
+	docs.Then("Then the request is successful and nothing is found")
+	tstAssert(t, response, err, http.StatusOK, "search-empty.json")
+}
+
+func TestGETSearch_Invalid(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they search without any words and for an unknown type")
+	response, err := tstPerformGet("/rest/api/v1/search?q=--&type=unicorn", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "search-invalid.json")
+}
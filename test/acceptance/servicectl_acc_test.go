@@ -2,9 +2,13 @@ package acceptance
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Interhyp/metadata-service/internal/types"
+	"github.com/Interhyp/metadata-service/test/mock/notifiermock"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -27,6 +31,51 @@ func TestGETServices_Success(t *testing.T) {
 	tstAssert(t, response, err, http.StatusOK, "services.json")
 }
 
+func TestGETServices_Query(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the list of services filtered by a query expression the service matches")
+	response, err := tstPerformGet("/rest/api/v1/services?q="+url.QueryEscape("lifecycle!=deprecated AND internetExposed=false"), token)
+
+	docs.Then("Then the request is successful and the response contains the service")
+	tstAssert(t, response, err, http.StatusOK, "services.json")
+
+	docs.When("When they request the list of services filtered by a query expression no service matches")
+	response, err = tstPerformGet("/rest/api/v1/services?q="+url.QueryEscape("tags=java OR labels.team=payments"), token)
+
+	docs.Then("Then the request is successful and the response contains an empty result")
+	tstAssert(t, response, err, http.StatusOK, "services-empty.json")
+}
+
+func TestGETServices_InvalidQuery(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the list of services filtered by an incomplete query expression")
+	response, err := tstPerformGet("/rest/api/v1/services?q="+url.QueryEscape("(owner=some-owner"), token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "services-query-invalid.json")
+}
+
+func TestGETServices_QueryTooDeep(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the list of services filtered by a query expression that is nested too deeply")
+	response, err := tstPerformGet("/rest/api/v1/services?q="+url.QueryEscape(strings.Repeat("NOT ", 1000)+"owner=some-owner"), token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "services-query-too-deep.json")
+}
+
 // get service
 
 func TestGETService_Success(t *testing.T) {
@@ -978,10 +1027,13 @@ func TestPATCHService_ChangeSpec(t *testing.T) {
 	docs.Given("Given an authenticated admin user")
 	token := tstValidAdminToken()
 
+	docs.Given("Given another existing service")
+	tstCreateDependencyService(t)
+
 	docs.When("When they perform a valid patch of an existing service that changes its spec")
 	body := tstServicePatch()
 	body.Spec = &openapi.ServiceSpecDto{
-		DependsOn:    []string{"some-service", "other-service"},
+		DependsOn:    []string{"whatever"},
 		ProvidesApis: []string{},
 		ConsumesApis: []string{"some-api"},
 	}
@@ -1278,6 +1330,40 @@ func TestDELETEService_GitServerDown(t *testing.T) {
 	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
 }
 
+func TestDELETEService_ReplacedByReference(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that names an existing service in replacedBy")
+	replaced := tstService("whatever")
+	response, err := tstPerformPost("/rest/api/v1/services/whatever", tstValidAdminToken(), &replaced)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusCreated, response.status)
+	servicePatch := tstServiceUnchangedPatch()
+	servicePatch.CommitHash = "6c8ac2c35791edf9979623c717a2430000000000"
+	servicePatch.ReplacedBy = p("some-service-backend")
+	response, err = tstPerformPatch("/rest/api/v1/services/whatever", tstValidAdminToken(), &servicePatch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to delete the service named in replacedBy")
+	body := tstDelete()
+	response, err = tstPerformDelete("/rest/api/v1/services/some-service-backend", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusConflict, "service-delete-replacedby.json")
+
+	docs.Then("And the service has not been deleted")
+	filename := "owners/some-owner/services/some-service-backend.yaml"
+	require.NotEqual(t, "<notfound>", metadataImpl.ReadContents(filename))
+	require.False(t, metadataImpl.FilesCommitted[filename])
+
+	docs.Then("And no kafka message has been sent for the deletion")
+	require.Equal(t, 2, len(kafkaImpl.Recording))
+}
+
 // get service promoters
 
 func TestGETServicePromoters_Success(t *testing.T) {
@@ -1305,3 +1391,1217 @@ func TestGETServicePromoters_NotFound(t *testing.T) {
 	docs.Then("Then the request fails and the error response is as expected")
 	tstAssert(t, response, err, http.StatusNotFound, "service-notfound.json")
 }
+
+func TestGETServiceQuicklinkStatus_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service with a working, a broken and a relative quicklink")
+	patch := tstServiceUnchangedPatch()
+	patch.Quicklinks = []openapi.Quicklink{
+		{Url: p(ts.URL + "/rest/api/v1/owners"), Title: p("Owners")},
+		{Url: p(ts.URL + "/rest/api/v1/services/unicorn"), Title: p("Runbook")},
+		{Url: p("/swagger-ui/index.html"), Title: p("Swagger UI")},
+	}
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", tstValidAdminToken(), &patch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the quicklink status before the links have been checked")
+	response, err = tstPerformGet("/rest/api/v1/services/some-service-backend/quicklinks/status", token)
+
+	docs.Then("Then the request is successful and the new quicklinks are reported as unchecked")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	unchecked := openapi.QuicklinkStatusListDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &unchecked))
+	require.Equal(t, 3, len(unchecked.Quicklinks))
+	require.Equal(t, "unchecked", unchecked.Quicklinks[0].Status)
+	require.Nil(t, unchecked.Quicklinks[0].CheckedAt)
+
+	docs.When("When all links have been checked and they request the quicklink status again")
+	require.Nil(t, application.Links.CheckAllLinks(appCtx))
+	response, err = tstPerformGet("/rest/api/v1/services/some-service-backend/quicklinks/status", token)
+
+	docs.Then("Then the request is successful and the result of each check is reported")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	checked := openapi.QuicklinkStatusListDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &checked))
+	require.Equal(t, 3, len(checked.Quicklinks))
+	require.Equal(t, openapi.QuicklinkStatusDto{
+		Url:        ts.URL + "/rest/api/v1/owners",
+		Title:      p("Owners"),
+		Status:     "ok",
+		HttpStatus: pi(200),
+		CheckedAt:  p("2022-11-06T18:14:10Z"),
+	}, checked.Quicklinks[0])
+	require.Equal(t, openapi.QuicklinkStatusDto{
+		Url:        ts.URL + "/rest/api/v1/services/unicorn",
+		Title:      p("Runbook"),
+		Status:     "broken",
+		HttpStatus: pi(404),
+		Error:      p("got http status 404"),
+		CheckedAt:  p("2022-11-06T18:14:10Z"),
+	}, checked.Quicklinks[1])
+	require.Equal(t, "skipped", checked.Quicklinks[2].Status)
+}
+
+func TestGETServiceQuicklinkStatus_NotFound(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the quicklink status for a service that does not exist")
+	response, err := tstPerformGet("/rest/api/v1/services/unicorn/quicklinks/status", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusNotFound, "service-notfound.json")
+}
+
+func TestGETServiceAlertRouting_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the alert routing of a service whose alert target was given without a kind")
+	response, err := tstPerformGet("/rest/api/v1/services/some-service-backend/alerting", token)
+
+	docs.Then("Then the request is successful and the alert target is resolved as a Teams webhook")
+	tstAssert(t, response, err, http.StatusOK, "service-alerting.json")
+}
+
+func TestGETServiceAlertRouting_NotFound(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the alert routing for a service that does not exist")
+	response, err := tstPerformGet("/rest/api/v1/services/unicorn/alerting", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusNotFound, "service-notfound.json")
+}
+
+func TestPATCHService_TypedAlertTarget(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they patch the alert target of a service to a Slack channel given with its kind")
+	patch := tstServiceUnchangedPatch()
+	patch.AlertTarget = p("slack:#Some-Channel")
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &patch)
+
+	docs.Then("Then the request is successful and the alert target is written normalized")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	written := openapi.ServiceDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &written))
+	require.Equal(t, "slack:#some-channel", written.AlertTarget)
+
+	docs.Then("And the alert routing resolves to the Slack channel")
+	response, err = tstPerformGet("/rest/api/v1/services/some-service-backend/alerting", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	routing := openapi.AlertRoutingDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &routing))
+	require.Equal(t, openapi.AlertRoutingDto{
+		Service: "some-service-backend",
+		Owner:   "some-owner",
+		Kind:    "slack",
+		Target:  "#some-channel",
+	}, routing)
+}
+
+func TestPATCHService_InvalidAlertTargetKind(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they patch the alert target of a service to an invalid PagerDuty integration key")
+	patch := tstServiceUnchangedPatch()
+	patch.AlertTarget = p("pagerduty:not-a-key")
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &patch)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-patch-invalid-alerttarget.json")
+}
+
+func TestPATCHService_PolicyDenied(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a policy that denies services without a description")
+	tstSetupPolicies(config.Policy{
+		Name:     "described",
+		Entity:   config.PolicyEntityService,
+		Severity: config.PolicySeverityDeny,
+		Message:  "services must be described",
+		Require:  `description!=""`,
+	})
+	defer tstSetupPolicies()
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they patch a service without a description")
+	patch := tstServiceUnchangedPatch()
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &patch)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-patch-policy-denied.json")
+
+	docs.Then("And nothing has been written")
+	require.False(t, metadataImpl.Pushed)
+}
+
+func TestPATCHService_PolicyWarning(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a policy that warns about services without a description")
+	tstSetupPolicies(config.Policy{
+		Name:     "described",
+		Entity:   config.PolicyEntityService,
+		Severity: config.PolicySeverityWarn,
+		Message:  "services should be described",
+		Require:  `description!=""`,
+	})
+	defer tstSetupPolicies()
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they patch a service without a description")
+	patch := tstServiceUnchangedPatch()
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &patch)
+
+	docs.Then("Then the request is successful and the response lists the policy warning")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	written := openapi.ServiceDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &written))
+	require.Equal(t, []openapi.ViolationDto{{
+		Code:       "service.policy",
+		Message:    "services should be described",
+		Parameters: map[string]string{"policy": "described", "severity": "warn"},
+	}}, written.PolicyWarnings)
+
+	docs.Then("And the warning is not part of the service when read again")
+	readAgain, err := tstPerformGet("/rest/api/v1/services/some-service-backend", tstUnauthenticated())
+	require.Nil(t, err)
+	require.NotContains(t, readAgain.body, "policyWarnings")
+}
+
+func TestGETServices_AtTimestamp(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that has been patched")
+	body := tstServicePatch()
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", tstValidAdminToken(), &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.When("When an unauthenticated user requests the list of services as of the time of the patch")
+	response, err = tstPerformGet("/rest/api/v1/services?at=2022-11-06T18:14:10Z", tstUnauthenticated())
+
+	docs.Then("Then the request is successful and the response contains the patched service")
+	tstAssert(t, response, err, http.StatusOK, "services-at-patch.json")
+}
+
+func TestGETService_AtOriginalCommit(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that has been patched")
+	body := tstServicePatch()
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", tstValidAdminToken(), &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.When("When an unauthenticated user requests the service as of the commit before the patch")
+	response, err = tstPerformGet("/rest/api/v1/services/some-service-backend?at=6c8ac2c35791edf9979623c717a243fc53400000", tstUnauthenticated())
+
+	docs.Then("Then the request is successful and the response shows the service before the patch")
+	tstAssert(t, response, err, http.StatusOK, "service.json")
+
+	docs.Then("And the current state of the service is unaffected")
+	response, err = tstPerformGet("/rest/api/v1/services/some-service-backend", tstUnauthenticated())
+	tstAssert(t, response, err, http.StatusOK, "service-patch.json")
+}
+
+func TestGETService_AtUnknownService(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a service that did not exist at the given commit")
+	response, err := tstPerformGet("/rest/api/v1/services/unicorn?at=6c8ac2c35791edf9979623c717a243fc53400000", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusNotFound, "service-notfound.json")
+}
+
+func TestGETService_AtBeforeFirstCommit(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a service as of a time before the first commit")
+	response, err := tstPerformGet("/rest/api/v1/services/some-service-backend?at=2020-01-01T00:00:00Z", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusNotFound, "at-notfound.json")
+}
+
+func TestGETService_AtInvalid(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a service with an invalid point in time")
+	response, err := tstPerformGet("/rest/api/v1/services/some-service-backend?at=yesterday", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "at-invalid.json")
+}
+
+func TestGETServiceHistory_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the history of an existing service")
+	response, err := tstPerformGet("/rest/api/v1/services/some-service-backend/history", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "service-history.json")
+}
+
+func TestGETServiceHistory_AfterPatch(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that has been patched")
+	body := tstServicePatch()
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", tstValidAdminToken(), &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.When("When an unauthenticated user requests the history of the service")
+	response, err = tstPerformGet("/rest/api/v1/services/some-service-backend/history", tstUnauthenticated())
+
+	docs.Then("Then the request is successful and the response lists the changed fields, newest first")
+	tstAssert(t, response, err, http.StatusOK, "service-history-after-patch.json")
+}
+
+func TestGETServiceHistory_NotFound(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the history of a service that does not exist")
+	response, err := tstPerformGet("/rest/api/v1/services/unicorn/history", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusNotFound, "service-notfound.json")
+}
+
+func tstCreateDependencyService(t *testing.T) {
+	body := tstService("whatever")
+	body.Spec = &openapi.ServiceSpecDto{
+		ProvidesApis: []string{"whatever-api"},
+	}
+	response, err := tstPerformPost("/rest/api/v1/services/whatever", tstValidAdminToken(), &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusCreated, response.status)
+}
+
+func tstPatchServiceSpec(t *testing.T) {
+	tstCreateDependencyService(t)
+
+	body := tstServicePatch()
+	body.Spec = &openapi.ServiceSpecDto{
+		DependsOn:    []string{"whatever"},
+		ProvidesApis: []string{"backend-api"},
+		ConsumesApis: []string{"whatever-api", "some-api"},
+	}
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", tstValidAdminToken(), &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+}
+
+func TestGETServiceDependencyGraph_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an existing service with dependencies")
+	tstPatchServiceSpec(t)
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the service dependency graph")
+	response, err := tstPerformGet("/rest/api/v1/dependencies", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "dependencies.json")
+}
+
+func TestGETServiceDependencyGraph_Dot(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an existing service with dependencies")
+	tstPatchServiceSpec(t)
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the service dependency graph in Graphviz DOT format")
+	response, err := tstPerformGet("/rest/api/v1/dependencies?format=dot", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	require.Equal(t, "text/vnd.graphviz", response.contentType)
+	require.Equal(t, `digraph dependencies {
+  node [shape=box];
+  "some-service-backend" [tooltip="owner: some-owner"];
+  "whatever" [tooltip="owner: some-owner"];
+  "some-service-backend" -> "whatever";
+}
+`, response.body)
+}
+
+func TestGETServiceDependencyGraph_InvalidFormat(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the service dependency graph in an unsupported format")
+	response, err := tstPerformGet("/rest/api/v1/dependencies?format=svg", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "dependencies-invalid-format.json")
+}
+
+func TestGETServiceDependencies_Upstream(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an existing service with dependencies")
+	tstPatchServiceSpec(t)
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the upstream dependency closure of the service")
+	response, err := tstPerformGet("/rest/api/v1/services/some-service-backend/dependencies?direction=upstream", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "dependencies.json")
+}
+
+func TestGETServiceDependencies_Downstream(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an existing service with dependencies")
+	tstPatchServiceSpec(t)
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the downstream dependency closure of the service it depends on")
+	response, err := tstPerformGet("/rest/api/v1/services/whatever/dependencies?direction=downstream", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "dependencies-downstream.json")
+}
+
+func TestGETServiceDependencies_InvalidDirection(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the dependency closure of a service with an invalid direction")
+	response, err := tstPerformGet("/rest/api/v1/services/some-service-backend/dependencies?direction=sideways", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "dependencies-invalid-direction.json")
+}
+
+func TestGETServiceDependencies_NotFound(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the dependency closure of a service that does not exist")
+	response, err := tstPerformGet("/rest/api/v1/services/unicorn/dependencies", token)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusNotFound, "service-notfound.json")
+}
+
+func TestGETServiceApiIndex_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an existing service that provides and consumes apis")
+	tstPatchServiceSpec(t)
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the api index")
+	response, err := tstPerformGet("/rest/api/v1/dependencies/apis", token)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "dependencies-apis.json")
+}
+
+func TestPATCHService_UnknownDependency(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to patch a service so it depends on a service that does not exist")
+	body := tstServicePatch()
+	body.Spec = &openapi.ServiceSpecDto{
+		DependsOn: []string{"unicorn"},
+	}
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-patch-unknown-dependency.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+	require.False(t, metadataImpl.Pushed)
+}
+
+func TestPATCHService_ExistingUnknownDependency(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that depends on a service which has been removed from the metadata repository by hand")
+	contents := metadataImpl.ReadContents("owners/some-owner/services/some-service-backend.yaml") + "spec:\n  dependsOn:\n  - unicorn\n"
+	require.Nil(t, metadataImpl.WriteFile("owners/some-owner/services/some-service-backend.yaml", []byte(contents)))
+	require.Nil(t, application.Updater.PerformFullUpdate(appCtx))
+	defer tstReset()
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they patch an unrelated field of the service")
+	body := tstServiceUnchangedPatch()
+	body.Description = p("still depends on unicorn")
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &body)
+
+	docs.Then("Then the request is successful, because the dependency was not added by the patch")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+}
+
+func TestPATCHService_ExistingLifecycleRuleViolation(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a deprecated service without sunset date, written to the metadata repository by hand")
+	contents := metadataImpl.ReadContents("owners/some-owner/services/some-service-backend.yaml") + "lifecycle: deprecated\n"
+	require.Nil(t, metadataImpl.WriteFile("owners/some-owner/services/some-service-backend.yaml", []byte(contents)))
+	require.Nil(t, application.Updater.PerformFullUpdate(appCtx))
+	defer tstReset()
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they patch an unrelated field of the service")
+	body := tstServiceUnchangedPatch()
+	body.Description = p("still deprecated")
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &body)
+
+	docs.Then("Then the request is successful, because the patch does not touch the lifecycle fields")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+}
+
+func TestPATCHService_DependencyCycle(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an existing service that depends on another service")
+	tstPatchServiceSpec(t)
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to patch the other service so it depends on the first one")
+	body := tstServiceUnchangedPatch()
+	body.CommitHash = "6c8ac2c35791edf9979623c717a2430000000000"
+	body.Spec = &openapi.ServiceSpecDto{
+		DependsOn: []string{"some-service-backend"},
+	}
+	response, err := tstPerformPatch("/rest/api/v1/services/whatever", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-patch-dependency-cycle.json")
+}
+
+func TestPOSTService_SelfDependency(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request the creation of a service that depends on itself")
+	body := tstService("whatever")
+	body.Spec = &openapi.ServiceSpecDto{
+		DependsOn: []string{"whatever"},
+	}
+	response, err := tstPerformPost("/rest/api/v1/services/whatever", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-create-self-dependency.json")
+}
+
+func TestPATCHService_UnprovidedApi_Reject(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given the service is configured to reject consumed apis that no service provides")
+	customConfigImpl.VServiceConsumedApiValidation = "reject"
+	defer func() { customConfigImpl.VServiceConsumedApiValidation = "warn" }()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to patch a service so it consumes an api that no service provides")
+	body := tstServicePatch()
+	body.Spec = &openapi.ServiceSpecDto{
+		ConsumesApis: []string{"some-api"},
+	}
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-patch-unprovided-api.json")
+}
+
+// revert service
+
+func TestPOSTServiceRevert_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that has been patched")
+	patch := tstServicePatch()
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", tstValidAdminToken(), &patch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they revert the service to the commit before the patch")
+	body := tstRevert()
+	response, err = tstPerformPost("/rest/api/v1/services/some-service-backend/revert", token, &body)
+
+	docs.Then("Then the request is successful and the response shows the service before the patch, except for its lifecycle")
+	tstAssert(t, response, err, http.StatusOK, "service-revert.json")
+
+	docs.Then("And the service has been committed and pushed")
+	filename := "owners/some-owner/services/some-service-backend.yaml"
+	require.True(t, metadataImpl.FilesCommitted[filename])
+	require.True(t, metadataImpl.Pushed)
+
+	docs.Then("And the reverted service has been cached and can be read again")
+	readAgain, err := tstPerformGet("/rest/api/v1/services/some-service-backend", tstUnauthenticated())
+	tstAssert(t, readAgain, err, http.StatusOK, "service-revert.json")
+
+	docs.Then("And kafka messages notifying other instances of both updates have been sent")
+	require.Equal(t, 2, len(kafkaImpl.Recording))
+}
+
+func TestPOSTServiceRevert_Unauthenticated(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they attempt to revert a service")
+	body := tstRevert()
+	response, err := tstPerformPost("/rest/api/v1/services/some-service-backend/revert", token, &body)
+
+	docs.Then("Then the request is denied as unauthorised")
+	tstAssert(t, response, err, http.StatusUnauthorized, "unauthorized.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTServiceRevert_MissingCommitHash(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to revert a service without giving a commit hash")
+	body := tstRevert()
+	body.CommitHash = ""
+	response, err := tstPerformPost("/rest/api/v1/services/some-service-backend/revert", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-revert-invalid.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTServiceRevert_UnknownCommit(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to revert a service to a commit that does not exist")
+	body := tstRevert()
+	body.CommitHash = "abcdef0"
+	response, err := tstPerformPost("/rest/api/v1/services/some-service-backend/revert", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusNotFound, "revert-commit-notfound.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPATCHService_DryRun(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request a dry run of a valid patch of an existing service")
+	body := tstServicePatch()
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend?dryRun=true", token, &body)
+
+	docs.Then("Then the request is successful and the response contains the patched service and the diff that would be committed")
+	tstAssert(t, response, err, http.StatusOK, "service-patch-dryrun.json")
+
+	docs.Then("And nothing has been committed or pushed")
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+	require.False(t, metadataImpl.Pushed)
+
+	docs.Then("And the cached service is unchanged")
+	readAgain, err := tstPerformGet("/rest/api/v1/services/some-service-backend", tstUnauthenticated())
+	tstAssert(t, readAgain, err, http.StatusOK, "service.json")
+
+	docs.Then("And no kafka messages have been sent")
+	require.Equal(t, 0, len(kafkaImpl.Recording))
+}
+
+func TestPATCHService_DryRunInvalidValues(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request a dry run of a patch of an existing service that depends on an unknown service")
+	body := tstServicePatch()
+	body.Spec = &openapi.ServiceSpecDto{
+		DependsOn: []string{"unicorn"},
+	}
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend?dryRun=true", token, &body)
+
+	docs.Then("Then the request fails with the same error as without dry run")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-patch-unknown-dependency.json")
+
+	docs.Then("And nothing has been committed or pushed")
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+	require.False(t, metadataImpl.Pushed)
+}
+
+// entity tags
+
+func TestGETService_NotModified(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request a single existing service, giving its current entity tag in If-None-Match")
+	response, err := tstPerformGetWithHeader("/rest/api/v1/services/some-service-backend", token, "If-None-Match", `"6c8ac2c35791edf9979623c717a243fc53400000"`)
+
+	docs.Then("Then the response is not modified without a body")
+	tstAssertNoBody(t, response, err, http.StatusNotModified)
+}
+
+func TestGETServices_NotModified(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they request the list of services, giving the entity tag of the list in If-None-Match")
+	first, err := tstPerformGet("/rest/api/v1/services", token)
+	require.Nil(t, err)
+	response, err := tstPerformGetWithHeader("/rest/api/v1/services", token, "If-None-Match", first.etag)
+
+	docs.Then("Then the response is not modified without a body")
+	tstAssertNoBody(t, response, err, http.StatusNotModified)
+}
+
+func TestPATCHService_PreconditionFailed(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they request a patch of a service with an outdated entity tag in If-Match")
+	body := tstServicePatch()
+	response, err := tstPerformPatchWithHeader("/rest/api/v1/services/some-service-backend", token, &body, "If-Match", `"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"`)
+
+	docs.Then("Then the request fails and the error response contains the current service")
+	tstAssert(t, response, err, http.StatusPreconditionFailed, "service-patch-precondition.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPATCHService_MergePatch(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they send a JSON merge patch that sets the description to empty and replaces the quicklinks")
+	response, err := tstPerformPatchDocument("/rest/api/v1/services/some-service-backend", token, types.MergePatch, `{
+		"description": "",
+		"quicklinks": [{"url": "/health", "title": "Health"}],
+		"timeStamp": "2022-11-06T18:14:10Z",
+		"commitHash": "6c8ac2c35791edf9979623c717a243fc53400000",
+		"jiraIssue": "ISSUE-2345"
+	}`)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "service-merge-patch.json")
+
+	docs.Then("And the service has been committed and pushed")
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/services/some-service-backend.yaml"])
+	require.True(t, metadataImpl.Pushed)
+}
+
+func TestPATCHService_JsonPatchMissingOwner(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they send a JSON patch that moves the service to an owner that does not exist")
+	response, err := tstPerformPatchDocument("/rest/api/v1/services/some-service-backend", token, types.JsonPatch, `[
+		{"op": "replace", "path": "/owner", "value": "does-not-exist"},
+		{"op": "replace", "path": "/timeStamp", "value": "2022-11-06T18:14:10Z"},
+		{"op": "replace", "path": "/commitHash", "value": "6c8ac2c35791edf9979623c717a243fc53400000"},
+		{"op": "replace", "path": "/jiraIssue", "value": "ISSUE-2345"}
+	]`)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-json-patch-missing-owner.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTServiceRename_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that depends on an existing service")
+	dependent := tstService("whatever")
+	dependent.Spec = &openapi.ServiceSpecDto{
+		DependsOn: []string{"some-service-backend"},
+	}
+	response, err := tstPerformPost("/rest/api/v1/services/whatever", tstValidAdminToken(), &dependent)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusCreated, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they rename the existing service together with its repositories")
+	body := tstServiceRename("renamed-backend")
+	response, err = tstPerformPost("/rest/api/v1/services/some-service-backend/rename", token, &body)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "service-rename.json")
+
+	docs.Then("And the service, its helm-deployment repository and the dependent service have been committed and pushed")
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/services/some-service-backend.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/services/renamed-backend.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/repositories/some-service-backend.helm-deployment.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/repositories/renamed-backend.helm-deployment.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/services/whatever.yaml"])
+	require.False(t, metadataImpl.FilesCommitted["owners/some-owner/repositories/renamed-backend.implementation.yaml"])
+	require.True(t, metadataImpl.Pushed)
+
+	docs.Then("And the service can only be read under its new name")
+	readAgain, err := tstPerformGet("/rest/api/v1/services/renamed-backend", tstUnauthenticated())
+	tstAssert(t, readAgain, err, http.StatusOK, "service-rename.json")
+	readOld, err := tstPerformGet("/rest/api/v1/services/some-service-backend", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNotFound, readOld.status)
+
+	docs.Then("And the dependent service refers to the new name")
+	readDependent, err := tstPerformGet("/rest/api/v1/services/whatever", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, readDependent.status)
+	require.Contains(t, readDependent.body, `"dependsOn":["renamed-backend"]`)
+
+	docs.Then("And a single kafka message covering both names has been sent for the rename")
+	require.Equal(t, 2, len(kafkaImpl.Recording))
+	actual, _ := json.Marshal(kafkaImpl.Recording[1])
+	require.Equal(t, `{"affected":{"ownerAliases":[],"serviceNames":["some-service-backend","renamed-backend","whatever"],`+
+		`"repositoryKeys":["some-service-backend.helm-deployment","renamed-backend.helm-deployment"]},`+
+		`"timeStamp":"2022-11-06T18:14:10Z","commitHash":"6c8ac2c35791edf9979623c717a2430000000000"}`, string(actual))
+}
+
+func TestPOSTServiceRename_ReplacedBy(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that names an existing service in replacedBy")
+	replaced := tstService("whatever")
+	response, err := tstPerformPost("/rest/api/v1/services/whatever", tstValidAdminToken(), &replaced)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusCreated, response.status)
+	servicePatch := tstServiceUnchangedPatch()
+	servicePatch.CommitHash = "6c8ac2c35791edf9979623c717a2430000000000"
+	servicePatch.ReplacedBy = p("some-service-backend")
+	response, err = tstPerformPatch("/rest/api/v1/services/whatever", tstValidAdminToken(), &servicePatch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And a repository that names the helm-deployment repository of the existing service in replacedBy")
+	patch := tstRepositoryUnchangedPatch()
+	patch.ReplacedBy = p("some-service-backend.helm-deployment")
+	response, err = tstPerformPatch("/rest/api/v1/repositories/karma-wrapper.helm-chart", tstValidAdminToken(), &patch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they rename the existing service together with its repositories")
+	body := tstServiceRename("renamed-backend")
+	response, err = tstPerformPost("/rest/api/v1/services/some-service-backend/rename", token, &body)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Then("Then the service and the repository naming the old names have been committed")
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/services/whatever.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/repositories/karma-wrapper.helm-chart.yaml"])
+
+	docs.Then("And they name the new names in replacedBy")
+	readService, err := tstPerformGet("/rest/api/v1/services/whatever", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, readService.status)
+	require.Contains(t, readService.body, `"replacedBy":"renamed-backend"`)
+	readRepository, err := tstPerformGet("/rest/api/v1/repositories/karma-wrapper.helm-chart", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, readRepository.status)
+	require.Contains(t, readRepository.body, `"replacedBy":"renamed-backend.helm-deployment"`)
+
+	docs.Then("And the kafka message for the rename covers them")
+	require.Equal(t, 4, len(kafkaImpl.Recording))
+	actual, _ := json.Marshal(kafkaImpl.Recording[3])
+	require.Equal(t, `{"affected":{"ownerAliases":[],"serviceNames":["some-service-backend","renamed-backend","whatever"],`+
+		`"repositoryKeys":["some-service-backend.helm-deployment","renamed-backend.helm-deployment","karma-wrapper.helm-chart"]},`+
+		`"timeStamp":"2022-11-06T18:14:10Z","commitHash":"6c8ac2c35791edf9979623c717a2430000000000"}`, string(actual))
+}
+
+func TestPOSTServiceRename_PolicyDenied(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a policy that denies repositories named like the new name of a service")
+	tstSetupPolicies(config.Policy{
+		Name:     "reserved",
+		Entity:   config.PolicyEntityRepository,
+		Severity: config.PolicySeverityDeny,
+		Message:  "the name renamed-backend is reserved",
+		Require:  "name!=renamed-backend",
+	})
+	defer tstSetupPolicies()
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they rename an existing service together with its repositories")
+	body := tstServiceRename("renamed-backend")
+	response, err := tstPerformPost("/rest/api/v1/services/some-service-backend/rename", token, &body)
+
+	docs.Then("Then the request fails, because the policy is checked against the renamed repository")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusBadRequest, response.status)
+	require.Contains(t, response.body, "the name renamed-backend is reserved")
+
+	docs.Then("And no changes have been committed")
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+	require.Equal(t, 0, len(kafkaImpl.Recording))
+}
+
+func TestPOSTServiceRename_RepositoriesNotRenamed(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to rename a service with a helm-deployment repository without renaming its repositories")
+	body := tstServiceRename("renamed-backend")
+	body.RenameRepositories = false
+	response, err := tstPerformPost("/rest/api/v1/services/some-service-backend/rename", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-rename-repositories.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTServiceRename_AlreadyExists(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given two existing services")
+	tstCreateDependencyService(t)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to rename one service to the name of the other")
+	body := tstServiceRename("whatever")
+	response, err := tstPerformPost("/rest/api/v1/services/some-service-backend/rename", token, &body)
+
+	docs.Then("Then the request fails with a conflict and the error response is as expected")
+	tstAssert(t, response, err, http.StatusConflict, "service-rename-conflict.json")
+
+	docs.Then("And the service has not been renamed")
+	require.False(t, metadataImpl.FilesCommitted["owners/some-owner/services/some-service-backend.yaml"])
+	require.Equal(t, 1, len(kafkaImpl.Recording))
+}
+
+func TestPOSTServiceRename_InvalidName(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to rename a service to an invalid name")
+	body := tstServiceRename("Renamed_Backend")
+	response, err := tstPerformPost("/rest/api/v1/services/some-service-backend/rename", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-rename-invalid-name.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+// lifecycle
+
+func TestPOSTServiceLifecycle_Deprecate(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an existing service that can replace another service")
+	tstCreateDependencyService(t)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they deprecate a service with a sunset date and its replacement")
+	body := tstServiceLifecycleTransition("deprecated")
+	body.DeprecatedSince = p("2022-11-06")
+	body.SunsetDate = p("2023-06-30")
+	body.ReplacedBy = p("whatever")
+	response, err := tstPerformPost("/rest/api/v1/services/some-service-backend/lifecycle", token, &body)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "service-lifecycle-deprecated.json")
+
+	docs.Then("And the service has been committed and pushed")
+	require.True(t, metadataImpl.FilesCommitted["owners/some-owner/services/some-service-backend.yaml"])
+	require.True(t, metadataImpl.Pushed)
+
+	docs.Then("And the service has been cached and can be read again")
+	readAgain, err := tstPerformGet("/rest/api/v1/services/some-service-backend", tstUnauthenticated())
+	tstAssert(t, readAgain, err, http.StatusOK, "service-lifecycle-deprecated.json")
+
+	docs.Then("And the deprecation and sunset dates are returned as response headers")
+	require.Equal(t, "@1667692800", readAgain.header.Get("Deprecation"))
+	require.Equal(t, "Fri, 30 Jun 2023 00:00:00 GMT", readAgain.header.Get("Sunset"))
+
+	docs.Then("And a lifecycle change notification has been sent to the matching consumers")
+	payload := openapi.ServiceDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &payload))
+	hasSentNotification(t, "receivesLifecycle", "some-service-backend", types.LifecycleChangedEvent, types.ServicePayload, &openapi.NotificationPayload{Service: &payload})
+	hasSentNotification(t, "receivesModified", "some-service-backend", types.ModifiedEvent, types.ServicePayload, &openapi.NotificationPayload{Service: &payload})
+}
+
+func TestPOSTServiceLifecycle_MissingSunset(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to deprecate a service without a sunset date and replacement")
+	body := tstServiceLifecycleTransition("deprecated")
+	response, err := tstPerformPost("/rest/api/v1/services/some-service-backend/lifecycle", token, &body)
+
+	docs.Then("Then the request fails and the error response lists both missing fields")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-lifecycle-missing.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTServiceLifecycle_NotAllowed(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a newly created experimental service")
+	tstCreateDependencyService(t)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to deprecate it, which the lifecycle model does not allow for experimental services")
+	body := tstServiceLifecycleTransition("deprecated")
+	body.SunsetDate = p("2023-06-30")
+	body.ReplacedBy = p("some-service-backend")
+	body.CommitHash = "6c8ac2c35791edf9979623c717a2430000000000"
+	response, err := tstPerformPost("/rest/api/v1/services/whatever/lifecycle", token, &body)
+
+	docs.Then("Then the request fails and the error response names the allowed transitions")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-lifecycle-notallowed.json")
+}
+
+func TestPOSTServiceLifecycle_RetireWithDependents(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that another service depends on")
+	tstPatchServiceSpec(t)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to retire it")
+	body := tstServiceLifecycleTransition("retired")
+	body.CommitHash = "6c8ac2c35791edf9979623c717a2430000000000"
+	response, err := tstPerformPost("/rest/api/v1/services/whatever/lifecycle", token, &body)
+
+	docs.Then("Then the request fails with a conflict naming the dependent service")
+	tstAssert(t, response, err, http.StatusConflict, "service-lifecycle-dependents.json")
+}
+
+func TestNotifySunsets_ServiceApproaching(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that another service depends on")
+	tstPatchServiceSpec(t)
+
+	docs.Given("And given its sunset date is less than the configured number of days away")
+	patch := tstServiceUnchangedPatch()
+	patch.CommitHash = "6c8ac2c35791edf9979623c717a2430000000000"
+	patch.SunsetDate = p("2022-11-20")
+	response, err := tstPerformPatch("/rest/api/v1/services/whatever", tstValidAdminToken(), &patch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	payload := openapi.ServiceDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &payload))
+
+	docs.When("When the daily sunset notifications are sent twice")
+	require.Nil(t, application.Updater.NotifySunsets(appCtx))
+	require.Nil(t, application.Updater.NotifySunsets(appCtx))
+
+	docs.Then("Then both its owner and the dependent service have been notified exactly once")
+	hasSentSunsetNotification(t, "receivesSunset", "whatever", types.SunsetApproachingEvent, types.ServicePayload, &openapi.NotificationPayload{Service: &payload},
+		openapi.NotificationRecipient{Name: "some-owner", Type: "Owner"})
+	hasSentSunsetNotification(t, "receivesSunset", "whatever", types.SunsetApproachingEvent, types.ServicePayload, &openapi.NotificationPayload{Service: &payload},
+		openapi.NotificationRecipient{Name: "some-service-backend", Type: "Service"})
+	require.Equal(t, 2, sentNotificationCount("receivesSunset"))
+}
+
+func TestNotifySunsets_RetriedAfterFailure(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service whose sunset date is less than the configured number of days away")
+	patch := tstServiceUnchangedPatch()
+	patch.SunsetDate = p("2022-11-25")
+	response, err := tstPerformPatch("/rest/api/v1/services/some-service-backend", tstValidAdminToken(), &patch)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given the notification consumer cannot be reached")
+	client := notifierImpl.Clients["receivesSunset"].(*notifiermock.NotifierClientMock)
+	client.SendErr = errors.New("connection refused")
+
+	docs.When("When the daily sunset notifications are sent, then the consumer recovers, and they are sent again")
+	require.Nil(t, application.Updater.NotifySunsets(appCtx))
+	require.Equal(t, 0, sentNotificationCount("receivesSunset"))
+	client.SendErr = nil
+	require.Nil(t, application.Updater.NotifySunsets(appCtx))
+
+	docs.Then("Then the owner has been notified on the second run")
+	require.Equal(t, 1, sentNotificationCount("receivesSunset"))
+}
+
+func TestPATCHService_DependOnRetired(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a service that has been retired")
+	tstCreateDependencyService(t)
+	retire := tstServiceLifecycleTransition("retired")
+	retire.CommitHash = "6c8ac2c35791edf9979623c717a2430000000000"
+	response, err := tstPerformPost("/rest/api/v1/services/whatever/lifecycle", tstValidAdminToken(), &retire)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to make another service depend on it")
+	body := tstServicePatch()
+	body.Spec = &openapi.ServiceSpecDto{
+		DependsOn: []string{"whatever"},
+	}
+	response, err = tstPerformPatch("/rest/api/v1/services/some-service-backend", token, &body)
+
+	docs.Then("Then the request fails and the error response is as expected")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-patch-retired-dependency.json")
+}
+
+func TestPATCHService_LifecycleChange(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a newly created experimental service")
+	tstCreateDependencyService(t)
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to change its lifecycle with a patch")
+	body := tstServicePatch()
+	body.Lifecycle = p("production")
+	body.CommitHash = "6c8ac2c35791edf9979623c717a2430000000000"
+	response, err := tstPerformPatch("/rest/api/v1/services/whatever", token, &body)
+
+	docs.Then("Then the request fails and the error response points to the lifecycle endpoint")
+	tstAssert(t, response, err, http.StatusBadRequest, "service-patch-lifecycle.json")
+}
@@ -0,0 +1,202 @@
+package acceptance
+
+import (
+	"encoding/json"
+	"github.com/Interhyp/metadata-service/api"
+	"github.com/Interhyp/metadata-service/internal/acorn/config"
+	"github.com/Interhyp/metadata-service/internal/types"
+	"github.com/StephanHCB/go-backend-service-common/docs"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+// apply transaction
+
+func TestPOSTTransaction_Success(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they create an owner together with its repository and service in a single transaction")
+	body := tstTransactionNewTeam()
+	response, err := tstPerformPost("/rest/api/v1/transactions", token, &body)
+
+	docs.Then("Then the request is successful and the response is as expected")
+	tstAssert(t, response, err, http.StatusOK, "transaction-new-team.json")
+
+	docs.Then("And all files have been committed and pushed")
+	require.True(t, metadataImpl.FilesCommitted["owners/new-team/owner.info.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/new-team/repositories/new-team-backend.implementation.yaml"])
+	require.True(t, metadataImpl.FilesCommitted["owners/new-team/services/new-team-backend.yaml"])
+	require.True(t, metadataImpl.Pushed)
+
+	docs.Then("And the new entities have been cached and can be read again")
+	readAgain, err := tstPerformGet("/rest/api/v1/owners/new-team", tstUnauthenticated())
+	tstAssert(t, readAgain, err, http.StatusOK, "owner-create.json")
+
+	docs.Then("And a single kafka message covering all new entities has been sent")
+	require.Equal(t, 1, len(kafkaImpl.Recording))
+	actual, _ := json.Marshal(kafkaImpl.Recording[0])
+	require.Equal(t, tstTransactionNewTeamExpectedKafka(), string(actual))
+
+	docs.Then("And notifications have been sent for each new entity")
+	ownerPayload := tstNewOwnerPayload()
+	hasSentNotification(t, "receivesOwner", "new-team", types.CreatedEvent, types.OwnerPayload, &ownerPayload)
+}
+
+func TestPOSTTransaction_Unauthenticated(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an unauthenticated user")
+	token := tstUnauthenticated()
+
+	docs.When("When they attempt to apply a transaction")
+	body := tstTransactionNewTeam()
+	response, err := tstPerformPost("/rest/api/v1/transactions", token, &body)
+
+	docs.Then("Then the request is denied as unauthorised")
+	tstAssert(t, response, err, http.StatusUnauthorized, "unauthorized.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTTransaction_InvalidOperations(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to apply a transaction with several invalid operations")
+	body := openapi.TransactionDto{
+		Operations: []openapi.TransactionOperationDto{
+			{Operation: "create", Kind: "team", Key: "new-team", Body: tstTransactionBody(tstOwner())},
+			{Operation: "delete", Kind: "service", Key: ""},
+			{Operation: "update", Kind: "owner", Key: "some-owner"},
+		},
+	}
+	response, err := tstPerformPost("/rest/api/v1/transactions", token, &body)
+
+	docs.Then("Then the request fails and the error response lists all problems")
+	tstAssert(t, response, err, http.StatusBadRequest, "transaction-invalid.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTTransaction_InvalidKeys(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they attempt to apply a transaction that creates an owner, a service and a repository with invalid keys")
+	body := openapi.TransactionDto{
+		JiraIssue: "ISSUE-2345",
+		Operations: []openapi.TransactionOperationDto{
+			{Operation: "create", Kind: "owner", Key: "-new-team", Body: tstTransactionBody(tstOwner())},
+			{Operation: "create", Kind: "service", Key: "New_Service", Body: tstTransactionBody(tstService("New_Service"))},
+			{Operation: "create", Kind: "repository", Key: "-ab.wrong", Body: tstTransactionBody(tstRepository())},
+		},
+	}
+	response, err := tstPerformPost("/rest/api/v1/transactions", token, &body)
+
+	docs.Then("Then the request fails and the error response points to each invalid key")
+	tstAssert(t, response, err, http.StatusBadRequest, "transaction-invalid-keys.json")
+
+	docs.Then("And no changes have been made in the metadata repository")
+	require.Equal(t, 0, len(metadataImpl.FilesWritten))
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+}
+
+func TestPOSTTransaction_PolicyWarning(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given a policy that warns about services without a description")
+	tstSetupPolicies(config.Policy{
+		Name:     "described",
+		Entity:   config.PolicyEntityService,
+		Severity: config.PolicySeverityWarn,
+		Message:  "services should be described",
+		Require:  `description!=""`,
+	})
+	defer tstSetupPolicies()
+
+	docs.Given("And given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they patch a service without a description in a transaction")
+	body := openapi.TransactionDto{
+		JiraIssue: "ISSUE-2345",
+		Operations: []openapi.TransactionOperationDto{
+			{Operation: "patch", Kind: "service", Key: "some-service-backend", Body: tstTransactionBody(tstServicePatch())},
+		},
+	}
+	response, err := tstPerformPost("/rest/api/v1/transactions", token, &body)
+
+	docs.Then("Then the request is successful and the response lists the warning, pointing to the operation")
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, response.status)
+	result := openapi.TransactionResultDto{}
+	require.Nil(t, json.Unmarshal([]byte(response.body), &result))
+	require.Equal(t, []openapi.ViolationDto{{
+		Pointer:    "/operations/0/body",
+		Code:       "service.policy",
+		Message:    "services should be described",
+		Parameters: map[string]string{"policy": "described", "severity": "warn"},
+	}}, result.PolicyWarnings)
+}
+
+func TestPOSTTransaction_RollbackOnFailure(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they apply a transaction whose last operation fails")
+	body := tstTransactionNewTeam()
+	body.Operations = append(body.Operations, openapi.TransactionOperationDto{
+		Operation: "delete",
+		Kind:      "service",
+		Key:       "unicorn",
+	})
+	response, err := tstPerformPost("/rest/api/v1/transactions", token, &body)
+
+	docs.Then("Then the request fails and the error response names the failed operation")
+	tstAssert(t, response, err, http.StatusNotFound, "transaction-rollback.json")
+
+	docs.Then("And nothing has been committed")
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+	require.False(t, metadataImpl.Pushed)
+
+	docs.Then("And the entities staged by earlier operations are not visible")
+	readAgain, err := tstPerformGet("/rest/api/v1/owners/new-team", tstUnauthenticated())
+	require.Nil(t, err)
+	require.Equal(t, http.StatusNotFound, readAgain.status)
+
+	docs.Then("And no kafka message has been sent")
+	require.Equal(t, 0, len(kafkaImpl.Recording))
+}
+
+func TestPOSTTransaction_OperationViolations(t *testing.T) {
+	tstReset()
+
+	docs.Given("Given an authenticated admin user")
+	token := tstValidAdminToken()
+
+	docs.When("When they apply a transaction where an operation has invalid values")
+	body := tstTransactionNewTeam()
+	body.Operations[1].Body["mainline"] = "trunk"
+	response, err := tstPerformPost("/rest/api/v1/transactions", token, &body)
+
+	docs.Then("Then the request fails and the violations point into the body of the failed operation")
+	tstAssert(t, response, err, http.StatusBadRequest, "transaction-operation-violations.json")
+
+	docs.Then("And nothing has been committed")
+	require.Equal(t, 0, len(metadataImpl.FilesCommitted))
+	require.False(t, metadataImpl.Pushed)
+}
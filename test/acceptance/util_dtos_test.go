@@ -1,6 +1,7 @@
 package acceptance
 
 import (
+	"encoding/json"
 	"github.com/Interhyp/metadata-service/api"
 	"github.com/Interhyp/metadata-service/internal/repository/notifier"
 )
@@ -41,6 +42,15 @@ func tstOwnerPatch() openapi.OwnerPatchDto {
 	}
 }
 
+func tstOwnerRename(newAlias string) openapi.OwnerRenameDto {
+	return openapi.OwnerRenameDto{
+		NewAlias:   newAlias,
+		TimeStamp:  "2022-11-06T18:14:10Z",
+		CommitHash: "6c8ac2c35791edf9979623c717a243fc53400000",
+		JiraIssue:  "ISSUE-2345",
+	}
+}
+
 func tstOwnerUnchanged() openapi.OwnerDto {
 	return openapi.OwnerDto{
 		Contact:            "somebody@some-organisation.com",
@@ -347,6 +357,63 @@ func tstDelete() openapi.DeletionDto {
 	}
 }
 
+func tstRevert() openapi.RevertDto {
+	return openapi.RevertDto{
+		CommitHash: "6c8ac2c35791edf9979623c717a243fc53400000",
+		JiraIssue:  "ISSUE-2345",
+	}
+}
+
+func tstServiceRename(newName string) openapi.ServiceRenameDto {
+	return openapi.ServiceRenameDto{
+		NewName:            newName,
+		RenameRepositories: true,
+		TimeStamp:          "2022-11-06T18:14:10Z",
+		CommitHash:         "6c8ac2c35791edf9979623c717a243fc53400000",
+		JiraIssue:          "ISSUE-2345",
+	}
+}
+
+func tstServiceLifecycleTransition(lifecycle string) openapi.ServiceLifecycleTransitionDto {
+	return openapi.ServiceLifecycleTransitionDto{
+		Lifecycle:  lifecycle,
+		TimeStamp:  "2022-11-06T18:14:10Z",
+		CommitHash: "6c8ac2c35791edf9979623c717a243fc53400000",
+		JiraIssue:  "ISSUE-2345",
+	}
+}
+
+// transaction
+
+func tstTransactionBody(dto interface{}) map[string]interface{} {
+	raw, _ := json.Marshal(dto)
+	result := make(map[string]interface{})
+	_ = json.Unmarshal(raw, &result)
+	return result
+}
+
+func tstTransactionNewTeam() openapi.TransactionDto {
+	repo := tstRepository()
+	repo.Owner = "new-team"
+	service := tstService("new-team-backend")
+	service.Owner = "new-team"
+	service.Repositories = []string{"new-team-backend.implementation"}
+	return openapi.TransactionDto{
+		JiraIssue: "ISSUE-2345",
+		Operations: []openapi.TransactionOperationDto{
+			{Operation: "create", Kind: "owner", Key: "new-team", Body: tstTransactionBody(tstOwner())},
+			{Operation: "create", Kind: "repository", Key: "new-team-backend.implementation", Body: tstTransactionBody(repo)},
+			{Operation: "create", Kind: "service", Key: "new-team-backend", Body: tstTransactionBody(service)},
+		},
+	}
+}
+
+func tstTransactionNewTeamExpectedKafka() string {
+	return `{"affected":{"ownerAliases":["new-team"],"serviceNames":["new-team-backend"],` +
+		`"repositoryKeys":["new-team-backend.implementation"]},"timeStamp":"2022-11-06T18:14:10Z",` +
+		`"commitHash":"6c8ac2c35791edf9979623c717a2430000000000"}`
+}
+
 func tstNewRepositoryPayload() openapi.NotificationPayload {
 	repo := tstRepository()
 	repo.CommitHash = "6c8ac2c35791edf9979623c717a2430000000000"
@@ -19,3 +19,20 @@ func hasSentNotification(t *testing.T, clientIdentifier string, name string, eve
 	}
 	require.Contains(t, mockClient.SentNotifications, mockClient.ToJson(expected))
 }
+
+func hasSentSunsetNotification(t *testing.T, clientIdentifier string, name string, event types.NotificationEventType, payloadType types.NotificationPayloadType, payload *openapi.NotificationPayload, recipient openapi.NotificationRecipient) {
+	client := notifierImpl.Clients[clientIdentifier]
+	mockClient := client.(*notifiermock.NotifierClientMock)
+	expected := openapi.Notification{
+		Name:      name,
+		Event:     event.String(),
+		Type:      payloadType.String(),
+		Payload:   payload,
+		Recipient: &recipient,
+	}
+	require.Contains(t, mockClient.SentNotifications, mockClient.ToJson(expected))
+}
+
+func sentNotificationCount(clientIdentifier string) int {
+	return len(notifierImpl.Clients[clientIdentifier].(*notifiermock.NotifierClientMock).SentNotifications)
+}
@@ -2,8 +2,10 @@ package acceptance
 
 import (
 	"context"
+	aconfig "github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Interhyp/metadata-service/internal/repository/config"
 	"github.com/Interhyp/metadata-service/internal/repository/notifier"
+	"github.com/Interhyp/metadata-service/internal/service/policies"
 	"github.com/Interhyp/metadata-service/internal/service/trigger"
 	"github.com/Interhyp/metadata-service/internal/web/app"
 	"github.com/Interhyp/metadata-service/internal/web/server"
@@ -164,8 +166,16 @@ func tstShutdown() {
 	ts.Close()
 }
 
+// tstSetupPolicies replaces the configured policies, call without arguments to remove them again.
+func tstSetupPolicies(configured ...aconfig.Policy) {
+	customConfigImpl.VPolicies = configured
+	_ = application.Policies.(*policies.Impl).SetupPolicies(appCtx)
+}
+
 func tstReset() {
 	metadataImpl.Reset()
+	// the cache may still hold entities written or deleted by a previous test
+	_ = application.Updater.PerformFullUpdate(appCtx)
 	kafkaImpl.Reset()
 	for _, client := range notifierImpl.Clients {
 		client.(*notifiermock.NotifierClientMock).Reset()
@@ -1,6 +1,7 @@
 package acceptance
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -10,7 +11,9 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 // placing these here because they are package global
@@ -79,6 +82,8 @@ type tstWebResponse struct {
 	body        string
 	contentType string
 	location    string
+	etag        string
+	header      http.Header
 }
 
 func tstWebResponseFromResponse(response *http.Response) (tstWebResponse, error) {
@@ -91,6 +96,7 @@ func tstWebResponseFromResponse(response *http.Response) (tstWebResponse, error)
 	if val, ok := response.Header[headers.Location]; ok {
 		loc = val[0]
 	}
+	etag := response.Header.Get(headers.ETag)
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
 		return tstWebResponse{}, err
@@ -104,6 +110,8 @@ func tstWebResponseFromResponse(response *http.Response) (tstWebResponse, error)
 		body:        string(body),
 		contentType: ct,
 		location:    loc,
+		etag:        etag,
+		header:      response.Header,
 	}, nil
 }
 
@@ -115,7 +123,15 @@ func tstPerformDeleteNoBody(relativeUrlWithLeadingSlash string, bearerToken stri
 	return tstPerformNoBody(http.MethodDelete, relativeUrlWithLeadingSlash, bearerToken)
 }
 
+func tstPerformGetWithHeader(relativeUrlWithLeadingSlash string, bearerToken string, header string, value string) (tstWebResponse, error) {
+	return tstPerformNoBodyWithHeaders(http.MethodGet, relativeUrlWithLeadingSlash, bearerToken, map[string]string{header: value})
+}
+
 func tstPerformNoBody(method string, relativeUrlWithLeadingSlash string, bearerToken string) (tstWebResponse, error) {
+	return tstPerformNoBodyWithHeaders(method, relativeUrlWithLeadingSlash, bearerToken, nil)
+}
+
+func tstPerformNoBodyWithHeaders(method string, relativeUrlWithLeadingSlash string, bearerToken string, extraHeaders map[string]string) (tstWebResponse, error) {
 	if ts == nil {
 		return tstWebResponse{}, errors.New("test web server was not initialized")
 	}
@@ -126,6 +142,9 @@ func tstPerformNoBody(method string, relativeUrlWithLeadingSlash string, bearerT
 	if bearerToken != "" {
 		request.Header.Set(headers.Authorization, "Bearer "+bearerToken)
 	}
+	for header, value := range extraHeaders {
+		request.Header.Set(header, value)
+	}
 	response, err := http.DefaultClient.Do(request)
 	if err != nil {
 		return tstWebResponse{}, err
@@ -145,6 +164,22 @@ func tstPerformPatch(relativeUrlWithLeadingSlash string, bearerToken string, bod
 	return tstPerformWithBody(http.MethodPatch, relativeUrlWithLeadingSlash, bearerToken, bodyPtr)
 }
 
+func tstPerformPatchWithHeader(relativeUrlWithLeadingSlash string, bearerToken string, bodyPtr interface{}, header string, value string) (tstWebResponse, error) {
+	bodyBytes, err := json.Marshal(bodyPtr)
+	if err != nil {
+		return tstWebResponse{}, err
+	}
+	return tstPerformRawWithBodyAndHeaders(http.MethodPatch, relativeUrlWithLeadingSlash, bearerToken, bodyBytes, map[string]string{header: value})
+}
+
+func tstPerformPutWithHeader(relativeUrlWithLeadingSlash string, bearerToken string, bodyPtr interface{}, header string, value string) (tstWebResponse, error) {
+	bodyBytes, err := json.Marshal(bodyPtr)
+	if err != nil {
+		return tstWebResponse{}, err
+	}
+	return tstPerformRawWithBodyAndHeaders(http.MethodPut, relativeUrlWithLeadingSlash, bearerToken, bodyBytes, map[string]string{header: value})
+}
+
 func tstPerformDelete(relativeUrlWithLeadingSlash string, bearerToken string, bodyPtr interface{}) (tstWebResponse, error) {
 	return tstPerformWithBody(http.MethodDelete, relativeUrlWithLeadingSlash, bearerToken, bodyPtr)
 }
@@ -157,7 +192,15 @@ func tstPerformWithBody(method string, relativeUrlWithLeadingSlash string, beare
 	return tstPerformRawWithBody(method, relativeUrlWithLeadingSlash, bearerToken, bodyBytes)
 }
 
+func tstPerformPatchDocument(relativeUrlWithLeadingSlash string, bearerToken string, contentType string, document string) (tstWebResponse, error) {
+	return tstPerformRawWithBodyAndHeaders(http.MethodPatch, relativeUrlWithLeadingSlash, bearerToken, []byte(document), map[string]string{headers.ContentType: contentType})
+}
+
 func tstPerformRawWithBody(method string, relativeUrlWithLeadingSlash string, bearerToken string, bodyBytes []byte) (tstWebResponse, error) {
+	return tstPerformRawWithBodyAndHeaders(method, relativeUrlWithLeadingSlash, bearerToken, bodyBytes, nil)
+}
+
+func tstPerformRawWithBodyAndHeaders(method string, relativeUrlWithLeadingSlash string, bearerToken string, bodyBytes []byte, extraHeaders map[string]string) (tstWebResponse, error) {
 	if ts == nil {
 		return tstWebResponse{}, errors.New("test web server was not initialized")
 	}
@@ -168,9 +211,88 @@ func tstPerformRawWithBody(method string, relativeUrlWithLeadingSlash string, be
 	if bearerToken != "" {
 		request.Header.Set(headers.Authorization, "Bearer "+bearerToken)
 	}
+	for header, value := range extraHeaders {
+		request.Header.Set(header, value)
+	}
 	response, err := http.DefaultClient.Do(request)
 	if err != nil {
 		return tstWebResponse{}, err
 	}
 	return tstWebResponseFromResponse(response)
 }
+
+// server-sent events
+
+type tstStreamEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+type tstEventStream struct {
+	status   int
+	response *http.Response
+	lines    chan string
+}
+
+// tstOpenEventStream returns once the response headers have been received, so the subscription is in place.
+func tstOpenEventStream(relativeUrlWithLeadingSlash string, lastEventId string) (*tstEventStream, error) {
+	if ts == nil {
+		return nil, errors.New("test web server was not initialized")
+	}
+	request, err := http.NewRequest(http.MethodGet, ts.URL+relativeUrlWithLeadingSlash, nil)
+	if err != nil {
+		return nil, err
+	}
+	if lastEventId != "" {
+		request.Header.Set("Last-Event-ID", lastEventId)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := &tstEventStream{
+		status:   response.StatusCode,
+		response: response,
+		lines:    make(chan string, 100),
+	}
+	go func() {
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			stream.lines <- scanner.Text()
+		}
+		close(stream.lines)
+	}()
+	return stream, nil
+}
+
+// next reads the next event, skipping comments, and fails if none arrives within a second.
+func (s *tstEventStream) next() (tstStreamEvent, error) {
+	result := tstStreamEvent{}
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				return result, errors.New("event stream closed")
+			}
+			switch {
+			case line == "" && result.event != "":
+				return result, nil
+			case strings.HasPrefix(line, "id: "):
+				result.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				result.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				result.data = strings.TrimPrefix(line, "data: ")
+			}
+		case <-timeout:
+			return result, errors.New("timed out waiting for event")
+		}
+	}
+}
+
+func (s *tstEventStream) close() {
+	_ = s.response.Body.Close()
+}
@@ -25,13 +25,20 @@ func (s *Mock) GetOwnerListTimestamp(ctx context.Context) (string, error) {
 }
 
 func (s *Mock) GetSortedOwnerAliases(ctx context.Context) ([]string, error) {
-	return nil, nil
+	return []string{"ownerWithGroup", "someOwner"}, nil
 }
 
 func (s *Mock) GetOwner(ctx context.Context, alias string) (openapi.OwnerDto, error) {
 	if alias == "ownerWithGroup" {
 		return openapi.OwnerDto{
-			Groups: &map[string][]string{"someGroupName": {"username1", "username2"}},
+			Groups:       &map[string][]string{"someGroupName": {"username1", "username2"}},
+			Promoters:    []string{"@ownerWithGroup.someGroupName", "promoter1"},
+			ProductOwner: p("productOwner1"),
+		}, nil
+	}
+	if alias == "someOwner" {
+		return openapi.OwnerDto{
+			ProductOwner: p("productOwner2"),
 		}, nil
 	}
 	return openapi.OwnerDto{}, nil
@@ -92,3 +99,23 @@ func (s *Mock) PutRepository(ctx context.Context, key string, entry openapi.Repo
 func (s *Mock) DeleteRepository(ctx context.Context, key string) error {
 	return nil
 }
+
+func (s *Mock) WithOverlay(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (s *Mock) WasNotificationSent(ctx context.Context, key string) (bool, error) {
+	return false, nil
+}
+
+func (s *Mock) MarkNotificationSent(ctx context.Context, key string) error {
+	return nil
+}
+
+func (s *Mock) ObtainLock(ctx context.Context, key string) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
+}
+
+func p(v string) *string {
+	return &v
+}
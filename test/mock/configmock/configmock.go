@@ -3,6 +3,7 @@ package configmock
 import (
 	"github.com/Interhyp/metadata-service/internal/acorn/config"
 	"github.com/Roshick/go-autumn-kafka/pkg/aukafka"
+	"net"
 	"regexp"
 )
 
@@ -204,6 +205,42 @@ func (c *MockConfig) ServiceNameMaxLength() uint16 {
 	panic("implement me")
 }
 
+func (c *MockConfig) ServiceConsumedApiValidation() string {
+	return config.ConsumedApiValidationWarn
+}
+
+func (c *MockConfig) ServiceLifecycleModel() config.ServiceLifecycleModel {
+	return config.ServiceLifecycleModel{
+		Initial: "experimental",
+		States: map[string]config.ServiceLifecycleState{
+			"experimental": {Next: []string{"production", "retired"}},
+			"production":   {Next: []string{"deprecated"}},
+			"deprecated":   {Next: []string{"production", "retired"}, RequiresSunsetDate: true, RequiresReplacedBy: true},
+			"retired":      {ProhibitsDependents: true},
+		},
+	}
+}
+
+func (c *MockConfig) SunsetWarningDays() uint16 {
+	return 30
+}
+
+func (c *MockConfig) LinkCheckIntervalMinutes() uint16 {
+	return 60
+}
+
+func (c *MockConfig) LinkCheckConcurrency() uint16 {
+	return 4
+}
+
+func (c *MockConfig) LinkCheckDelayMilliseconds() uint16 {
+	return 1000
+}
+
+func (c *MockConfig) LinkCheckAllowedNetworks() []*net.IPNet {
+	return nil
+}
+
 func (c *MockConfig) RepositoryNamePermittedRegex() *regexp.Regexp {
 	//TODO implement me
 	panic("implement me")
@@ -244,6 +281,18 @@ func (c *MockConfig) AllowedFileCategories() []string {
 	panic("implement me")
 }
 
+func (c *MockConfig) Policies() []config.Policy {
+	return nil
+}
+
+func (c *MockConfig) GraphqlMaxDepth() uint16 {
+	return 8
+}
+
+func (c *MockConfig) GraphqlMaxComplexity() uint16 {
+	return 5000
+}
+
 func (c *MockConfig) Kafka() *aukafka.Config {
 	//TODO implement me
 	panic("implement me")
@@ -3,17 +3,22 @@ package metadatamock
 import (
 	"context"
 	"errors"
+	"fmt"
 	auzerolog "github.com/StephanHCB/go-autumn-logging-zerolog"
 	"io"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Interhyp/metadata-service/internal/acorn/errors/nochangeserror"
+	"github.com/Interhyp/metadata-service/internal/acorn/errors/nosuchcommiterror"
 	"github.com/Interhyp/metadata-service/internal/acorn/repository"
 
 	"github.com/StephanHCB/go-backend-service-common/api/apierrors"
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
 )
 import _ "github.com/go-git/go-git/v5"
 
@@ -25,9 +30,18 @@ type Impl struct {
 	FilesCommitted map[string]bool
 	Pushed         bool
 
+	// OriginalContents holds the file contents as of origCommitHash, keyed by path
+	OriginalContents map[string]string
+
 	SimulateRemoteFailure      bool
 	SimulateConcurrencyFailure bool
 	SimulateUnchangedFailure   bool
+
+	// SimulateRemoteCommits are pulled by the next Pull, which does not change any files
+	SimulateRemoteCommits []repository.CommitInfo
+
+	newCommits   []repository.CommitInfo
+	knownCommits map[string]bool
 }
 
 func New() repository.Metadata {
@@ -133,6 +147,8 @@ const (
 	newCommitHash     = "6c8ac2c35791edf9979623c717a2430000000000"
 	origCommitMessage = "ISSUE-0000: original"
 	newCommitMessage  = "ISSUE-2345: new"
+	origCommitAuthor  = "Original Author"
+	newCommitAuthor   = "New Author"
 )
 
 func (r *Impl) commitHash(filename string) string {
@@ -154,6 +170,8 @@ func (r *Impl) commitMessage(filename string) string {
 }
 
 func (r *Impl) writeFile(filename string, contents string) error {
+	r.OriginalContents[filename] = contents
+
 	f, err := r.Fs.Create(filename)
 	if err != nil {
 		return err
@@ -166,6 +184,7 @@ func (r *Impl) writeFile(filename string, contents string) error {
 
 func (r *Impl) Clone(ctx context.Context) error {
 	r.Fs = memfs.New()
+	r.OriginalContents = make(map[string]string)
 	err := r.Fs.MkdirAll("owners/some-owner/services", 0755)
 	if err != nil {
 		return err
@@ -222,11 +241,20 @@ func (r *Impl) Clone(ctx context.Context) error {
 	r.SimulateRemoteFailure = false
 	r.SimulateConcurrencyFailure = false
 	r.SimulateUnchangedFailure = false
+	r.SimulateRemoteCommits = nil
+	r.newCommits = make([]repository.CommitInfo, 0)
+	r.knownCommits = make(map[string]bool)
 	r.Pushed = false
 	return nil
 }
 
 func (r *Impl) Pull(ctx context.Context) error {
+	r.newCommits = make([]repository.CommitInfo, 0)
+	for _, commitInfo := range r.SimulateRemoteCommits {
+		r.newCommits = append(r.newCommits, commitInfo)
+		r.knownCommits[commitInfo.CommitHash] = true
+	}
+	r.SimulateRemoteCommits = nil
 	return nil
 }
 
@@ -265,11 +293,13 @@ func (r *Impl) LastUpdated() time.Time {
 }
 
 func (r *Impl) NewPulledCommits() []repository.CommitInfo {
-	return make([]repository.CommitInfo, 0)
+	result := make([]repository.CommitInfo, len(r.newCommits))
+	_ = copy(result, r.newCommits)
+	return result
 }
 
 func (r *Impl) IsCommitKnown(hash string) bool {
-	return false
+	return r.knownCommits[hash]
 }
 
 func (r *Impl) Stat(filename string) (os.FileInfo, error) {
@@ -304,6 +334,136 @@ func (r *Impl) ReadFile(filename string) ([]byte, repository.CommitInfo, error)
 	return data, commitInfo, nil
 }
 
+// ReadFileHistory simulates a history of at most two commits per file: the original commit that
+// created all files set up in Clone, and the new commit if the file has since been committed.
+func (r *Impl) ReadFileHistory(ctx context.Context, pathMatcher func(path string) bool) ([]repository.FileRevision, error) {
+	newRevisions := make([]repository.FileRevision, 0)
+	origRevisions := make([]repository.FileRevision, 0)
+
+	paths := make(map[string]bool)
+	for filename := range r.OriginalContents {
+		paths[filename] = true
+	}
+	for filename := range r.FilesCommitted {
+		paths[filename] = true
+	}
+	sortedPaths := make([]string, 0, len(paths))
+	for filename := range paths {
+		if pathMatcher(filename) {
+			sortedPaths = append(sortedPaths, filename)
+		}
+	}
+	sort.Strings(sortedPaths)
+
+	for _, filename := range sortedPaths {
+		if r.FilesCommitted[filename] {
+			revision := repository.FileRevision{
+				CommitInfo: repository.CommitInfo{
+					CommitHash: newCommitHash,
+					TimeStamp:  r.Now(),
+					Message:    newCommitMessage,
+				},
+				Author: newCommitAuthor,
+				Path:   filename,
+			}
+			contents, err := util.ReadFile(r.Fs, filename)
+			if err == nil {
+				revision.Contents = contents
+			}
+			newRevisions = append(newRevisions, revision)
+		}
+		if contents, ok := r.OriginalContents[filename]; ok {
+			origRevisions = append(origRevisions, repository.FileRevision{
+				CommitInfo: repository.CommitInfo{
+					CommitHash: origCommitHash,
+					TimeStamp:  r.Now(),
+					Message:    origCommitMessage,
+				},
+				Author:   origCommitAuthor,
+				Path:     filename,
+				Contents: []byte(contents),
+			})
+		}
+	}
+
+	return append(r.oneRevisionPerCommit(newRevisions), r.oneRevisionPerCommit(origRevisions)...), nil
+}
+
+// oneRevisionPerCommit mirrors the real implementation, which prefers the path that still exists.
+func (r *Impl) oneRevisionPerCommit(revisions []repository.FileRevision) []repository.FileRevision {
+	if len(revisions) <= 1 {
+		return revisions
+	}
+	for _, revision := range revisions {
+		if revision.Contents != nil {
+			return []repository.FileRevision{revision}
+		}
+	}
+	return revisions[:1]
+}
+
+// SnapshotAt resolves at against the simulated history, see ReadFileHistory. Both simulated commits
+// happened at r.Now().
+func (r *Impl) SnapshotAt(ctx context.Context, at string) (repository.Metadata, repository.CommitInfo, error) {
+	hasNewCommit := len(r.FilesCommitted) > 0
+
+	useNewCommit := false
+	if pointInTime, err := time.Parse(time.RFC3339, at); err == nil {
+		if pointInTime.Before(r.Now()) {
+			return nil, repository.CommitInfo{}, nosuchcommiterror.New(ctx, fmt.Sprintf("no mainline commit found for %s", at))
+		}
+		useNewCommit = hasNewCommit
+	} else {
+		matchesOrig := strings.HasPrefix(origCommitHash, strings.ToLower(at))
+		matchesNew := hasNewCommit && strings.HasPrefix(newCommitHash, strings.ToLower(at))
+		if matchesOrig && matchesNew {
+			return nil, repository.CommitInfo{}, nosuchcommiterror.New(ctx, fmt.Sprintf("commit hash %s is ambiguous", at))
+		}
+		if !matchesOrig && !matchesNew {
+			return nil, repository.CommitInfo{}, nosuchcommiterror.New(ctx, fmt.Sprintf("no mainline commit found for %s", at))
+		}
+		useNewCommit = matchesNew
+	}
+
+	if useNewCommit {
+		filesCommitted := make(map[string]bool)
+		for filename, committed := range r.FilesCommitted {
+			filesCommitted[filename] = committed
+		}
+		snapshot := &Impl{
+			Fs:               r.Fs,
+			Now:              r.Now,
+			FilesCommitted:   filesCommitted,
+			OriginalContents: r.OriginalContents,
+		}
+		commitInfo := repository.CommitInfo{
+			CommitHash: newCommitHash,
+			TimeStamp:  r.Now(),
+			Message:    newCommitMessage,
+		}
+		return snapshot, commitInfo, nil
+	}
+
+	fs := memfs.New()
+	for filename, contents := range r.OriginalContents {
+		if err := util.WriteFile(fs, filename, []byte(contents), 0644); err != nil {
+			return nil, repository.CommitInfo{}, err
+		}
+	}
+	snapshot := &Impl{
+		Fs:               fs,
+		Now:              r.Now,
+		FilesCommitted:   make(map[string]bool),
+		OriginalContents: r.OriginalContents,
+	}
+	commitInfo := repository.CommitInfo{
+		CommitHash: origCommitHash,
+		TimeStamp:  r.Now(),
+		Message:    origCommitMessage,
+	}
+	return snapshot, commitInfo, nil
+}
+
 func (r *Impl) WriteFile(filename string, contents []byte) error {
 	fileHandle, err := r.Fs.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
@@ -9,18 +9,25 @@ import (
 
 type NotifierClientMock struct {
 	SentNotifications []string
+	// SendErr makes Send fail without recording the notification
+	SendErr error
 }
 
 func (n *NotifierClientMock) Setup(clientIdentifier string, url string) error {
 	return nil
 }
 
-func (n *NotifierClientMock) Send(ctx context.Context, notification openapi.Notification) {
+func (n *NotifierClientMock) Send(ctx context.Context, notification openapi.Notification) error {
+	if n.SendErr != nil {
+		return n.SendErr
+	}
 	n.SentNotifications = append(n.SentNotifications, n.ToJson(notification))
+	return nil
 }
 
 func (n *NotifierClientMock) Reset() {
 	n.SentNotifications = make([]string, 0)
+	n.SendErr = nil
 }
 
 func (n *NotifierClientMock) ToJson(notification openapi.Notification) string {